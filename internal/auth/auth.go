@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/config"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// TokenTTL is how long an issued token stays valid.
+const TokenTTL = 5 * time.Minute
+
+var (
+	ErrMissingToken = errors.New("authorization is required")
+	ErrMalformed    = errors.New("authorization must be 'Bearer <token>'")
+	ErrInvalidToken = errors.New("invalid token")
+)
+
+type claims struct {
+	Login string `json:"login"`
+	jwt.RegisteredClaims
+}
+
+// IssueToken signs a token for login with the admin JWT key.
+func IssueToken(login string) (string, error) {
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &claims{
+		Login: login,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(TokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	})
+
+	return token.SignedString([]byte(config.AdminCfg.JwtKey))
+}
+
+// ParseBearer validates an Authorization value of the form "Bearer <token>"
+// and returns the login the token was issued to.
+func ParseBearer(authorization string) (string, error) {
+	if authorization == "" {
+		return "", ErrMissingToken
+	}
+
+	parts := strings.Split(authorization, " ")
+
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return "", ErrMalformed
+	}
+
+	parsed := &claims{}
+	token, err := jwt.ParseWithClaims(parts[1], parsed, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+
+		return []byte(config.AdminCfg.JwtKey), nil
+	})
+
+	if err != nil || !token.Valid {
+		return "", ErrInvalidToken
+	}
+
+	return parsed.Login, nil
+}
@@ -0,0 +1,46 @@
+// Package broker carries change events between instances over a message
+// broker. Delivery is at least once: an event is acknowledged only after its
+// handler succeeds, so handlers must tolerate duplicates.
+package broker
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/M2rk13/Otus-327619/internal/model/event"
+)
+
+// Publisher appends events to the stream shared by all instances.
+type Publisher interface {
+	Publish(ctx context.Context, ev *event.Event) error
+}
+
+// Handler processes a delivered event. An event whose handler fails is
+// delivered again later, possibly to another consumer of the group.
+type Handler func(ctx context.Context, ev *event.Event) error
+
+// Subscriber delivers the events of the stream to consumer groups. Every group
+// sees every event, starting with the oldest one kept by the broker, and
+// each event goes to one consumer of the group. Subscribe blocks until ctx
+// is cancelled.
+type Subscriber interface {
+	Subscribe(ctx context.Context, group, consumer string, handle Handler) error
+}
+
+type Broker interface {
+	Publisher
+	Subscriber
+	Close() error
+}
+
+// decode reads a delivered message. Messages that are not events can never
+// be handled, so the implementations acknowledge and skip them.
+func decode(data []byte) (*event.Event, error) {
+	var ev event.Event
+
+	if err := json.Unmarshal(data, &ev); err != nil {
+		return nil, err
+	}
+
+	return &ev, nil
+}
@@ -0,0 +1,124 @@
+package broker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/model/event"
+)
+
+// MemoryBroker keeps the stream in memory. It has the delivery semantics of
+// the real brokers within one process and is meant for tests and single
+// instance setups.
+type MemoryBroker struct {
+	mu     sync.Mutex
+	events []*event.Event
+	groups map[string]*memoryGroup
+	// published is closed and replaced on every publish to wake up waiting
+	// consumers.
+	published chan struct{}
+	// redeliverAfter is how long a delivered event may stay unacknowledged
+	// before it is handed out again.
+	redeliverAfter time.Duration
+}
+
+type memoryGroup struct {
+	next int
+	// pending maps the offsets of unacknowledged events to the time they were
+	// last delivered.
+	pending map[int]time.Time
+}
+
+func NewMemoryBroker(redeliverAfter time.Duration) *MemoryBroker {
+	return &MemoryBroker{
+		groups:         make(map[string]*memoryGroup),
+		published:      make(chan struct{}),
+		redeliverAfter: redeliverAfter,
+	}
+}
+
+func (b *MemoryBroker) Publish(_ context.Context, ev *event.Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stored := *ev
+	b.events = append(b.events, &stored)
+	close(b.published)
+	b.published = make(chan struct{})
+
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(ctx context.Context, group, _ string, handle Handler) error {
+	ticker := time.NewTicker(b.redeliverAfter)
+	defer ticker.Stop()
+
+	for {
+		offset, ev, published := b.claim(group)
+
+		if ev == nil {
+			select {
+			case <-published:
+			case <-ticker.C:
+			case <-ctx.Done():
+				return nil
+			}
+
+			continue
+		}
+
+		if handle(ctx, ev) == nil {
+			b.ack(group, offset)
+		}
+	}
+}
+
+func (b *MemoryBroker) Close() error {
+	return nil
+}
+
+// claim hands out the oldest event the group has to redeliver, or else its
+// next new event. Without one it returns the channel signalling the next
+// publish.
+func (b *MemoryBroker) claim(group string) (int, *event.Event, <-chan struct{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state, ok := b.groups[group]
+
+	if !ok {
+		state = &memoryGroup{pending: make(map[int]time.Time)}
+		b.groups[group] = state
+	}
+
+	now := time.Now()
+	offset := -1
+
+	for pendingOffset, deliveredAt := range state.pending {
+		if now.Sub(deliveredAt) >= b.redeliverAfter && (offset < 0 || pendingOffset < offset) {
+			offset = pendingOffset
+		}
+	}
+
+	if offset < 0 {
+		if state.next >= len(b.events) {
+			return 0, nil, b.published
+		}
+
+		offset = state.next
+		state.next++
+	}
+
+	state.pending[offset] = now
+	ev := *b.events[offset]
+
+	return offset, &ev, b.published
+}
+
+func (b *MemoryBroker) ack(group string, offset int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.groups[group].pending, offset)
+}
@@ -0,0 +1,124 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/model/event"
+)
+
+// collector records the event ids handled by each consumer.
+type collector struct {
+	mu      sync.Mutex
+	handled map[string][]string
+}
+
+func (c *collector) handler(consumer string, fail func(*event.Event) bool) Handler {
+	return func(_ context.Context, ev *event.Event) error {
+		if fail != nil && fail(ev) {
+			return errors.New("handler failed")
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.handled[consumer] = append(c.handled[consumer], ev.Id)
+
+		return nil
+	}
+}
+
+func (c *collector) count(consumers ...string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	total := 0
+
+	for _, consumer := range consumers {
+		total += len(c.handled[consumer])
+	}
+
+	return total
+}
+
+func waitFor(t *testing.T, ok func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+
+	for !ok() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestMemoryBroker_ConsumerGroups(t *testing.T) {
+	b := NewMemoryBroker(time.Hour)
+	c := &collector{handled: make(map[string][]string)}
+
+	var wg sync.WaitGroup
+	ctx, cancel := context.WithCancel(context.Background())
+
+	for _, sub := range []struct{ group, consumer string }{{"a", "a1"}, {"a", "a2"}, {"b", "b1"}} {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			_ = b.Subscribe(ctx, sub.group, sub.consumer, c.handler(sub.consumer, nil))
+		}()
+	}
+
+	for i := range 20 {
+		if err := b.Publish(ctx, &event.Event{Id: fmt.Sprint(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	waitFor(t, func() bool { return c.count("a1", "a2") == 20 && c.count("b1") == 20 })
+
+	cancel()
+	wg.Wait()
+
+	if total := c.count("a1", "a2"); total != 20 {
+		t.Fatalf("group a handled %d events, want each of the 20 once", total)
+	}
+}
+
+func TestMemoryBroker_RedeliversFailedEvents(t *testing.T) {
+	b := NewMemoryBroker(10 * time.Millisecond)
+	c := &collector{handled: make(map[string][]string)}
+
+	var mu sync.Mutex
+	attempts := 0
+	failFirst := func(*event.Event) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+
+		return attempts == 1
+	}
+
+	var wg sync.WaitGroup
+	ctx, cancel := context.WithCancel(context.Background())
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+		_ = b.Subscribe(ctx, "g", "c1", c.handler("c1", failFirst))
+	}()
+
+	_ = b.Publish(ctx, &event.Event{Id: "e1"})
+	waitFor(t, func() bool { return c.count("c1") == 1 })
+
+	cancel()
+	wg.Wait()
+
+	if attempts != 2 {
+		t.Fatalf("attempts = %d, want the failed event delivered again once", attempts)
+	}
+}
@@ -0,0 +1,130 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/model/event"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// natsSubjects are the subjects of the stream; events are published on
+// their type, e.g. conversion.created.
+const natsSubjects = "conversion.>"
+
+// NatsBroker keeps the events in a JetStream stream. A consumer group is a
+// durable pull consumer shared by the instances of the group; events not
+// acknowledged within ackWait are delivered again.
+type NatsBroker struct {
+	conn    *nats.Conn
+	js      jetstream.JetStream
+	stream  string
+	ackWait time.Duration
+}
+
+// NewNatsBroker connects to the server at url and creates the stream unless
+// it exists.
+func NewNatsBroker(ctx context.Context, url, stream string, ackWait time.Duration) (*NatsBroker, error) {
+	conn, err := nats.Connect(url, nats.Name("otus-converter"))
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to nats: %w", err)
+	}
+
+	js, err := jetstream.New(conn)
+
+	if err == nil {
+		_, err = js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{Name: stream, Subjects: []string{natsSubjects}})
+	}
+
+	if err != nil {
+		conn.Close()
+
+		return nil, fmt.Errorf("failed to setup stream %s: %w", stream, err)
+	}
+
+	return &NatsBroker{conn: conn, js: js, stream: stream, ackWait: ackWait}, nil
+}
+
+// Publish sends ev on the subject of its type. The event id doubles as the
+// message id, so a publish repeated after a lost acknowledgement is dropped
+// by the server.
+func (b *NatsBroker) Publish(ctx context.Context, ev *event.Event) error {
+	data, err := json.Marshal(ev)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = b.js.Publish(ctx, ev.Type, data, jetstream.WithMsgID(ev.Id))
+
+	return err
+}
+
+func (b *NatsBroker) Subscribe(ctx context.Context, group, consumer string, handle Handler) error {
+	cons, err := b.js.CreateOrUpdateConsumer(ctx, b.stream, jetstream.ConsumerConfig{
+		Durable:       group,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       b.ackWait,
+		DeliverPolicy: jetstream.DeliverAllPolicy,
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to create consumer group %s: %w", group, err)
+	}
+
+	messages, err := cons.Messages(jetstream.PullExpiry(time.Second))
+
+	if err != nil {
+		return fmt.Errorf("failed to consume stream %s as %s: %w", b.stream, consumer, err)
+	}
+
+	stop := context.AfterFunc(ctx, messages.Stop)
+	defer stop()
+
+	for {
+		msg, err := messages.Next()
+
+		if errors.Is(err, jetstream.ErrMsgIteratorClosed) {
+			return nil
+		}
+
+		if err != nil {
+			fmt.Printf("Failed to read stream %s for group %s: %v\n", b.stream, group, err)
+
+			select {
+			case <-time.After(time.Second):
+			case <-ctx.Done():
+			}
+
+			continue
+		}
+
+		b.handle(ctx, msg, handle)
+	}
+}
+
+func (b *NatsBroker) Close() error {
+	return b.conn.Drain()
+}
+
+func (b *NatsBroker) handle(ctx context.Context, msg jetstream.Msg, handle Handler) {
+	ev, err := decode(msg.Data())
+
+	if err != nil {
+		fmt.Printf("Skipping malformed message on %s: %v\n", msg.Subject(), err)
+	} else if err = handle(ctx, ev); err != nil {
+		_ = msg.NakWithDelay(b.ackWait)
+
+		return
+	}
+
+	if err = msg.Ack(); err != nil {
+		fmt.Printf("Failed to acknowledge message on %s: %v\n", msg.Subject(), err)
+	}
+}
@@ -0,0 +1,164 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/model/event"
+
+	"github.com/go-redis/redis"
+)
+
+const (
+	redisEventField = "event"
+	redisReadCount  = 10
+	redisBlock      = time.Second
+)
+
+// RedisStreamBroker keeps the events in a Redis stream and delivers them
+// through its consumer groups. Events a consumer failed to handle, or left
+// unacknowledged when it went away, stay pending and are claimed again by a
+// consumer of the group once they have been idle for claimIdle.
+type RedisStreamBroker struct {
+	client    *redis.Client
+	stream    string
+	maxLen    int64
+	claimIdle time.Duration
+}
+
+// NewRedisStreamBroker uses the stream key on client, trimming it to about
+// maxLen events; zero keeps all events.
+func NewRedisStreamBroker(client *redis.Client, stream string, maxLen int64, claimIdle time.Duration) *RedisStreamBroker {
+	return &RedisStreamBroker{client: client, stream: stream, maxLen: maxLen, claimIdle: claimIdle}
+}
+
+func (b *RedisStreamBroker) Publish(_ context.Context, ev *event.Event) error {
+	data, err := json.Marshal(ev)
+
+	if err != nil {
+		return err
+	}
+
+	return b.client.XAdd(&redis.XAddArgs{
+		Stream:       b.stream,
+		MaxLenApprox: b.maxLen,
+		Values:       map[string]interface{}{redisEventField: data},
+	}).Err()
+}
+
+func (b *RedisStreamBroker) Subscribe(ctx context.Context, group, consumer string, handle Handler) error {
+	err := b.client.XGroupCreateMkStream(b.stream, group, "0").Err()
+
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create consumer group %s: %w", group, err)
+	}
+
+	for ctx.Err() == nil {
+		messages, err := b.next(group, consumer)
+
+		if err != nil {
+			fmt.Printf("Failed to read stream %s for group %s: %v\n", b.stream, group, err)
+
+			select {
+			case <-time.After(redisBlock):
+			case <-ctx.Done():
+			}
+
+			continue
+		}
+
+		for _, message := range messages {
+			b.handle(ctx, group, message, handle)
+		}
+	}
+
+	return nil
+}
+
+func (b *RedisStreamBroker) Close() error {
+	return nil
+}
+
+// next returns the messages to handle: pending ones idle for claimIdle first,
+// which includes the ones this consumer failed to handle, then new ones.
+func (b *RedisStreamBroker) next(group, consumer string) ([]redis.XMessage, error) {
+	claimed, err := b.claim(group, consumer)
+
+	if err != nil || len(claimed) > 0 {
+		return claimed, err
+	}
+
+	return b.read(group, consumer, redisBlock)
+}
+
+func (b *RedisStreamBroker) read(group, consumer string, block time.Duration) ([]redis.XMessage, error) {
+	streams, err := b.client.XReadGroup(&redis.XReadGroupArgs{
+		Group:    group,
+		Consumer: consumer,
+		Streams:  []string{b.stream, ">"},
+		Count:    redisReadCount,
+		Block:    block,
+	}).Result()
+
+	if err == redis.Nil {
+		return nil, nil
+	}
+
+	if err != nil || len(streams) == 0 {
+		return nil, err
+	}
+
+	return streams[0].Messages, nil
+}
+
+func (b *RedisStreamBroker) claim(group, consumer string) ([]redis.XMessage, error) {
+	pending, err := b.client.XPendingExt(&redis.XPendingExtArgs{
+		Stream: b.stream,
+		Group:  group,
+		Start:  "-",
+		End:    "+",
+		Count:  redisReadCount,
+	}).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+
+	for _, entry := range pending {
+		if entry.Idle >= b.claimIdle {
+			ids = append(ids, entry.Id)
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	return b.client.XClaim(&redis.XClaimArgs{
+		Stream:   b.stream,
+		Group:    group,
+		Consumer: consumer,
+		MinIdle:  b.claimIdle,
+		Messages: ids,
+	}).Result()
+}
+
+func (b *RedisStreamBroker) handle(ctx context.Context, group string, message redis.XMessage, handle Handler) {
+	data, _ := message.Values[redisEventField].(string)
+	ev, err := decode([]byte(data))
+
+	if err != nil {
+		fmt.Printf("Skipping malformed message %s of stream %s: %v\n", message.ID, b.stream, err)
+	} else if handle(ctx, ev) != nil {
+		return
+	}
+
+	if err = b.client.XAck(b.stream, group, message.ID).Err(); err != nil {
+		fmt.Printf("Failed to acknowledge message %s of stream %s: %v\n", message.ID, b.stream, err)
+	}
+}
@@ -7,13 +7,15 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/M2rk13/Otus-327619/internal/enum"
 )
 
 type AppConfig struct {
-	StorageType string
+	StorageType  string
+	DeletePolicy string
 }
 
 type AdminConfig struct {
@@ -23,9 +25,11 @@ type AdminConfig struct {
 }
 
 type FileConfig struct {
-	RequestsFilePath  string
-	ResponsesFilePath string
-	LogsFilePath      string
+	RequestsFilePath    string
+	ResponsesFilePath   string
+	LogsFilePath        string
+	QuotesFilePath      string
+	IdempotencyFilePath string
 }
 
 type MongoConfig struct {
@@ -43,6 +47,131 @@ type PostgresConfig struct {
 	PostgresUri string
 }
 
+type CacheConfig struct {
+	Enabled bool
+	ItemTTL time.Duration
+	ListTTL time.Duration
+	Size    int
+}
+
+type RatesConfig struct {
+	BaseCurrency        string
+	Providers           []string
+	ProviderTimeout     time.Duration
+	BreakerThreshold    int
+	BreakerCooldown     time.Duration
+	ExchangeRateHostURL string
+	ExchangeRateHostKey string
+	ECBURL              string
+	StaticRatesFile     string
+	RatesFile           string
+}
+
+type QuoteCacheConfig struct {
+	TTL      time.Duration
+	StaleTTL time.Duration
+	Size     int
+}
+
+// RoundingConfig holds the rounding policies as "mode[:scale]" strings, the
+// default one and overrides per account login.
+type RoundingConfig struct {
+	DefaultPolicy string
+	Accounts      map[string]string
+}
+
+type FeesConfig struct {
+	SchedulesFile string
+}
+
+// QuoteLockConfig sets how long a quote returned by POST /api/quotes can be
+// executed.
+type QuoteLockConfig struct {
+	TTL time.Duration
+}
+
+// IdempotencyConfig sets how long the response to a request sent with an
+// Idempotency-Key is replayed.
+type IdempotencyConfig struct {
+	TTL time.Duration
+}
+
+// PipelineConfig lists the sources, stages and sinks of the ingestion
+// pipeline, in order.
+type PipelineConfig struct {
+	Sources        []string
+	Stages         []string
+	Sinks          []string
+	Buffer         int
+	DemoIterations int
+	DemoInterval   time.Duration
+}
+
+// IngestConfig sizes the queues between the ingestion pipeline and the
+// storage workers and the worker pools draining them.
+type IngestConfig struct {
+	QueueSize       int
+	QueuePolicy     string
+	RequestWorkers  int
+	ResponseWorkers int
+	LogWorkers      int
+	BatchSize       int
+	FlushInterval   time.Duration
+	// DrainTimeout bounds how long the queued items are stored on shutdown
+	// when there is no outbox to keep them.
+	DrainTimeout time.Duration
+}
+
+// RetryConfig sets how the storage workers retry failed writes before
+// handing the item to the dead-letter store.
+type RetryConfig struct {
+	Attempts  int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// DeadLetterConfig selects where items that could not be stored are kept.
+type DeadLetterConfig struct {
+	Store    string
+	FilePath string
+}
+
+// OutboxConfig sets the local journal that keeps accepted records until the
+// storage backend confirms them, and change events until the broker does.
+type OutboxConfig struct {
+	Enabled  bool
+	FilePath string
+}
+
+// EventsConfig selects the broker change events are published to. A
+// non-empty Group also subscribes this instance to the events, applying them
+// to its repository. Consumer names this instance: it is its consumer name
+// in Group and the origin of the events it publishes, so it must be unique.
+type EventsConfig struct {
+	Broker       string
+	Stream       string
+	QueueSize    int
+	Redeliver    time.Duration
+	StreamMaxLen int64
+	NatsURL      string
+	Group        string
+	Consumer     string
+}
+
+// GrpcConfig sets the address the gRPC API listens on next to the web server.
+type GrpcConfig struct {
+	Addr string
+}
+
+type AuditConfig struct {
+	Retention time.Duration
+}
+
+type TrashConfig struct {
+	Retention     time.Duration
+	PurgeInterval time.Duration
+}
+
 var (
 	AppCfg      AppConfig
 	AdminCfg    AdminConfig
@@ -50,6 +179,22 @@ var (
 	MongoCfg    MongoConfig
 	RedisCfg    RedisConfig
 	PostgresCfg PostgresConfig
+	TrashCfg    TrashConfig
+	AuditCfg    AuditConfig
+	CacheCfg    CacheConfig
+	QuoteCfg    QuoteCacheConfig
+	RatesCfg    RatesConfig
+	RoundingCfg RoundingConfig
+	FeesCfg     FeesConfig
+	LockCfg     QuoteLockConfig
+	IdemCfg     IdempotencyConfig
+	PipelineCfg PipelineConfig
+	IngestCfg   IngestConfig
+	RetryCfg    RetryConfig
+	DeadCfg     DeadLetterConfig
+	OutboxCfg   OutboxConfig
+	EventsCfg   EventsConfig
+	GrpcCfg     GrpcConfig
 )
 
 func LoadAll() {
@@ -59,6 +204,22 @@ func LoadAll() {
 	MongoCfg = loadMongoConfig()
 	RedisCfg = loadRedisConfig()
 	PostgresCfg = loadPostgresConfig()
+	TrashCfg = loadTrashConfig()
+	AuditCfg = loadAuditConfig()
+	CacheCfg = loadCacheConfig()
+	QuoteCfg = loadQuoteCacheConfig()
+	RatesCfg = loadRatesConfig()
+	RoundingCfg = loadRoundingConfig()
+	FeesCfg = loadFeesConfig()
+	LockCfg = loadQuoteLockConfig()
+	IdemCfg = loadIdempotencyConfig()
+	PipelineCfg = loadPipelineConfig()
+	IngestCfg = loadIngestConfig()
+	RetryCfg = loadRetryConfig()
+	DeadCfg = loadDeadLetterConfig()
+	OutboxCfg = loadOutboxConfig()
+	EventsCfg = loadEventsConfig()
+	GrpcCfg = loadGrpcConfig()
 
 	if AdminCfg.Login == "" || AdminCfg.Password == "" || AdminCfg.JwtKey == "" {
 		panic("Admin credentials are required")
@@ -74,8 +235,15 @@ func loadAppConfig() AppConfig {
 		storageType = enum.File
 	}
 
+	deletePolicy := os.Getenv("DELETE_POLICY")
+
+	if deletePolicy != enum.Cascade {
+		deletePolicy = enum.Restrict
+	}
+
 	return AppConfig{
-		StorageType: storageType,
+		StorageType:  storageType,
+		DeletePolicy: deletePolicy,
 	}
 }
 
@@ -89,9 +257,11 @@ func loadAdminConfig() AdminConfig {
 
 func loadFileConfig() FileConfig {
 	cfg := FileConfig{
-		RequestsFilePath:  os.Getenv("REQUESTS_FILE_PATH"),
-		ResponsesFilePath: os.Getenv("RESPONSES_FILE_PATH"),
-		LogsFilePath:      os.Getenv("LOGS_FILE_PATH"),
+		RequestsFilePath:    os.Getenv("REQUESTS_FILE_PATH"),
+		ResponsesFilePath:   os.Getenv("RESPONSES_FILE_PATH"),
+		LogsFilePath:        os.Getenv("LOGS_FILE_PATH"),
+		QuotesFilePath:      os.Getenv("QUOTES_FILE_PATH"),
+		IdempotencyFilePath: os.Getenv("IDEMPOTENCY_FILE_PATH"),
 	}
 
 	if cfg.RequestsFilePath == "" {
@@ -106,6 +276,14 @@ func loadFileConfig() FileConfig {
 		cfg.LogsFilePath = filepath.Join("data", "logs.json")
 	}
 
+	if cfg.QuotesFilePath == "" {
+		cfg.QuotesFilePath = filepath.Join("data", "quotes.json")
+	}
+
+	if cfg.IdempotencyFilePath == "" {
+		cfg.IdempotencyFilePath = filepath.Join("data", "idempotency.json")
+	}
+
 	return cfg
 }
 
@@ -136,3 +314,406 @@ func loadPostgresConfig() PostgresConfig {
 		PostgresUri: os.Getenv("POSTGRES_DSN"),
 	}
 }
+
+func loadTrashConfig() TrashConfig {
+	retentionDays, err := strconv.Atoi(os.Getenv("TRASH_RETENTION_DAYS"))
+
+	if err != nil || retentionDays <= 0 {
+		retentionDays = 30
+	}
+
+	intervalMinutes, err := strconv.Atoi(os.Getenv("TRASH_PURGE_INTERVAL_MINUTES"))
+
+	if err != nil || intervalMinutes <= 0 {
+		intervalMinutes = 60
+	}
+
+	return TrashConfig{
+		Retention:     time.Duration(retentionDays) * 24 * time.Hour,
+		PurgeInterval: time.Duration(intervalMinutes) * time.Minute,
+	}
+}
+
+func loadAuditConfig() AuditConfig {
+	retentionDays, err := strconv.Atoi(os.Getenv("AUDIT_RETENTION_DAYS"))
+
+	if err != nil || retentionDays <= 0 {
+		retentionDays = 90
+	}
+
+	return AuditConfig{
+		Retention: time.Duration(retentionDays) * 24 * time.Hour,
+	}
+}
+
+func loadCacheConfig() CacheConfig {
+	enabled, _ := strconv.ParseBool(os.Getenv("CACHE_ENABLED"))
+
+	itemTTL, err := strconv.Atoi(os.Getenv("CACHE_ITEM_TTL_SECONDS"))
+
+	if err != nil || itemTTL <= 0 {
+		itemTTL = 300
+	}
+
+	listTTL, err := strconv.Atoi(os.Getenv("CACHE_LIST_TTL_SECONDS"))
+
+	if err != nil || listTTL <= 0 {
+		listTTL = 5
+	}
+
+	size, err := strconv.Atoi(os.Getenv("CACHE_LRU_SIZE"))
+
+	if err != nil || size <= 0 {
+		size = 1000
+	}
+
+	return CacheConfig{
+		Enabled: enabled,
+		ItemTTL: time.Duration(itemTTL) * time.Second,
+		ListTTL: time.Duration(listTTL) * time.Second,
+		Size:    size,
+	}
+}
+
+func loadQuoteCacheConfig() QuoteCacheConfig {
+	ttl, err := strconv.Atoi(os.Getenv("QUOTE_CACHE_TTL_SECONDS"))
+
+	if err != nil || ttl <= 0 {
+		ttl = 60
+	}
+
+	staleTTL, err := strconv.Atoi(os.Getenv("QUOTE_CACHE_STALE_SECONDS"))
+
+	if err != nil || staleTTL < 0 {
+		staleTTL = 300
+	}
+
+	size, err := strconv.Atoi(os.Getenv("QUOTE_CACHE_SIZE"))
+
+	if err != nil || size <= 0 {
+		size = 1000
+	}
+
+	return QuoteCacheConfig{
+		TTL:      time.Duration(ttl) * time.Second,
+		StaleTTL: time.Duration(staleTTL) * time.Second,
+		Size:     size,
+	}
+}
+
+func loadRatesConfig() RatesConfig {
+	base := strings.ToUpper(strings.TrimSpace(os.Getenv("RATES_BASE_CURRENCY")))
+
+	if base == "" {
+		base = "USD"
+	}
+
+	providers := strings.Split(os.Getenv("RATES_PROVIDERS"), ",")
+
+	if os.Getenv("RATES_PROVIDERS") == "" {
+		providers = []string{enum.ProviderExchangeRateHost, enum.ProviderECB, enum.ProviderStatic}
+	}
+
+	for i := range providers {
+		providers[i] = strings.TrimSpace(providers[i])
+	}
+
+	timeoutMs, err := strconv.Atoi(os.Getenv("RATES_PROVIDER_TIMEOUT_MS"))
+
+	if err != nil || timeoutMs <= 0 {
+		timeoutMs = 3000
+	}
+
+	threshold, err := strconv.Atoi(os.Getenv("RATES_BREAKER_THRESHOLD"))
+
+	if err != nil || threshold <= 0 {
+		threshold = 3
+	}
+
+	cooldown, err := strconv.Atoi(os.Getenv("RATES_BREAKER_COOLDOWN_SECONDS"))
+
+	if err != nil || cooldown <= 0 {
+		cooldown = 30
+	}
+
+	exchangeRateHostURL := os.Getenv("EXCHANGERATE_HOST_URL")
+
+	if exchangeRateHostURL == "" {
+		exchangeRateHostURL = "https://api.exchangerate.host"
+	}
+
+	ecbURL := os.Getenv("ECB_URL")
+
+	if ecbURL == "" {
+		ecbURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+	}
+
+	return RatesConfig{
+		BaseCurrency:        base,
+		Providers:           providers,
+		ProviderTimeout:     time.Duration(timeoutMs) * time.Millisecond,
+		BreakerThreshold:    threshold,
+		BreakerCooldown:     time.Duration(cooldown) * time.Second,
+		ExchangeRateHostURL: exchangeRateHostURL,
+		ExchangeRateHostKey: os.Getenv("EXCHANGERATE_HOST_ACCESS_KEY"),
+		ECBURL:              ecbURL,
+		StaticRatesFile:     os.Getenv("RATES_STATIC_FILE"),
+		RatesFile:           os.Getenv("RATES_FILE"),
+	}
+}
+
+func loadRoundingConfig() RoundingConfig {
+	defaultPolicy := strings.TrimSpace(os.Getenv("ROUNDING_DEFAULT_POLICY"))
+
+	if defaultPolicy == "" {
+		defaultPolicy = enum.RoundHalfUp
+	}
+
+	return RoundingConfig{
+		DefaultPolicy: defaultPolicy,
+		Accounts:      parseKeyValues(os.Getenv("ROUNDING_ACCOUNT_POLICIES")),
+	}
+}
+
+func loadFeesConfig() FeesConfig {
+	return FeesConfig{SchedulesFile: os.Getenv("FEES_FILE")}
+}
+
+func loadQuoteLockConfig() QuoteLockConfig {
+	ttl, err := strconv.Atoi(os.Getenv("QUOTE_LOCK_TTL_SECONDS"))
+
+	if err != nil || ttl <= 0 {
+		ttl = 30
+	}
+
+	return QuoteLockConfig{TTL: time.Duration(ttl) * time.Second}
+}
+
+func loadIdempotencyConfig() IdempotencyConfig {
+	ttl, err := strconv.Atoi(os.Getenv("IDEMPOTENCY_TTL_HOURS"))
+
+	if err != nil || ttl <= 0 {
+		ttl = 24
+	}
+
+	return IdempotencyConfig{TTL: time.Duration(ttl) * time.Hour}
+}
+
+func loadPipelineConfig() PipelineConfig {
+	// An empty PIPELINE_SOURCES disables ingestion; only an unset one falls
+	// back to the demo generator.
+	sources := []string{enum.SourceDemo}
+
+	if value, ok := os.LookupEnv("PIPELINE_SOURCES"); ok {
+		sources = parseList(value)
+	}
+
+	stages := parseList(os.Getenv("PIPELINE_STAGES"))
+
+	if len(stages) == 0 {
+		stages = []string{enum.StageNormalize, enum.StageValidate}
+	}
+
+	sinks := parseList(os.Getenv("PIPELINE_SINKS"))
+
+	if len(sinks) == 0 {
+		sinks = []string{enum.SinkStorage}
+	}
+
+	buffer, err := strconv.Atoi(os.Getenv("PIPELINE_BUFFER"))
+
+	if err != nil || buffer <= 0 {
+		buffer = 10
+	}
+
+	iterations, err := strconv.Atoi(os.Getenv("DEMO_ITERATIONS"))
+
+	if err != nil || iterations < 0 {
+		iterations = 5
+	}
+
+	intervalMs, err := strconv.Atoi(os.Getenv("DEMO_INTERVAL_MS"))
+
+	if err != nil || intervalMs <= 0 {
+		intervalMs = 500
+	}
+
+	return PipelineConfig{
+		Sources:        sources,
+		Stages:         stages,
+		Sinks:          sinks,
+		Buffer:         buffer,
+		DemoIterations: iterations,
+		DemoInterval:   time.Duration(intervalMs) * time.Millisecond,
+	}
+}
+
+func loadIngestConfig() IngestConfig {
+	policy := strings.TrimSpace(os.Getenv("INGEST_QUEUE_POLICY"))
+
+	switch policy {
+	case enum.QueueBlock, enum.QueueDropOldest, enum.QueueReject:
+	case "":
+		policy = enum.QueueBlock
+	default:
+		log.Fatalf("Unknown ingest queue policy: %s", policy)
+	}
+
+	// A queue without room has nothing to drop to make some.
+	if size, err := strconv.Atoi(os.Getenv("INGEST_QUEUE_SIZE")); policy == enum.QueueDropOldest && err == nil && size <= 0 {
+		log.Fatalf("Ingest queue policy %s needs a positive INGEST_QUEUE_SIZE, got %d", policy, size)
+	}
+
+	flushMs, err := strconv.Atoi(os.Getenv("INGEST_FLUSH_INTERVAL_MS"))
+
+	if err != nil || flushMs < 0 {
+		flushMs = 200
+	}
+
+	return IngestConfig{
+		QueueSize:       positiveInt("INGEST_QUEUE_SIZE", 100),
+		QueuePolicy:     policy,
+		RequestWorkers:  positiveInt("INGEST_REQUEST_WORKERS", 1),
+		ResponseWorkers: positiveInt("INGEST_RESPONSE_WORKERS", 1),
+		LogWorkers:      positiveInt("INGEST_LOG_WORKERS", 1),
+		BatchSize:       positiveInt("INGEST_BATCH_SIZE", 10),
+		FlushInterval:   time.Duration(flushMs) * time.Millisecond,
+		DrainTimeout:    time.Duration(positiveInt("INGEST_DRAIN_TIMEOUT_MS", 5000)) * time.Millisecond,
+	}
+}
+
+func loadRetryConfig() RetryConfig {
+	return RetryConfig{
+		Attempts:  positiveInt("STORAGE_RETRY_ATTEMPTS", 10),
+		BaseDelay: time.Duration(positiveInt("STORAGE_RETRY_BASE_DELAY_MS", 50)) * time.Millisecond,
+		MaxDelay:  time.Duration(positiveInt("STORAGE_RETRY_MAX_DELAY_MS", 5000)) * time.Millisecond,
+	}
+}
+
+func loadDeadLetterConfig() DeadLetterConfig {
+	store := strings.TrimSpace(os.Getenv("DEAD_LETTER_STORE"))
+
+	switch store {
+	case enum.File, enum.Postgres:
+	case "":
+		store = enum.File
+	default:
+		log.Fatalf("Unknown dead letter store: %s", store)
+	}
+
+	filePath := os.Getenv("DEAD_LETTER_FILE_PATH")
+
+	if filePath == "" {
+		filePath = filepath.Join("data", "dead_letters.json")
+	}
+
+	return DeadLetterConfig{Store: store, FilePath: filePath}
+}
+
+func loadOutboxConfig() OutboxConfig {
+	enabled, err := strconv.ParseBool(os.Getenv("OUTBOX_ENABLED"))
+
+	if err != nil {
+		enabled = true
+	}
+
+	filePath := os.Getenv("OUTBOX_FILE_PATH")
+
+	if filePath == "" {
+		filePath = filepath.Join("data", "outbox.json")
+	}
+
+	return OutboxConfig{Enabled: enabled, FilePath: filePath}
+}
+
+func loadEventsConfig() EventsConfig {
+	brokerType := strings.TrimSpace(os.Getenv("EVENTS_BROKER"))
+
+	switch brokerType {
+	case enum.BrokerNone, enum.BrokerMemory, enum.BrokerRedis, enum.BrokerNats:
+	case "":
+		brokerType = enum.BrokerNone
+	default:
+		log.Fatalf("Unknown events broker: %s", brokerType)
+	}
+
+	stream := os.Getenv("EVENTS_STREAM")
+
+	if stream == "" {
+		stream = "conversion_events"
+	}
+
+	natsURL := os.Getenv("NATS_URL")
+
+	if natsURL == "" {
+		natsURL = "nats://127.0.0.1:4222"
+	}
+
+	consumer := os.Getenv("EVENTS_CONSUMER")
+
+	if consumer == "" {
+		consumer, _ = os.Hostname()
+	}
+
+	return EventsConfig{
+		Broker:       brokerType,
+		Stream:       stream,
+		QueueSize:    positiveInt("EVENTS_QUEUE_SIZE", 1000),
+		Redeliver:    time.Duration(positiveInt("EVENTS_REDELIVER_SECONDS", 30)) * time.Second,
+		StreamMaxLen: int64(positiveInt("EVENTS_STREAM_MAX_LEN", 100000)),
+		NatsURL:      natsURL,
+		Group:        strings.TrimSpace(os.Getenv("EVENTS_GROUP")),
+		Consumer:     consumer,
+	}
+}
+
+func loadGrpcConfig() GrpcConfig {
+	addr := strings.TrimSpace(os.Getenv("GRPC_ADDR"))
+
+	if addr == "" {
+		addr = ":9090"
+	}
+
+	return GrpcConfig{Addr: addr}
+}
+
+// positiveInt reads a positive integer variable, falling back to def when it
+// is unset or invalid.
+func positiveInt(name string, def int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+
+	if err != nil || value <= 0 {
+		return def
+	}
+
+	return value
+}
+
+// parseList reads a comma-separated list, skipping empty entries.
+func parseList(value string) []string {
+	var list []string
+
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			list = append(list, entry)
+		}
+	}
+
+	return list
+}
+
+// parseKeyValues reads a "key=value,key=value" list. Entries without a key
+// are ignored.
+func parseKeyValues(value string) map[string]string {
+	values := make(map[string]string)
+
+	for _, entry := range strings.Split(value, ",") {
+		key, val, _ := strings.Cut(entry, "=")
+
+		if key = strings.TrimSpace(key); key != "" {
+			values[key] = strings.TrimSpace(val)
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,9 @@
+package enum
+
+const (
+	ActionCreate  string = "CREATE"
+	ActionUpdate         = "UPDATE"
+	ActionDelete         = "DELETE"
+	ActionRestore        = "RESTORE"
+	ActionPurge          = "PURGE"
+)
@@ -0,0 +1,7 @@
+package enum
+
+const (
+	BreakerClosed   string = "closed"
+	BreakerOpen            = "open"
+	BreakerHalfOpen        = "half_open"
+)
@@ -0,0 +1,8 @@
+package enum
+
+const (
+	BrokerNone   string = "none"
+	BrokerMemory        = "memory"
+	BrokerRedis         = "redis"
+	BrokerNats          = "nats"
+)
@@ -0,0 +1,6 @@
+package enum
+
+const (
+	Restrict string = "restrict"
+	Cascade         = "cascade"
+)
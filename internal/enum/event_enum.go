@@ -0,0 +1,8 @@
+package enum
+
+const (
+	EventCreated  string = "conversion.created"
+	EventUpdated         = "conversion.updated"
+	EventDeleted         = "conversion.deleted"
+	EventRestored        = "conversion.restored"
+)
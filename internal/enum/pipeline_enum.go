@@ -0,0 +1,14 @@
+package enum
+
+const (
+	SourceDemo string = "demo"
+)
+
+const (
+	StageNormalize string = "normalize"
+	StageValidate         = "validate"
+)
+
+const (
+	SinkStorage string = "storage"
+)
@@ -0,0 +1,7 @@
+package enum
+
+const (
+	QueueBlock      string = "block"
+	QueueDropOldest        = "drop_oldest"
+	QueueReject            = "reject"
+)
@@ -0,0 +1,8 @@
+package enum
+
+const (
+	ProviderExchangeRateHost string = "exchangeratehost"
+	ProviderECB                     = "ecb"
+	ProviderStatic                  = "static"
+	ProviderFile                    = "file"
+)
@@ -0,0 +1,10 @@
+package enum
+
+const (
+	RoundHalfUp   string = "half_up"
+	RoundHalfEven        = "half_even"
+	RoundDown            = "down"
+	RoundUp              = "up"
+	RoundCeiling         = "ceiling"
+	RoundFloor           = "floor"
+)
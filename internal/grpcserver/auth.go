@@ -0,0 +1,109 @@
+package grpcserver
+
+import (
+	"context"
+	"strings"
+
+	"github.com/M2rk13/Otus-327619/internal/auth"
+	"github.com/M2rk13/Otus-327619/internal/grpcserver/pb"
+	"github.com/M2rk13/Otus-327619/internal/service"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	authorizationKey = "authorization"
+	requestIDKey     = "x-request-id"
+	reflectionPrefix = "/grpc.reflection."
+)
+
+// publicMethods can be called without a token, like the GET routes of the web
+// server. Every other method needs "authorization: Bearer <token>" metadata
+// with a token from POST /api/auth/login.
+var publicMethods = map[string]bool{
+	pb.ConverterService_GetHistory_FullMethodName:     true,
+	pb.ConverterService_GetHistoryByID_FullMethodName: true,
+	pb.ConverterService_StreamLogs_FullMethodName:     true,
+	pb.RequestService_Get_FullMethodName:              true,
+	pb.RequestService_List_FullMethodName:             true,
+	pb.ResponseService_Get_FullMethodName:             true,
+	pb.ResponseService_List_FullMethodName:            true,
+	pb.ConversionLogService_Get_FullMethodName:        true,
+	pb.ConversionLogService_List_FullMethodName:       true,
+}
+
+// authenticate checks the token of a call to method and carries the actor and
+// the request ID into the service layer. The caller's x-request-id is kept
+// or generated and sent back in the header metadata.
+func authenticate(ctx context.Context, method string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	actor := ""
+
+	if !publicMethods[method] && !strings.HasPrefix(method, reflectionPrefix) {
+		login, err := auth.ParseBearer(firstValue(md, authorizationKey))
+
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+
+		actor = login
+	}
+
+	requestID := firstValue(md, requestIDKey)
+
+	if requestID == "" {
+		requestID = uuid.New().String()
+	}
+
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, requestID))
+
+	return service.WithAuditMeta(ctx, actor, requestID), nil
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
+
+func unaryAuthInterceptor(
+	ctx context.Context,
+	req any,
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	ctx, err := authenticate(ctx, info.FullMethod)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+func streamAuthInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := authenticate(ss.Context(), info.FullMethod)
+
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+}
+
+// authenticatedStream passes the context with the audit metadata on to the
+// stream handler.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,105 @@
+package grpcserver
+
+import (
+	"context"
+
+	"github.com/M2rk13/Otus-327619/internal/grpcserver/pb"
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/service"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type converterServer struct {
+	pb.UnimplementedConverterServiceServer
+	shutdown      context.Context
+	storageSvc    *service.StorageService
+	conversionSvc *service.ConversionService
+}
+
+// Convert is POST /api/convert.
+func (s *converterServer) Convert(ctx context.Context, msg *pb.ConvertRequest) (*pb.ConversionLog, error) {
+	amount, err := decimalFromPb("amount", msg.GetAmount())
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	req := &api.Request{From: msg.GetFrom(), To: msg.GetTo(), Amount: amount}
+	convLog, err := s.conversionSvc.Convert(ctx, req, service.ConvertOptions{
+		Rounding: roundingFromPb(msg.GetRounding()),
+		QuoteId:  msg.GetQuoteId(),
+	})
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return logToPb(convLog), nil
+}
+
+// GetHistory is GET /api/logs.
+func (s *converterServer) GetHistory(_ context.Context, msg *pb.ListRequest) (*pb.GetHistoryResponse, error) {
+	resp := &pb.GetHistoryResponse{}
+
+	for _, convLog := range s.storageSvc.GetAllConversionLogs(msg.GetIncludeDeleted()) {
+		resp.Logs = append(resp.Logs, logToPb(convLog))
+	}
+
+	return resp, nil
+}
+
+// GetHistoryByID is GET /api/logs/{id}.
+func (s *converterServer) GetHistoryByID(_ context.Context, msg *pb.IdRequest) (*pb.ConversionLog, error) {
+	convLog := s.storageSvc.GetConversionLogByID(msg.GetId())
+
+	if convLog == nil {
+		return nil, status.Error(codes.NotFound, "Item not found")
+	}
+
+	return logToPb(convLog), nil
+}
+
+// StreamLogs sends the logs stored by this instance while the client is
+// connected. The watch starts before the stored logs are read, so none is
+// missed in between; logs seen in both are sent once.
+func (s *converterServer) StreamLogs(msg *pb.StreamLogsRequest, stream grpc.ServerStreamingServer[pb.ConversionLog]) error {
+	ctx := stream.Context()
+	watch := s.storageSvc.WatchConversionLogs(ctx)
+	sent := make(map[string]bool)
+
+	if msg.GetIncludeExisting() {
+		for _, convLog := range s.storageSvc.GetAllConversionLogs(false) {
+			if err := stream.Send(logToPb(convLog)); err != nil {
+				return err
+			}
+
+			sent[convLog.Id] = true
+		}
+	}
+
+	for {
+		select {
+		case <-s.shutdown.Done():
+			return status.Error(codes.Unavailable, "Server is shutting down")
+		case convLog, ok := <-watch:
+			if !ok {
+				if ctx.Err() != nil {
+					return status.FromContextError(ctx.Err()).Err()
+				}
+
+				return status.Error(codes.ResourceExhausted, "Stream fell behind, reconnect to continue")
+			}
+
+			if sent[convLog.Id] {
+				continue
+			}
+
+			if err := stream.Send(logToPb(convLog)); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package grpcserver
+
+import (
+	"context"
+
+	"github.com/M2rk13/Otus-327619/internal/grpcserver/pb"
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/log"
+	"github.com/M2rk13/Otus-327619/internal/service"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// The CRUD services mirror the /api/requests, /api/responses and /api/logs
+// routes. Update takes the version to replace from the message instead of
+// If-Match and, like the REST API, refuses to replace a record without one.
+
+var (
+	errNotFound        = status.Error(codes.NotFound, "Item not found")
+	errVersionRequired = status.Error(codes.FailedPrecondition, "Version of the record being replaced is required")
+)
+
+// checkCurrencies normalises and validates the currencies of req like the web
+// server does. Embedded requests may be left empty.
+func checkCurrencies(currencies *service.CurrencyRegistry, prefix string, req *api.Request, embedded bool) error {
+	if err := currencies.NormalizeRequest(prefix, req, embedded); err != nil {
+		return toStatus(err)
+	}
+
+	return nil
+}
+
+type requestServer struct {
+	pb.UnimplementedRequestServiceServer
+	storageSvc *service.StorageService
+	currencies *service.CurrencyRegistry
+}
+
+func (s *requestServer) Create(ctx context.Context, msg *pb.Request) (*pb.Request, error) {
+	req, err := s.read(msg)
+
+	if err != nil {
+		return nil, err
+	}
+
+	req.Id = ""
+
+	if err = s.storageSvc.CreateRequest(ctx, &req); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return requestToPb(&req), nil
+}
+
+func (s *requestServer) Get(_ context.Context, msg *pb.IdRequest) (*pb.Request, error) {
+	req := s.storageSvc.GetRequestByID(msg.GetId())
+
+	if req == nil {
+		return nil, errNotFound
+	}
+
+	return requestToPb(req), nil
+}
+
+func (s *requestServer) List(_ context.Context, msg *pb.ListRequest) (*pb.RequestList, error) {
+	list := &pb.RequestList{}
+
+	for _, req := range s.storageSvc.GetAllRequests(msg.GetIncludeDeleted()) {
+		list.Items = append(list.Items, requestToPb(req))
+	}
+
+	return list, nil
+}
+
+func (s *requestServer) Update(ctx context.Context, msg *pb.Request) (*pb.Request, error) {
+	req, err := s.read(msg)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Version <= 0 {
+		return nil, errVersionRequired
+	}
+
+	if err = s.storageSvc.UpdateRequest(ctx, &req); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return requestToPb(&req), nil
+}
+
+func (s *requestServer) Delete(ctx context.Context, msg *pb.IdRequest) (*emptypb.Empty, error) {
+	if err := s.storageSvc.DeleteRequest(ctx, msg.GetId()); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *requestServer) Restore(ctx context.Context, msg *pb.IdRequest) (*emptypb.Empty, error) {
+	if err := s.storageSvc.RestoreRequest(ctx, msg.GetId()); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *requestServer) read(msg *pb.Request) (api.Request, error) {
+	req, err := requestFromPb("", msg)
+
+	if err != nil {
+		return api.Request{}, toStatus(err)
+	}
+
+	return req, checkCurrencies(s.currencies, "", &req, false)
+}
+
+type responseServer struct {
+	pb.UnimplementedResponseServiceServer
+	storageSvc *service.StorageService
+	currencies *service.CurrencyRegistry
+}
+
+func (s *responseServer) Create(ctx context.Context, msg *pb.Response) (*pb.Response, error) {
+	resp, err := s.read(msg)
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Id = ""
+
+	if err = s.storageSvc.CreateResponse(ctx, &resp); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return responseToPb(&resp), nil
+}
+
+func (s *responseServer) Get(_ context.Context, msg *pb.IdRequest) (*pb.Response, error) {
+	resp := s.storageSvc.GetResponseByID(msg.GetId())
+
+	if resp == nil {
+		return nil, errNotFound
+	}
+
+	return responseToPb(resp), nil
+}
+
+func (s *responseServer) List(_ context.Context, msg *pb.ListRequest) (*pb.ResponseList, error) {
+	list := &pb.ResponseList{}
+
+	for _, resp := range s.storageSvc.GetAllResponses(msg.GetIncludeDeleted()) {
+		list.Items = append(list.Items, responseToPb(resp))
+	}
+
+	return list, nil
+}
+
+func (s *responseServer) Update(ctx context.Context, msg *pb.Response) (*pb.Response, error) {
+	resp, err := s.read(msg)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Version <= 0 {
+		return nil, errVersionRequired
+	}
+
+	if err = s.storageSvc.UpdateResponse(ctx, &resp); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return responseToPb(&resp), nil
+}
+
+func (s *responseServer) Delete(ctx context.Context, msg *pb.IdRequest) (*emptypb.Empty, error) {
+	if err := s.storageSvc.DeleteResponse(ctx, msg.GetId()); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *responseServer) Restore(ctx context.Context, msg *pb.IdRequest) (*emptypb.Empty, error) {
+	if err := s.storageSvc.RestoreResponse(ctx, msg.GetId()); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *responseServer) read(msg *pb.Response) (api.Response, error) {
+	resp, err := responseFromPb("", msg)
+
+	if err != nil {
+		return api.Response{}, toStatus(err)
+	}
+
+	return resp, checkCurrencies(s.currencies, "query.", &resp.Query, true)
+}
+
+type conversionLogServer struct {
+	pb.UnimplementedConversionLogServiceServer
+	storageSvc *service.StorageService
+	currencies *service.CurrencyRegistry
+}
+
+func (s *conversionLogServer) Create(ctx context.Context, msg *pb.ConversionLog) (*pb.ConversionLog, error) {
+	convLog, err := s.read(msg)
+
+	if err != nil {
+		return nil, err
+	}
+
+	convLog.Id = ""
+
+	if err = s.storageSvc.CreateConversionLog(ctx, &convLog); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return logToPb(&convLog), nil
+}
+
+func (s *conversionLogServer) Get(_ context.Context, msg *pb.IdRequest) (*pb.ConversionLog, error) {
+	convLog := s.storageSvc.GetConversionLogByID(msg.GetId())
+
+	if convLog == nil {
+		return nil, errNotFound
+	}
+
+	return logToPb(convLog), nil
+}
+
+func (s *conversionLogServer) List(_ context.Context, msg *pb.ListRequest) (*pb.ConversionLogList, error) {
+	list := &pb.ConversionLogList{}
+
+	for _, convLog := range s.storageSvc.GetAllConversionLogs(msg.GetIncludeDeleted()) {
+		list.Items = append(list.Items, logToPb(convLog))
+	}
+
+	return list, nil
+}
+
+func (s *conversionLogServer) Update(ctx context.Context, msg *pb.ConversionLog) (*pb.ConversionLog, error) {
+	convLog, err := s.read(msg)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if convLog.Version <= 0 {
+		return nil, errVersionRequired
+	}
+
+	if err = s.storageSvc.UpdateConversionLog(ctx, &convLog); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return logToPb(&convLog), nil
+}
+
+func (s *conversionLogServer) Delete(ctx context.Context, msg *pb.IdRequest) (*emptypb.Empty, error) {
+	if err := s.storageSvc.DeleteConversionLog(ctx, msg.GetId()); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *conversionLogServer) Restore(ctx context.Context, msg *pb.IdRequest) (*emptypb.Empty, error) {
+	if err := s.storageSvc.RestoreConversionLog(ctx, msg.GetId()); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *conversionLogServer) read(msg *pb.ConversionLog) (log.ConversionLog, error) {
+	convLog, err := logFromPb(msg)
+
+	if err != nil {
+		return log.ConversionLog{}, toStatus(err)
+	}
+
+	return convLog, checkCurrencies(s.currencies, "request.", &convLog.Request, true)
+}
@@ -0,0 +1,43 @@
+package grpcserver
+
+import (
+	"errors"
+
+	"github.com/M2rk13/Otus-327619/internal/lifecycle"
+	"github.com/M2rk13/Otus-327619/internal/repository"
+	"github.com/M2rk13/Otus-327619/internal/service"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// toStatus maps service errors to gRPC codes the way respondWithError maps
+// them to HTTP statuses in the web server.
+func toStatus(err error) error {
+	var validationErr *service.ValidationError
+
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return status.Error(codes.NotFound, "Item not found")
+	case errors.Is(err, repository.ErrAlreadyExists):
+		return status.Error(codes.AlreadyExists, "Item with this id already exists")
+	case errors.Is(err, repository.ErrReferenced):
+		return status.Error(codes.FailedPrecondition, "Item is referenced by other records")
+	case errors.Is(err, repository.ErrMissingReference):
+		return status.Error(codes.FailedPrecondition, "Referenced item does not exist")
+	case errors.Is(err, repository.ErrVersionConflict):
+		return status.Error(codes.Aborted, "Item has been modified, version does not match")
+	case errors.Is(err, repository.ErrQuoteUsed):
+		return status.Error(codes.AlreadyExists, "Quote has already been used")
+	case errors.Is(err, repository.ErrQuoteExpired):
+		return status.Error(codes.FailedPrecondition, "Quote has expired")
+	case errors.As(err, &validationErr):
+		return status.Error(codes.InvalidArgument, validationErr.Error())
+	case errors.Is(err, service.ErrRateUnavailable):
+		return status.Error(codes.Unavailable, "Exchange rate is not available")
+	case errors.Is(err, lifecycle.ErrQueueFull), errors.Is(err, lifecycle.ErrClosed):
+		return status.Error(codes.Unavailable, "Ingest queue is not accepting items, retry later")
+	default:
+		return status.Errorf(codes.Internal, "Storage error: %v", err)
+	}
+}
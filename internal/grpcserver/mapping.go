@@ -0,0 +1,308 @@
+package grpcserver
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/grpcserver/pb"
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/log"
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+	"github.com/M2rk13/Otus-327619/internal/service"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// The mapping mirrors the JSON encoding of the REST API: decimals travel as
+// strings, an empty string standing for zero.
+
+func decimalToPb(d money.Decimal) string {
+	return d.String()
+}
+
+func decimalFromPb(field, value string) (money.Decimal, error) {
+	if value == "" {
+		return money.Zero, nil
+	}
+
+	d, err := money.Parse(value)
+
+	if err != nil {
+		return money.Zero, &service.ValidationError{Field: field, Message: err.Error()}
+	}
+
+	return d, nil
+}
+
+func timeToPb(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+
+	return timestamppb.New(*t)
+}
+
+func timeFromPb(t *timestamppb.Timestamp) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	value := t.AsTime()
+
+	return &value
+}
+
+func requestToPb(req *api.Request) *pb.Request {
+	return &pb.Request{
+		Id:        req.Id,
+		From:      req.From,
+		To:        req.To,
+		Amount:    decimalToPb(req.Amount),
+		Version:   req.Version,
+		DeletedAt: timeToPb(req.DeletedAt),
+	}
+}
+
+// requestFromPb converts a request; prefix names the field it is embedded in
+// for validation errors.
+func requestFromPb(prefix string, msg *pb.Request) (api.Request, error) {
+	amount, err := decimalFromPb(prefix+"amount", msg.GetAmount())
+
+	if err != nil {
+		return api.Request{}, err
+	}
+
+	return api.Request{
+		Id:        msg.GetId(),
+		From:      msg.GetFrom(),
+		To:        msg.GetTo(),
+		Amount:    amount,
+		Version:   msg.GetVersion(),
+		DeletedAt: timeFromPb(msg.GetDeletedAt()),
+	}, nil
+}
+
+func feesToPb(fees *api.Fees) *pb.Fees {
+	if fees == nil {
+		return nil
+	}
+
+	return &pb.Fees{
+		Schedule:      fees.Schedule,
+		MidRate:       decimalToPb(fees.MidRate),
+		SpreadPercent: decimalToPb(fees.SpreadPercent),
+		AppliedRate:   decimalToPb(fees.AppliedRate),
+		Fee:           decimalToPb(fees.Fee),
+		FeeCurrency:   fees.FeeCurrency,
+		GrossResult:   decimalToPb(fees.GrossResult),
+		NetResult:     decimalToPb(fees.NetResult),
+	}
+}
+
+func feesFromPb(msg *pb.Fees) (*api.Fees, error) {
+	if msg == nil {
+		return nil, nil
+	}
+
+	fees := &api.Fees{Schedule: msg.GetSchedule(), FeeCurrency: msg.GetFeeCurrency()}
+	fields := []struct {
+		name  string
+		value string
+		dest  *money.Decimal
+	}{
+		{"fees.mid_rate", msg.GetMidRate(), &fees.MidRate},
+		{"fees.spread_percent", msg.GetSpreadPercent(), &fees.SpreadPercent},
+		{"fees.applied_rate", msg.GetAppliedRate(), &fees.AppliedRate},
+		{"fees.fee", msg.GetFee(), &fees.Fee},
+		{"fees.gross_result", msg.GetGrossResult(), &fees.GrossResult},
+		{"fees.net_result", msg.GetNetResult(), &fees.NetResult},
+	}
+
+	for _, field := range fields {
+		value, err := decimalFromPb(field.name, field.value)
+
+		if err != nil {
+			return nil, err
+		}
+
+		*field.dest = value
+	}
+
+	return fees, nil
+}
+
+func responseToPb(resp *api.Response) *pb.Response {
+	legs := make([]*pb.Leg, 0, len(resp.Info.Legs))
+
+	for _, leg := range resp.Info.Legs {
+		legs = append(legs, &pb.Leg{From: leg.From, To: leg.To, Rate: decimalToPb(leg.Rate), Provider: leg.Provider})
+	}
+
+	return &pb.Response{
+		Id:      resp.Id,
+		Success: resp.Success,
+		Terms:   resp.Terms,
+		Privacy: resp.Privacy,
+		Query:   requestToPb(&resp.Query),
+		Info: &pb.Info{
+			Timestamp: resp.Info.Timestamp,
+			Quote:     decimalToPb(resp.Info.Quote),
+			Provider:  resp.Info.Provider,
+			Legs:      legs,
+		},
+		Result:    decimalToPb(resp.Result),
+		Fees:      feesToPb(resp.Fees),
+		Version:   resp.Version,
+		DeletedAt: timeToPb(resp.DeletedAt),
+	}
+}
+
+func responseFromPb(prefix string, msg *pb.Response) (api.Response, error) {
+	query, err := requestFromPb(prefix+"query.", msg.GetQuery())
+
+	if err != nil {
+		return api.Response{}, err
+	}
+
+	quote, err := decimalFromPb(prefix+"info.quote", msg.GetInfo().GetQuote())
+
+	if err != nil {
+		return api.Response{}, err
+	}
+
+	result, err := decimalFromPb(prefix+"result", msg.GetResult())
+
+	if err != nil {
+		return api.Response{}, err
+	}
+
+	fees, err := feesFromPb(msg.GetFees())
+
+	if err != nil {
+		return api.Response{}, err
+	}
+
+	var legs []api.Leg
+
+	for i, leg := range msg.GetInfo().GetLegs() {
+		rate, err := decimalFromPb(fmt.Sprintf("%sinfo.legs[%d].rate", prefix, i), leg.GetRate())
+
+		if err != nil {
+			return api.Response{}, err
+		}
+
+		legs = append(legs, api.Leg{From: leg.GetFrom(), To: leg.GetTo(), Rate: rate, Provider: leg.GetProvider()})
+	}
+
+	return api.Response{
+		Id:      msg.GetId(),
+		Success: msg.GetSuccess(),
+		Terms:   msg.GetTerms(),
+		Privacy: msg.GetPrivacy(),
+		Query:   query,
+		Info: api.Info{
+			Timestamp: msg.GetInfo().GetTimestamp(),
+			Quote:     quote,
+			Provider:  msg.GetInfo().GetProvider(),
+			Legs:      legs,
+		},
+		Result:    result,
+		Fees:      fees,
+		Version:   msg.GetVersion(),
+		DeletedAt: timeFromPb(msg.GetDeletedAt()),
+	}, nil
+}
+
+func roundingToPb(policy *money.RoundingPolicy) *pb.RoundingPolicy {
+	if policy == nil {
+		return nil
+	}
+
+	return &pb.RoundingPolicy{Mode: policy.Mode, Scale: policy.Scale}
+}
+
+func roundingFromPb(msg *pb.RoundingPolicy) *money.RoundingPolicy {
+	if msg == nil {
+		return nil
+	}
+
+	return &money.RoundingPolicy{Mode: msg.GetMode(), Scale: msg.Scale}
+}
+
+func logToPb(convLog *log.ConversionLog) *pb.ConversionLog {
+	targets := make([]*pb.Target, 0, len(convLog.Targets))
+
+	for _, target := range convLog.Targets {
+		targets = append(targets, &pb.Target{
+			Request:  requestToPb(&target.Request),
+			Response: responseToPb(&target.Response),
+			Rounding: roundingToPb(target.Rounding),
+		})
+	}
+
+	return &pb.ConversionLog{
+		Id:        convLog.Id,
+		Timestamp: timestamppb.New(convLog.Timestamp),
+		Request:   requestToPb(&convLog.Request),
+		Response:  responseToPb(&convLog.Response),
+		Rounding:  roundingToPb(convLog.Rounding),
+		BatchId:   convLog.BatchId,
+		Targets:   targets,
+		Version:   convLog.Version,
+		DeletedAt: timeToPb(convLog.DeletedAt),
+	}
+}
+
+func logFromPb(msg *pb.ConversionLog) (log.ConversionLog, error) {
+	req, err := requestFromPb("request.", msg.GetRequest())
+
+	if err != nil {
+		return log.ConversionLog{}, err
+	}
+
+	resp, err := responseFromPb("response.", msg.GetResponse())
+
+	if err != nil {
+		return log.ConversionLog{}, err
+	}
+
+	var targets []log.Target
+
+	for _, target := range msg.GetTargets() {
+		targetReq, err := requestFromPb("targets.request.", target.GetRequest())
+
+		if err != nil {
+			return log.ConversionLog{}, err
+		}
+
+		targetResp, err := responseFromPb("targets.response.", target.GetResponse())
+
+		if err != nil {
+			return log.ConversionLog{}, err
+		}
+
+		targets = append(targets, log.Target{
+			Request:  targetReq,
+			Response: targetResp,
+			Rounding: roundingFromPb(target.GetRounding()),
+		})
+	}
+
+	var timestamp time.Time
+
+	if msg.GetTimestamp() != nil {
+		timestamp = msg.GetTimestamp().AsTime()
+	}
+
+	return log.ConversionLog{
+		Id:        msg.GetId(),
+		Timestamp: timestamp,
+		Request:   req,
+		Response:  resp,
+		Rounding:  roundingFromPb(msg.GetRounding()),
+		BatchId:   msg.GetBatchId(),
+		Targets:   targets,
+		Version:   msg.GetVersion(),
+		DeletedAt: timeFromPb(msg.GetDeletedAt()),
+	}, nil
+}
@@ -0,0 +1,1316 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.7
+// 	protoc        (unknown)
+// source: converter/v1/converter.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Request struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	From          string                 `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	To            string                 `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+	Amount        string                 `protobuf:"bytes,4,opt,name=amount,proto3" json:"amount,omitempty"`
+	Version       int64                  `protobuf:"varint,5,opt,name=version,proto3" json:"version,omitempty"`
+	DeletedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=deleted_at,json=deletedAt,proto3" json:"deleted_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Request) Reset() {
+	*x = Request{}
+	mi := &file_converter_v1_converter_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Request) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Request) ProtoMessage() {}
+
+func (x *Request) ProtoReflect() protoreflect.Message {
+	mi := &file_converter_v1_converter_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Request.ProtoReflect.Descriptor instead.
+func (*Request) Descriptor() ([]byte, []int) {
+	return file_converter_v1_converter_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Request) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Request) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *Request) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *Request) GetAmount() string {
+	if x != nil {
+		return x.Amount
+	}
+	return ""
+}
+
+func (x *Request) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Request) GetDeletedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DeletedAt
+	}
+	return nil
+}
+
+// Leg is one step of a derived quote, e.g. GBP→USD of a GBP→JPY cross rate.
+type Leg struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          string                 `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To            string                 `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	Rate          string                 `protobuf:"bytes,3,opt,name=rate,proto3" json:"rate,omitempty"`
+	Provider      string                 `protobuf:"bytes,4,opt,name=provider,proto3" json:"provider,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Leg) Reset() {
+	*x = Leg{}
+	mi := &file_converter_v1_converter_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Leg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Leg) ProtoMessage() {}
+
+func (x *Leg) ProtoReflect() protoreflect.Message {
+	mi := &file_converter_v1_converter_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Leg.ProtoReflect.Descriptor instead.
+func (*Leg) Descriptor() ([]byte, []int) {
+	return file_converter_v1_converter_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Leg) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *Leg) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *Leg) GetRate() string {
+	if x != nil {
+		return x.Rate
+	}
+	return ""
+}
+
+func (x *Leg) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+type Info struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp     int64                  `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Quote         string                 `protobuf:"bytes,2,opt,name=quote,proto3" json:"quote,omitempty"`
+	Provider      string                 `protobuf:"bytes,3,opt,name=provider,proto3" json:"provider,omitempty"`
+	Legs          []*Leg                 `protobuf:"bytes,4,rep,name=legs,proto3" json:"legs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Info) Reset() {
+	*x = Info{}
+	mi := &file_converter_v1_converter_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Info) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Info) ProtoMessage() {}
+
+func (x *Info) ProtoReflect() protoreflect.Message {
+	mi := &file_converter_v1_converter_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Info.ProtoReflect.Descriptor instead.
+func (*Info) Descriptor() ([]byte, []int) {
+	return file_converter_v1_converter_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Info) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *Info) GetQuote() string {
+	if x != nil {
+		return x.Quote
+	}
+	return ""
+}
+
+func (x *Info) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *Info) GetLegs() []*Leg {
+	if x != nil {
+		return x.Legs
+	}
+	return nil
+}
+
+// Fees is the pricing breakdown of a conversion.
+type Fees struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Schedule      string                 `protobuf:"bytes,1,opt,name=schedule,proto3" json:"schedule,omitempty"`
+	MidRate       string                 `protobuf:"bytes,2,opt,name=mid_rate,json=midRate,proto3" json:"mid_rate,omitempty"`
+	SpreadPercent string                 `protobuf:"bytes,3,opt,name=spread_percent,json=spreadPercent,proto3" json:"spread_percent,omitempty"`
+	AppliedRate   string                 `protobuf:"bytes,4,opt,name=applied_rate,json=appliedRate,proto3" json:"applied_rate,omitempty"`
+	Fee           string                 `protobuf:"bytes,5,opt,name=fee,proto3" json:"fee,omitempty"`
+	FeeCurrency   string                 `protobuf:"bytes,6,opt,name=fee_currency,json=feeCurrency,proto3" json:"fee_currency,omitempty"`
+	GrossResult   string                 `protobuf:"bytes,7,opt,name=gross_result,json=grossResult,proto3" json:"gross_result,omitempty"`
+	NetResult     string                 `protobuf:"bytes,8,opt,name=net_result,json=netResult,proto3" json:"net_result,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Fees) Reset() {
+	*x = Fees{}
+	mi := &file_converter_v1_converter_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Fees) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Fees) ProtoMessage() {}
+
+func (x *Fees) ProtoReflect() protoreflect.Message {
+	mi := &file_converter_v1_converter_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Fees.ProtoReflect.Descriptor instead.
+func (*Fees) Descriptor() ([]byte, []int) {
+	return file_converter_v1_converter_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Fees) GetSchedule() string {
+	if x != nil {
+		return x.Schedule
+	}
+	return ""
+}
+
+func (x *Fees) GetMidRate() string {
+	if x != nil {
+		return x.MidRate
+	}
+	return ""
+}
+
+func (x *Fees) GetSpreadPercent() string {
+	if x != nil {
+		return x.SpreadPercent
+	}
+	return ""
+}
+
+func (x *Fees) GetAppliedRate() string {
+	if x != nil {
+		return x.AppliedRate
+	}
+	return ""
+}
+
+func (x *Fees) GetFee() string {
+	if x != nil {
+		return x.Fee
+	}
+	return ""
+}
+
+func (x *Fees) GetFeeCurrency() string {
+	if x != nil {
+		return x.FeeCurrency
+	}
+	return ""
+}
+
+func (x *Fees) GetGrossResult() string {
+	if x != nil {
+		return x.GrossResult
+	}
+	return ""
+}
+
+func (x *Fees) GetNetResult() string {
+	if x != nil {
+		return x.NetResult
+	}
+	return ""
+}
+
+type Response struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Success       bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
+	Terms         string                 `protobuf:"bytes,3,opt,name=terms,proto3" json:"terms,omitempty"`
+	Privacy       string                 `protobuf:"bytes,4,opt,name=privacy,proto3" json:"privacy,omitempty"`
+	Query         *Request               `protobuf:"bytes,5,opt,name=query,proto3" json:"query,omitempty"`
+	Info          *Info                  `protobuf:"bytes,6,opt,name=info,proto3" json:"info,omitempty"`
+	Result        string                 `protobuf:"bytes,7,opt,name=result,proto3" json:"result,omitempty"`
+	Fees          *Fees                  `protobuf:"bytes,8,opt,name=fees,proto3" json:"fees,omitempty"`
+	Version       int64                  `protobuf:"varint,9,opt,name=version,proto3" json:"version,omitempty"`
+	DeletedAt     *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=deleted_at,json=deletedAt,proto3" json:"deleted_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Response) Reset() {
+	*x = Response{}
+	mi := &file_converter_v1_converter_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Response) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Response) ProtoMessage() {}
+
+func (x *Response) ProtoReflect() protoreflect.Message {
+	mi := &file_converter_v1_converter_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Response.ProtoReflect.Descriptor instead.
+func (*Response) Descriptor() ([]byte, []int) {
+	return file_converter_v1_converter_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Response) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Response) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *Response) GetTerms() string {
+	if x != nil {
+		return x.Terms
+	}
+	return ""
+}
+
+func (x *Response) GetPrivacy() string {
+	if x != nil {
+		return x.Privacy
+	}
+	return ""
+}
+
+func (x *Response) GetQuery() *Request {
+	if x != nil {
+		return x.Query
+	}
+	return nil
+}
+
+func (x *Response) GetInfo() *Info {
+	if x != nil {
+		return x.Info
+	}
+	return nil
+}
+
+func (x *Response) GetResult() string {
+	if x != nil {
+		return x.Result
+	}
+	return ""
+}
+
+func (x *Response) GetFees() *Fees {
+	if x != nil {
+		return x.Fees
+	}
+	return nil
+}
+
+func (x *Response) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Response) GetDeletedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DeletedAt
+	}
+	return nil
+}
+
+// RoundingPolicy says how a result is rounded. Without scale the result is
+// rounded to the minor units of the currency.
+type RoundingPolicy struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Mode          string                 `protobuf:"bytes,1,opt,name=mode,proto3" json:"mode,omitempty"`
+	Scale         *int32                 `protobuf:"varint,2,opt,name=scale,proto3,oneof" json:"scale,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RoundingPolicy) Reset() {
+	*x = RoundingPolicy{}
+	mi := &file_converter_v1_converter_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RoundingPolicy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoundingPolicy) ProtoMessage() {}
+
+func (x *RoundingPolicy) ProtoReflect() protoreflect.Message {
+	mi := &file_converter_v1_converter_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoundingPolicy.ProtoReflect.Descriptor instead.
+func (*RoundingPolicy) Descriptor() ([]byte, []int) {
+	return file_converter_v1_converter_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *RoundingPolicy) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *RoundingPolicy) GetScale() int32 {
+	if x != nil && x.Scale != nil {
+		return *x.Scale
+	}
+	return 0
+}
+
+// Target is one leg of a multi-target conversion.
+type Target struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Request       *Request               `protobuf:"bytes,1,opt,name=request,proto3" json:"request,omitempty"`
+	Response      *Response              `protobuf:"bytes,2,opt,name=response,proto3" json:"response,omitempty"`
+	Rounding      *RoundingPolicy        `protobuf:"bytes,3,opt,name=rounding,proto3" json:"rounding,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Target) Reset() {
+	*x = Target{}
+	mi := &file_converter_v1_converter_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Target) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Target) ProtoMessage() {}
+
+func (x *Target) ProtoReflect() protoreflect.Message {
+	mi := &file_converter_v1_converter_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Target.ProtoReflect.Descriptor instead.
+func (*Target) Descriptor() ([]byte, []int) {
+	return file_converter_v1_converter_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Target) GetRequest() *Request {
+	if x != nil {
+		return x.Request
+	}
+	return nil
+}
+
+func (x *Target) GetResponse() *Response {
+	if x != nil {
+		return x.Response
+	}
+	return nil
+}
+
+func (x *Target) GetRounding() *RoundingPolicy {
+	if x != nil {
+		return x.Rounding
+	}
+	return nil
+}
+
+type ConversionLog struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Request       *Request               `protobuf:"bytes,3,opt,name=request,proto3" json:"request,omitempty"`
+	Response      *Response              `protobuf:"bytes,4,opt,name=response,proto3" json:"response,omitempty"`
+	Rounding      *RoundingPolicy        `protobuf:"bytes,5,opt,name=rounding,proto3" json:"rounding,omitempty"`
+	BatchId       string                 `protobuf:"bytes,6,opt,name=batch_id,json=batchId,proto3" json:"batch_id,omitempty"`
+	Targets       []*Target              `protobuf:"bytes,7,rep,name=targets,proto3" json:"targets,omitempty"`
+	Version       int64                  `protobuf:"varint,8,opt,name=version,proto3" json:"version,omitempty"`
+	DeletedAt     *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=deleted_at,json=deletedAt,proto3" json:"deleted_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConversionLog) Reset() {
+	*x = ConversionLog{}
+	mi := &file_converter_v1_converter_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConversionLog) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConversionLog) ProtoMessage() {}
+
+func (x *ConversionLog) ProtoReflect() protoreflect.Message {
+	mi := &file_converter_v1_converter_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConversionLog.ProtoReflect.Descriptor instead.
+func (*ConversionLog) Descriptor() ([]byte, []int) {
+	return file_converter_v1_converter_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ConversionLog) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ConversionLog) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *ConversionLog) GetRequest() *Request {
+	if x != nil {
+		return x.Request
+	}
+	return nil
+}
+
+func (x *ConversionLog) GetResponse() *Response {
+	if x != nil {
+		return x.Response
+	}
+	return nil
+}
+
+func (x *ConversionLog) GetRounding() *RoundingPolicy {
+	if x != nil {
+		return x.Rounding
+	}
+	return nil
+}
+
+func (x *ConversionLog) GetBatchId() string {
+	if x != nil {
+		return x.BatchId
+	}
+	return ""
+}
+
+func (x *ConversionLog) GetTargets() []*Target {
+	if x != nil {
+		return x.Targets
+	}
+	return nil
+}
+
+func (x *ConversionLog) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *ConversionLog) GetDeletedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DeletedAt
+	}
+	return nil
+}
+
+type IdRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IdRequest) Reset() {
+	*x = IdRequest{}
+	mi := &file_converter_v1_converter_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IdRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IdRequest) ProtoMessage() {}
+
+func (x *IdRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_converter_v1_converter_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IdRequest.ProtoReflect.Descriptor instead.
+func (*IdRequest) Descriptor() ([]byte, []int) {
+	return file_converter_v1_converter_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *IdRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ListRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	IncludeDeleted bool                   `protobuf:"varint,1,opt,name=include_deleted,json=includeDeleted,proto3" json:"include_deleted,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ListRequest) Reset() {
+	*x = ListRequest{}
+	mi := &file_converter_v1_converter_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRequest) ProtoMessage() {}
+
+func (x *ListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_converter_v1_converter_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRequest.ProtoReflect.Descriptor instead.
+func (*ListRequest) Descriptor() ([]byte, []int) {
+	return file_converter_v1_converter_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ListRequest) GetIncludeDeleted() bool {
+	if x != nil {
+		return x.IncludeDeleted
+	}
+	return false
+}
+
+// ConvertRequest is POST /api/convert: with quote_id a locked quote is
+// executed and from, to and amount may be left empty.
+type ConvertRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          string                 `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To            string                 `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	Amount        string                 `protobuf:"bytes,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	Rounding      *RoundingPolicy        `protobuf:"bytes,4,opt,name=rounding,proto3" json:"rounding,omitempty"`
+	QuoteId       string                 `protobuf:"bytes,5,opt,name=quote_id,json=quoteId,proto3" json:"quote_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConvertRequest) Reset() {
+	*x = ConvertRequest{}
+	mi := &file_converter_v1_converter_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConvertRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConvertRequest) ProtoMessage() {}
+
+func (x *ConvertRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_converter_v1_converter_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConvertRequest.ProtoReflect.Descriptor instead.
+func (*ConvertRequest) Descriptor() ([]byte, []int) {
+	return file_converter_v1_converter_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ConvertRequest) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *ConvertRequest) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *ConvertRequest) GetAmount() string {
+	if x != nil {
+		return x.Amount
+	}
+	return ""
+}
+
+func (x *ConvertRequest) GetRounding() *RoundingPolicy {
+	if x != nil {
+		return x.Rounding
+	}
+	return nil
+}
+
+func (x *ConvertRequest) GetQuoteId() string {
+	if x != nil {
+		return x.QuoteId
+	}
+	return ""
+}
+
+type GetHistoryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Logs          []*ConversionLog       `protobuf:"bytes,1,rep,name=logs,proto3" json:"logs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetHistoryResponse) Reset() {
+	*x = GetHistoryResponse{}
+	mi := &file_converter_v1_converter_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetHistoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetHistoryResponse) ProtoMessage() {}
+
+func (x *GetHistoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_converter_v1_converter_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetHistoryResponse.ProtoReflect.Descriptor instead.
+func (*GetHistoryResponse) Descriptor() ([]byte, []int) {
+	return file_converter_v1_converter_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetHistoryResponse) GetLogs() []*ConversionLog {
+	if x != nil {
+		return x.Logs
+	}
+	return nil
+}
+
+type StreamLogsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// include_existing sends the stored logs before the new ones.
+	IncludeExisting bool `protobuf:"varint,1,opt,name=include_existing,json=includeExisting,proto3" json:"include_existing,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *StreamLogsRequest) Reset() {
+	*x = StreamLogsRequest{}
+	mi := &file_converter_v1_converter_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamLogsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamLogsRequest) ProtoMessage() {}
+
+func (x *StreamLogsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_converter_v1_converter_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamLogsRequest.ProtoReflect.Descriptor instead.
+func (*StreamLogsRequest) Descriptor() ([]byte, []int) {
+	return file_converter_v1_converter_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *StreamLogsRequest) GetIncludeExisting() bool {
+	if x != nil {
+		return x.IncludeExisting
+	}
+	return false
+}
+
+type RequestList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Items         []*Request             `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RequestList) Reset() {
+	*x = RequestList{}
+	mi := &file_converter_v1_converter_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RequestList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestList) ProtoMessage() {}
+
+func (x *RequestList) ProtoReflect() protoreflect.Message {
+	mi := &file_converter_v1_converter_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestList.ProtoReflect.Descriptor instead.
+func (*RequestList) Descriptor() ([]byte, []int) {
+	return file_converter_v1_converter_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *RequestList) GetItems() []*Request {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+type ResponseList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Items         []*Response            `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResponseList) Reset() {
+	*x = ResponseList{}
+	mi := &file_converter_v1_converter_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResponseList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResponseList) ProtoMessage() {}
+
+func (x *ResponseList) ProtoReflect() protoreflect.Message {
+	mi := &file_converter_v1_converter_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResponseList.ProtoReflect.Descriptor instead.
+func (*ResponseList) Descriptor() ([]byte, []int) {
+	return file_converter_v1_converter_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ResponseList) GetItems() []*Response {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+type ConversionLogList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Items         []*ConversionLog       `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConversionLogList) Reset() {
+	*x = ConversionLogList{}
+	mi := &file_converter_v1_converter_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConversionLogList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConversionLogList) ProtoMessage() {}
+
+func (x *ConversionLogList) ProtoReflect() protoreflect.Message {
+	mi := &file_converter_v1_converter_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConversionLogList.ProtoReflect.Descriptor instead.
+func (*ConversionLogList) Descriptor() ([]byte, []int) {
+	return file_converter_v1_converter_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ConversionLogList) GetItems() []*ConversionLog {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+var File_converter_v1_converter_proto protoreflect.FileDescriptor
+
+const file_converter_v1_converter_proto_rawDesc = "" +
+	"\n" +
+	"\x1cconverter/v1/converter.proto\x12\fconverter.v1\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xaa\x01\n" +
+	"\aRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04from\x18\x02 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x03 \x01(\tR\x02to\x12\x16\n" +
+	"\x06amount\x18\x04 \x01(\tR\x06amount\x12\x18\n" +
+	"\aversion\x18\x05 \x01(\x03R\aversion\x129\n" +
+	"\n" +
+	"deleted_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tdeletedAt\"Y\n" +
+	"\x03Leg\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x02 \x01(\tR\x02to\x12\x12\n" +
+	"\x04rate\x18\x03 \x01(\tR\x04rate\x12\x1a\n" +
+	"\bprovider\x18\x04 \x01(\tR\bprovider\"}\n" +
+	"\x04Info\x12\x1c\n" +
+	"\ttimestamp\x18\x01 \x01(\x03R\ttimestamp\x12\x14\n" +
+	"\x05quote\x18\x02 \x01(\tR\x05quote\x12\x1a\n" +
+	"\bprovider\x18\x03 \x01(\tR\bprovider\x12%\n" +
+	"\x04legs\x18\x04 \x03(\v2\x11.converter.v1.LegR\x04legs\"\xfe\x01\n" +
+	"\x04Fees\x12\x1a\n" +
+	"\bschedule\x18\x01 \x01(\tR\bschedule\x12\x19\n" +
+	"\bmid_rate\x18\x02 \x01(\tR\amidRate\x12%\n" +
+	"\x0espread_percent\x18\x03 \x01(\tR\rspreadPercent\x12!\n" +
+	"\fapplied_rate\x18\x04 \x01(\tR\vappliedRate\x12\x10\n" +
+	"\x03fee\x18\x05 \x01(\tR\x03fee\x12!\n" +
+	"\ffee_currency\x18\x06 \x01(\tR\vfeeCurrency\x12!\n" +
+	"\fgross_result\x18\a \x01(\tR\vgrossResult\x12\x1d\n" +
+	"\n" +
+	"net_result\x18\b \x01(\tR\tnetResult\"\xce\x02\n" +
+	"\bResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\asuccess\x18\x02 \x01(\bR\asuccess\x12\x14\n" +
+	"\x05terms\x18\x03 \x01(\tR\x05terms\x12\x18\n" +
+	"\aprivacy\x18\x04 \x01(\tR\aprivacy\x12+\n" +
+	"\x05query\x18\x05 \x01(\v2\x15.converter.v1.RequestR\x05query\x12&\n" +
+	"\x04info\x18\x06 \x01(\v2\x12.converter.v1.InfoR\x04info\x12\x16\n" +
+	"\x06result\x18\a \x01(\tR\x06result\x12&\n" +
+	"\x04fees\x18\b \x01(\v2\x12.converter.v1.FeesR\x04fees\x12\x18\n" +
+	"\aversion\x18\t \x01(\x03R\aversion\x129\n" +
+	"\n" +
+	"deleted_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\tdeletedAt\"I\n" +
+	"\x0eRoundingPolicy\x12\x12\n" +
+	"\x04mode\x18\x01 \x01(\tR\x04mode\x12\x19\n" +
+	"\x05scale\x18\x02 \x01(\x05H\x00R\x05scale\x88\x01\x01B\b\n" +
+	"\x06_scale\"\xa7\x01\n" +
+	"\x06Target\x12/\n" +
+	"\arequest\x18\x01 \x01(\v2\x15.converter.v1.RequestR\arequest\x122\n" +
+	"\bresponse\x18\x02 \x01(\v2\x16.converter.v1.ResponseR\bresponse\x128\n" +
+	"\brounding\x18\x03 \x01(\v2\x1c.converter.v1.RoundingPolicyR\brounding\"\x98\x03\n" +
+	"\rConversionLog\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x128\n" +
+	"\ttimestamp\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12/\n" +
+	"\arequest\x18\x03 \x01(\v2\x15.converter.v1.RequestR\arequest\x122\n" +
+	"\bresponse\x18\x04 \x01(\v2\x16.converter.v1.ResponseR\bresponse\x128\n" +
+	"\brounding\x18\x05 \x01(\v2\x1c.converter.v1.RoundingPolicyR\brounding\x12\x19\n" +
+	"\bbatch_id\x18\x06 \x01(\tR\abatchId\x12.\n" +
+	"\atargets\x18\a \x03(\v2\x14.converter.v1.TargetR\atargets\x12\x18\n" +
+	"\aversion\x18\b \x01(\x03R\aversion\x129\n" +
+	"\n" +
+	"deleted_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tdeletedAt\"\x1b\n" +
+	"\tIdRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"6\n" +
+	"\vListRequest\x12'\n" +
+	"\x0finclude_deleted\x18\x01 \x01(\bR\x0eincludeDeleted\"\xa1\x01\n" +
+	"\x0eConvertRequest\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x02 \x01(\tR\x02to\x12\x16\n" +
+	"\x06amount\x18\x03 \x01(\tR\x06amount\x128\n" +
+	"\brounding\x18\x04 \x01(\v2\x1c.converter.v1.RoundingPolicyR\brounding\x12\x19\n" +
+	"\bquote_id\x18\x05 \x01(\tR\aquoteId\"E\n" +
+	"\x12GetHistoryResponse\x12/\n" +
+	"\x04logs\x18\x01 \x03(\v2\x1b.converter.v1.ConversionLogR\x04logs\">\n" +
+	"\x11StreamLogsRequest\x12)\n" +
+	"\x10include_existing\x18\x01 \x01(\bR\x0fincludeExisting\":\n" +
+	"\vRequestList\x12+\n" +
+	"\x05items\x18\x01 \x03(\v2\x15.converter.v1.RequestR\x05items\"<\n" +
+	"\fResponseList\x12,\n" +
+	"\x05items\x18\x01 \x03(\v2\x16.converter.v1.ResponseR\x05items\"F\n" +
+	"\x11ConversionLogList\x121\n" +
+	"\x05items\x18\x01 \x03(\v2\x1b.converter.v1.ConversionLogR\x05items2\xb9\x02\n" +
+	"\x10ConverterService\x12D\n" +
+	"\aConvert\x12\x1c.converter.v1.ConvertRequest\x1a\x1b.converter.v1.ConversionLog\x12I\n" +
+	"\n" +
+	"GetHistory\x12\x19.converter.v1.ListRequest\x1a .converter.v1.GetHistoryResponse\x12F\n" +
+	"\x0eGetHistoryByID\x12\x17.converter.v1.IdRequest\x1a\x1b.converter.v1.ConversionLog\x12L\n" +
+	"\n" +
+	"StreamLogs\x12\x1f.converter.v1.StreamLogsRequest\x1a\x1b.converter.v1.ConversionLog0\x012\xec\x02\n" +
+	"\x0eRequestService\x126\n" +
+	"\x06Create\x12\x15.converter.v1.Request\x1a\x15.converter.v1.Request\x125\n" +
+	"\x03Get\x12\x17.converter.v1.IdRequest\x1a\x15.converter.v1.Request\x12<\n" +
+	"\x04List\x12\x19.converter.v1.ListRequest\x1a\x19.converter.v1.RequestList\x126\n" +
+	"\x06Update\x12\x15.converter.v1.Request\x1a\x15.converter.v1.Request\x129\n" +
+	"\x06Delete\x12\x17.converter.v1.IdRequest\x1a\x16.google.protobuf.Empty\x12:\n" +
+	"\aRestore\x12\x17.converter.v1.IdRequest\x1a\x16.google.protobuf.Empty2\xf3\x02\n" +
+	"\x0fResponseService\x128\n" +
+	"\x06Create\x12\x16.converter.v1.Response\x1a\x16.converter.v1.Response\x126\n" +
+	"\x03Get\x12\x17.converter.v1.IdRequest\x1a\x16.converter.v1.Response\x12=\n" +
+	"\x04List\x12\x19.converter.v1.ListRequest\x1a\x1a.converter.v1.ResponseList\x128\n" +
+	"\x06Update\x12\x16.converter.v1.Response\x1a\x16.converter.v1.Response\x129\n" +
+	"\x06Delete\x12\x17.converter.v1.IdRequest\x1a\x16.google.protobuf.Empty\x12:\n" +
+	"\aRestore\x12\x17.converter.v1.IdRequest\x1a\x16.google.protobuf.Empty2\x96\x03\n" +
+	"\x14ConversionLogService\x12B\n" +
+	"\x06Create\x12\x1b.converter.v1.ConversionLog\x1a\x1b.converter.v1.ConversionLog\x12;\n" +
+	"\x03Get\x12\x17.converter.v1.IdRequest\x1a\x1b.converter.v1.ConversionLog\x12B\n" +
+	"\x04List\x12\x19.converter.v1.ListRequest\x1a\x1f.converter.v1.ConversionLogList\x12B\n" +
+	"\x06Update\x12\x1b.converter.v1.ConversionLog\x1a\x1b.converter.v1.ConversionLog\x129\n" +
+	"\x06Delete\x12\x17.converter.v1.IdRequest\x1a\x16.google.protobuf.Empty\x12:\n" +
+	"\aRestore\x12\x17.converter.v1.IdRequest\x1a\x16.google.protobuf.EmptyB6Z4github.com/M2rk13/Otus-327619/internal/grpcserver/pbb\x06proto3"
+
+var (
+	file_converter_v1_converter_proto_rawDescOnce sync.Once
+	file_converter_v1_converter_proto_rawDescData []byte
+)
+
+func file_converter_v1_converter_proto_rawDescGZIP() []byte {
+	file_converter_v1_converter_proto_rawDescOnce.Do(func() {
+		file_converter_v1_converter_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_converter_v1_converter_proto_rawDesc), len(file_converter_v1_converter_proto_rawDesc)))
+	})
+	return file_converter_v1_converter_proto_rawDescData
+}
+
+var file_converter_v1_converter_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
+var file_converter_v1_converter_proto_goTypes = []any{
+	(*Request)(nil),               // 0: converter.v1.Request
+	(*Leg)(nil),                   // 1: converter.v1.Leg
+	(*Info)(nil),                  // 2: converter.v1.Info
+	(*Fees)(nil),                  // 3: converter.v1.Fees
+	(*Response)(nil),              // 4: converter.v1.Response
+	(*RoundingPolicy)(nil),        // 5: converter.v1.RoundingPolicy
+	(*Target)(nil),                // 6: converter.v1.Target
+	(*ConversionLog)(nil),         // 7: converter.v1.ConversionLog
+	(*IdRequest)(nil),             // 8: converter.v1.IdRequest
+	(*ListRequest)(nil),           // 9: converter.v1.ListRequest
+	(*ConvertRequest)(nil),        // 10: converter.v1.ConvertRequest
+	(*GetHistoryResponse)(nil),    // 11: converter.v1.GetHistoryResponse
+	(*StreamLogsRequest)(nil),     // 12: converter.v1.StreamLogsRequest
+	(*RequestList)(nil),           // 13: converter.v1.RequestList
+	(*ResponseList)(nil),          // 14: converter.v1.ResponseList
+	(*ConversionLogList)(nil),     // 15: converter.v1.ConversionLogList
+	(*timestamppb.Timestamp)(nil), // 16: google.protobuf.Timestamp
+	(*emptypb.Empty)(nil),         // 17: google.protobuf.Empty
+}
+var file_converter_v1_converter_proto_depIdxs = []int32{
+	16, // 0: converter.v1.Request.deleted_at:type_name -> google.protobuf.Timestamp
+	1,  // 1: converter.v1.Info.legs:type_name -> converter.v1.Leg
+	0,  // 2: converter.v1.Response.query:type_name -> converter.v1.Request
+	2,  // 3: converter.v1.Response.info:type_name -> converter.v1.Info
+	3,  // 4: converter.v1.Response.fees:type_name -> converter.v1.Fees
+	16, // 5: converter.v1.Response.deleted_at:type_name -> google.protobuf.Timestamp
+	0,  // 6: converter.v1.Target.request:type_name -> converter.v1.Request
+	4,  // 7: converter.v1.Target.response:type_name -> converter.v1.Response
+	5,  // 8: converter.v1.Target.rounding:type_name -> converter.v1.RoundingPolicy
+	16, // 9: converter.v1.ConversionLog.timestamp:type_name -> google.protobuf.Timestamp
+	0,  // 10: converter.v1.ConversionLog.request:type_name -> converter.v1.Request
+	4,  // 11: converter.v1.ConversionLog.response:type_name -> converter.v1.Response
+	5,  // 12: converter.v1.ConversionLog.rounding:type_name -> converter.v1.RoundingPolicy
+	6,  // 13: converter.v1.ConversionLog.targets:type_name -> converter.v1.Target
+	16, // 14: converter.v1.ConversionLog.deleted_at:type_name -> google.protobuf.Timestamp
+	5,  // 15: converter.v1.ConvertRequest.rounding:type_name -> converter.v1.RoundingPolicy
+	7,  // 16: converter.v1.GetHistoryResponse.logs:type_name -> converter.v1.ConversionLog
+	0,  // 17: converter.v1.RequestList.items:type_name -> converter.v1.Request
+	4,  // 18: converter.v1.ResponseList.items:type_name -> converter.v1.Response
+	7,  // 19: converter.v1.ConversionLogList.items:type_name -> converter.v1.ConversionLog
+	10, // 20: converter.v1.ConverterService.Convert:input_type -> converter.v1.ConvertRequest
+	9,  // 21: converter.v1.ConverterService.GetHistory:input_type -> converter.v1.ListRequest
+	8,  // 22: converter.v1.ConverterService.GetHistoryByID:input_type -> converter.v1.IdRequest
+	12, // 23: converter.v1.ConverterService.StreamLogs:input_type -> converter.v1.StreamLogsRequest
+	0,  // 24: converter.v1.RequestService.Create:input_type -> converter.v1.Request
+	8,  // 25: converter.v1.RequestService.Get:input_type -> converter.v1.IdRequest
+	9,  // 26: converter.v1.RequestService.List:input_type -> converter.v1.ListRequest
+	0,  // 27: converter.v1.RequestService.Update:input_type -> converter.v1.Request
+	8,  // 28: converter.v1.RequestService.Delete:input_type -> converter.v1.IdRequest
+	8,  // 29: converter.v1.RequestService.Restore:input_type -> converter.v1.IdRequest
+	4,  // 30: converter.v1.ResponseService.Create:input_type -> converter.v1.Response
+	8,  // 31: converter.v1.ResponseService.Get:input_type -> converter.v1.IdRequest
+	9,  // 32: converter.v1.ResponseService.List:input_type -> converter.v1.ListRequest
+	4,  // 33: converter.v1.ResponseService.Update:input_type -> converter.v1.Response
+	8,  // 34: converter.v1.ResponseService.Delete:input_type -> converter.v1.IdRequest
+	8,  // 35: converter.v1.ResponseService.Restore:input_type -> converter.v1.IdRequest
+	7,  // 36: converter.v1.ConversionLogService.Create:input_type -> converter.v1.ConversionLog
+	8,  // 37: converter.v1.ConversionLogService.Get:input_type -> converter.v1.IdRequest
+	9,  // 38: converter.v1.ConversionLogService.List:input_type -> converter.v1.ListRequest
+	7,  // 39: converter.v1.ConversionLogService.Update:input_type -> converter.v1.ConversionLog
+	8,  // 40: converter.v1.ConversionLogService.Delete:input_type -> converter.v1.IdRequest
+	8,  // 41: converter.v1.ConversionLogService.Restore:input_type -> converter.v1.IdRequest
+	7,  // 42: converter.v1.ConverterService.Convert:output_type -> converter.v1.ConversionLog
+	11, // 43: converter.v1.ConverterService.GetHistory:output_type -> converter.v1.GetHistoryResponse
+	7,  // 44: converter.v1.ConverterService.GetHistoryByID:output_type -> converter.v1.ConversionLog
+	7,  // 45: converter.v1.ConverterService.StreamLogs:output_type -> converter.v1.ConversionLog
+	0,  // 46: converter.v1.RequestService.Create:output_type -> converter.v1.Request
+	0,  // 47: converter.v1.RequestService.Get:output_type -> converter.v1.Request
+	13, // 48: converter.v1.RequestService.List:output_type -> converter.v1.RequestList
+	0,  // 49: converter.v1.RequestService.Update:output_type -> converter.v1.Request
+	17, // 50: converter.v1.RequestService.Delete:output_type -> google.protobuf.Empty
+	17, // 51: converter.v1.RequestService.Restore:output_type -> google.protobuf.Empty
+	4,  // 52: converter.v1.ResponseService.Create:output_type -> converter.v1.Response
+	4,  // 53: converter.v1.ResponseService.Get:output_type -> converter.v1.Response
+	14, // 54: converter.v1.ResponseService.List:output_type -> converter.v1.ResponseList
+	4,  // 55: converter.v1.ResponseService.Update:output_type -> converter.v1.Response
+	17, // 56: converter.v1.ResponseService.Delete:output_type -> google.protobuf.Empty
+	17, // 57: converter.v1.ResponseService.Restore:output_type -> google.protobuf.Empty
+	7,  // 58: converter.v1.ConversionLogService.Create:output_type -> converter.v1.ConversionLog
+	7,  // 59: converter.v1.ConversionLogService.Get:output_type -> converter.v1.ConversionLog
+	15, // 60: converter.v1.ConversionLogService.List:output_type -> converter.v1.ConversionLogList
+	7,  // 61: converter.v1.ConversionLogService.Update:output_type -> converter.v1.ConversionLog
+	17, // 62: converter.v1.ConversionLogService.Delete:output_type -> google.protobuf.Empty
+	17, // 63: converter.v1.ConversionLogService.Restore:output_type -> google.protobuf.Empty
+	42, // [42:64] is the sub-list for method output_type
+	20, // [20:42] is the sub-list for method input_type
+	20, // [20:20] is the sub-list for extension type_name
+	20, // [20:20] is the sub-list for extension extendee
+	0,  // [0:20] is the sub-list for field type_name
+}
+
+func init() { file_converter_v1_converter_proto_init() }
+func file_converter_v1_converter_proto_init() {
+	if File_converter_v1_converter_proto != nil {
+		return
+	}
+	file_converter_v1_converter_proto_msgTypes[5].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_converter_v1_converter_proto_rawDesc), len(file_converter_v1_converter_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   16,
+			NumExtensions: 0,
+			NumServices:   4,
+		},
+		GoTypes:           file_converter_v1_converter_proto_goTypes,
+		DependencyIndexes: file_converter_v1_converter_proto_depIdxs,
+		MessageInfos:      file_converter_v1_converter_proto_msgTypes,
+	}.Build()
+	File_converter_v1_converter_proto = out.File
+	file_converter_v1_converter_proto_goTypes = nil
+	file_converter_v1_converter_proto_depIdxs = nil
+}
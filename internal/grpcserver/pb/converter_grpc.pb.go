@@ -0,0 +1,1124 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: converter/v1/converter.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ConverterService_Convert_FullMethodName        = "/converter.v1.ConverterService/Convert"
+	ConverterService_GetHistory_FullMethodName     = "/converter.v1.ConverterService/GetHistory"
+	ConverterService_GetHistoryByID_FullMethodName = "/converter.v1.ConverterService/GetHistoryByID"
+	ConverterService_StreamLogs_FullMethodName     = "/converter.v1.ConverterService/StreamLogs"
+)
+
+// ConverterServiceClient is the client API for ConverterService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// ConverterService converts amounts and serves the conversion history.
+type ConverterServiceClient interface {
+	Convert(ctx context.Context, in *ConvertRequest, opts ...grpc.CallOption) (*ConversionLog, error)
+	GetHistory(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*GetHistoryResponse, error)
+	GetHistoryByID(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*ConversionLog, error)
+	// StreamLogs sends the conversion logs as they are stored. A client that
+	// falls behind is disconnected with RESOURCE_EXHAUSTED.
+	StreamLogs(ctx context.Context, in *StreamLogsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ConversionLog], error)
+}
+
+type converterServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewConverterServiceClient(cc grpc.ClientConnInterface) ConverterServiceClient {
+	return &converterServiceClient{cc}
+}
+
+func (c *converterServiceClient) Convert(ctx context.Context, in *ConvertRequest, opts ...grpc.CallOption) (*ConversionLog, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConversionLog)
+	err := c.cc.Invoke(ctx, ConverterService_Convert_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *converterServiceClient) GetHistory(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*GetHistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetHistoryResponse)
+	err := c.cc.Invoke(ctx, ConverterService_GetHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *converterServiceClient) GetHistoryByID(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*ConversionLog, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConversionLog)
+	err := c.cc.Invoke(ctx, ConverterService_GetHistoryByID_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *converterServiceClient) StreamLogs(ctx context.Context, in *StreamLogsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ConversionLog], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ConverterService_ServiceDesc.Streams[0], ConverterService_StreamLogs_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamLogsRequest, ConversionLog]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ConverterService_StreamLogsClient = grpc.ServerStreamingClient[ConversionLog]
+
+// ConverterServiceServer is the server API for ConverterService service.
+// All implementations must embed UnimplementedConverterServiceServer
+// for forward compatibility.
+//
+// ConverterService converts amounts and serves the conversion history.
+type ConverterServiceServer interface {
+	Convert(context.Context, *ConvertRequest) (*ConversionLog, error)
+	GetHistory(context.Context, *ListRequest) (*GetHistoryResponse, error)
+	GetHistoryByID(context.Context, *IdRequest) (*ConversionLog, error)
+	// StreamLogs sends the conversion logs as they are stored. A client that
+	// falls behind is disconnected with RESOURCE_EXHAUSTED.
+	StreamLogs(*StreamLogsRequest, grpc.ServerStreamingServer[ConversionLog]) error
+	mustEmbedUnimplementedConverterServiceServer()
+}
+
+// UnimplementedConverterServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedConverterServiceServer struct{}
+
+func (UnimplementedConverterServiceServer) Convert(context.Context, *ConvertRequest) (*ConversionLog, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Convert not implemented")
+}
+func (UnimplementedConverterServiceServer) GetHistory(context.Context, *ListRequest) (*GetHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHistory not implemented")
+}
+func (UnimplementedConverterServiceServer) GetHistoryByID(context.Context, *IdRequest) (*ConversionLog, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHistoryByID not implemented")
+}
+func (UnimplementedConverterServiceServer) StreamLogs(*StreamLogsRequest, grpc.ServerStreamingServer[ConversionLog]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamLogs not implemented")
+}
+func (UnimplementedConverterServiceServer) mustEmbedUnimplementedConverterServiceServer() {}
+func (UnimplementedConverterServiceServer) testEmbeddedByValue()                          {}
+
+// UnsafeConverterServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ConverterServiceServer will
+// result in compilation errors.
+type UnsafeConverterServiceServer interface {
+	mustEmbedUnimplementedConverterServiceServer()
+}
+
+func RegisterConverterServiceServer(s grpc.ServiceRegistrar, srv ConverterServiceServer) {
+	// If the following call pancis, it indicates UnimplementedConverterServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ConverterService_ServiceDesc, srv)
+}
+
+func _ConverterService_Convert_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConvertRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConverterServiceServer).Convert(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConverterService_Convert_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConverterServiceServer).Convert(ctx, req.(*ConvertRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConverterService_GetHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConverterServiceServer).GetHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConverterService_GetHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConverterServiceServer).GetHistory(ctx, req.(*ListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConverterService_GetHistoryByID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConverterServiceServer).GetHistoryByID(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConverterService_GetHistoryByID_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConverterServiceServer).GetHistoryByID(ctx, req.(*IdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConverterService_StreamLogs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamLogsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ConverterServiceServer).StreamLogs(m, &grpc.GenericServerStream[StreamLogsRequest, ConversionLog]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ConverterService_StreamLogsServer = grpc.ServerStreamingServer[ConversionLog]
+
+// ConverterService_ServiceDesc is the grpc.ServiceDesc for ConverterService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ConverterService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "converter.v1.ConverterService",
+	HandlerType: (*ConverterServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Convert",
+			Handler:    _ConverterService_Convert_Handler,
+		},
+		{
+			MethodName: "GetHistory",
+			Handler:    _ConverterService_GetHistory_Handler,
+		},
+		{
+			MethodName: "GetHistoryByID",
+			Handler:    _ConverterService_GetHistoryByID_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamLogs",
+			Handler:       _ConverterService_StreamLogs_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "converter/v1/converter.proto",
+}
+
+const (
+	RequestService_Create_FullMethodName  = "/converter.v1.RequestService/Create"
+	RequestService_Get_FullMethodName     = "/converter.v1.RequestService/Get"
+	RequestService_List_FullMethodName    = "/converter.v1.RequestService/List"
+	RequestService_Update_FullMethodName  = "/converter.v1.RequestService/Update"
+	RequestService_Delete_FullMethodName  = "/converter.v1.RequestService/Delete"
+	RequestService_Restore_FullMethodName = "/converter.v1.RequestService/Restore"
+)
+
+// RequestServiceClient is the client API for RequestService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type RequestServiceClient interface {
+	Create(ctx context.Context, in *Request, opts ...grpc.CallOption) (*Request, error)
+	Get(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*Request, error)
+	List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*RequestList, error)
+	Update(ctx context.Context, in *Request, opts ...grpc.CallOption) (*Request, error)
+	Delete(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	Restore(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type requestServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRequestServiceClient(cc grpc.ClientConnInterface) RequestServiceClient {
+	return &requestServiceClient{cc}
+}
+
+func (c *requestServiceClient) Create(ctx context.Context, in *Request, opts ...grpc.CallOption) (*Request, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Request)
+	err := c.cc.Invoke(ctx, RequestService_Create_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *requestServiceClient) Get(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*Request, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Request)
+	err := c.cc.Invoke(ctx, RequestService_Get_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *requestServiceClient) List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*RequestList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RequestList)
+	err := c.cc.Invoke(ctx, RequestService_List_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *requestServiceClient) Update(ctx context.Context, in *Request, opts ...grpc.CallOption) (*Request, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Request)
+	err := c.cc.Invoke(ctx, RequestService_Update_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *requestServiceClient) Delete(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, RequestService_Delete_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *requestServiceClient) Restore(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, RequestService_Restore_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RequestServiceServer is the server API for RequestService service.
+// All implementations must embed UnimplementedRequestServiceServer
+// for forward compatibility.
+type RequestServiceServer interface {
+	Create(context.Context, *Request) (*Request, error)
+	Get(context.Context, *IdRequest) (*Request, error)
+	List(context.Context, *ListRequest) (*RequestList, error)
+	Update(context.Context, *Request) (*Request, error)
+	Delete(context.Context, *IdRequest) (*emptypb.Empty, error)
+	Restore(context.Context, *IdRequest) (*emptypb.Empty, error)
+	mustEmbedUnimplementedRequestServiceServer()
+}
+
+// UnimplementedRequestServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRequestServiceServer struct{}
+
+func (UnimplementedRequestServiceServer) Create(context.Context, *Request) (*Request, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Create not implemented")
+}
+func (UnimplementedRequestServiceServer) Get(context.Context, *IdRequest) (*Request, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
+}
+func (UnimplementedRequestServiceServer) List(context.Context, *ListRequest) (*RequestList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method List not implemented")
+}
+func (UnimplementedRequestServiceServer) Update(context.Context, *Request) (*Request, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Update not implemented")
+}
+func (UnimplementedRequestServiceServer) Delete(context.Context, *IdRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedRequestServiceServer) Restore(context.Context, *IdRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Restore not implemented")
+}
+func (UnimplementedRequestServiceServer) mustEmbedUnimplementedRequestServiceServer() {}
+func (UnimplementedRequestServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeRequestServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RequestServiceServer will
+// result in compilation errors.
+type UnsafeRequestServiceServer interface {
+	mustEmbedUnimplementedRequestServiceServer()
+}
+
+func RegisterRequestServiceServer(s grpc.ServiceRegistrar, srv RequestServiceServer) {
+	// If the following call pancis, it indicates UnimplementedRequestServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&RequestService_ServiceDesc, srv)
+}
+
+func _RequestService_Create_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RequestServiceServer).Create(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RequestService_Create_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RequestServiceServer).Create(ctx, req.(*Request))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RequestService_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RequestServiceServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RequestService_Get_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RequestServiceServer).Get(ctx, req.(*IdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RequestService_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RequestServiceServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RequestService_List_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RequestServiceServer).List(ctx, req.(*ListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RequestService_Update_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RequestServiceServer).Update(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RequestService_Update_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RequestServiceServer).Update(ctx, req.(*Request))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RequestService_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RequestServiceServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RequestService_Delete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RequestServiceServer).Delete(ctx, req.(*IdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RequestService_Restore_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RequestServiceServer).Restore(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RequestService_Restore_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RequestServiceServer).Restore(ctx, req.(*IdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// RequestService_ServiceDesc is the grpc.ServiceDesc for RequestService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RequestService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "converter.v1.RequestService",
+	HandlerType: (*RequestServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Create",
+			Handler:    _RequestService_Create_Handler,
+		},
+		{
+			MethodName: "Get",
+			Handler:    _RequestService_Get_Handler,
+		},
+		{
+			MethodName: "List",
+			Handler:    _RequestService_List_Handler,
+		},
+		{
+			MethodName: "Update",
+			Handler:    _RequestService_Update_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _RequestService_Delete_Handler,
+		},
+		{
+			MethodName: "Restore",
+			Handler:    _RequestService_Restore_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "converter/v1/converter.proto",
+}
+
+const (
+	ResponseService_Create_FullMethodName  = "/converter.v1.ResponseService/Create"
+	ResponseService_Get_FullMethodName     = "/converter.v1.ResponseService/Get"
+	ResponseService_List_FullMethodName    = "/converter.v1.ResponseService/List"
+	ResponseService_Update_FullMethodName  = "/converter.v1.ResponseService/Update"
+	ResponseService_Delete_FullMethodName  = "/converter.v1.ResponseService/Delete"
+	ResponseService_Restore_FullMethodName = "/converter.v1.ResponseService/Restore"
+)
+
+// ResponseServiceClient is the client API for ResponseService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ResponseServiceClient interface {
+	Create(ctx context.Context, in *Response, opts ...grpc.CallOption) (*Response, error)
+	Get(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*Response, error)
+	List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ResponseList, error)
+	Update(ctx context.Context, in *Response, opts ...grpc.CallOption) (*Response, error)
+	Delete(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	Restore(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type responseServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewResponseServiceClient(cc grpc.ClientConnInterface) ResponseServiceClient {
+	return &responseServiceClient{cc}
+}
+
+func (c *responseServiceClient) Create(ctx context.Context, in *Response, opts ...grpc.CallOption) (*Response, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Response)
+	err := c.cc.Invoke(ctx, ResponseService_Create_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *responseServiceClient) Get(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*Response, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Response)
+	err := c.cc.Invoke(ctx, ResponseService_Get_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *responseServiceClient) List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ResponseList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResponseList)
+	err := c.cc.Invoke(ctx, ResponseService_List_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *responseServiceClient) Update(ctx context.Context, in *Response, opts ...grpc.CallOption) (*Response, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Response)
+	err := c.cc.Invoke(ctx, ResponseService_Update_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *responseServiceClient) Delete(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, ResponseService_Delete_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *responseServiceClient) Restore(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, ResponseService_Restore_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ResponseServiceServer is the server API for ResponseService service.
+// All implementations must embed UnimplementedResponseServiceServer
+// for forward compatibility.
+type ResponseServiceServer interface {
+	Create(context.Context, *Response) (*Response, error)
+	Get(context.Context, *IdRequest) (*Response, error)
+	List(context.Context, *ListRequest) (*ResponseList, error)
+	Update(context.Context, *Response) (*Response, error)
+	Delete(context.Context, *IdRequest) (*emptypb.Empty, error)
+	Restore(context.Context, *IdRequest) (*emptypb.Empty, error)
+	mustEmbedUnimplementedResponseServiceServer()
+}
+
+// UnimplementedResponseServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedResponseServiceServer struct{}
+
+func (UnimplementedResponseServiceServer) Create(context.Context, *Response) (*Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Create not implemented")
+}
+func (UnimplementedResponseServiceServer) Get(context.Context, *IdRequest) (*Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
+}
+func (UnimplementedResponseServiceServer) List(context.Context, *ListRequest) (*ResponseList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method List not implemented")
+}
+func (UnimplementedResponseServiceServer) Update(context.Context, *Response) (*Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Update not implemented")
+}
+func (UnimplementedResponseServiceServer) Delete(context.Context, *IdRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedResponseServiceServer) Restore(context.Context, *IdRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Restore not implemented")
+}
+func (UnimplementedResponseServiceServer) mustEmbedUnimplementedResponseServiceServer() {}
+func (UnimplementedResponseServiceServer) testEmbeddedByValue()                         {}
+
+// UnsafeResponseServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ResponseServiceServer will
+// result in compilation errors.
+type UnsafeResponseServiceServer interface {
+	mustEmbedUnimplementedResponseServiceServer()
+}
+
+func RegisterResponseServiceServer(s grpc.ServiceRegistrar, srv ResponseServiceServer) {
+	// If the following call pancis, it indicates UnimplementedResponseServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ResponseService_ServiceDesc, srv)
+}
+
+func _ResponseService_Create_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Response)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ResponseServiceServer).Create(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ResponseService_Create_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ResponseServiceServer).Create(ctx, req.(*Response))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ResponseService_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ResponseServiceServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ResponseService_Get_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ResponseServiceServer).Get(ctx, req.(*IdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ResponseService_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ResponseServiceServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ResponseService_List_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ResponseServiceServer).List(ctx, req.(*ListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ResponseService_Update_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Response)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ResponseServiceServer).Update(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ResponseService_Update_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ResponseServiceServer).Update(ctx, req.(*Response))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ResponseService_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ResponseServiceServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ResponseService_Delete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ResponseServiceServer).Delete(ctx, req.(*IdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ResponseService_Restore_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ResponseServiceServer).Restore(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ResponseService_Restore_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ResponseServiceServer).Restore(ctx, req.(*IdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ResponseService_ServiceDesc is the grpc.ServiceDesc for ResponseService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ResponseService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "converter.v1.ResponseService",
+	HandlerType: (*ResponseServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Create",
+			Handler:    _ResponseService_Create_Handler,
+		},
+		{
+			MethodName: "Get",
+			Handler:    _ResponseService_Get_Handler,
+		},
+		{
+			MethodName: "List",
+			Handler:    _ResponseService_List_Handler,
+		},
+		{
+			MethodName: "Update",
+			Handler:    _ResponseService_Update_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _ResponseService_Delete_Handler,
+		},
+		{
+			MethodName: "Restore",
+			Handler:    _ResponseService_Restore_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "converter/v1/converter.proto",
+}
+
+const (
+	ConversionLogService_Create_FullMethodName  = "/converter.v1.ConversionLogService/Create"
+	ConversionLogService_Get_FullMethodName     = "/converter.v1.ConversionLogService/Get"
+	ConversionLogService_List_FullMethodName    = "/converter.v1.ConversionLogService/List"
+	ConversionLogService_Update_FullMethodName  = "/converter.v1.ConversionLogService/Update"
+	ConversionLogService_Delete_FullMethodName  = "/converter.v1.ConversionLogService/Delete"
+	ConversionLogService_Restore_FullMethodName = "/converter.v1.ConversionLogService/Restore"
+)
+
+// ConversionLogServiceClient is the client API for ConversionLogService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ConversionLogServiceClient interface {
+	Create(ctx context.Context, in *ConversionLog, opts ...grpc.CallOption) (*ConversionLog, error)
+	Get(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*ConversionLog, error)
+	List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ConversionLogList, error)
+	Update(ctx context.Context, in *ConversionLog, opts ...grpc.CallOption) (*ConversionLog, error)
+	Delete(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	Restore(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type conversionLogServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewConversionLogServiceClient(cc grpc.ClientConnInterface) ConversionLogServiceClient {
+	return &conversionLogServiceClient{cc}
+}
+
+func (c *conversionLogServiceClient) Create(ctx context.Context, in *ConversionLog, opts ...grpc.CallOption) (*ConversionLog, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConversionLog)
+	err := c.cc.Invoke(ctx, ConversionLogService_Create_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *conversionLogServiceClient) Get(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*ConversionLog, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConversionLog)
+	err := c.cc.Invoke(ctx, ConversionLogService_Get_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *conversionLogServiceClient) List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ConversionLogList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConversionLogList)
+	err := c.cc.Invoke(ctx, ConversionLogService_List_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *conversionLogServiceClient) Update(ctx context.Context, in *ConversionLog, opts ...grpc.CallOption) (*ConversionLog, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConversionLog)
+	err := c.cc.Invoke(ctx, ConversionLogService_Update_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *conversionLogServiceClient) Delete(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, ConversionLogService_Delete_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *conversionLogServiceClient) Restore(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, ConversionLogService_Restore_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ConversionLogServiceServer is the server API for ConversionLogService service.
+// All implementations must embed UnimplementedConversionLogServiceServer
+// for forward compatibility.
+type ConversionLogServiceServer interface {
+	Create(context.Context, *ConversionLog) (*ConversionLog, error)
+	Get(context.Context, *IdRequest) (*ConversionLog, error)
+	List(context.Context, *ListRequest) (*ConversionLogList, error)
+	Update(context.Context, *ConversionLog) (*ConversionLog, error)
+	Delete(context.Context, *IdRequest) (*emptypb.Empty, error)
+	Restore(context.Context, *IdRequest) (*emptypb.Empty, error)
+	mustEmbedUnimplementedConversionLogServiceServer()
+}
+
+// UnimplementedConversionLogServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedConversionLogServiceServer struct{}
+
+func (UnimplementedConversionLogServiceServer) Create(context.Context, *ConversionLog) (*ConversionLog, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Create not implemented")
+}
+func (UnimplementedConversionLogServiceServer) Get(context.Context, *IdRequest) (*ConversionLog, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
+}
+func (UnimplementedConversionLogServiceServer) List(context.Context, *ListRequest) (*ConversionLogList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method List not implemented")
+}
+func (UnimplementedConversionLogServiceServer) Update(context.Context, *ConversionLog) (*ConversionLog, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Update not implemented")
+}
+func (UnimplementedConversionLogServiceServer) Delete(context.Context, *IdRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedConversionLogServiceServer) Restore(context.Context, *IdRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Restore not implemented")
+}
+func (UnimplementedConversionLogServiceServer) mustEmbedUnimplementedConversionLogServiceServer() {}
+func (UnimplementedConversionLogServiceServer) testEmbeddedByValue()                              {}
+
+// UnsafeConversionLogServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ConversionLogServiceServer will
+// result in compilation errors.
+type UnsafeConversionLogServiceServer interface {
+	mustEmbedUnimplementedConversionLogServiceServer()
+}
+
+func RegisterConversionLogServiceServer(s grpc.ServiceRegistrar, srv ConversionLogServiceServer) {
+	// If the following call pancis, it indicates UnimplementedConversionLogServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ConversionLogService_ServiceDesc, srv)
+}
+
+func _ConversionLogService_Create_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConversionLog)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConversionLogServiceServer).Create(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConversionLogService_Create_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConversionLogServiceServer).Create(ctx, req.(*ConversionLog))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConversionLogService_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConversionLogServiceServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConversionLogService_Get_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConversionLogServiceServer).Get(ctx, req.(*IdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConversionLogService_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConversionLogServiceServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConversionLogService_List_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConversionLogServiceServer).List(ctx, req.(*ListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConversionLogService_Update_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConversionLog)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConversionLogServiceServer).Update(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConversionLogService_Update_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConversionLogServiceServer).Update(ctx, req.(*ConversionLog))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConversionLogService_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConversionLogServiceServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConversionLogService_Delete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConversionLogServiceServer).Delete(ctx, req.(*IdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConversionLogService_Restore_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConversionLogServiceServer).Restore(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConversionLogService_Restore_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConversionLogServiceServer).Restore(ctx, req.(*IdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ConversionLogService_ServiceDesc is the grpc.ServiceDesc for ConversionLogService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ConversionLogService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "converter.v1.ConversionLogService",
+	HandlerType: (*ConversionLogServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Create",
+			Handler:    _ConversionLogService_Create_Handler,
+		},
+		{
+			MethodName: "Get",
+			Handler:    _ConversionLogService_Get_Handler,
+		},
+		{
+			MethodName: "List",
+			Handler:    _ConversionLogService_List_Handler,
+		},
+		{
+			MethodName: "Update",
+			Handler:    _ConversionLogService_Update_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _ConversionLogService_Delete_Handler,
+		},
+		{
+			MethodName: "Restore",
+			Handler:    _ConversionLogService_Restore_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "converter/v1/converter.proto",
+}
@@ -0,0 +1,92 @@
+package grpcserver
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/grpcserver/pb"
+	"github.com/M2rk13/Otus-327619/internal/service"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// StartGRPCServer serves the gRPC API on addr until ctx is cancelled. It
+// shares the services with the web server, so both APIs see the same data.
+func StartGRPCServer(
+	ctx context.Context,
+	wg *sync.WaitGroup,
+	addr string,
+	storageSvc *service.StorageService,
+	conversionSvc *service.ConversionService,
+	currencies *service.CurrencyRegistry,
+) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		listener, err := net.Listen("tcp", addr)
+
+		if err != nil {
+			log.Fatalf("gRPC server listen error: %v", err)
+		}
+
+		server := NewServer(ctx, storageSvc, conversionSvc, currencies)
+
+		go func() {
+			fmt.Printf("gRPC server started on %s\n", addr)
+			if err := server.Serve(listener); err != nil {
+				log.Fatalf("gRPC server serve error: %v", err)
+			}
+		}()
+
+		<-ctx.Done()
+		fmt.Println("gRPC server is shutting down...")
+
+		stopped := make(chan struct{})
+
+		go func() {
+			server.GracefulStop()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+			fmt.Println("gRPC server gracefully stopped.")
+		case <-time.After(5 * time.Second):
+			server.Stop()
+			fmt.Println("gRPC server graceful shutdown timed out, connections closed.")
+		}
+	}()
+}
+
+// NewServer registers the services on a new gRPC server with the auth
+// interceptors and server reflection, so that tools like grpcurl can list
+// and call them. Streams are ended when ctx is cancelled.
+func NewServer(
+	ctx context.Context,
+	storageSvc *service.StorageService,
+	conversionSvc *service.ConversionService,
+	currencies *service.CurrencyRegistry,
+) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(unaryAuthInterceptor),
+		grpc.StreamInterceptor(streamAuthInterceptor),
+	)
+
+	pb.RegisterConverterServiceServer(server, &converterServer{
+		shutdown:      ctx,
+		storageSvc:    storageSvc,
+		conversionSvc: conversionSvc,
+	})
+	pb.RegisterRequestServiceServer(server, &requestServer{storageSvc: storageSvc, currencies: currencies})
+	pb.RegisterResponseServiceServer(server, &responseServer{storageSvc: storageSvc, currencies: currencies})
+	pb.RegisterConversionLogServiceServer(server, &conversionLogServer{storageSvc: storageSvc, currencies: currencies})
+	reflection.Register(server)
+
+	return server
+}
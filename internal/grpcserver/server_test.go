@@ -0,0 +1,250 @@
+package grpcserver
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/auth"
+	"github.com/M2rk13/Otus-327619/internal/config"
+	"github.com/M2rk13/Otus-327619/internal/enum"
+	"github.com/M2rk13/Otus-327619/internal/grpcserver/pb"
+	"github.com/M2rk13/Otus-327619/internal/model/fee"
+	"github.com/M2rk13/Otus-327619/internal/repository"
+	"github.com/M2rk13/Otus-327619/internal/service"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// startTestServer serves the API over an in-memory listener, backed by a file
+// store in a temporary directory and the demo rates.
+func startTestServer(t *testing.T) *grpc.ClientConn {
+	t.Helper()
+
+	dir := t.TempDir()
+	config.AdminCfg.JwtKey = "test-key"
+	config.FileCfg = config.FileConfig{
+		RequestsFilePath:    filepath.Join(dir, "requests.json"),
+		ResponsesFilePath:   filepath.Join(dir, "responses.json"),
+		LogsFilePath:        filepath.Join(dir, "logs.json"),
+		QuotesFilePath:      filepath.Join(dir, "quotes.json"),
+		IdempotencyFilePath: filepath.Join(dir, "idempotency.json"),
+	}
+
+	store := repository.NewFileStore(enum.Cascade)
+
+	if err := store.SetupPersistence(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(store.ClosePersistence)
+
+	rounding, err := service.NewRoundingPolicies(enum.RoundHalfUp, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	currencies := service.NewCurrencyRegistry(service.ISO4217)
+	storage := service.NewStorageService(store, service.NewAuditService(repository.NewMemoryAuditStore(time.Hour)), nil)
+	conversion := service.NewConversionService(
+		service.NewStaticRateProvider(service.DemoRates),
+		storage,
+		currencies,
+		rounding,
+		service.NewFeeEngine(fee.Schedules{}),
+		time.Minute,
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	listener := bufconn.Listen(1 << 20)
+	server := NewServer(ctx, storage, conversion, currencies)
+
+	go func() { _ = server.Serve(listener) }()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		_ = conn.Close()
+		cancel()
+		server.Stop()
+	})
+
+	return conn
+}
+
+func authorized(t *testing.T) context.Context {
+	t.Helper()
+	token, err := auth.IssueToken("admin")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return metadata.AppendToOutgoingContext(context.Background(), authorizationKey, "Bearer "+token)
+}
+
+func TestServer_RequiresTokenForMutations(t *testing.T) {
+	requests := pb.NewRequestServiceClient(startTestServer(t))
+	ctx := context.Background()
+	req := &pb.Request{From: "USD", To: "EUR", Amount: "10"}
+
+	if _, err := requests.Create(ctx, req); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("create without token: %v, want Unauthenticated", err)
+	}
+
+	badToken := metadata.AppendToOutgoingContext(ctx, authorizationKey, "Bearer nonsense")
+
+	if _, err := requests.Create(badToken, req); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("create with invalid token: %v, want Unauthenticated", err)
+	}
+
+	if _, err := requests.List(ctx, &pb.ListRequest{}); err != nil {
+		t.Fatalf("list without token: %v", err)
+	}
+}
+
+func TestServer_RequestLifecycle(t *testing.T) {
+	requests := pb.NewRequestServiceClient(startTestServer(t))
+	ctx := authorized(t)
+
+	if _, err := requests.Create(ctx, &pb.Request{From: "USD", To: "XXX", Amount: "10"}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("create with unknown currency: %v, want InvalidArgument", err)
+	}
+
+	created, err := requests.Create(ctx, &pb.Request{From: "usd", To: "eur", Amount: "10.50"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if created.GetFrom() != "USD" || created.GetAmount() != "10.5" || created.GetVersion() != 1 {
+		t.Fatalf("unexpected created request %+v", created)
+	}
+
+	created.Amount = "20"
+	updated, err := requests.Update(ctx, created)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = requests.Update(ctx, created); status.Code(err) != codes.Aborted {
+		t.Fatalf("update of stale version: %v, want Aborted", err)
+	}
+
+	if _, err = requests.Update(ctx, &pb.Request{Id: updated.GetId(), From: "USD", To: "EUR", Amount: "30"}); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("update without version: %v, want FailedPrecondition", err)
+	}
+
+	if _, err = requests.Delete(ctx, &pb.IdRequest{Id: updated.GetId()}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = requests.Get(ctx, &pb.IdRequest{Id: updated.GetId()}); status.Code(err) != codes.NotFound {
+		t.Fatalf("get of deleted request: %v, want NotFound", err)
+	}
+
+	if _, err = requests.Restore(ctx, &pb.IdRequest{Id: updated.GetId()}); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := requests.Get(ctx, &pb.IdRequest{Id: updated.GetId()})
+
+	if err != nil || restored.GetAmount() != "20" {
+		t.Fatalf("restored request %+v: %v", restored, err)
+	}
+}
+
+func TestServer_ConvertAndStreamLogs(t *testing.T) {
+	conn := startTestServer(t)
+	converter := pb.NewConverterServiceClient(conn)
+	ctx := authorized(t)
+
+	first, err := converter.Convert(ctx, &pb.ConvertRequest{From: "USD", To: "EUR", Amount: "100"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first.GetResponse().GetResult() != "91.34" {
+		t.Fatalf("result = %s, want 91.34", first.GetResponse().GetResult())
+	}
+
+	streamCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := converter.StreamLogs(streamCtx, &pb.StreamLogsRequest{IncludeExisting: true})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if existing, err := stream.Recv(); err != nil || existing.GetId() != first.GetId() {
+		t.Fatalf("first streamed log %v: %v, want the stored log", existing, err)
+	}
+
+	second, err := converter.Convert(ctx, &pb.ConvertRequest{From: "USD", To: "GBP", Amount: "10"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if streamed, err := stream.Recv(); err != nil || streamed.GetId() != second.GetId() {
+		t.Fatalf("next streamed log %v: %v, want the new log", streamed, err)
+	}
+
+	history, err := converter.GetHistoryByID(context.Background(), &pb.IdRequest{Id: second.GetId()})
+
+	if err != nil || history.GetRequest().GetTo() != "GBP" {
+		t.Fatalf("history %v: %v", history, err)
+	}
+}
+
+func TestServer_ReflectionIsPublic(t *testing.T) {
+	client := grpc_reflection_v1.NewServerReflectionClient(startTestServer(t))
+	stream, err := client.ServerReflectionInfo(context.Background())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = stream.Send(&grpc_reflection_v1.ServerReflectionRequest{
+		MessageRequest: &grpc_reflection_v1.ServerReflectionRequest_ListServices{},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := stream.Recv()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	services := make(map[string]bool)
+
+	for _, svc := range resp.GetListServicesResponse().GetService() {
+		services[svc.GetName()] = true
+	}
+
+	if !services["converter.v1.ConverterService"] || !services["converter.v1.ConversionLogService"] {
+		t.Fatalf("reflection lists %v", services)
+	}
+}
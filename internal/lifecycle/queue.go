@@ -0,0 +1,222 @@
+// Package lifecycle provides queues whose closing and backlog can be observed
+// from any goroutine.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/enum"
+)
+
+var (
+	ErrClosed    = errors.New("queue is closed")
+	ErrQueueFull = errors.New("queue is full")
+)
+
+// Observable is the read-only view of a queue's lifecycle.
+type Observable interface {
+	// Closed is closed once the queue no longer accepts items.
+	Closed() <-chan struct{}
+	// Len is the number of items waiting to be received.
+	Len() int
+}
+
+// Entry is a queued item with the time it was pushed.
+type Entry[T any] struct {
+	Item     T
+	Enqueued time.Time
+}
+
+// QueueStats are the depth and counters of a queue.
+type QueueStats struct {
+	Depth    int    `json:"depth"`
+	Capacity int    `json:"capacity"`
+	Policy   string `json:"policy"`
+	Pushed   int64  `json:"pushed"`
+	Dropped  int64  `json:"dropped"`
+	Rejected int64  `json:"rejected"`
+}
+
+// Queue is a bounded FIFO shared by producers and consumers. What Push does
+// when the queue is full depends on its policy: enum.QueueBlock waits for
+// room, enum.QueueDropOldest discards the oldest item to make room and
+// enum.QueueReject fails with ErrQueueFull. Items discarded to make room are
+// handed to the callback set with OnDrop.
+//
+// Closing is safe while producers are pushing: Push fails with ErrClosed
+// instead of panicking, and consumers receive the remaining items before
+// Items is closed.
+type Queue[T any] struct {
+	items  chan Entry[T]
+	policy string
+	closed chan struct{}
+	onDrop func(T)
+	once   sync.Once
+	// mu is held for reading by every Push and for writing while the items
+	// channel is closed, so that no send races the close.
+	mu sync.RWMutex
+
+	pushed   atomic.Int64
+	dropped  atomic.Int64
+	rejected atomic.Int64
+}
+
+// NewQueue creates a queue that blocks producers while it is full.
+func NewQueue[T any](size int) *Queue[T] {
+	return NewQueueWithPolicy[T](size, enum.QueueBlock)
+}
+
+// NewQueueWithPolicy creates a queue with the given full-queue policy. A
+// queue that drops its oldest items needs room for at least one.
+func NewQueueWithPolicy[T any](size int, policy string) *Queue[T] {
+	if policy == enum.QueueDropOldest {
+		size = max(size, 1)
+	}
+
+	return &Queue[T]{
+		items:  make(chan Entry[T], size),
+		policy: policy,
+		closed: make(chan struct{}),
+	}
+}
+
+// Push adds item to the queue. It fails with ErrClosed once the queue is
+// closed, with ErrQueueFull if the queue rejects items while full and with
+// the context error if ctx is done while waiting for room.
+func (q *Queue[T]) Push(ctx context.Context, item T) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	select {
+	case <-q.closed:
+		return ErrClosed
+	default:
+	}
+
+	entry := Entry[T]{Item: item, Enqueued: time.Now()}
+
+	switch q.policy {
+	case enum.QueueReject:
+		select {
+		case q.items <- entry:
+		default:
+			q.rejected.Add(1)
+
+			return ErrQueueFull
+		}
+	case enum.QueueDropOldest:
+		// Waiting on both the send and the receive makes progress without
+		// spinning: the item goes in as soon as there is room, and otherwise
+		// the oldest one is taken out to make some.
+		for pushed := q.tryPush(entry); !pushed; {
+			select {
+			case q.items <- entry:
+				pushed = true
+			case oldest := <-q.items:
+				q.drop(oldest.Item)
+				pushed = q.tryPush(entry)
+			case <-q.closed:
+				return ErrClosed
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	default:
+		select {
+		case q.items <- entry:
+		case <-q.closed:
+			return ErrClosed
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	q.pushed.Add(1)
+
+	return nil
+}
+
+func (q *Queue[T]) tryPush(entry Entry[T]) bool {
+	select {
+	case q.items <- entry:
+		return true
+	default:
+		return false
+	}
+}
+
+func (q *Queue[T]) drop(item T) {
+	q.dropped.Add(1)
+
+	if q.onDrop != nil {
+		q.onDrop(item)
+	}
+}
+
+// OnDrop sets the callback receiving the items a dropping queue discards. It
+// is called from Push, so it must be set before the queue is used and must
+// not push to the queue itself.
+func (q *Queue[T]) OnDrop(fn func(T)) {
+	q.onDrop = fn
+}
+
+// Items is the channel consumers receive from. It is closed after Close once
+// every queued item has been received.
+func (q *Queue[T]) Items() <-chan Entry[T] {
+	return q.items
+}
+
+// Close stops the queue from accepting items. It is safe to call more than
+// once and from several goroutines.
+func (q *Queue[T]) Close() {
+	q.once.Do(func() {
+		close(q.closed)
+
+		q.mu.Lock()
+		defer q.mu.Unlock()
+
+		close(q.items)
+	})
+}
+
+func (q *Queue[T]) Closed() <-chan struct{} {
+	return q.closed
+}
+
+func (q *Queue[T]) Len() int {
+	return len(q.items)
+}
+
+func (q *Queue[T]) Stats() QueueStats {
+	return QueueStats{
+		Depth:    len(q.items),
+		Capacity: cap(q.items),
+		Policy:   q.policy,
+		Pushed:   q.pushed.Load(),
+		Dropped:  q.dropped.Load(),
+		Rejected: q.rejected.Load(),
+	}
+}
+
+// Drain removes and returns the entries queued right now without waiting for
+// more. Consumers call it on shutdown so that accepted items are not lost.
+func (q *Queue[T]) Drain() []Entry[T] {
+	var drained []Entry[T]
+
+	for {
+		select {
+		case entry, ok := <-q.items:
+			if !ok {
+				return drained
+			}
+
+			drained = append(drained, entry)
+		default:
+			return drained
+		}
+	}
+}
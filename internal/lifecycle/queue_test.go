@@ -0,0 +1,147 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/enum"
+)
+
+func TestQueue_PushCloseDrain(t *testing.T) {
+	q := NewQueue[int](3)
+
+	for i := 1; i <= 2; i++ {
+		if err := q.Push(context.Background(), i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if q.Len() != 2 {
+		t.Fatalf("Len = %d, want 2", q.Len())
+	}
+
+	q.Close()
+	q.Close()
+
+	select {
+	case <-q.Closed():
+	default:
+		t.Fatal("Closed should be closed after Close")
+	}
+
+	if err := q.Push(context.Background(), 3); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Push after Close should fail with ErrClosed, got %v", err)
+	}
+
+	if got := q.Drain(); len(got) != 2 || got[0].Item != 1 || got[1].Item != 2 {
+		t.Fatalf("Drain = %v, want [1 2]", got)
+	}
+
+	if _, ok := <-q.Items(); ok {
+		t.Fatal("Items should be closed once a closed queue is drained")
+	}
+}
+
+func TestQueue_PushContext(t *testing.T) {
+	q := NewQueue[int](1)
+	_ = q.Push(context.Background(), 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := q.Push(ctx, 2); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Push on a full queue should wait for ctx, got %v", err)
+	}
+}
+
+func TestQueue_CloseWhileProducing(t *testing.T) {
+	q := NewQueue[int](1)
+
+	var producers sync.WaitGroup
+
+	for p := 0; p < 8; p++ {
+		producers.Add(1)
+
+		go func() {
+			defer producers.Done()
+
+			for i := 0; ; i++ {
+				if err := q.Push(context.Background(), i); err != nil {
+					if !errors.Is(err, ErrClosed) {
+						t.Errorf("unexpected error %v", err)
+					}
+
+					return
+				}
+			}
+		}()
+	}
+
+	received := 0
+
+	for range q.Items() {
+		if received++; received == 100 {
+			q.Close()
+		}
+	}
+
+	producers.Wait()
+
+	if received < 100 {
+		t.Fatalf("received %d items, want at least 100", received)
+	}
+}
+
+func TestQueue_Policies(t *testing.T) {
+	ctx := context.Background()
+	reject := NewQueueWithPolicy[int](1, enum.QueueReject)
+	_ = reject.Push(ctx, 1)
+
+	if err := reject.Push(ctx, 2); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("a full rejecting queue should fail with ErrQueueFull, got %v", err)
+	}
+
+	if stats := reject.Stats(); stats.Rejected != 1 || stats.Pushed != 1 || stats.Depth != 1 || stats.Capacity != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	dropOldest := NewQueueWithPolicy[int](2, enum.QueueDropOldest)
+
+	var dropped []int
+	dropOldest.OnDrop(func(item int) { dropped = append(dropped, item) })
+
+	for i := 1; i <= 3; i++ {
+		if err := dropOldest.Push(ctx, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := dropOldest.Drain(); len(got) != 2 || got[0].Item != 2 || got[1].Item != 3 {
+		t.Fatalf("the oldest item should be dropped, got %v", got)
+	}
+
+	if stats := dropOldest.Stats(); stats.Dropped != 1 || stats.Pushed != 3 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	if len(dropped) != 1 || dropped[0] != 1 {
+		t.Fatalf("OnDrop should receive the dropped item, got %v", dropped)
+	}
+}
+
+func TestQueue_DropOldestWithoutCapacity(t *testing.T) {
+	q := NewQueueWithPolicy[int](0, enum.QueueDropOldest)
+
+	for i := 1; i <= 2; i++ {
+		if err := q.Push(context.Background(), i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := q.Drain(); len(got) != 1 || got[0].Item != 2 {
+		t.Fatalf("a dropping queue should keep room for one item, got %v", got)
+	}
+}
@@ -1,31 +1,98 @@
 package api
 
+import (
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+)
+
 type Request struct {
-	Id     string  `json:"id"`
-	From   string  `json:"from"`
-	To     string  `json:"to"`
-	Amount float64 `json:"amount"`
+	Id        string        `json:"id"`
+	From      string        `json:"from"`
+	To        string        `json:"to"`
+	Amount    money.Decimal `json:"amount" swaggertype:"string" example:"100.50"`
+	Version   int64         `json:"version"`
+	DeletedAt *time.Time    `json:"deleted_at,omitempty"`
 }
 
 func (r *Request) GetId() string {
 	return r.Id
 }
 
+func (r *Request) GetVersion() int64 {
+	return r.Version
+}
+
+func (r *Request) SetVersion(version int64) {
+	r.Version = version
+}
+
+func (r *Request) GetDeletedAt() *time.Time {
+	return r.DeletedAt
+}
+
+func (r *Request) SetDeletedAt(deletedAt *time.Time) {
+	r.DeletedAt = deletedAt
+}
+
+// Leg is one step of a derived quote, e.g. GBP→USD of a GBP→JPY cross rate.
+type Leg struct {
+	From     string        `json:"from"`
+	To       string        `json:"to"`
+	Rate     money.Decimal `json:"rate" swaggertype:"string" example:"1.25"`
+	Provider string        `json:"provider,omitempty"`
+}
+
 type Info struct {
-	Timestamp int64   `json:"timestamp"`
-	Quote     float64 `json:"quote"`
+	Timestamp int64         `json:"timestamp"`
+	Quote     money.Decimal `json:"quote" swaggertype:"string" example:"0.9134"`
+	Provider  string        `json:"provider,omitempty"`
+	Legs      []Leg         `json:"legs,omitempty"`
+}
+
+// Fees is the pricing breakdown of a conversion: the provider's mid rate, the
+// rate after the spread, the fixed fee taken from the amount in the source
+// currency, and the result before and after both.
+type Fees struct {
+	Schedule      string        `json:"schedule" example:"default"`
+	MidRate       money.Decimal `json:"mid_rate" swaggertype:"string" example:"0.9134"`
+	SpreadPercent money.Decimal `json:"spread_percent" swaggertype:"string" example:"0.5"`
+	AppliedRate   money.Decimal `json:"applied_rate" swaggertype:"string" example:"0.908833"`
+	Fee           money.Decimal `json:"fee" swaggertype:"string" example:"1.00"`
+	FeeCurrency   string        `json:"fee_currency" example:"USD"`
+	GrossResult   money.Decimal `json:"gross_result" swaggertype:"string" example:"91.34"`
+	NetResult     money.Decimal `json:"net_result" swaggertype:"string" example:"89.97"`
 }
 
 type Response struct {
-	Id      string  `json:"id"`
-	Success bool    `json:"success"`
-	Terms   string  `json:"terms"`
-	Privacy string  `json:"privacy"`
-	Query   Request `json:"query"`
-	Info    Info    `json:"info"`
-	Result  float64 `json:"result"`
+	Id        string        `json:"id"`
+	Success   bool          `json:"success"`
+	Terms     string        `json:"terms"`
+	Privacy   string        `json:"privacy"`
+	Query     Request       `json:"query"`
+	Info      Info          `json:"info"`
+	Result    money.Decimal `json:"result" swaggertype:"string" example:"91.80"`
+	Fees      *Fees         `json:"fees,omitempty"`
+	Version   int64         `json:"version"`
+	DeletedAt *time.Time    `json:"deleted_at,omitempty"`
 }
 
 func (r *Response) GetId() string {
 	return r.Id
 }
+
+func (r *Response) GetVersion() int64 {
+	return r.Version
+}
+
+func (r *Response) SetVersion(version int64) {
+	r.Version = version
+}
+
+func (r *Response) GetDeletedAt() *time.Time {
+	return r.DeletedAt
+}
+
+func (r *Response) SetDeletedAt(deletedAt *time.Time) {
+	r.DeletedAt = deletedAt
+}
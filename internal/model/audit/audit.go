@@ -0,0 +1,59 @@
+package audit
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Record describes a single mutation: who made it, what it touched and how
+// the item looked before and after.
+type Record struct {
+	Id        string          `json:"id"`
+	Timestamp time.Time       `json:"timestamp"`
+	Actor     string          `json:"actor"`
+	RequestId string          `json:"request_id,omitempty"`
+	Action    string          `json:"action"`
+	Entity    string          `json:"entity"`
+	EntityId  string          `json:"entity_id,omitempty"`
+	Before    json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+	After     json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+	Changes   []Change        `json:"changes,omitempty"`
+}
+
+// Change is a single field that differs between Before and After. Field is
+// a JSON pointer into the item.
+type Change struct {
+	Field  string `json:"field"`
+	Before any    `json:"before"`
+	After  any    `json:"after"`
+}
+
+// Filter selects audit records. Empty fields match everything.
+type Filter struct {
+	Entity string
+	Actor  string
+	From   time.Time
+	To     time.Time
+	Limit  int
+}
+
+// Matches reports whether rec passes the filter, ignoring Limit.
+func (f Filter) Matches(rec *Record) bool {
+	if f.Entity != "" && rec.Entity != f.Entity {
+		return false
+	}
+
+	if f.Actor != "" && rec.Actor != f.Actor {
+		return false
+	}
+
+	if !f.From.IsZero() && rec.Timestamp.Before(f.From) {
+		return false
+	}
+
+	if !f.To.IsZero() && rec.Timestamp.After(f.To) {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,12 @@
+package currency
+
+// Currency is an ISO 4217 currency. MinorUnits is the number of digits after
+// the decimal separator, e.g. 2 for USD and 0 for JPY. Withdrawn currencies
+// are kept with Active unset so that old records can still be read.
+type Currency struct {
+	Code       string `json:"code"`
+	Numeric    string `json:"numeric"`
+	MinorUnits int    `json:"minor_units"`
+	Name       string `json:"name"`
+	Active     bool   `json:"active"`
+}
@@ -1,6 +1,10 @@
 package db
 
-import "time"
+import (
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+)
 
 type Account struct {
 	ID        string
@@ -15,6 +19,6 @@ type RequestCount struct {
 type RateHistory struct {
 	From     string
 	To       string
-	Rate     float64
+	Rate     money.Decimal
 	DateTime time.Time
 }
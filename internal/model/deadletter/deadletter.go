@@ -0,0 +1,17 @@
+package deadletter
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Letter is an item the storage workers gave up on, kept with the error of
+// the last attempt so that it can be inspected and replayed.
+type Letter struct {
+	Id       string          `json:"id"`
+	Entity   string          `json:"entity"`
+	Payload  json.RawMessage `json:"payload" swaggertype:"object"`
+	Error    string          `json:"error"`
+	Attempts int             `json:"attempts"`
+	FailedAt time.Time       `json:"failed_at"`
+}
@@ -0,0 +1,21 @@
+package event
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event announces a change of a stored request, response or conversion log.
+// Data is the record after the change and Version its version; both are
+// empty for deletions. Origin names the instance that made the change.
+type Event struct {
+	Id        string          `json:"id"`
+	Type      string          `json:"type"`
+	Entity    string          `json:"entity"`
+	EntityId  string          `json:"entity_id"`
+	Version   int64           `json:"version,omitempty"`
+	Origin    string          `json:"origin,omitempty"`
+	Timestamp time.Time       `json:"timestamp"`
+	Actor     string          `json:"actor,omitempty"`
+	Data      json.RawMessage `json:"data,omitempty"`
+}
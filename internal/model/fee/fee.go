@@ -0,0 +1,27 @@
+package fee
+
+import "github.com/M2rk13/Otus-327619/internal/model/money"
+
+// Tier overrides the spread and fixed fee of a schedule from MinAmount of the
+// source currency upwards. Unset fields keep the value of the tier below, or
+// of the schedule for the first tier.
+type Tier struct {
+	MinAmount     money.Decimal  `json:"min_amount"`
+	SpreadPercent *money.Decimal `json:"spread_percent,omitempty"`
+	FixedFee      *money.Decimal `json:"fixed_fee,omitempty"`
+}
+
+// Schedule is a set of fee rules: a percentage spread taken off the mid rate,
+// a fixed fee per source currency, and amount tiers per source currency.
+type Schedule struct {
+	SpreadPercent money.Decimal            `json:"spread_percent"`
+	FixedFees     map[string]money.Decimal `json:"fixed_fees,omitempty"`
+	Tiers         map[string][]Tier        `json:"tiers,omitempty"`
+}
+
+// Schedules is the fee configuration: the default schedule and the ones of
+// accounts that replace it.
+type Schedules struct {
+	Default  Schedule            `json:"default"`
+	Accounts map[string]Schedule `json:"accounts,omitempty"`
+}
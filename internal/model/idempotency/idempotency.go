@@ -0,0 +1,28 @@
+package idempotency
+
+import "time"
+
+// Record is the outcome of a request sent with an Idempotency-Key, kept so
+// that retries with the same key get the same answer. Keys are scoped to the
+// account. Fingerprint identifies the request the key was first used with;
+// Status stays zero while that request is still being processed.
+type Record struct {
+	Account     string            `json:"account"`
+	Key         string            `json:"key"`
+	Fingerprint string            `json:"fingerprint"`
+	Status      int               `json:"status"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Body        []byte            `json:"body,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	ExpiresAt   time.Time         `json:"expires_at"`
+}
+
+// Completed reports whether the response of the first request is stored.
+func (r *Record) Completed() bool {
+	return r.Status != 0
+}
+
+// Expired reports whether the key can be reused at the given time.
+func (r *Record) Expired(at time.Time) bool {
+	return !at.Before(r.ExpiresAt)
+}
@@ -4,19 +4,70 @@ import (
 	"time"
 
 	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/money"
 )
 
+// ConversionLog links a request to its response. Rounding is the policy the
+// result was rounded with, so that the conversion can be reproduced. BatchId
+// is shared by the logs of conversions made in one batch call.
+//
+// The log of a multi-target conversion links one request/response pair per
+// target currency in Targets instead; its own Request and Response then only
+// carry the source amount and the shared quote time and link to nothing.
 type ConversionLog struct {
-	Id        string       `json:"id"`
-	Timestamp time.Time    `json:"timestamp"`
-	Request   api.Request  `json:"request"`
-	Response  api.Response `json:"response"`
+	Id        string                `json:"id"`
+	Timestamp time.Time             `json:"timestamp"`
+	Request   api.Request           `json:"request"`
+	Response  api.Response          `json:"response"`
+	Rounding  *money.RoundingPolicy `json:"rounding,omitempty"`
+	BatchId   string                `json:"batch_id,omitempty"`
+	Targets   []Target              `json:"targets,omitempty"`
+	Version   int64                 `json:"version"`
+	DeletedAt *time.Time            `json:"deleted_at,omitempty"`
 }
 
 func (c *ConversionLog) GetId() string {
 	return c.Id
 }
 
+func (c *ConversionLog) GetVersion() int64 {
+	return c.Version
+}
+
+func (c *ConversionLog) SetVersion(version int64) {
+	c.Version = version
+}
+
+func (c *ConversionLog) GetDeletedAt() *time.Time {
+	return c.DeletedAt
+}
+
+func (c *ConversionLog) SetDeletedAt(deletedAt *time.Time) {
+	c.DeletedAt = deletedAt
+}
+
+// RequestIds returns the ids of the requests the log links to.
+func (c *ConversionLog) RequestIds() []string {
+	ids := []string{c.Request.Id}
+
+	for _, target := range c.Targets {
+		ids = append(ids, target.Request.Id)
+	}
+
+	return ids
+}
+
+// ResponseIds returns the ids of the responses the log links to.
+func (c *ConversionLog) ResponseIds() []string {
+	ids := []string{c.Response.Id}
+
+	for _, target := range c.Targets {
+		ids = append(ids, target.Response.Id)
+	}
+
+	return ids
+}
+
 func NewConversionLog(id string, req api.Request, resp api.Response) *ConversionLog {
 	return &ConversionLog{
 		Id:        id,
@@ -25,3 +76,54 @@ func NewConversionLog(id string, req api.Request, resp api.Response) *Conversion
 		Response:  resp,
 	}
 }
+
+// ExpandedConversionLog is a conversion log together with the request and
+// response records it links to, as currently stored.
+type ExpandedConversionLog struct {
+	Log      *ConversionLog `json:"log"`
+	Request  *api.Request   `json:"request"`
+	Response *api.Response  `json:"response"`
+}
+
+// Target is one leg of a multi-target conversion: the stored request and
+// response for a target currency and the policy its result was rounded with.
+type Target struct {
+	Request  api.Request           `json:"request"`
+	Response api.Response          `json:"response"`
+	Rounding *money.RoundingPolicy `json:"rounding,omitempty"`
+}
+
+// TargetResult is the converted amount in one target currency.
+type TargetResult struct {
+	Result   money.Decimal `json:"result" swaggertype:"string" example:"91.80"`
+	Quote    money.Decimal `json:"quote" swaggertype:"string" example:"0.9134"`
+	Provider string        `json:"provider,omitempty"`
+	Legs     []api.Leg     `json:"legs,omitempty"`
+	Fees     *api.Fees     `json:"fees,omitempty"`
+}
+
+// MultiConversion is the result of converting one amount into several
+// currencies, keyed by target currency. Timestamp is the time of the oldest
+// quote used, so that it holds for every result.
+type MultiConversion struct {
+	Log       *ConversionLog          `json:"log"`
+	From      string                  `json:"from"`
+	Amount    money.Decimal           `json:"amount" swaggertype:"string" example:"100.50"`
+	Timestamp int64                   `json:"timestamp"`
+	Results   map[string]TargetResult `json:"results"`
+}
+
+// BatchItem is the outcome of one conversion of a batch: the stored log, or
+// the reason the item was not converted.
+type BatchItem struct {
+	Log   *ConversionLog `json:"log,omitempty"`
+	Error string         `json:"error,omitempty"`
+}
+
+// Batch is the result of a batch conversion. Items are in request order.
+type Batch struct {
+	BatchId   string      `json:"batch_id"`
+	Converted int         `json:"converted"`
+	Failed    int         `json:"failed"`
+	Items     []BatchItem `json:"items"`
+}
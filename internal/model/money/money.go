@@ -0,0 +1,209 @@
+package money
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Decimal is an exact decimal number for amounts, quotes and results. It is
+// encoded as a JSON string ("95.91"), stored as NUMERIC in Postgres and as
+// Decimal128 in Mongo. Plain JSON and BSON numbers are accepted when reading
+// so that records written before it existed still load.
+type Decimal struct {
+	value decimal.Decimal
+}
+
+var Zero = Decimal{}
+
+func NewFromInt(value int64) Decimal {
+	return Decimal{value: decimal.NewFromInt(value)}
+}
+
+// NewFromFloat converts value using the shortest decimal that represents it,
+// so 0.9134 becomes exactly 0.9134.
+func NewFromFloat(value float64) Decimal {
+	return Decimal{value: decimal.NewFromFloat(value)}
+}
+
+func Parse(value string) (Decimal, error) {
+	d, err := decimal.NewFromString(value)
+
+	if err != nil {
+		return Zero, fmt.Errorf("invalid decimal %q", value)
+	}
+
+	return Decimal{value: d}, nil
+}
+
+// MustParse is Parse for constants; it panics on invalid input.
+func MustParse(value string) Decimal {
+	d, err := Parse(value)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return d
+}
+
+func (d Decimal) Add(other Decimal) Decimal {
+	return Decimal{value: d.value.Add(other.value)}
+}
+
+func (d Decimal) Sub(other Decimal) Decimal {
+	return Decimal{value: d.value.Sub(other.value)}
+}
+
+func (d Decimal) Mul(other Decimal) Decimal {
+	return Decimal{value: d.value.Mul(other.value)}
+}
+
+// Percent returns percent per cent of d, exactly.
+func (d Decimal) Percent(percent Decimal) Decimal {
+	return Decimal{value: d.value.Mul(percent.value).Shift(-2)}
+}
+
+// Div divides with places digits after the decimal point, rounding half away
+// from zero.
+func (d Decimal) Div(other Decimal, places int32) Decimal {
+	return Decimal{value: d.value.DivRound(other.value, places)}
+}
+
+// Round rounds half away from zero to places digits after the decimal point.
+func (d Decimal) Round(places int32) Decimal {
+	return Decimal{value: d.value.Round(places)}
+}
+
+func (d Decimal) Cmp(other Decimal) int {
+	return d.value.Cmp(other.value)
+}
+
+func (d Decimal) Equal(other Decimal) bool {
+	return d.value.Equal(other.value)
+}
+
+func (d Decimal) IsZero() bool {
+	return d.value.IsZero()
+}
+
+func (d Decimal) IsNegative() bool {
+	return d.value.IsNegative()
+}
+
+func (d Decimal) IsPositive() bool {
+	return d.value.IsPositive()
+}
+
+// Places is the number of significant digits after the decimal point.
+func (d Decimal) Places() int32 {
+	if exp := d.value.Exponent(); exp < 0 {
+		trimmed, _ := decimal.NewFromString(d.value.String())
+
+		if exp = trimmed.Exponent(); exp < 0 {
+			return -exp
+		}
+	}
+
+	return 0
+}
+
+func (d Decimal) Float64() float64 {
+	f, _ := d.value.Float64()
+
+	return f
+}
+
+func (d Decimal) String() string {
+	return d.value.String()
+}
+
+// StringFixed formats d with exactly places digits after the decimal point.
+func (d Decimal) StringFixed(places int32) string {
+	return d.value.StringFixed(places)
+}
+
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.value.String())
+}
+
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*d = Zero
+
+		return nil
+	}
+
+	text := string(bytes.Trim(data, `"`))
+	parsed, err := Parse(text)
+
+	if err != nil {
+		return err
+	}
+
+	*d = parsed
+
+	return nil
+}
+
+func (d Decimal) Value() (driver.Value, error) {
+	return d.value.String(), nil
+}
+
+func (d *Decimal) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*d = Zero
+	case float64:
+		*d = NewFromFloat(v)
+	case int64:
+		*d = NewFromInt(v)
+	case string:
+		return d.UnmarshalJSON([]byte(v))
+	case []byte:
+		return d.UnmarshalJSON(v)
+	default:
+		return fmt.Errorf("cannot scan %T into money.Decimal", src)
+	}
+
+	return nil
+}
+
+func (d Decimal) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	dec, err := primitive.ParseDecimal128(d.value.String())
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return bson.MarshalValue(dec)
+}
+
+func (d *Decimal) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	raw := bson.RawValue{Type: t, Value: data}
+
+	switch t {
+	case bsontype.Null, bsontype.Undefined:
+		*d = Zero
+	case bsontype.Decimal128:
+		return d.UnmarshalJSON([]byte(raw.Decimal128().String()))
+	case bsontype.Double:
+		*d = NewFromFloat(raw.Double())
+	case bsontype.Int32:
+		*d = NewFromInt(int64(raw.Int32()))
+	case bsontype.Int64:
+		*d = NewFromInt(raw.Int64())
+	case bsontype.String:
+		return d.UnmarshalJSON([]byte(raw.StringValue()))
+	default:
+		return fmt.Errorf("cannot decode BSON %s into money.Decimal", t)
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/M2rk13/Otus-327619/internal/enum"
+)
+
+func TestDecimal_JSON(t *testing.T) {
+	var amount struct {
+		Amount Decimal `json:"amount"`
+	}
+
+	if err := json.Unmarshal([]byte(`{"amount": 95.907}`), &amount); err != nil || amount.Amount.String() != "95.907" {
+		t.Fatalf("numbers should decode exactly, got %s, %v", amount.Amount, err)
+	}
+
+	if err := json.Unmarshal([]byte(`{"amount": "0.1"}`), &amount); err != nil || !amount.Amount.Equal(MustParse("0.1")) {
+		t.Fatalf("strings should decode exactly, got %s, %v", amount.Amount, err)
+	}
+
+	if err := json.Unmarshal([]byte(`{"amount": null}`), &amount); err != nil || !amount.Amount.IsZero() {
+		t.Fatalf("null should decode to zero, got %s, %v", amount.Amount, err)
+	}
+
+	data, err := json.Marshal(MustParse("0.1").Add(MustParse("0.2")))
+
+	if err != nil || string(data) != `"0.3"` {
+		t.Fatalf("expected \"0.3\", got %s, %v", data, err)
+	}
+}
+
+func TestDecimal_RoundMode(t *testing.T) {
+	cases := []struct {
+		value, mode, want string
+	}{
+		{"2.345", enum.RoundHalfUp, "2.35"},
+		{"-2.345", enum.RoundHalfUp, "-2.35"},
+		{"2.345", enum.RoundHalfEven, "2.34"},
+		{"2.355", enum.RoundHalfEven, "2.36"},
+		{"2.349", enum.RoundDown, "2.34"},
+		{"-2.349", enum.RoundDown, "-2.34"},
+		{"2.341", enum.RoundUp, "2.35"},
+		{"-2.341", enum.RoundUp, "-2.35"},
+		{"-2.341", enum.RoundCeiling, "-2.34"},
+		{"-2.341", enum.RoundFloor, "-2.35"},
+		{"2.3", enum.RoundUp, "2.3"},
+	}
+
+	for _, tc := range cases {
+		got, err := MustParse(tc.value).RoundMode(2, tc.mode)
+
+		if err != nil || !got.Equal(MustParse(tc.want)) {
+			t.Errorf("%s %s: expected %s, got %s (%v)", tc.mode, tc.value, tc.want, got, err)
+		}
+	}
+
+	if _, err := Zero.RoundMode(2, "sideways"); err == nil {
+		t.Error("unknown mode should fail")
+	}
+}
@@ -0,0 +1,46 @@
+package money
+
+import (
+	"fmt"
+
+	"github.com/M2rk13/Otus-327619/internal/enum"
+)
+
+// RoundingPolicy says how a result is rounded: Mode is one of the
+// enum.Round* values and Scale the number of digits after the decimal point.
+// A nil Scale stands for the minor units of the currency.
+type RoundingPolicy struct {
+	Mode  string `json:"mode" example:"half_even"`
+	Scale *int32 `json:"scale,omitempty" example:"2"`
+}
+
+// RoundMode rounds d to places digits after the decimal point:
+//
+//	half_up    half away from zero (2.345 → 2.35, -2.345 → -2.35)
+//	half_even  half to the even neighbour (2.345 → 2.34, 2.355 → 2.36)
+//	down       towards zero, i.e. truncation (2.349 → 2.34)
+//	up         away from zero (2.341 → 2.35)
+//	ceiling    towards positive infinity
+//	floor      towards negative infinity
+func (d Decimal) RoundMode(places int32, mode string) (Decimal, error) {
+	switch mode {
+	case enum.RoundHalfUp:
+		return d.Round(places), nil
+	case enum.RoundHalfEven:
+		return Decimal{value: d.value.RoundBank(places)}, nil
+	case enum.RoundDown:
+		return Decimal{value: d.value.Truncate(places)}, nil
+	case enum.RoundUp:
+		if d.IsNegative() {
+			return Decimal{value: d.value.Shift(places).Floor().Shift(-places)}, nil
+		}
+
+		return Decimal{value: d.value.Shift(places).Ceil().Shift(-places)}, nil
+	case enum.RoundCeiling:
+		return Decimal{value: d.value.Shift(places).Ceil().Shift(-places)}, nil
+	case enum.RoundFloor:
+		return Decimal{value: d.value.Shift(places).Floor().Shift(-places)}, nil
+	default:
+		return d, fmt.Errorf("unknown rounding mode %q", mode)
+	}
+}
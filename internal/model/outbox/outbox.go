@@ -0,0 +1,17 @@
+package outbox
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Entry is a record accepted for storage but not yet confirmed as stored. An
+// entry with Acked set and no payload marks the entry of the same entity and
+// id as done.
+type Entry struct {
+	Entity     string          `json:"entity"`
+	Id         string          `json:"id"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+	AcceptedAt time.Time       `json:"accepted_at,omitzero"`
+	Acked      bool            `json:"acked,omitempty"`
+}
@@ -0,0 +1,38 @@
+package quote
+
+import (
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+)
+
+// Quote is a priced conversion locked for an account. It can be executed once
+// until ExpiresAt, at the rate, fees and rounding recorded here; UsedAt is set
+// when it is.
+type Quote struct {
+	Id        string               `json:"id"`
+	Account   string               `json:"account"`
+	From      string               `json:"from"`
+	To        string               `json:"to"`
+	Amount    money.Decimal        `json:"amount" swaggertype:"string" example:"100.50"`
+	Rate      money.Decimal        `json:"rate" swaggertype:"string" example:"0.908833"`
+	Result    money.Decimal        `json:"result" swaggertype:"string" example:"89.97"`
+	Fees      api.Fees             `json:"fees"`
+	Rounding  money.RoundingPolicy `json:"rounding"`
+	Provider  string               `json:"provider,omitempty"`
+	Legs      []api.Leg            `json:"legs,omitempty"`
+	QuotedAt  time.Time            `json:"quoted_at"`
+	CreatedAt time.Time            `json:"created_at"`
+	ExpiresAt time.Time            `json:"expires_at"`
+	UsedAt    *time.Time           `json:"used_at,omitempty"`
+}
+
+func (q *Quote) GetId() string {
+	return q.Id
+}
+
+// Expired reports whether the quote can no longer be used at the given time.
+func (q *Quote) Expired(at time.Time) bool {
+	return !at.Before(q.ExpiresAt)
+}
@@ -0,0 +1,37 @@
+package rate
+
+import (
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+)
+
+// Quote is the exchange rate for a currency pair as reported by a provider.
+// Legs lists the steps of a quote derived from other pairs and is empty for a
+// direct quote.
+type Quote struct {
+	From      string        `json:"from"`
+	To        string        `json:"to"`
+	Rate      money.Decimal `json:"rate" swaggertype:"string" example:"0.9134"`
+	Timestamp time.Time     `json:"timestamp"`
+	Provider  string        `json:"provider"`
+	Legs      []api.Leg     `json:"legs,omitempty"`
+	Stale     bool          `json:"stale,omitempty"`
+}
+
+// Pair is a currency pair a provider can quote.
+type Pair struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// ProviderHealth is the circuit breaker state of a rate provider.
+type ProviderHealth struct {
+	Name                string     `json:"name"`
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastSuccess         *time.Time `json:"last_success,omitempty"`
+	LastFailure         *time.Time `json:"last_failure,omitempty"`
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/model/audit"
+
+	"github.com/go-redis/redis"
+)
+
+const auditKey = "audit:log"
+
+// AuditStore keeps the audit trail. It is independent of the storage backend
+// so that every mutation is recorded the same way whichever Repository is in
+// use. Records older than the retention period are dropped.
+type AuditStore interface {
+	Append(rec *audit.Record) error
+	// Find returns matching records, newest first.
+	Find(filter audit.Filter) ([]*audit.Record, error)
+}
+
+// RedisAuditStore keeps records in a sorted set scored by timestamp, which
+// makes trimming and time range queries cheap.
+type RedisAuditStore struct {
+	client    *redis.Client
+	retention time.Duration
+}
+
+func NewRedisAuditStore(client *redis.Client, retention time.Duration) *RedisAuditStore {
+	return &RedisAuditStore{client: client, retention: retention}
+}
+
+func (s *RedisAuditStore) Append(rec *audit.Record) error {
+	data, err := json.Marshal(rec)
+
+	if err != nil {
+		return err
+	}
+
+	if err = s.client.ZAdd(auditKey, redis.Z{Score: auditScore(rec.Timestamp), Member: data}).Err(); err != nil {
+		return err
+	}
+
+	cutoff := auditScore(time.Now().Add(-s.retention))
+
+	return s.client.ZRemRangeByScore(auditKey, "-inf", "("+formatScore(cutoff)).Err()
+}
+
+func (s *RedisAuditStore) Find(filter audit.Filter) ([]*audit.Record, error) {
+	rng := redis.ZRangeBy{Min: "-inf", Max: "+inf"}
+
+	if !filter.From.IsZero() {
+		rng.Min = formatScore(auditScore(filter.From))
+	}
+
+	if !filter.To.IsZero() {
+		rng.Max = formatScore(auditScore(filter.To))
+	}
+
+	members, err := s.client.ZRevRangeByScore(auditKey, rng).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]*audit.Record, 0)
+
+	for _, member := range members {
+		rec := &audit.Record{}
+
+		if err = json.Unmarshal([]byte(member), rec); err != nil {
+			continue
+		}
+
+		if !filter.Matches(rec) {
+			continue
+		}
+
+		records = append(records, rec)
+
+		if filter.Limit > 0 && len(records) == filter.Limit {
+			break
+		}
+	}
+
+	return records, nil
+}
+
+func auditScore(t time.Time) float64 {
+	return float64(t.UnixMicro())
+}
+
+func formatScore(score float64) string {
+	return strconv.FormatFloat(score, 'f', 0, 64)
+}
+
+// MemoryAuditStore keeps records in process memory. It is used when Redis is
+// not configured; the trail does not survive a restart.
+type MemoryAuditStore struct {
+	mu        sync.Mutex
+	records   []*audit.Record
+	retention time.Duration
+}
+
+func NewMemoryAuditStore(retention time.Duration) *MemoryAuditStore {
+	return &MemoryAuditStore{retention: retention}
+}
+
+func (s *MemoryAuditStore) Append(rec *audit.Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := time.Now().Add(-s.retention)
+	kept := s.records[:0]
+
+	for _, item := range s.records {
+		if !item.Timestamp.Before(cutoff) {
+			kept = append(kept, item)
+		}
+	}
+
+	s.records = append(kept, rec)
+
+	return nil
+}
+
+func (s *MemoryAuditStore) Find(filter audit.Filter) ([]*audit.Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := make([]*audit.Record, 0)
+
+	for i := len(s.records) - 1; i >= 0; i-- {
+		if !filter.Matches(s.records[i]) {
+			continue
+		}
+
+		records = append(records, s.records[i])
+
+		if filter.Limit > 0 && len(records) == filter.Limit {
+			break
+		}
+	}
+
+	return records, nil
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// Cache is a byte-oriented key/value store with per-entry expiry used by
+// CachedRepository.
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte, ttl time.Duration)
+	Delete(keys ...string)
+}
+
+type RedisCache struct {
+	client *redis.Client
+}
+
+func NewRedisCache(client *redis.Client) *RedisCache {
+	return &RedisCache{client: client}
+}
+
+func (c *RedisCache) Get(key string) ([]byte, bool) {
+	value, err := c.client.Get(key).Bytes()
+
+	if err != nil {
+		return nil, false
+	}
+
+	return value, true
+}
+
+func (c *RedisCache) Set(key string, value []byte, ttl time.Duration) {
+	c.client.Set(key, value, ttl)
+}
+
+func (c *RedisCache) Delete(keys ...string) {
+	if len(keys) > 0 {
+		c.client.Del(keys...)
+	}
+}
+
+type lruEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// LRUCache is an in-process cache holding at most size entries, evicting the
+// least recently used one when full.
+type LRUCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+func NewLRUCache(size int) *LRUCache {
+	return &LRUCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (c *LRUCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*lruEntry)
+
+	if time.Now().After(entry.expiresAt) {
+		c.remove(elem)
+
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+
+	return entry.value, true
+}
+
+func (c *LRUCache) Set(key string, value []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*lruEntry)
+		entry.value = value
+		entry.expiresAt = time.Now().Add(ttl)
+		c.order.MoveToFront(elem)
+
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, value: value, expiresAt: time.Now().Add(ttl)})
+
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *LRUCache) Delete(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if elem, ok := c.entries[key]; ok {
+			c.remove(elem)
+		}
+	}
+}
+
+func (c *LRUCache) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*lruEntry).key)
+}
@@ -0,0 +1,339 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/idempotency"
+	logmodel "github.com/M2rk13/Otus-327619/internal/model/log"
+	"github.com/M2rk13/Otus-327619/internal/model/quote"
+)
+
+const (
+	requestsCacheKey  = "requests"
+	responsesCacheKey = "responses"
+	logsCacheKey      = "conversion_logs"
+)
+
+type CacheStats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+}
+
+// CachedRepository is a read-through cache in front of another Repository.
+// Lookups by id are kept for itemTTL, list results for the shorter listTTL.
+// Writes go straight to the wrapped repository and drop the affected entries,
+// including those of the dependants a delete cascaded to, so that every
+// instance sharing the cache stops serving them.
+//
+// A read that loaded a record just before a write may store it after the
+// write dropped its entry. The entries of a write are therefore dropped once
+// more after refillGuard.
+type CachedRepository struct {
+	repo        Repository
+	cache       Cache
+	itemTTL     time.Duration
+	listTTL     time.Duration
+	refillGuard time.Duration
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+const defaultRefillGuard = 500 * time.Millisecond
+
+func NewCachedRepository(repo Repository, cache Cache, itemTTL, listTTL time.Duration) *CachedRepository {
+	return &CachedRepository{
+		repo:        repo,
+		cache:       cache,
+		itemTTL:     itemTTL,
+		listTTL:     listTTL,
+		refillGuard: defaultRefillGuard,
+	}
+}
+
+func (r *CachedRepository) Stats() CacheStats {
+	return CacheStats{Hits: r.hits.Load(), Misses: r.misses.Load()}
+}
+
+func itemKey(entity, id string) string {
+	return fmt.Sprintf("cache:%s:%s", entity, id)
+}
+
+func listKey(entity string, includeDeleted bool) string {
+	return fmt.Sprintf("cache:%s:list:%t", entity, includeDeleted)
+}
+
+// invalidate drops keys now and again after refillGuard.
+func (r *CachedRepository) invalidate(keys ...string) {
+	r.cache.Delete(keys...)
+	time.AfterFunc(r.refillGuard, func() { r.cache.Delete(keys...) })
+}
+
+func (r *CachedRepository) invalidateLists(entities ...string) {
+	keys := make([]string, 0, len(entities)*2)
+
+	for _, entity := range entities {
+		keys = append(keys, listKey(entity, false), listKey(entity, true))
+	}
+
+	r.invalidate(keys...)
+}
+
+func (r *CachedRepository) invalidateItem(entity, id string) {
+	r.invalidate(itemKey(entity, id))
+}
+
+// invalidateCascaded drops the dependants a delete took along.
+func (r *CachedRepository) invalidateCascaded(cascaded Cascaded) {
+	keys := make([]string, 0, len(cascaded.Responses)+len(cascaded.Logs))
+
+	for _, resp := range cascaded.Responses {
+		keys = append(keys, itemKey(responsesCacheKey, resp.Id))
+	}
+
+	for _, convLog := range cascaded.Logs {
+		keys = append(keys, itemKey(logsCacheKey, convLog.Id))
+	}
+
+	if len(keys) > 0 {
+		r.invalidate(keys...)
+	}
+}
+
+// cachedLoad returns the cached value under key or loads and caches it.
+// Missing items are not cached, so a freshly created record shows up at once.
+func cachedLoad[T any](r *CachedRepository, key string, ttl time.Duration, load func() (T, bool)) T {
+	if data, ok := r.cache.Get(key); ok {
+		var value T
+
+		if err := json.Unmarshal(data, &value); err == nil {
+			r.hits.Add(1)
+
+			return value
+		}
+	}
+
+	r.misses.Add(1)
+	value, found := load()
+
+	if found {
+		if data, err := json.Marshal(value); err == nil {
+			r.cache.Set(key, data, ttl)
+		}
+	}
+
+	return value
+}
+
+func getItem[T any](r *CachedRepository, entity, id string, load func(string) *T) *T {
+	return cachedLoad(r, itemKey(entity, id), r.itemTTL, func() (*T, bool) {
+		item := load(id)
+
+		return item, item != nil
+	})
+}
+
+func getList[T any](r *CachedRepository, entity string, includeDeleted bool, load func(bool) []*T) []*T {
+	return cachedLoad(r, listKey(entity, includeDeleted), r.listTTL, func() ([]*T, bool) {
+		return load(includeDeleted), true
+	})
+}
+
+func (r *CachedRepository) CreateRequest(req *api.Request) error {
+	if err := r.repo.CreateRequest(req); err != nil {
+		return err
+	}
+
+	r.invalidateLists(requestsCacheKey)
+
+	return nil
+}
+
+func (r *CachedRepository) CreateRequests(items []*api.Request) error {
+	err := r.repo.CreateRequests(items)
+	r.invalidateLists(requestsCacheKey)
+
+	return err
+}
+
+func (r *CachedRepository) GetRequestByID(id string) *api.Request {
+	return getItem(r, requestsCacheKey, id, r.repo.GetRequestByID)
+}
+
+func (r *CachedRepository) GetAllRequests(includeDeleted bool) []*api.Request {
+	return getList(r, requestsCacheKey, includeDeleted, r.repo.GetAllRequests)
+}
+
+func (r *CachedRepository) UpdateRequest(req *api.Request) error {
+	err := r.repo.UpdateRequest(req)
+	r.invalidateItem(requestsCacheKey, req.Id)
+	r.invalidateLists(requestsCacheKey)
+
+	return err
+}
+
+func (r *CachedRepository) DeleteRequest(id string) (Cascaded, error) {
+	cascaded, err := r.repo.DeleteRequest(id)
+	r.invalidateItem(requestsCacheKey, id)
+	r.invalidateCascaded(cascaded)
+	r.invalidateLists(requestsCacheKey, responsesCacheKey, logsCacheKey)
+
+	return cascaded, err
+}
+
+func (r *CachedRepository) RestoreRequest(id string) error {
+	err := r.repo.RestoreRequest(id)
+	r.invalidateItem(requestsCacheKey, id)
+	r.invalidateLists(requestsCacheKey)
+
+	return err
+}
+
+func (r *CachedRepository) CreateResponse(resp *api.Response) error {
+	if err := r.repo.CreateResponse(resp); err != nil {
+		return err
+	}
+
+	r.invalidateLists(responsesCacheKey)
+
+	return nil
+}
+
+func (r *CachedRepository) CreateResponses(items []*api.Response) error {
+	err := r.repo.CreateResponses(items)
+	r.invalidateLists(responsesCacheKey)
+
+	return err
+}
+
+func (r *CachedRepository) GetResponseByID(id string) *api.Response {
+	return getItem(r, responsesCacheKey, id, r.repo.GetResponseByID)
+}
+
+func (r *CachedRepository) GetAllResponses(includeDeleted bool) []*api.Response {
+	return getList(r, responsesCacheKey, includeDeleted, r.repo.GetAllResponses)
+}
+
+func (r *CachedRepository) UpdateResponse(resp *api.Response) error {
+	err := r.repo.UpdateResponse(resp)
+	r.invalidateItem(responsesCacheKey, resp.Id)
+	r.invalidateLists(responsesCacheKey)
+
+	return err
+}
+
+func (r *CachedRepository) DeleteResponse(id string) (Cascaded, error) {
+	cascaded, err := r.repo.DeleteResponse(id)
+	r.invalidateItem(responsesCacheKey, id)
+	r.invalidateCascaded(cascaded)
+	r.invalidateLists(responsesCacheKey, logsCacheKey)
+
+	return cascaded, err
+}
+
+func (r *CachedRepository) RestoreResponse(id string) error {
+	err := r.repo.RestoreResponse(id)
+	r.invalidateItem(responsesCacheKey, id)
+	r.invalidateLists(responsesCacheKey)
+
+	return err
+}
+
+func (r *CachedRepository) CreateConversionLog(log *logmodel.ConversionLog) error {
+	if err := r.repo.CreateConversionLog(log); err != nil {
+		return err
+	}
+
+	r.invalidateLists(logsCacheKey)
+
+	return nil
+}
+
+func (r *CachedRepository) CreateConversionLogs(items []*logmodel.ConversionLog) error {
+	err := r.repo.CreateConversionLogs(items)
+	r.invalidateLists(logsCacheKey)
+
+	return err
+}
+
+func (r *CachedRepository) GetConversionLogByID(id string) *logmodel.ConversionLog {
+	return getItem(r, logsCacheKey, id, r.repo.GetConversionLogByID)
+}
+
+func (r *CachedRepository) GetAllConversionLogs(includeDeleted bool) []*logmodel.ConversionLog {
+	return getList(r, logsCacheKey, includeDeleted, r.repo.GetAllConversionLogs)
+}
+
+func (r *CachedRepository) UpdateConversionLog(log *logmodel.ConversionLog) error {
+	err := r.repo.UpdateConversionLog(log)
+	r.invalidateItem(logsCacheKey, log.Id)
+	r.invalidateLists(logsCacheKey)
+
+	return err
+}
+
+func (r *CachedRepository) DeleteConversionLog(id string) error {
+	err := r.repo.DeleteConversionLog(id)
+	r.invalidateItem(logsCacheKey, id)
+	r.invalidateLists(logsCacheKey)
+
+	return err
+}
+
+func (r *CachedRepository) RestoreConversionLog(id string) error {
+	err := r.repo.RestoreConversionLog(id)
+	r.invalidateItem(logsCacheKey, id)
+	r.invalidateLists(logsCacheKey)
+
+	return err
+}
+
+// Quotes and idempotency keys are claimed across instances, so they always go
+// to the wrapped repository.
+
+func (r *CachedRepository) CreateQuote(q *quote.Quote) error {
+	return r.repo.CreateQuote(q)
+}
+
+func (r *CachedRepository) GetQuoteByID(id string) *quote.Quote {
+	return r.repo.GetQuoteByID(id)
+}
+
+func (r *CachedRepository) UseQuote(id string, at time.Time) (*quote.Quote, error) {
+	return r.repo.UseQuote(id, at)
+}
+
+func (r *CachedRepository) ReserveIdempotencyKey(rec *idempotency.Record) (*idempotency.Record, error) {
+	return r.repo.ReserveIdempotencyKey(rec)
+}
+
+func (r *CachedRepository) CompleteIdempotencyKey(rec *idempotency.Record) error {
+	return r.repo.CompleteIdempotencyKey(rec)
+}
+
+func (r *CachedRepository) ReleaseIdempotencyKey(account, key string) error {
+	return r.repo.ReleaseIdempotencyKey(account, key)
+}
+
+func (r *CachedRepository) PurgeDeleted(before time.Time) (int, error) {
+	purged, err := r.repo.PurgeDeleted(before)
+	r.invalidateLists(requestsCacheKey, responsesCacheKey, logsCacheKey)
+
+	return purged, err
+}
+
+func (r *CachedRepository) GetNewConversionRequests() []*api.Request {
+	return r.repo.GetNewConversionRequests()
+}
+
+func (r *CachedRepository) GetNewConversionResponses() []*api.Response {
+	return r.repo.GetNewConversionResponses()
+}
+
+func (r *CachedRepository) GetNewConversionLogs() []*logmodel.ConversionLog {
+	return r.repo.GetNewConversionLogs()
+}
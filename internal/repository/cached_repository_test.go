@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/enum"
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+	logmodel "github.com/M2rk13/Otus-327619/internal/model/log"
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+)
+
+func newTestCachedRepository(t *testing.T) *CachedRepository {
+	t.Helper()
+
+	return NewCachedRepository(newTestFileStore(t, enum.Cascade), NewLRUCache(100), time.Minute, time.Minute)
+}
+
+func TestCachedRepository_HitsAndInvalidation(t *testing.T) {
+	cached := newTestCachedRepository(t)
+
+	req := &api.Request{From: "USD", To: "EUR", Amount: money.NewFromInt(10)}
+
+	if err := cached.CreateRequest(req); err != nil {
+		t.Fatal(err)
+	}
+
+	cached.GetRequestByID(req.Id)
+	got := cached.GetRequestByID(req.Id)
+
+	if stats := cached.Stats(); stats.Hits != 1 || stats.Misses != 1 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+
+	got.Amount = money.NewFromInt(99)
+
+	if !cached.GetRequestByID(req.Id).Amount.Equal(money.NewFromInt(10)) {
+		t.Fatal("cached items must not be shared with callers")
+	}
+
+	if err := cached.UpdateRequest(&api.Request{Id: req.Id, From: "USD", To: "EUR", Amount: money.NewFromInt(20)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cached.GetRequestByID(req.Id).Amount.Equal(money.NewFromInt(20)) {
+		t.Fatal("update should invalidate the cached item")
+	}
+
+	if len(cached.GetAllRequests(false)) != 1 {
+		t.Fatal("list should contain the request")
+	}
+
+	if _, err := cached.DeleteRequest(req.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	if cached.GetRequestByID(req.Id) != nil || len(cached.GetAllRequests(false)) != 0 {
+		t.Fatal("delete should invalidate the item and the list")
+	}
+}
+
+func TestCachedRepository_DeleteDropsDependants(t *testing.T) {
+	store := newTestFileStore(t, enum.Cascade)
+	cache := NewLRUCache(100)
+
+	// Both instances share the cache, as they would share Redis.
+	reader := NewCachedRepository(store, cache, time.Minute, time.Minute)
+	writer := NewCachedRepository(store, cache, time.Minute, time.Minute)
+
+	req := &api.Request{From: "USD", To: "EUR", Amount: money.NewFromInt(10)}
+
+	if err := writer.CreateRequest(req); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := &api.Response{Success: true, Query: *req}
+
+	if err := writer.CreateResponse(resp); err != nil {
+		t.Fatal(err)
+	}
+
+	cl := &logmodel.ConversionLog{Request: *req, Response: *resp}
+
+	if err := writer.CreateConversionLog(cl); err != nil {
+		t.Fatal(err)
+	}
+
+	if reader.GetResponseByID(resp.Id) == nil || reader.GetConversionLogByID(cl.Id) == nil {
+		t.Fatal("response and log should be found")
+	}
+
+	if _, err := writer.DeleteRequest(req.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	if reader.GetResponseByID(resp.Id) != nil || reader.GetConversionLogByID(cl.Id) != nil {
+		t.Fatal("dependants of a deleted request should not be served from cache")
+	}
+}
+
+// slowReadRepository holds GetRequestByID until release is closed, after
+// telling loading that the record has been read.
+type slowReadRepository struct {
+	*FileStore
+	loading chan struct{}
+	release chan struct{}
+}
+
+func (r *slowReadRepository) GetRequestByID(id string) *api.Request {
+	req := r.FileStore.GetRequestByID(id)
+	close(r.loading)
+	<-r.release
+
+	return req
+}
+
+func TestCachedRepository_DropsStaleRefill(t *testing.T) {
+	store := newTestFileStore(t, enum.Cascade)
+	cache := NewLRUCache(100)
+	slow := &slowReadRepository{FileStore: store, loading: make(chan struct{}), release: make(chan struct{})}
+	reader := NewCachedRepository(slow, cache, time.Minute, time.Minute)
+	writer := NewCachedRepository(store, cache, time.Minute, time.Minute)
+	writer.refillGuard = 20 * time.Millisecond
+
+	req := &api.Request{From: "USD", To: "EUR", Amount: money.NewFromInt(10)}
+
+	if err := writer.CreateRequest(req); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		reader.GetRequestByID(req.Id)
+	}()
+
+	// The reader has loaded the old record when the update lands, and caches
+	// it afterwards.
+	<-slow.loading
+
+	if err := writer.UpdateRequest(&api.Request{Id: req.Id, From: "USD", To: "EUR", Amount: money.NewFromInt(20)}); err != nil {
+		t.Fatal(err)
+	}
+
+	close(slow.release)
+	<-done
+
+	for deadline := time.Now().Add(time.Second); ; {
+		got := writer.GetRequestByID(req.Id)
+
+		if got.Amount.Equal(money.NewFromInt(20)) {
+			return
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("the stale refill should be dropped, got amount %s", got.Amount)
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestLRUCache_Eviction(t *testing.T) {
+	cache := NewLRUCache(2)
+
+	cache.Set("a", []byte("1"), time.Minute)
+	cache.Set("b", []byte("2"), time.Minute)
+	cache.Get("a")
+	cache.Set("c", []byte("3"), time.Minute)
+
+	if _, ok := cache.Get("b"); ok {
+		t.Fatal("least recently used entry should be evicted")
+	}
+
+	if _, ok := cache.Get("a"); !ok {
+		t.Fatal("recently used entry should be kept")
+	}
+
+	cache.Set("d", []byte("4"), -time.Second)
+
+	if _, ok := cache.Get("d"); ok {
+		t.Fatal("expired entry should not be returned")
+	}
+}
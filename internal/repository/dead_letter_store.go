@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+
+	"github.com/M2rk13/Otus-327619/internal/model/deadletter"
+)
+
+// DeadLetterStore keeps the items the storage workers failed to write. It is
+// independent of the Repository so that letters can be kept locally while
+// the database they failed to reach is down.
+type DeadLetterStore interface {
+	Add(letter *deadletter.Letter) error
+	// List returns the letters of entity, or all letters if it is empty,
+	// oldest first.
+	List(entity string) ([]*deadletter.Letter, error)
+	// Get and Remove fail with ErrNotFound for unknown ids.
+	Get(id string) (*deadletter.Letter, error)
+	Remove(id string) error
+}
+
+// FileDeadLetterStore keeps letters in a JSON lines file, like FileStore.
+type FileDeadLetterStore struct {
+	item *repositoryItem[*deadletter.Letter]
+}
+
+func NewFileDeadLetterStore(filePath string) (*FileDeadLetterStore, error) {
+	item := &repositoryItem[*deadletter.Letter]{filePath: filePath}
+
+	if err := setupPersistence(item); err != nil {
+		return nil, fmt.Errorf("failed to setup persistence for dead letters: %v", err)
+	}
+
+	return &FileDeadLetterStore{item: item}, nil
+}
+
+func (s *FileDeadLetterStore) Close() {
+	if s.item.file != nil {
+		_ = s.item.file.Close()
+		fmt.Println("Closed dead letters persistence file.")
+	}
+}
+
+func (s *FileDeadLetterStore) Add(letter *deadletter.Letter) error {
+	stored := *letter
+	s.item.add(&stored)
+
+	return nil
+}
+
+func (s *FileDeadLetterStore) List(entity string) ([]*deadletter.Letter, error) {
+	s.item.mu.Lock()
+	defer s.item.mu.Unlock()
+
+	letters := make([]*deadletter.Letter, 0)
+
+	for _, letter := range s.item.data {
+		if entity == "" || letter.Entity == entity {
+			found := *letter
+			letters = append(letters, &found)
+		}
+	}
+
+	return letters, nil
+}
+
+func (s *FileDeadLetterStore) Get(id string) (*deadletter.Letter, error) {
+	s.item.mu.Lock()
+	defer s.item.mu.Unlock()
+
+	index := slices.IndexFunc(s.item.data, func(letter *deadletter.Letter) bool { return letter.Id == id })
+
+	if index < 0 {
+		return nil, ErrNotFound
+	}
+
+	found := *s.item.data[index]
+
+	return &found, nil
+}
+
+func (s *FileDeadLetterStore) Remove(id string) error {
+	if purgeWhere(s.item, func(letter *deadletter.Letter) bool { return letter.Id == id }) == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// PostgresDeadLetterStore keeps letters in the dead_letters table of the
+// Postgres database.
+type PostgresDeadLetterStore struct {
+	db *sql.DB
+}
+
+func NewPostgresDeadLetterStore(store *PostgresStore) *PostgresDeadLetterStore {
+	return &PostgresDeadLetterStore{db: store.db}
+}
+
+func (s *PostgresDeadLetterStore) Add(letter *deadletter.Letter) error {
+	query := `INSERT INTO dead_letters (id, entity, payload, error, attempts, failed_at) VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err := s.db.Exec(query, letter.Id, letter.Entity, []byte(letter.Payload), letter.Error, letter.Attempts, letter.FailedAt)
+
+	return err
+}
+
+func (s *PostgresDeadLetterStore) List(entity string) ([]*deadletter.Letter, error) {
+	query := `SELECT id, entity, payload, error, attempts, failed_at FROM dead_letters
+		WHERE $1 = '' OR entity = $1 ORDER BY failed_at`
+
+	rows, err := s.db.Query(query, entity)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	letters := make([]*deadletter.Letter, 0)
+
+	for rows.Next() {
+		letter, err := scanDeadLetter(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		letters = append(letters, letter)
+	}
+
+	return letters, rows.Err()
+}
+
+func (s *PostgresDeadLetterStore) Get(id string) (*deadletter.Letter, error) {
+	query := `SELECT id, entity, payload, error, attempts, failed_at FROM dead_letters WHERE id = $1`
+	letter, err := scanDeadLetter(s.db.QueryRow(query, id))
+
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+
+	return letter, err
+}
+
+func (s *PostgresDeadLetterStore) Remove(id string) error {
+	res, err := s.db.Exec(`DELETE FROM dead_letters WHERE id = $1`, id)
+
+	if err != nil {
+		return err
+	}
+
+	if affected, err := res.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func scanDeadLetter(row interface{ Scan(dest ...any) error }) (*deadletter.Letter, error) {
+	var letter deadletter.Letter
+	var payload []byte
+
+	if err := row.Scan(&letter.Id, &letter.Entity, &payload, &letter.Error, &letter.Attempts, &letter.FailedAt); err != nil {
+		return nil, err
+	}
+
+	letter.Payload = payload
+
+	return &letter, nil
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+
+	"github.com/jackc/pgconn"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrNotFound         = errors.New("item not found")
+	ErrAlreadyExists    = errors.New("item with this id already exists")
+	ErrReferenced       = errors.New("item is referenced by other records")
+	ErrMissingReference = errors.New("referenced item does not exist")
+	ErrVersionConflict  = errors.New("item version does not match")
+	ErrQuoteExpired     = errors.New("quote has expired")
+	ErrQuoteUsed        = errors.New("quote has already been used")
+)
+
+// Unavailable reports whether err means that the store could not be reached
+// or did not answer in time, as opposed to rejecting the operation. Only
+// such failures may succeed when the operation is repeated unchanged.
+func Unavailable(err error) bool {
+	var netErr net.Error
+	var pgErr *pgconn.PgError
+
+	switch {
+	case err == nil:
+		return false
+	case errors.As(err, &pgErr):
+		// Connection exceptions, serialization failures, deadlocks and
+		// server shutdowns.
+		return strings.HasPrefix(pgErr.Code, "08") ||
+			pgErr.Code == "40001" || pgErr.Code == "40P01" ||
+			strings.HasPrefix(pgErr.Code, "57P0")
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, driver.ErrBadConn),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.As(err, &netErr),
+		pgconn.Timeout(err),
+		pgconn.SafeToRetry(err),
+		mongo.IsNetworkError(err),
+		mongo.IsTimeout(err):
+		return true
+	}
+
+	return false
+}
+
+// PartialError is returned by a batch create that stored the first Stored
+// records of the batch before failing with Err. The remaining records were
+// not stored.
+type PartialError struct {
+	Stored int
+	Err    error
+}
+
+func (e *PartialError) Error() string {
+	return fmt.Sprintf("stored %d records before failing: %v", e.Stored, e.Err)
+}
+
+func (e *PartialError) Unwrap() error {
+	return e.Err
+}
+
+// StoredBefore returns how many records of a batch were stored before a batch
+// create failed with err.
+func StoredBefore(err error) int {
+	var partial *PartialError
+
+	if errors.As(err, &partial) {
+		return partial.Stored
+	}
+
+	return 0
+}
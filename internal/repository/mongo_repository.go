@@ -2,14 +2,17 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/M2rk13/Otus-327619/internal/config"
+	"github.com/M2rk13/Otus-327619/internal/enum"
 	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/idempotency"
 	logmodel "github.com/M2rk13/Otus-327619/internal/model/log"
+	"github.com/M2rk13/Otus-327619/internal/model/quote"
 
-	"github.com/go-redis/redis"
 	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -17,13 +20,16 @@ import (
 )
 
 type MongoStore struct {
-	mongoClient *mongo.Client
-	redisClient *redis.Client
-	dbName      string
-	redisTTL    time.Duration
+	mongoClient  *mongo.Client
+	dbName       string
+	deletePolicy string
 }
 
-func NewMongoStore(ctx context.Context, mongoCfg config.MongoConfig, redisCfg config.RedisConfig) (*MongoStore, error) {
+func NewMongoStore(
+	ctx context.Context,
+	mongoCfg config.MongoConfig,
+	deletePolicy string,
+) (*MongoStore, error) {
 	mongoClient, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoCfg.MogoUri))
 
 	if err != nil {
@@ -34,64 +40,123 @@ func NewMongoStore(ctx context.Context, mongoCfg config.MongoConfig, redisCfg co
 		return nil, fmt.Errorf("failed to ping mongo: %w", err)
 	}
 
-	redisClient := redis.NewClient(&redis.Options{
-		Addr:     redisCfg.Addr,
-		Password: redisCfg.Password,
-		DB:       0,
-	})
+	store := &MongoStore{
+		mongoClient:  mongoClient,
+		dbName:       mongoCfg.MongoDb,
+		deletePolicy: deletePolicy,
+	}
 
-	if _, err := redisClient.Ping().Result(); err != nil {
-		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	if err := store.ensureIndexes(ctx); err != nil {
+		return nil, err
 	}
 
-	return &MongoStore{
-		mongoClient: mongoClient,
-		redisClient: redisClient,
-		dbName:      mongoCfg.MongoDb,
-		redisTTL:    redisCfg.TTL,
-	}, nil
+	return store, nil
+}
+
+// ensureIndexes creates a unique index on id for every record collection, so
+// that a replayed or replicated create cannot store a second record.
+func (s *MongoStore) ensureIndexes(ctx context.Context) error {
+	for _, name := range []string{"requests", "responses", "conversion_logs"} {
+		_, err := s.collection(name).Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		})
+
+		if err != nil {
+			return fmt.Errorf("failed to create id index on %s: %w", name, err)
+		}
+	}
+
+	return nil
 }
 
 func (s *MongoStore) Close(ctx context.Context) {
 	if s.mongoClient != nil {
 		s.mongoClient.Disconnect(ctx)
 	}
+}
 
-	if s.redisClient != nil {
-		s.redisClient.Close()
-	}
+func (s *MongoStore) collection(name string) *mongo.Collection {
+	return s.mongoClient.Database(s.dbName).Collection(name)
 }
 
-func (s *MongoStore) logChangeToRedis(action, entity, id string) {
-	key := fmt.Sprintf("log:%s:%s:%s", entity, id, time.Now().Format(time.RFC3339Nano))
-	value := fmt.Sprintf("Action '%s' performed on entity '%s' with ID '%s'", action, entity, id)
-	s.redisClient.Set(key, value, s.redisTTL)
+// live narrows filter to documents that are not soft deleted. Documents
+// written before soft deletes existed have no deletedat field and match too.
+func live(filter bson.M) bson.M {
+	filter["deletedat"] = nil
+
+	return filter
 }
 
-func (s *MongoStore) collection(name string) *mongo.Collection {
-	return s.mongoClient.Database(s.dbName).Collection(name)
+func (s *MongoStore) exists(collection, id string) (bool, error) {
+	return s.isReferenced(collection, bson.M{"id": id})
 }
 
-func (s *MongoStore) CreateRequest(req *api.Request) {
-	req.Id = uuid.New().String()
-	_, _ = s.collection("requests").InsertOne(context.Background(), req)
-	s.logChangeToRedis("CREATE", "request", req.Id)
+// isReferenced reports whether a live document matches filter.
+func (s *MongoStore) isReferenced(collection string, filter bson.M) (bool, error) {
+	count, err := s.collection(collection).CountDocuments(context.Background(), live(filter), options.Count().SetLimit(1))
+
+	return count > 0, err
 }
 
-func (s *MongoStore) GetRequestByID(id string) *api.Request {
-	var req api.Request
-	err := s.collection("requests").FindOne(context.Background(), bson.M{"id": id}).Decode(&req)
+func (s *MongoStore) checkLink(collection, id string) error {
+	if id == "" {
+		return nil
+	}
+
+	found, err := s.exists(collection, id)
+
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return ErrMissingReference
+	}
+
+	return nil
+}
+
+func (s *MongoStore) checkResponseLinks(resp *api.Response) error {
+	return s.checkLink("requests", resp.Query.Id)
+}
+
+func (s *MongoStore) checkConversionLogLinks(log *logmodel.ConversionLog) error {
+	for _, id := range log.RequestIds() {
+		if err := s.checkLink("requests", id); err != nil {
+			return err
+		}
+	}
+
+	for _, id := range log.ResponseIds() {
+		if err := s.checkLink("responses", id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func findOne[T any](s *MongoStore, collection string, filter bson.M) *T {
+	var item T
+	err := s.collection(collection).FindOne(context.Background(), filter).Decode(&item)
 
 	if err != nil {
 		return nil
 	}
 
-	return &req
+	return &item
 }
 
-func (s *MongoStore) GetAllRequests() []*api.Request {
-	var results []*api.Request
-	cursor, err := s.collection("requests").Find(context.Background(), bson.M{})
+func findAll[T any](s *MongoStore, collection string, includeDeleted bool) []*T {
+	var results []*T
+	filter := bson.M{}
+
+	if !includeDeleted {
+		filter = live(filter)
+	}
+
+	cursor, err := s.collection(collection).Find(context.Background(), filter)
 
 	if err != nil {
 		return results
@@ -103,138 +168,640 @@ func (s *MongoStore) GetAllRequests() []*api.Request {
 	return results
 }
 
-func (s *MongoStore) UpdateRequest(req *api.Request) bool {
-	res, _ := s.collection("requests").UpdateOne(context.Background(), bson.M{"id": req.Id}, bson.M{"$set": req})
+// update replaces the document holding item and bumps its version. A non-zero
+// item version must match the stored one.
+func (s *MongoStore) update(collection string, item Record) error {
+	item.SetDeletedAt(nil)
+	raw, err := bson.Marshal(item)
+
+	if err != nil {
+		return err
+	}
+
+	var fields bson.M
+
+	if err := bson.Unmarshal(raw, &fields); err != nil {
+		return err
+	}
+
+	delete(fields, "version")
 
-	if res.ModifiedCount > 0 {
-		s.logChangeToRedis("UPDATE", "request", req.Id)
+	filter := live(bson.M{"id": item.GetId()})
 
-		return true
+	if item.GetVersion() != 0 {
+		filter["version"] = item.GetVersion()
 	}
 
-	return false
+	var updated struct {
+		Version int64 `bson:"version"`
+	}
+
+	err = s.collection(collection).FindOneAndUpdate(
+		context.Background(),
+		filter,
+		bson.M{"$set": fields, "$inc": bson.M{"version": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		found, existsErr := s.exists(collection, item.GetId())
+
+		if existsErr != nil {
+			return existsErr
+		}
+
+		if found {
+			return ErrVersionConflict
+		}
+
+		return ErrNotFound
+	}
+
+	if err != nil {
+		return err
+	}
+
+	item.SetVersion(updated.Version)
+
+	return nil
 }
 
-func (s *MongoStore) DeleteRequest(id string) bool {
-	res, _ := s.collection("requests").DeleteOne(context.Background(), bson.M{"id": id})
+func (s *MongoStore) delete(collection, id string, deletedAt time.Time) error {
+	res, err := s.collection(collection).UpdateOne(
+		context.Background(),
+		live(bson.M{"id": id}),
+		bson.M{"$set": bson.M{"deletedat": deletedAt}},
+	)
 
-	if res.DeletedCount > 0 {
-		s.logChangeToRedis("DELETE", "request", id)
+	if err != nil {
+		return err
+	}
 
-		return true
+	if res.MatchedCount == 0 {
+		return ErrNotFound
 	}
 
-	return false
+	return nil
 }
 
-func (s *MongoStore) CreateResponse(resp *api.Response) {
-	resp.Id = uuid.New().String()
-	_, _ = s.collection("responses").InsertOne(context.Background(), resp)
-	s.logChangeToRedis("CREATE", "response", resp.Id)
+// deleteDependantDocuments enforces the delete policy for the live documents matched
+// by filter in collection: restrict refuses when any exist, cascade marks them
+// deleted as well and returns them as they were before.
+func deleteDependantDocuments[T any, PT interface {
+	*T
+	Record
+}](s *MongoStore, collection string, filter bson.M, deletedAt time.Time) ([]PT, error) {
+	if s.deletePolicy != enum.Cascade {
+		referenced, err := s.isReferenced(collection, filter)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if referenced {
+			return nil, ErrReferenced
+		}
+
+		return nil, nil
+	}
+
+	cursor, err := s.collection(collection).Find(context.Background(), live(filter))
+
+	if err != nil {
+		return nil, err
+	}
+
+	var dependants []PT
+
+	if err := cursor.All(context.Background(), &dependants); err != nil {
+		return nil, err
+	}
+
+	if len(dependants) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, 0, len(dependants))
+
+	for _, item := range dependants {
+		ids = append(ids, item.GetId())
+	}
+
+	_, err = s.collection(collection).UpdateMany(
+		context.Background(),
+		live(bson.M{"id": bson.M{"$in": ids}}),
+		bson.M{"$set": bson.M{"deletedat": deletedAt}},
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return dependants, nil
 }
 
-func (s *MongoStore) GetResponseByID(id string) *api.Response {
-	var resp api.Response
-	err := s.collection("responses").FindOne(context.Background(), bson.M{"id": id}).Decode(&resp)
+// restore clears the deleted mark of the document with id after check has
+// approved its links. Restoring a live document is a no-op.
+func restore[T any](s *MongoStore, collection, id string, check func(*T) error) error {
+	deleted := findOne[T](s, collection, bson.M{"id": id, "deletedat": bson.M{"$ne": nil}})
+
+	if deleted == nil {
+		found, err := s.exists(collection, id)
+
+		if err != nil {
+			return err
+		}
+
+		if found {
+			return nil
+		}
+
+		return ErrNotFound
+	}
+
+	if err := check(deleted); err != nil {
+		return err
+	}
+
+	_, err := s.collection(collection).UpdateOne(
+		context.Background(),
+		bson.M{"id": id},
+		bson.M{"$set": bson.M{"deletedat": nil}},
+	)
 
 	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *MongoStore) CreateRequest(req *api.Request) error {
+	if req.Id == "" {
+		req.Id = uuid.New().String()
+	}
+
+	req.Version = 1
+	req.DeletedAt = nil
+
+	if _, err := s.collection("requests").InsertOne(context.Background(), req); err != nil {
+		return mapDuplicateKeyError(err)
+	}
+
+	return nil
+}
+
+func (s *MongoStore) CreateRequests(reqs []*api.Request) error {
+	docs := make([]any, 0, len(reqs))
+
+	for _, req := range reqs {
+		if req.Id == "" {
+			req.Id = uuid.New().String()
+		}
+
+		req.Version = 1
+		req.DeletedAt = nil
+		docs = append(docs, req)
+	}
+
+	return s.insertMany("requests", docs)
+}
+
+// insertMany inserts docs in order in one round trip. The insert is not
+// atomic: the server stops at the first failing document, e.g. one with a
+// taken id, and keeps the documents before it, which is reported as a
+// *PartialError.
+func (s *MongoStore) insertMany(collection string, docs []any) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	_, err := s.collection(collection).InsertMany(context.Background(), docs)
+
+	if err == nil {
 		return nil
 	}
 
-	return &resp
+	var bulkErr mongo.BulkWriteException
+
+	if errors.As(err, &bulkErr) && len(bulkErr.WriteErrors) > 0 && bulkErr.WriteErrors[0].Index > 0 {
+		return &PartialError{Stored: bulkErr.WriteErrors[0].Index, Err: mapDuplicateKeyError(err)}
+	}
+
+	return mapDuplicateKeyError(err)
+}
+
+// mapDuplicateKeyError translates a violation of the unique id index into
+// ErrAlreadyExists and passes other errors through.
+func mapDuplicateKeyError(err error) error {
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrAlreadyExists
+	}
+
+	return err
+}
+
+func (s *MongoStore) GetRequestByID(id string) *api.Request {
+	return findOne[api.Request](s, "requests", live(bson.M{"id": id}))
 }
 
-func (s *MongoStore) GetAllResponses() []*api.Response {
-	var results []*api.Response
-	cursor, err := s.collection("responses").Find(context.Background(), bson.M{})
+func (s *MongoStore) GetAllRequests(includeDeleted bool) []*api.Request {
+	return findAll[api.Request](s, "requests", includeDeleted)
+}
+
+func (s *MongoStore) UpdateRequest(req *api.Request) error {
+	return s.update("requests", req)
+}
+
+func (s *MongoStore) DeleteRequest(id string) (Cascaded, error) {
+	found, err := s.exists("requests", id)
 
 	if err != nil {
-		return results
+		return Cascaded{}, err
 	}
 
-	defer cursor.Close(context.Background())
-	_ = cursor.All(context.Background(), &results)
+	if !found {
+		return Cascaded{}, ErrNotFound
+	}
 
-	return results
+	cursor, err := s.collection("responses").Find(context.Background(), live(bson.M{"query.id": id}))
+
+	if err != nil {
+		return Cascaded{}, err
+	}
+
+	var responses []*api.Response
+
+	if err := cursor.All(context.Background(), &responses); err != nil {
+		return Cascaded{}, err
+	}
+
+	responseIDs := []string{}
+
+	for _, resp := range responses {
+		responseIDs = append(responseIDs, resp.Id)
+	}
+
+	now := time.Now()
+	logFilter := bson.M{"$or": bson.A{
+		bson.M{"request.id": id},
+		bson.M{"targets.request.id": id},
+		bson.M{"response.id": bson.M{"$in": responseIDs}},
+		bson.M{"targets.response.id": bson.M{"$in": responseIDs}},
+	}}
+
+	logs, err := deleteDependantDocuments[logmodel.ConversionLog](s, "conversion_logs", logFilter, now)
+
+	if err != nil {
+		return Cascaded{}, err
+	}
+
+	cascadedResponses, err := deleteDependantDocuments[api.Response](s, "responses", bson.M{"query.id": id}, now)
+
+	if err != nil {
+		return Cascaded{}, err
+	}
+
+	if err := s.delete("requests", id, now); err != nil {
+		return Cascaded{}, err
+	}
+
+	return Cascaded{Responses: cascadedResponses, Logs: logs}, nil
+}
+
+func (s *MongoStore) RestoreRequest(id string) error {
+	return restore(s, "requests", id, func(*api.Request) error { return nil })
+}
+
+func (s *MongoStore) CreateResponse(resp *api.Response) error {
+	if err := s.checkResponseLinks(resp); err != nil {
+		return err
+	}
+
+	if resp.Id == "" {
+		resp.Id = uuid.New().String()
+	}
+
+	resp.Version = 1
+	resp.DeletedAt = nil
+
+	if _, err := s.collection("responses").InsertOne(context.Background(), resp); err != nil {
+		return mapDuplicateKeyError(err)
+	}
+
+	return nil
 }
 
-func (s *MongoStore) UpdateResponse(resp *api.Response) bool {
-	res, _ := s.collection("responses").UpdateOne(context.Background(), bson.M{"id": resp.Id}, bson.M{"$set": resp})
+func (s *MongoStore) CreateResponses(resps []*api.Response) error {
+	docs := make([]any, 0, len(resps))
+
+	for _, resp := range resps {
+		if err := s.checkResponseLinks(resp); err != nil {
+			return err
+		}
+	}
+
+	for _, resp := range resps {
+		if resp.Id == "" {
+			resp.Id = uuid.New().String()
+		}
+
+		resp.Version = 1
+		resp.DeletedAt = nil
+		docs = append(docs, resp)
+	}
+
+	return s.insertMany("responses", docs)
+}
+
+func (s *MongoStore) GetResponseByID(id string) *api.Response {
+	return findOne[api.Response](s, "responses", live(bson.M{"id": id}))
+}
+
+func (s *MongoStore) GetAllResponses(includeDeleted bool) []*api.Response {
+	return findAll[api.Response](s, "responses", includeDeleted)
+}
+
+func (s *MongoStore) UpdateResponse(resp *api.Response) error {
+	if err := s.checkResponseLinks(resp); err != nil {
+		return err
+	}
+
+	return s.update("responses", resp)
+}
+
+func (s *MongoStore) DeleteResponse(id string) (Cascaded, error) {
+	found, err := s.exists("responses", id)
+
+	if err != nil {
+		return Cascaded{}, err
+	}
+
+	if !found {
+		return Cascaded{}, ErrNotFound
+	}
+
+	now := time.Now()
 
-	if res.ModifiedCount > 0 {
-		s.logChangeToRedis("UPDATE", "response", resp.Id)
+	logFilter := bson.M{"$or": bson.A{bson.M{"response.id": id}, bson.M{"targets.response.id": id}}}
+	logs, err := deleteDependantDocuments[logmodel.ConversionLog](s, "conversion_logs", logFilter, now)
 
-		return true
+	if err != nil {
+		return Cascaded{}, err
+	}
+
+	if err := s.delete("responses", id, now); err != nil {
+		return Cascaded{}, err
 	}
 
-	return false
+	return Cascaded{Logs: logs}, nil
+}
+
+func (s *MongoStore) RestoreResponse(id string) error {
+	return restore(s, "responses", id, s.checkResponseLinks)
 }
 
-func (s *MongoStore) DeleteResponse(id string) bool {
-	res, _ := s.collection("responses").DeleteOne(context.Background(), bson.M{"id": id})
+func (s *MongoStore) CreateConversionLog(log *logmodel.ConversionLog) error {
+	if err := s.checkConversionLogLinks(log); err != nil {
+		return err
+	}
 
-	if res.DeletedCount > 0 {
-		s.logChangeToRedis("DELETE", "response", id)
+	if log.Id == "" {
+		log.Id = uuid.New().String()
+	}
 
-		return true
+	log.Version = 1
+	log.DeletedAt = nil
+
+	if _, err := s.collection("conversion_logs").InsertOne(context.Background(), log); err != nil {
+		return mapDuplicateKeyError(err)
 	}
 
-	return false
+	return nil
 }
 
-func (s *MongoStore) CreateConversionLog(log *logmodel.ConversionLog) {
-	log.Id = uuid.New().String()
-	_, _ = s.collection("conversion_logs").InsertOne(context.Background(), log)
-	s.logChangeToRedis("CREATE", "conversion_log", log.Id)
+func (s *MongoStore) CreateConversionLogs(logs []*logmodel.ConversionLog) error {
+	docs := make([]any, 0, len(logs))
+
+	for _, logItem := range logs {
+		if err := s.checkConversionLogLinks(logItem); err != nil {
+			return err
+		}
+	}
+
+	for _, logItem := range logs {
+		if logItem.Id == "" {
+			logItem.Id = uuid.New().String()
+		}
+
+		logItem.Version = 1
+		logItem.DeletedAt = nil
+		docs = append(docs, logItem)
+	}
+
+	return s.insertMany("conversion_logs", docs)
 }
 
 func (s *MongoStore) GetConversionLogByID(id string) *logmodel.ConversionLog {
-	var log logmodel.ConversionLog
-	err := s.collection("conversion_logs").FindOne(context.Background(), bson.M{"id": id}).Decode(&log)
+	return findOne[logmodel.ConversionLog](s, "conversion_logs", live(bson.M{"id": id}))
+}
+
+func (s *MongoStore) GetAllConversionLogs(includeDeleted bool) []*logmodel.ConversionLog {
+	return findAll[logmodel.ConversionLog](s, "conversion_logs", includeDeleted)
+}
+
+func (s *MongoStore) UpdateConversionLog(log *logmodel.ConversionLog) error {
+	if err := s.checkConversionLogLinks(log); err != nil {
+		return err
+	}
+
+	return s.update("conversion_logs", log)
+}
+
+func (s *MongoStore) DeleteConversionLog(id string) error {
+	return s.delete("conversion_logs", id, time.Now())
+}
+
+func (s *MongoStore) RestoreConversionLog(id string) error {
+	return restore(s, "conversion_logs", id, s.checkConversionLogLinks)
+}
+
+func (s *MongoStore) CreateQuote(q *quote.Quote) error {
+	if q.Id == "" {
+		q.Id = uuid.New().String()
+	}
+
+	q.UsedAt = nil
+
+	if _, err := s.collection("quotes").InsertOne(context.Background(), q); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *MongoStore) GetQuoteByID(id string) *quote.Quote {
+	return findOne[quote.Quote](s, "quotes", bson.M{"id": id})
+}
+
+func (s *MongoStore) UseQuote(id string, at time.Time) (*quote.Quote, error) {
+	filter := bson.M{"id": id, "usedat": nil, "expiresat": bson.M{"$gt": at}}
+	update := bson.M{"$set": bson.M{"usedat": at}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var q quote.Quote
+	err := s.collection("quotes").FindOneAndUpdate(context.Background(), filter, update, opts).Decode(&q)
+
+	if err == nil {
+		return &q, nil
+	}
+
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	existing := s.GetQuoteByID(id)
+
+	switch {
+	case existing == nil:
+		return nil, ErrNotFound
+	case existing.UsedAt != nil:
+		return nil, ErrQuoteUsed
+	default:
+		return nil, ErrQuoteExpired
+	}
+}
+
+// idempotencyDocId is the _id of the record for account and key, so that the
+// collection cannot hold two records for one key.
+func idempotencyDocId(account, key string) string {
+	return account + "\x00" + key
+}
+
+func (s *MongoStore) ReserveIdempotencyKey(rec *idempotency.Record) (*idempotency.Record, error) {
+	ctx := context.Background()
+	filter := bson.M{"_id": idempotencyDocId(rec.Account, rec.Key)}
+
+	expired := bson.M{"_id": filter["_id"], "expiresat": bson.M{"$lte": time.Now()}}
+
+	if _, err := s.collection("idempotency_keys").DeleteOne(ctx, expired); err != nil {
+		return nil, err
+	}
+
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before)
+
+	var existing idempotency.Record
+	err := s.collection("idempotency_keys").FindOneAndUpdate(ctx, filter, bson.M{"$setOnInsert": rec}, opts).Decode(&existing)
+
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
 
 	if err != nil {
-		return nil
+		return nil, err
 	}
 
-	return &log
+	return &existing, nil
 }
 
-func (s *MongoStore) GetAllConversionLogs() []*logmodel.ConversionLog {
-	var results []*logmodel.ConversionLog
-	cursor, err := s.collection("conversion_logs").Find(context.Background(), bson.M{})
+func (s *MongoStore) CompleteIdempotencyKey(rec *idempotency.Record) error {
+	update := bson.M{"$set": bson.M{"status": rec.Status, "headers": rec.Headers, "body": rec.Body}}
+	res, err := s.collection("idempotency_keys").UpdateOne(context.Background(), bson.M{"_id": idempotencyDocId(rec.Account, rec.Key)}, update)
 
 	if err != nil {
-		return results
+		return err
 	}
 
-	defer cursor.Close(context.Background())
-	_ = cursor.All(context.Background(), &results)
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
 
-	return results
+	return nil
+}
+
+func (s *MongoStore) ReleaseIdempotencyKey(account, key string) error {
+	_, err := s.collection("idempotency_keys").DeleteOne(context.Background(), bson.M{"_id": idempotencyDocId(account, key)})
+
+	return err
 }
 
-func (s *MongoStore) UpdateConversionLog(log *logmodel.ConversionLog) bool {
-	res, _ := s.collection("conversion_logs").UpdateOne(context.Background(), bson.M{"id": log.Id}, bson.M{"$set": log})
+// PurgeDeleted removes documents deleted before the cutoff. Documents still
+// linked from a kept document, deleted or not, are left for a later run.
+func (s *MongoStore) PurgeDeleted(before time.Time) (int, error) {
+	ctx := context.Background()
+	expired := bson.M{"deletedat": bson.M{"$lt": before}}
 
-	if res.ModifiedCount > 0 {
-		s.logChangeToRedis("UPDATE", "conversion_log", log.Id)
+	logsRes, err := s.collection("conversion_logs").DeleteMany(ctx, expired)
 
-		return true
+	if err != nil {
+		return 0, err
 	}
 
-	return false
-}
+	linkedResponses, err := s.collection("conversion_logs").Distinct(ctx, "response.id", bson.M{})
 
-func (s *MongoStore) DeleteConversionLog(id string) bool {
-	res, _ := s.collection("conversion_logs").DeleteOne(context.Background(), bson.M{"id": id})
+	if err != nil {
+		return 0, err
+	}
+
+	linkedTargetResponses, err := s.collection("conversion_logs").Distinct(ctx, "targets.response.id", bson.M{})
+
+	if err != nil {
+		return 0, err
+	}
+
+	responsesRes, err := s.collection("responses").DeleteMany(ctx, bson.M{
+		"deletedat": bson.M{"$lt": before},
+		"id":        bson.M{"$nin": append(append(bson.A{}, linkedResponses...), linkedTargetResponses...)},
+	})
+
+	if err != nil {
+		return 0, err
+	}
 
-	if res.DeletedCount > 0 {
-		s.logChangeToRedis("DELETE", "conversion_log", id)
+	linkedFromLogs, err := s.collection("conversion_logs").Distinct(ctx, "request.id", bson.M{})
 
-		return true
+	if err != nil {
+		return 0, err
 	}
 
-	return false
+	linkedFromTargets, err := s.collection("conversion_logs").Distinct(ctx, "targets.request.id", bson.M{})
+
+	if err != nil {
+		return 0, err
+	}
+
+	linkedFromResponses, err := s.collection("responses").Distinct(ctx, "query.id", bson.M{})
+
+	if err != nil {
+		return 0, err
+	}
+
+	linkedRequests := append(append(append(bson.A{}, linkedFromLogs...), linkedFromTargets...), linkedFromResponses...)
+
+	requestsRes, err := s.collection("requests").DeleteMany(ctx, bson.M{
+		"deletedat": bson.M{"$lt": before},
+		"id":        bson.M{"$nin": linkedRequests},
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	quotesRes, err := s.collection("quotes").DeleteMany(ctx, bson.M{"expiresat": bson.M{"$lt": before}})
+
+	if err != nil {
+		return 0, err
+	}
+
+	keysRes, err := s.collection("idempotency_keys").DeleteMany(ctx, bson.M{"expiresat": bson.M{"$lt": before}})
+
+	if err != nil {
+		return 0, err
+	}
+
+	purged := logsRes.DeletedCount + responsesRes.DeletedCount + requestsRes.DeletedCount + quotesRes.DeletedCount + keysRes.DeletedCount
+
+	return int(purged), nil
 }
 
 func (s *MongoStore) GetNewConversionRequests() []*api.Request        { return nil }
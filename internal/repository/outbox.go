@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/M2rk13/Otus-327619/internal/model/outbox"
+)
+
+// outboxCompactEvery is the number of acknowledgements after which the
+// outbox file is rewritten with the pending entries only.
+const outboxCompactEvery = 1000
+
+// Outbox journals the records accepted for storage until the backend
+// confirms them, so that they survive a restart while it is unreachable.
+type Outbox interface {
+	Append(entries ...*outbox.Entry) error
+	// Ack marks the entries of entity with the given ids as stored.
+	Ack(entity string, ids ...string) error
+	// Pending returns the entries not acknowledged yet, oldest first.
+	Pending() []*outbox.Entry
+}
+
+// FileOutbox keeps the outbox in a JSON lines file. Entries and
+// acknowledgements are only ever appended and synced to disk; the file is
+// compacted on open and every outboxCompactEvery acknowledgements.
+type FileOutbox struct {
+	item  *repositoryItem[*outbox.Entry]
+	acked int
+}
+
+func NewFileOutbox(filePath string) (*FileOutbox, error) {
+	item := &repositoryItem[*outbox.Entry]{filePath: filePath}
+
+	if err := setupPersistence(item); err != nil {
+		return nil, fmt.Errorf("failed to setup persistence for outbox: %v", err)
+	}
+
+	done := make(map[string]bool)
+
+	for _, entry := range item.data {
+		if entry.Acked {
+			done[outboxKey(entry.Entity, entry.Id)] = true
+		}
+	}
+
+	item.data = slices.DeleteFunc(item.data, func(entry *outbox.Entry) bool {
+		return entry.Acked || done[outboxKey(entry.Entity, entry.Id)]
+	})
+
+	if err := item.rewriteAllDataToFile(); err != nil {
+		return nil, fmt.Errorf("failed to compact outbox: %v", err)
+	}
+
+	return &FileOutbox{item: item}, nil
+}
+
+func (o *FileOutbox) Close() {
+	if o.item.file != nil {
+		_ = o.item.file.Close()
+		fmt.Println("Closed outbox persistence file.")
+	}
+}
+
+func (o *FileOutbox) Append(entries ...*outbox.Entry) error {
+	o.item.mu.Lock()
+	defer o.item.mu.Unlock()
+
+	o.item.data = append(o.item.data, entries...)
+
+	for _, entry := range entries {
+		o.item.appendToFile(entry)
+	}
+
+	return o.sync()
+}
+
+func (o *FileOutbox) Ack(entity string, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	o.item.mu.Lock()
+	defer o.item.mu.Unlock()
+
+	o.item.data = slices.DeleteFunc(o.item.data, func(entry *outbox.Entry) bool {
+		return entry.Entity == entity && slices.Contains(ids, entry.Id)
+	})
+
+	o.acked += len(ids)
+
+	if o.acked >= outboxCompactEvery {
+		o.acked = 0
+
+		return o.item.rewriteAllDataToFile()
+	}
+
+	for _, id := range ids {
+		o.item.appendToFile(&outbox.Entry{Entity: entity, Id: id, Acked: true})
+	}
+
+	return o.sync()
+}
+
+func (o *FileOutbox) Pending() []*outbox.Entry {
+	o.item.mu.Lock()
+	defer o.item.mu.Unlock()
+
+	return slices.Clone(o.item.data)
+}
+
+// sync flushes the appended lines to disk. The caller holds mu.
+func (o *FileOutbox) sync() error {
+	if o.item.file == nil {
+		return fmt.Errorf("outbox file %s is not open", o.item.filePath)
+	}
+
+	return o.item.file.Sync()
+}
+
+func outboxKey(entity, id string) string {
+	return entity + "/" + id
+}
@@ -4,21 +4,33 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/M2rk13/Otus-327619/internal/config"
+	"github.com/M2rk13/Otus-327619/internal/enum"
 	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/idempotency"
 	logmodel "github.com/M2rk13/Otus-327619/internal/model/log"
+	"github.com/M2rk13/Otus-327619/internal/model/quote"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+)
+
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
 )
 
 type PostgresStore struct {
-	db *sql.DB
+	db           *sql.DB
+	deletePolicy string
 }
 
-func NewPostgresStore(ctx context.Context, cfg config.PostgresConfig) (*PostgresStore, error) {
+func NewPostgresStore(ctx context.Context, cfg config.PostgresConfig, deletePolicy string) (*PostgresStore, error) {
 	db, err := sql.Open("pgx", cfg.PostgresUri)
 
 	if err != nil {
@@ -31,7 +43,13 @@ func NewPostgresStore(ctx context.Context, cfg config.PostgresConfig) (*Postgres
 		return nil, fmt.Errorf("failed to ping postgres: %w", err)
 	}
 
-	store := &PostgresStore{db: db}
+	if err := migrate(ctx, db); err != nil {
+		db.Close()
+
+		return nil, fmt.Errorf("failed to migrate postgres schema: %w", err)
+	}
+
+	store := &PostgresStore{db: db, deletePolicy: deletePolicy}
 
 	log.Println("PostgreSQL repository initialized successfully.")
 
@@ -62,25 +80,257 @@ func (s *PostgresStore) executeInTransaction(ctx context.Context, fn func(tx *sq
 	return tx.Commit()
 }
 
-func (s *PostgresStore) CreateRequest(req *api.Request) {
-	req.Id = uuid.New().String()
-	query := `INSERT INTO requests (id, "from", "to", amount) VALUES ($1, $2, $3, $4)`
+// mapConstraintError translates a violation of a primary key into
+// ErrAlreadyExists and one of a foreign key into ErrMissingReference, and
+// leaves any other error untouched.
+func mapConstraintError(err error) error {
+	var pgErr *pgconn.PgError
 
-	err := s.executeInTransaction(context.Background(), func(tx *sql.Tx) error {
-		_, err := tx.Exec(query, req.Id, req.From, req.To, req.Amount)
+	if !errors.As(err, &pgErr) {
+		return err
+	}
 
+	switch pgErr.Code {
+	case uniqueViolation:
+		return ErrAlreadyExists
+	case foreignKeyViolation:
+		return ErrMissingReference
+	default:
 		return err
+	}
+}
+
+func liveExists(tx *sql.Tx, table, id string) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM ` + table + ` WHERE id = $1 AND deleted_at IS NULL)`
+
+	if err := tx.QueryRow(query, id).Scan(&exists); err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// lockLive locks the live row for the rest of the transaction, or fails with
+// ErrNotFound. A delete takes the lock before it looks at dependants, so a
+// concurrent create linking to the row waits in checkLink and then sees it
+// deleted instead of slipping in behind the cascade.
+func lockLive(tx *sql.Tx, table, id string) error {
+	var found int
+	err := tx.QueryRow(`SELECT 1 FROM `+table+` WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, id).Scan(&found)
+
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+
+	return err
+}
+
+// checkLink makes sure the linked row is live and keeps it that way until the
+// transaction ends. Foreign keys alone do not see soft deletes.
+func checkLink(tx *sql.Tx, table, id string) error {
+	if id == "" {
+		return nil
+	}
+
+	var found int
+	err := tx.QueryRow(`SELECT 1 FROM `+table+` WHERE id = $1 AND deleted_at IS NULL FOR SHARE`, id).Scan(&found)
+
+	if err == sql.ErrNoRows {
+		return ErrMissingReference
+	}
+
+	return err
+}
+
+func checkResponseLinks(tx *sql.Tx, resp *api.Response) error {
+	return checkLink(tx, "requests", resp.Query.Id)
+}
+
+// checkConversionLogLinks checks the links of the log and of its targets. The
+// targets live in a JSONB column, so unlike request_id and response_id they
+// are not backed by foreign keys.
+func checkConversionLogLinks(tx *sql.Tx, logItem *logmodel.ConversionLog) error {
+	for _, id := range logItem.RequestIds() {
+		if err := checkLink(tx, "requests", id); err != nil {
+			return err
+		}
+	}
+
+	for _, id := range logItem.ResponseIds() {
+		if err := checkLink(tx, "responses", id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// targetLinked builds a condition matching conversion logs with a target that
+// satisfies cond, in which t is the target document.
+func targetLinked(alias, cond string) string {
+	return `EXISTS (SELECT 1 FROM jsonb_array_elements(COALESCE(` + alias + `targets, '[]'::jsonb)) t WHERE ` + cond + `)`
+}
+
+// resolveUpdateMiss tells apart the two reasons a versioned update can match no
+// rows: the item is gone or its version has moved on.
+func resolveUpdateMiss(tx *sql.Tx, table, id string) error {
+	exists, err := liveExists(tx, table, id)
+
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		return ErrVersionConflict
+	}
+
+	return ErrNotFound
+}
+
+func expectAffected(res sql.Result) error {
+	rowsAffected, _ := res.RowsAffected()
+
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// deleteDependantRows applies the delete policy to the live rows of table matched
+// by the where clause on $1: restrict refuses when any exist, cascade marks
+// them deleted as well and returns them, read with columns and scan, as they
+// were before.
+func deleteDependantRows[T Record](
+	s *PostgresStore,
+	tx *sql.Tx,
+	table, columns, where, id string,
+	deletedAt time.Time,
+	scan func(rowScanner) (T, error),
+) ([]T, error) {
+	if s.deletePolicy != enum.Cascade {
+		var referenced bool
+		query := `SELECT EXISTS (SELECT 1 FROM ` + table + ` WHERE deleted_at IS NULL AND (` + where + `))`
+
+		if err := tx.QueryRow(query, id).Scan(&referenced); err != nil {
+			return nil, err
+		}
+
+		if referenced {
+			return nil, ErrReferenced
+		}
+
+		return nil, nil
+	}
+
+	query := `UPDATE ` + table + ` SET deleted_at = $2 WHERE deleted_at IS NULL AND (` + where + `) RETURNING ` + columns
+	rows, err := tx.Query(query, id, deletedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var deleted []T
+
+	for rows.Next() {
+		item, err := scan(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		item.SetDeletedAt(nil)
+		deleted = append(deleted, item)
+	}
+
+	return deleted, rows.Err()
+}
+
+func softDelete(tx *sql.Tx, table, id string, deletedAt time.Time) error {
+	res, err := tx.Exec(`UPDATE `+table+` SET deleted_at = $2 WHERE id = $1 AND deleted_at IS NULL`, id, deletedAt)
+
+	if err != nil {
+		return err
+	}
+
+	return expectAffected(res)
+}
+
+// restore clears the deleted mark of id after check has approved the links of
+// the deleted row. Restoring a live row is a no-op.
+func (s *PostgresStore) restore(table, id string, check func(tx *sql.Tx) error) error {
+	return s.executeInTransaction(context.Background(), func(tx *sql.Tx) error {
+		var deletedAt *time.Time
+		err := tx.QueryRow(`SELECT deleted_at FROM `+table+` WHERE id = $1 FOR UPDATE`, id).Scan(&deletedAt)
+
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+
+		if err != nil || deletedAt == nil {
+			return err
+		}
+
+		if err := check(tx); err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`UPDATE `+table+` SET deleted_at = NULL WHERE id = $1`, id)
+
+		return err
+	})
+}
+
+func (s *PostgresStore) CreateRequest(req *api.Request) error {
+	err := s.executeInTransaction(context.Background(), func(tx *sql.Tx) error {
+		return insertRequest(tx, req)
 	})
 
 	if err != nil {
 		log.Printf("ERROR: failed to create request: %v", err)
 	}
+
+	return mapConstraintError(err)
+}
+
+func (s *PostgresStore) CreateRequests(reqs []*api.Request) error {
+	err := s.executeInTransaction(context.Background(), func(tx *sql.Tx) error {
+		for _, req := range reqs {
+			if err := insertRequest(tx, req); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		log.Printf("ERROR: failed to create requests: %v", err)
+	}
+
+	return mapConstraintError(err)
+}
+
+func insertRequest(tx *sql.Tx, req *api.Request) error {
+	if req.Id == "" {
+		req.Id = uuid.New().String()
+	}
+
+	req.Version = 1
+	req.DeletedAt = nil
+	query := `INSERT INTO requests (id, "from", "to", amount, version) VALUES ($1, $2, $3, $4, $5)`
+	_, err := tx.Exec(query, req.Id, req.From, req.To, req.Amount, req.Version)
+
+	return err
 }
 
 func (s *PostgresStore) GetRequestByID(id string) *api.Request {
-	query := `SELECT id, "from", "to", amount FROM requests WHERE id = $1`
+	query := `SELECT id, "from", "to", amount, version, deleted_at FROM requests WHERE id = $1 AND deleted_at IS NULL`
 	var req api.Request
-	err := s.db.QueryRow(query, id).Scan(&req.Id, &req.From, &req.To, &req.Amount)
+	err := s.db.QueryRow(query, id).Scan(&req.Id, &req.From, &req.To, &req.Amount, &req.Version, &req.DeletedAt)
 
 	if err != nil {
 		if err != sql.ErrNoRows {
@@ -93,8 +343,13 @@ func (s *PostgresStore) GetRequestByID(id string) *api.Request {
 	return &req
 }
 
-func (s *PostgresStore) GetAllRequests() []*api.Request {
-	query := `SELECT id, "from", "to", amount FROM requests`
+func (s *PostgresStore) GetAllRequests(includeDeleted bool) []*api.Request {
+	query := `SELECT id, "from", "to", amount, version, deleted_at FROM requests`
+
+	if !includeDeleted {
+		query += ` WHERE deleted_at IS NULL`
+	}
+
 	rows, err := s.db.Query(query)
 
 	if err != nil {
@@ -110,7 +365,7 @@ func (s *PostgresStore) GetAllRequests() []*api.Request {
 	for rows.Next() {
 		var req api.Request
 
-		if err := rows.Scan(&req.Id, &req.From, &req.To, &req.Amount); err != nil {
+		if err := rows.Scan(&req.Id, &req.From, &req.To, &req.Amount, &req.Version, &req.DeletedAt); err != nil {
 			log.Printf("ERROR: failed to scan request: %v", err)
 
 			continue
@@ -122,97 +377,231 @@ func (s *PostgresStore) GetAllRequests() []*api.Request {
 	return requests
 }
 
-func (s *PostgresStore) UpdateRequest(req *api.Request) bool {
-	query := `UPDATE requests SET "from" = $2, "to" = $3, amount = $4 WHERE id = $1`
+func (s *PostgresStore) UpdateRequest(req *api.Request) error {
+	query := `
+		UPDATE requests
+		SET "from" = $2, "to" = $3, amount = $4, version = version + 1
+		WHERE id = $1 AND deleted_at IS NULL AND ($5::bigint = 0 OR version = $5)
+		RETURNING version`
+
+	req.DeletedAt = nil
+
+	return s.executeInTransaction(context.Background(), func(tx *sql.Tx) error {
+		err := tx.QueryRow(query, req.Id, req.From, req.To, req.Amount, req.Version).Scan(&req.Version)
+
+		if err == sql.ErrNoRows {
+			return resolveUpdateMiss(tx, "requests", req.Id)
+		}
+
+		return err
+	})
+}
+
+func (s *PostgresStore) DeleteRequest(id string) (Cascaded, error) {
+	var cascaded Cascaded
 
 	err := s.executeInTransaction(context.Background(), func(tx *sql.Tx) error {
-		res, err := tx.Exec(query, req.Id, req.From, req.To, req.Amount)
+		now := time.Now()
+
+		if err := lockLive(tx, "requests", id); err != nil {
+			return err
+		}
+
+		requestResponses := `(SELECT id FROM responses WHERE query_id = $1 AND deleted_at IS NULL)`
+		logsWhere := `request_id = $1 OR response_id IN ` + requestResponses + `
+			OR ` + targetLinked("", `t->'request'->>'id' = $1 OR t->'response'->>'id' IN `+requestResponses)
+
+		logs, err := deleteDependantRows(s, tx, "conversion_logs", conversionLogColumns, logsWhere, id, now, scanConversionLog)
 
 		if err != nil {
 			return err
 		}
 
-		rowsAffected, _ := res.RowsAffected()
+		responses, err := deleteDependantRows(s, tx, "responses", responseColumns, `query_id = $1`, id, now, scanResponse)
 
-		if rowsAffected == 0 {
-			return sql.ErrNoRows
+		if err != nil {
+			return err
 		}
 
-		return nil
+		cascaded = Cascaded{Responses: responses, Logs: logs}
+
+		return softDelete(tx, "requests", id, now)
 	})
 
-	return err == nil
+	if err != nil {
+		return Cascaded{}, err
+	}
+
+	return cascaded, nil
+}
+
+func (s *PostgresStore) RestoreRequest(id string) error {
+	return s.restore("requests", id, func(*sql.Tx) error { return nil })
+}
+
+// marshalLegs encodes the legs of a derived quote for the info_legs column,
+// a direct quote has none and is stored as NULL.
+func marshalLegs(legs []api.Leg) ([]byte, error) {
+	if len(legs) == 0 {
+		return nil, nil
+	}
+
+	return json.Marshal(legs)
+}
+
+func unmarshalLegs(data []byte, legs *[]api.Leg) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(data, legs)
 }
 
-func (s *PostgresStore) DeleteRequest(id string) bool {
-	query := `DELETE FROM requests WHERE id = $1`
+// marshalTargets encodes the targets of a multi-target log for the targets
+// column, other logs have none and store NULL.
+func marshalTargets(targets []logmodel.Target) ([]byte, error) {
+	if len(targets) == 0 {
+		return nil, nil
+	}
+
+	return json.Marshal(targets)
+}
+
+func unmarshalTargets(data []byte, targets *[]logmodel.Target) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(data, targets)
+}
+
+// marshalNullable encodes an optional value for a JSONB column, nil is
+// stored as NULL.
+func marshalNullable[T any](value *T) ([]byte, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(value)
+}
+
+func unmarshalNullable[T any](data []byte, value **T) error {
+	if len(data) == 0 {
+		*value = nil
+
+		return nil
+	}
 
+	*value = new(T)
+
+	return json.Unmarshal(data, *value)
+}
+
+func (s *PostgresStore) CreateResponse(resp *api.Response) error {
 	err := s.executeInTransaction(context.Background(), func(tx *sql.Tx) error {
-		res, err := tx.Exec(query, id)
+		return insertResponse(tx, resp)
+	})
 
-		if err != nil {
-			return err
-		}
+	if err != nil {
+		log.Printf("ERROR: failed to create response: %v", err)
+	}
 
-		rowsAffected, _ := res.RowsAffected()
+	return mapConstraintError(err)
+}
 
-		if rowsAffected == 0 {
-			return sql.ErrNoRows
+func (s *PostgresStore) CreateResponses(resps []*api.Response) error {
+	err := s.executeInTransaction(context.Background(), func(tx *sql.Tx) error {
+		for _, resp := range resps {
+			if err := insertResponse(tx, resp); err != nil {
+				return err
+			}
 		}
 
 		return nil
 	})
 
-	return err == nil
+	if err != nil {
+		log.Printf("ERROR: failed to create responses: %v", err)
+	}
+
+	return mapConstraintError(err)
 }
 
-func (s *PostgresStore) CreateResponse(resp *api.Response) {
-	resp.Id = uuid.New().String()
+func insertResponse(tx *sql.Tx, resp *api.Response) error {
+	if resp.Id == "" {
+		resp.Id = uuid.New().String()
+	}
+
+	resp.Version = 1
+	resp.DeletedAt = nil
 	query := `INSERT INTO responses
-    	(id, success, terms, privacy, query_id, query_from, query_to, query_amount, info_timestamp, info_quote, result)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+    	(id, success, terms, privacy, query_id, query_from, query_to, query_amount, info_timestamp, info_quote, info_provider, info_legs, result, fees, version)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`
 
-	err := s.executeInTransaction(context.Background(), func(tx *sql.Tx) error {
-		_, err := tx.Exec(query,
-			resp.Id,
-			resp.Success,
-			resp.Terms,
-			resp.Privacy,
-			resp.Query.Id,
-			resp.Query.From,
-			resp.Query.To,
-			resp.Query.Amount,
-			resp.Info.Timestamp,
-			resp.Info.Quote,
-			resp.Result)
+	legsJSON, err := marshalLegs(resp.Info.Legs)
 
+	if err != nil {
 		return err
-	})
+	}
+
+	feesJSON, err := marshalNullable(resp.Fees)
 
 	if err != nil {
-		log.Printf("ERROR: failed to create response: %v", err)
+		return err
+	}
+
+	if err := checkResponseLinks(tx, resp); err != nil {
+		return err
 	}
+
+	_, err = tx.Exec(query,
+		resp.Id,
+		resp.Success,
+		resp.Terms,
+		resp.Privacy,
+		resp.Query.Id,
+		resp.Query.From,
+		resp.Query.To,
+		resp.Query.Amount,
+		resp.Info.Timestamp,
+		resp.Info.Quote,
+		resp.Info.Provider,
+		legsJSON,
+		resp.Result,
+		feesJSON,
+		resp.Version)
+
+	return err
 }
 
-func (s *PostgresStore) GetResponseByID(id string) *api.Response {
-	query := `
-		SELECT
-		    id,
-		    success,
-		    terms,
-		    privacy,
-		    query_id,
-		    query_from,
-		    query_to,
-		    query_amount,
-		    info_timestamp,
-		    info_quote,
-		    result
-		FROM responses
-		WHERE id = $1`
+const responseColumns = `
+	id,
+	success,
+	terms,
+	privacy,
+	COALESCE(query_id, ''),
+	query_from,
+	query_to,
+	query_amount,
+	info_timestamp,
+	info_quote,
+	COALESCE(info_provider, ''),
+	info_legs,
+	result,
+	fees,
+	version,
+	deleted_at`
+
+// rowScanner is the Scan of *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
 
+// scanResponse reads a row selected with responseColumns.
+func scanResponse(row rowScanner) (*api.Response, error) {
 	var resp api.Response
-	err := s.db.QueryRow(query, id).Scan(&resp.Id,
+	var legsJSON, feesJSON []byte
+	err := row.Scan(&resp.Id,
 		&resp.Success,
 		&resp.Terms,
 		&resp.Privacy,
@@ -222,7 +611,31 @@ func (s *PostgresStore) GetResponseByID(id string) *api.Response {
 		&resp.Query.Amount,
 		&resp.Info.Timestamp,
 		&resp.Info.Quote,
-		&resp.Result)
+		&resp.Info.Provider,
+		&legsJSON,
+		&resp.Result,
+		&feesJSON,
+		&resp.Version,
+		&resp.DeletedAt)
+
+	if err == nil {
+		err = unmarshalLegs(legsJSON, &resp.Info.Legs)
+	}
+
+	if err == nil {
+		err = unmarshalNullable(feesJSON, &resp.Fees)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+func (s *PostgresStore) GetResponseByID(id string) *api.Response {
+	query := `SELECT ` + responseColumns + ` FROM responses WHERE id = $1 AND deleted_at IS NULL`
+	resp, err := scanResponse(s.db.QueryRow(query, id))
 
 	if err != nil {
 		if err != sql.ErrNoRows {
@@ -232,24 +645,15 @@ func (s *PostgresStore) GetResponseByID(id string) *api.Response {
 		return nil
 	}
 
-	return &resp
+	return resp
 }
 
-func (s *PostgresStore) GetAllResponses() []*api.Response {
-	query := `
-		SELECT
-			id,
-			success,
-			terms,
-			privacy,
-			query_id,
-			query_from,
-			query_to,
-			query_amount,
-			info_timestamp,
-			info_quote,
-			result
-		FROM responses`
+func (s *PostgresStore) GetAllResponses(includeDeleted bool) []*api.Response {
+	query := `SELECT ` + responseColumns + ` FROM responses`
+
+	if !includeDeleted {
+		query += ` WHERE deleted_at IS NULL`
+	}
 
 	rows, err := s.db.Query(query)
 
@@ -264,19 +668,7 @@ func (s *PostgresStore) GetAllResponses() []*api.Response {
 	var responses []*api.Response
 
 	for rows.Next() {
-		var resp api.Response
-
-		err := rows.Scan(&resp.Id,
-			&resp.Success,
-			&resp.Terms,
-			&resp.Privacy,
-			&resp.Query.Id,
-			&resp.Query.From,
-			&resp.Query.To,
-			&resp.Query.Amount,
-			&resp.Info.Timestamp,
-			&resp.Info.Quote,
-			&resp.Result)
+		resp, err := scanResponse(rows)
 
 		if err != nil {
 			log.Printf("ERROR: failed to scan response: %v", err)
@@ -284,29 +676,51 @@ func (s *PostgresStore) GetAllResponses() []*api.Response {
 			continue
 		}
 
-		responses = append(responses, &resp)
+		responses = append(responses, resp)
 	}
 
 	return responses
 }
 
-func (s *PostgresStore) UpdateResponse(resp *api.Response) bool {
+func (s *PostgresStore) UpdateResponse(resp *api.Response) error {
 	query := `
 		UPDATE responses
 		SET success = $2,
 		    terms = $3,
 		    privacy = $4,
-		    query_id = $5,
+		    query_id = NULLIF($5, ''),
 		    query_from = $6,
 		    query_to = $7,
 		    query_amount = $8,
 		    info_timestamp = $9,
 		    info_quote = $10,
-		    result = $11
-		WHERE id = $1`
+		    info_provider = $11,
+		    info_legs = $12,
+		    result = $13,
+		    fees = $14,
+		    version = version + 1
+		WHERE id = $1 AND deleted_at IS NULL AND ($15::bigint = 0 OR version = $15)
+		RETURNING version`
 
-	err := s.executeInTransaction(context.Background(), func(tx *sql.Tx) error {
-		res, err := tx.Exec(query,
+	resp.DeletedAt = nil
+	legsJSON, err := marshalLegs(resp.Info.Legs)
+
+	if err != nil {
+		return err
+	}
+
+	feesJSON, err := marshalNullable(resp.Fees)
+
+	if err != nil {
+		return err
+	}
+
+	err = s.executeInTransaction(context.Background(), func(tx *sql.Tx) error {
+		if err := checkResponseLinks(tx, resp); err != nil {
+			return err
+		}
+
+		err := tx.QueryRow(query,
 			resp.Id,
 			resp.Success,
 			resp.Terms,
@@ -317,54 +731,104 @@ func (s *PostgresStore) UpdateResponse(resp *api.Response) bool {
 			resp.Query.Amount,
 			resp.Info.Timestamp,
 			resp.Info.Quote,
-			resp.Result)
+			resp.Info.Provider,
+			legsJSON,
+			resp.Result,
+			feesJSON,
+			resp.Version).Scan(&resp.Version)
+
+		if err == sql.ErrNoRows {
+			return resolveUpdateMiss(tx, "responses", resp.Id)
+		}
 
-		if err != nil {
+		return err
+	})
+
+	return mapConstraintError(err)
+}
+
+func (s *PostgresStore) DeleteResponse(id string) (Cascaded, error) {
+	var cascaded Cascaded
+
+	err := s.executeInTransaction(context.Background(), func(tx *sql.Tx) error {
+		now := time.Now()
+
+		if err := lockLive(tx, "responses", id); err != nil {
 			return err
 		}
 
-		rowsAffected, _ := res.RowsAffected()
+		logsWhere := `response_id = $1 OR ` + targetLinked("", `t->'response'->>'id' = $1`)
+		logs, err := deleteDependantRows(s, tx, "conversion_logs", conversionLogColumns, logsWhere, id, now, scanConversionLog)
 
-		if rowsAffected == 0 {
-			return sql.ErrNoRows
+		if err != nil {
+			return err
 		}
 
-		return nil
+		cascaded = Cascaded{Logs: logs}
+
+		return softDelete(tx, "responses", id, now)
 	})
 
-	return err == nil
+	if err != nil {
+		return Cascaded{}, err
+	}
+
+	return cascaded, nil
 }
 
-func (s *PostgresStore) DeleteResponse(id string) bool {
-	query := `DELETE FROM responses WHERE id = $1`
+func (s *PostgresStore) RestoreResponse(id string) error {
+	return s.restore("responses", id, func(tx *sql.Tx) error {
+		var queryID string
 
-	err := s.executeInTransaction(context.Background(), func(tx *sql.Tx) error {
-		res, err := tx.Exec(query, id)
-
-		if err != nil {
+		if err := tx.QueryRow(`SELECT COALESCE(query_id, '') FROM responses WHERE id = $1`, id).Scan(&queryID); err != nil {
 			return err
 		}
 
-		rowsAffected, _ := res.RowsAffected()
+		return checkLink(tx, "requests", queryID)
+	})
+}
+
+func (s *PostgresStore) CreateConversionLog(logItem *logmodel.ConversionLog) error {
+	err := s.executeInTransaction(context.Background(), func(tx *sql.Tx) error {
+		return insertConversionLog(tx, logItem)
+	})
+
+	if err != nil {
+		log.Printf("ERROR: failed to create conversion log: %v", err)
+	}
+
+	return mapConstraintError(err)
+}
 
-		if rowsAffected == 0 {
-			return sql.ErrNoRows
+func (s *PostgresStore) CreateConversionLogs(logs []*logmodel.ConversionLog) error {
+	err := s.executeInTransaction(context.Background(), func(tx *sql.Tx) error {
+		for _, logItem := range logs {
+			if err := insertConversionLog(tx, logItem); err != nil {
+				return err
+			}
 		}
 
 		return nil
 	})
 
-	return err == nil
+	if err != nil {
+		log.Printf("ERROR: failed to create conversion logs: %v", err)
+	}
+
+	return mapConstraintError(err)
 }
 
-func (s *PostgresStore) CreateConversionLog(logItem *logmodel.ConversionLog) {
-	logItem.Id = uuid.New().String()
+func insertConversionLog(tx *sql.Tx, logItem *logmodel.ConversionLog) error {
+	if logItem.Id == "" {
+		logItem.Id = uuid.New().String()
+	}
+
 	requestJSON, err := json.Marshal(logItem.Request)
 
 	if err != nil {
 		log.Printf("ERROR: failed to marshal request for log: %v", err)
 
-		return
+		return err
 	}
 
 	responseJSON, err := json.Marshal(logItem.Response)
@@ -372,55 +836,107 @@ func (s *PostgresStore) CreateConversionLog(logItem *logmodel.ConversionLog) {
 	if err != nil {
 		log.Printf("ERROR: failed to marshal response for log: %v", err)
 
-		return
+		return err
 	}
 
-	query := `INSERT INTO conversion_logs (id, timestamp, request, response) VALUES ($1, $2, $3, $4)`
-
-	err = s.executeInTransaction(context.Background(), func(tx *sql.Tx) error {
-		_, err := tx.Exec(query, logItem.Id, logItem.Timestamp, requestJSON, responseJSON)
+	roundingJSON, err := marshalNullable(logItem.Rounding)
 
+	if err != nil {
 		return err
-	})
+	}
+
+	targetsJSON, err := marshalTargets(logItem.Targets)
 
 	if err != nil {
-		log.Printf("ERROR: failed to create conversion log: %v", err)
+		return err
+	}
+
+	logItem.Version = 1
+	logItem.DeletedAt = nil
+	query := `INSERT INTO conversion_logs (id, timestamp, request_id, response_id, request, response, rounding, batch_id, targets, version)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5, $6, $7, NULLIF($8, ''), $9, $10)`
+
+	if err := checkConversionLogLinks(tx, logItem); err != nil {
+		return err
 	}
+
+	_, err = tx.Exec(query,
+		logItem.Id,
+		logItem.Timestamp,
+		logItem.Request.Id,
+		logItem.Response.Id,
+		requestJSON,
+		responseJSON,
+		roundingJSON,
+		logItem.BatchId,
+		targetsJSON,
+		logItem.Version)
+
+	return err
 }
 
-func (s *PostgresStore) GetConversionLogByID(id string) *logmodel.ConversionLog {
-	query := `SELECT id, timestamp, request, response FROM conversion_logs WHERE id = $1`
+const conversionLogColumns = `id, timestamp, request, response, rounding, COALESCE(batch_id, ''), targets, version, deleted_at`
 
+// scanConversionLog reads a row selected with conversionLogColumns.
+func scanConversionLog(row rowScanner) (*logmodel.ConversionLog, error) {
 	var logItem logmodel.ConversionLog
-	var requestJSON, responseJSON []byte
-
-	err := s.db.QueryRow(query, id).Scan(&logItem.Id, &logItem.Timestamp, &requestJSON, &responseJSON)
+	var requestJSON, responseJSON, roundingJSON, targetsJSON []byte
+
+	err := row.Scan(&logItem.Id,
+		&logItem.Timestamp,
+		&requestJSON,
+		&responseJSON,
+		&roundingJSON,
+		&logItem.BatchId,
+		&targetsJSON,
+		&logItem.Version,
+		&logItem.DeletedAt)
 
 	if err != nil {
-		if err != sql.ErrNoRows {
-			log.Printf("ERROR: failed to get conversion log by id: %v", err)
-		}
-
-		return nil
+		return nil, err
 	}
 
 	if err := json.Unmarshal(requestJSON, &logItem.Request); err != nil {
-		log.Printf("ERROR: failed to unmarshal request from log: %v", err)
-
-		return nil
+		return nil, fmt.Errorf("failed to unmarshal request from log: %w", err)
 	}
 
 	if err := json.Unmarshal(responseJSON, &logItem.Response); err != nil {
-		log.Printf("ERROR: failed to unmarshal response from log: %v", err)
+		return nil, fmt.Errorf("failed to unmarshal response from log: %w", err)
+	}
+
+	if err := unmarshalNullable(roundingJSON, &logItem.Rounding); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal rounding from log: %w", err)
+	}
+
+	if err := unmarshalTargets(targetsJSON, &logItem.Targets); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal targets from log: %w", err)
+	}
+
+	return &logItem, nil
+}
+
+func (s *PostgresStore) GetConversionLogByID(id string) *logmodel.ConversionLog {
+	query := `SELECT ` + conversionLogColumns + ` FROM conversion_logs WHERE id = $1 AND deleted_at IS NULL`
+	logItem, err := scanConversionLog(s.db.QueryRow(query, id))
+
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("ERROR: failed to get conversion log by id: %v", err)
+		}
 
 		return nil
 	}
 
-	return &logItem
+	return logItem
 }
 
-func (s *PostgresStore) GetAllConversionLogs() []*logmodel.ConversionLog {
-	query := `SELECT id, timestamp, request, response FROM conversion_logs`
+func (s *PostgresStore) GetAllConversionLogs(includeDeleted bool) []*logmodel.ConversionLog {
+	query := `SELECT ` + conversionLogColumns + ` FROM conversion_logs`
+
+	if !includeDeleted {
+		query += ` WHERE deleted_at IS NULL`
+	}
+
 	rows, err := s.db.Query(query)
 
 	if err != nil {
@@ -434,40 +950,27 @@ func (s *PostgresStore) GetAllConversionLogs() []*logmodel.ConversionLog {
 	var logs []*logmodel.ConversionLog
 
 	for rows.Next() {
-		var logItem logmodel.ConversionLog
-		var requestJSON, responseJSON []byte
+		logItem, err := scanConversionLog(rows)
 
-		if err := rows.Scan(&logItem.Id, &logItem.Timestamp, &requestJSON, &responseJSON); err != nil {
+		if err != nil {
 			log.Printf("ERROR: failed to scan conversion log: %v", err)
 
 			continue
 		}
 
-		if err := json.Unmarshal(requestJSON, &logItem.Request); err != nil {
-			log.Printf("ERROR: failed to unmarshal request from log: %v", err)
-
-			continue
-		}
-
-		if err := json.Unmarshal(responseJSON, &logItem.Response); err != nil {
-			log.Printf("ERROR: failed to unmarshal response from log: %v", err)
-
-			continue
-		}
-
-		logs = append(logs, &logItem)
+		logs = append(logs, logItem)
 	}
 
 	return logs
 }
 
-func (s *PostgresStore) UpdateConversionLog(logItem *logmodel.ConversionLog) bool {
+func (s *PostgresStore) UpdateConversionLog(logItem *logmodel.ConversionLog) error {
 	requestJSON, err := json.Marshal(logItem.Request)
 
 	if err != nil {
 		log.Printf("ERROR: failed to marshal request for log update: %v", err)
 
-		return false
+		return err
 	}
 
 	responseJSON, err := json.Marshal(logItem.Response)
@@ -475,42 +978,295 @@ func (s *PostgresStore) UpdateConversionLog(logItem *logmodel.ConversionLog) boo
 	if err != nil {
 		log.Printf("ERROR: failed to marshal response for log update: %v", err)
 
-		return false
+		return err
+	}
+
+	roundingJSON, err := marshalNullable(logItem.Rounding)
+
+	if err != nil {
+		return err
 	}
 
-	query := `UPDATE conversion_logs SET timestamp = $2, request = $3, response = $4 WHERE id = $1`
+	targetsJSON, err := marshalTargets(logItem.Targets)
+
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE conversion_logs
+		SET timestamp = $2,
+		    request_id = NULLIF($3, ''),
+		    response_id = NULLIF($4, ''),
+		    request = $5,
+		    response = $6,
+		    rounding = $7,
+		    batch_id = NULLIF($8, ''),
+		    targets = $9,
+		    version = version + 1
+		WHERE id = $1 AND deleted_at IS NULL AND ($10::bigint = 0 OR version = $10)
+		RETURNING version`
+
+	logItem.DeletedAt = nil
 
 	err = s.executeInTransaction(context.Background(), func(tx *sql.Tx) error {
-		res, err := tx.Exec(query, logItem.Id, logItem.Timestamp, requestJSON, responseJSON)
-		if err != nil {
+		if err := checkConversionLogLinks(tx, logItem); err != nil {
 			return err
 		}
-		rowsAffected, _ := res.RowsAffected()
-		if rowsAffected == 0 {
-			return sql.ErrNoRows
+
+		err := tx.QueryRow(query,
+			logItem.Id,
+			logItem.Timestamp,
+			logItem.Request.Id,
+			logItem.Response.Id,
+			requestJSON,
+			responseJSON,
+			roundingJSON,
+			logItem.BatchId,
+			targetsJSON,
+			logItem.Version).Scan(&logItem.Version)
+
+		if err == sql.ErrNoRows {
+			return resolveUpdateMiss(tx, "conversion_logs", logItem.Id)
 		}
-		return nil
+
+		return err
+	})
+
+	return mapConstraintError(err)
+}
+
+func (s *PostgresStore) DeleteConversionLog(id string) error {
+	return s.executeInTransaction(context.Background(), func(tx *sql.Tx) error {
+		return softDelete(tx, "conversion_logs", id, time.Now())
+	})
+}
+
+func (s *PostgresStore) RestoreConversionLog(id string) error {
+	return s.restore("conversion_logs", id, func(tx *sql.Tx) error {
+		var logItem logmodel.ConversionLog
+		var targetsJSON []byte
+		query := `SELECT COALESCE(request_id, ''), COALESCE(response_id, ''), targets FROM conversion_logs WHERE id = $1`
+
+		if err := tx.QueryRow(query, id).Scan(&logItem.Request.Id, &logItem.Response.Id, &targetsJSON); err != nil {
+			return err
+		}
+
+		if err := unmarshalTargets(targetsJSON, &logItem.Targets); err != nil {
+			return err
+		}
+
+		return checkConversionLogLinks(tx, &logItem)
 	})
+}
+
+// CreateQuote stores the quote as a document; expiry and use are kept in
+// columns so that UseQuote can claim it in a single statement.
+func (s *PostgresStore) CreateQuote(q *quote.Quote) error {
+	if q.Id == "" {
+		q.Id = uuid.New().String()
+	}
+
+	q.UsedAt = nil
+	data, err := json.Marshal(q)
+
+	if err != nil {
+		return fmt.Errorf("failed to marshal quote: %w", err)
+	}
+
+	query := `INSERT INTO quotes (id, account, data, expires_at) VALUES ($1, $2, $3, $4)`
+
+	if _, err = s.db.Exec(query, q.Id, q.Account, data, q.ExpiresAt); err != nil {
+		log.Printf("ERROR: failed to create quote: %v", err)
+	}
+
+	return err
+}
+
+func scanQuote(row *sql.Row) (*quote.Quote, error) {
+	var data []byte
+	var usedAt *time.Time
+
+	if err := row.Scan(&data, &usedAt); err != nil {
+		return nil, err
+	}
+
+	var q quote.Quote
+
+	if err := json.Unmarshal(data, &q); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal quote: %w", err)
+	}
+
+	q.UsedAt = usedAt
+
+	return &q, nil
+}
+
+func (s *PostgresStore) GetQuoteByID(id string) *quote.Quote {
+	q, err := scanQuote(s.db.QueryRow(`SELECT data, used_at FROM quotes WHERE id = $1`, id))
+
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("ERROR: failed to get quote by id: %v", err)
+		}
+
+		return nil
+	}
+
+	return q
+}
+
+func (s *PostgresStore) UseQuote(id string, at time.Time) (*quote.Quote, error) {
+	query := `UPDATE quotes SET used_at = $2
+		WHERE id = $1 AND used_at IS NULL AND expires_at > $2
+		RETURNING data, used_at`
+
+	q, err := scanQuote(s.db.QueryRow(query, id, at))
 
-	return err == nil
+	if err == nil {
+		return q, nil
+	}
+
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	// Nothing was claimed: tell apart the reasons from the current row.
+	existing := s.GetQuoteByID(id)
+
+	switch {
+	case existing == nil:
+		return nil, ErrNotFound
+	case existing.UsedAt != nil:
+		return nil, ErrQuoteUsed
+	default:
+		return nil, ErrQuoteExpired
+	}
 }
 
-func (s *PostgresStore) DeleteConversionLog(id string) bool {
-	query := `DELETE FROM conversion_logs WHERE id = $1`
+// ReserveIdempotencyKey inserts the record unless the key is held by a live
+// one; the primary key makes concurrent reservations of a key fail over to
+// reading the winner.
+func (s *PostgresStore) ReserveIdempotencyKey(rec *idempotency.Record) (*idempotency.Record, error) {
+	var existing *idempotency.Record
 
 	err := s.executeInTransaction(context.Background(), func(tx *sql.Tx) error {
-		res, err := tx.Exec(query, id)
+		_, err := tx.Exec(`DELETE FROM idempotency_keys WHERE account = $1 AND key = $2 AND expires_at <= $3`,
+			rec.Account, rec.Key, time.Now())
+
+		if err != nil {
+			return err
+		}
+
+		res, err := tx.Exec(`INSERT INTO idempotency_keys (account, key, fingerprint, status, created_at, expires_at)
+			VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (account, key) DO NOTHING`,
+			rec.Account, rec.Key, rec.Fingerprint, rec.Status, rec.CreatedAt, rec.ExpiresAt)
+
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected, _ := res.RowsAffected(); rowsAffected > 0 {
+			return nil
+		}
+
+		existing = &idempotency.Record{}
+		var headersJSON []byte
+		query := `SELECT account, key, fingerprint, status, headers, body, created_at, expires_at
+			FROM idempotency_keys WHERE account = $1 AND key = $2`
+
+		err = tx.QueryRow(query, rec.Account, rec.Key).Scan(&existing.Account,
+			&existing.Key,
+			&existing.Fingerprint,
+			&existing.Status,
+			&headersJSON,
+			&existing.Body,
+			&existing.CreatedAt,
+			&existing.ExpiresAt)
+
 		if err != nil {
 			return err
 		}
-		rowsAffected, _ := res.RowsAffected()
-		if rowsAffected == 0 {
-			return sql.ErrNoRows
+
+		if len(headersJSON) == 0 {
+			return nil
+		}
+
+		return json.Unmarshal(headersJSON, &existing.Headers)
+	})
+
+	if err != nil {
+		log.Printf("ERROR: failed to reserve idempotency key: %v", err)
+
+		return nil, err
+	}
+
+	return existing, nil
+}
+
+func (s *PostgresStore) CompleteIdempotencyKey(rec *idempotency.Record) error {
+	headersJSON, err := json.Marshal(rec.Headers)
+
+	if err != nil {
+		return err
+	}
+
+	res, err := s.db.Exec(`UPDATE idempotency_keys SET status = $3, headers = $4, body = $5 WHERE account = $1 AND key = $2`,
+		rec.Account, rec.Key, rec.Status, headersJSON, rec.Body)
+
+	if err != nil {
+		return err
+	}
+
+	return expectAffected(res)
+}
+
+func (s *PostgresStore) ReleaseIdempotencyKey(account, key string) error {
+	_, err := s.db.Exec(`DELETE FROM idempotency_keys WHERE account = $1 AND key = $2`, account, key)
+
+	return err
+}
+
+// PurgeDeleted removes rows deleted before the cutoff. Rows still linked from
+// a kept row, deleted or not, are left for a later run.
+func (s *PostgresStore) PurgeDeleted(before time.Time) (int, error) {
+	queries := []string{
+		`DELETE FROM conversion_logs WHERE deleted_at < $1`,
+		`DELETE FROM responses r
+		WHERE r.deleted_at < $1
+		  AND NOT EXISTS (SELECT 1 FROM conversion_logs l WHERE l.response_id = r.id
+		      OR ` + targetLinked("l.", `t->'response'->>'id' = r.id`) + `)`,
+		`DELETE FROM requests q
+		WHERE q.deleted_at < $1
+		  AND NOT EXISTS (SELECT 1 FROM responses r WHERE r.query_id = q.id)
+		  AND NOT EXISTS (SELECT 1 FROM conversion_logs l WHERE l.request_id = q.id
+		      OR ` + targetLinked("l.", `t->'request'->>'id' = q.id`) + `)`,
+		`DELETE FROM quotes WHERE expires_at < $1`,
+		`DELETE FROM idempotency_keys WHERE expires_at < $1`,
+	}
+
+	purged := 0
+
+	err := s.executeInTransaction(context.Background(), func(tx *sql.Tx) error {
+		for _, query := range queries {
+			res, err := tx.Exec(query, before)
+
+			if err != nil {
+				return err
+			}
+
+			rowsAffected, _ := res.RowsAffected()
+			purged += int(rowsAffected)
 		}
+
 		return nil
 	})
 
-	return err == nil
+	if err != nil {
+		return 0, err
+	}
+
+	return purged, nil
 }
 
 func (s *PostgresStore) GetNewConversionRequests() []*api.Request        { return nil }
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgconn"
+)
+
+func TestMapConstraintError(t *testing.T) {
+	other := &pgconn.PgError{Code: "22P02"}
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{name: "unique violation", err: &pgconn.PgError{Code: uniqueViolation}, want: ErrAlreadyExists},
+		{name: "wrapped foreign key violation", err: fmt.Errorf("insert: %w", &pgconn.PgError{Code: foreignKeyViolation}), want: ErrMissingReference},
+		{name: "other server error", err: other, want: other},
+		{name: "nil", err: nil, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mapConstraintError(tt.err); !errors.Is(got, tt.want) {
+				t.Fatalf("mapConstraintError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// schemaLockID keys the advisory lock that keeps instances starting together
+// from migrating the schema at the same time.
+const schemaLockID = 327619
+
+// postgresSchema creates the tables and brings tables created by an older
+// version up to date. Every statement is safe to run again.
+//
+// The foreign keys are added NOT VALID, so that they hold for new rows at
+// once, and validated only after links to rows that do not exist have been
+// cleared: the records keep their copies of the linked request and response,
+// but no longer point to them.
+var postgresSchema = []string{
+	`CREATE TABLE IF NOT EXISTS requests (
+		id TEXT PRIMARY KEY,
+		"from" VARCHAR(3) NOT NULL,
+		"to" VARCHAR(3) NOT NULL,
+		amount NUMERIC NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS responses (
+		id TEXT PRIMARY KEY,
+		success BOOLEAN NOT NULL,
+		terms TEXT,
+		privacy TEXT,
+		query_id TEXT,
+		query_from VARCHAR(3),
+		query_to VARCHAR(3),
+		query_amount NUMERIC,
+		info_timestamp BIGINT,
+		info_quote NUMERIC,
+		result NUMERIC
+	)`,
+	`CREATE TABLE IF NOT EXISTS conversion_logs (
+		id TEXT PRIMARY KEY,
+		timestamp TIMESTAMPTZ NOT NULL,
+		request JSONB,
+		response JSONB
+	)`,
+
+	`ALTER TABLE requests ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1`,
+	`ALTER TABLE requests ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ`,
+
+	`ALTER TABLE responses ADD COLUMN IF NOT EXISTS info_provider TEXT`,
+	`ALTER TABLE responses ADD COLUMN IF NOT EXISTS info_legs JSONB`,
+	`ALTER TABLE responses ADD COLUMN IF NOT EXISTS fees JSONB`,
+	`ALTER TABLE responses ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1`,
+	`ALTER TABLE responses ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ`,
+
+	`ALTER TABLE conversion_logs ADD COLUMN IF NOT EXISTS request_id TEXT`,
+	`ALTER TABLE conversion_logs ADD COLUMN IF NOT EXISTS response_id TEXT`,
+	`ALTER TABLE conversion_logs ADD COLUMN IF NOT EXISTS rounding JSONB`,
+	`ALTER TABLE conversion_logs ADD COLUMN IF NOT EXISTS batch_id TEXT`,
+	`ALTER TABLE conversion_logs ADD COLUMN IF NOT EXISTS targets JSONB`,
+	`ALTER TABLE conversion_logs ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1`,
+	`ALTER TABLE conversion_logs ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ`,
+
+	// Logs written before the link columns existed only carry the links in
+	// their copies.
+	`UPDATE conversion_logs SET request_id = NULLIF(request->>'id', '')
+		WHERE request_id IS NULL AND request IS NOT NULL`,
+	`UPDATE conversion_logs SET response_id = NULLIF(response->>'id', '')
+		WHERE response_id IS NULL AND response IS NOT NULL`,
+
+	`DO $$
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'responses_query_id_fkey') THEN
+			ALTER TABLE responses ADD CONSTRAINT responses_query_id_fkey
+				FOREIGN KEY (query_id) REFERENCES requests (id) NOT VALID;
+		END IF;
+
+		IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'conversion_logs_request_id_fkey') THEN
+			ALTER TABLE conversion_logs ADD CONSTRAINT conversion_logs_request_id_fkey
+				FOREIGN KEY (request_id) REFERENCES requests (id) NOT VALID;
+		END IF;
+
+		IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'conversion_logs_response_id_fkey') THEN
+			ALTER TABLE conversion_logs ADD CONSTRAINT conversion_logs_response_id_fkey
+				FOREIGN KEY (response_id) REFERENCES responses (id) NOT VALID;
+		END IF;
+	END
+	$$`,
+
+	`UPDATE responses SET query_id = NULL
+		WHERE query_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM requests WHERE requests.id = responses.query_id)`,
+	`UPDATE conversion_logs SET request_id = NULL
+		WHERE request_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM requests WHERE requests.id = conversion_logs.request_id)`,
+	`UPDATE conversion_logs SET response_id = NULL
+		WHERE response_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM responses WHERE responses.id = conversion_logs.response_id)`,
+
+	`ALTER TABLE responses VALIDATE CONSTRAINT responses_query_id_fkey`,
+	`ALTER TABLE conversion_logs VALIDATE CONSTRAINT conversion_logs_request_id_fkey`,
+	`ALTER TABLE conversion_logs VALIDATE CONSTRAINT conversion_logs_response_id_fkey`,
+
+	`CREATE INDEX IF NOT EXISTS responses_query_id_idx ON responses (query_id)`,
+	`CREATE INDEX IF NOT EXISTS conversion_logs_request_id_idx ON conversion_logs (request_id)`,
+	`CREATE INDEX IF NOT EXISTS conversion_logs_response_id_idx ON conversion_logs (response_id)`,
+	`CREATE INDEX IF NOT EXISTS conversion_logs_batch_id_idx ON conversion_logs (batch_id)`,
+
+	`CREATE TABLE IF NOT EXISTS quotes (
+		id TEXT PRIMARY KEY,
+		account TEXT NOT NULL,
+		data JSONB NOT NULL,
+		expires_at TIMESTAMPTZ NOT NULL,
+		used_at TIMESTAMPTZ
+	)`,
+	`CREATE INDEX IF NOT EXISTS quotes_expires_at_idx ON quotes (expires_at)`,
+
+	`CREATE TABLE IF NOT EXISTS idempotency_keys (
+		account TEXT NOT NULL,
+		key TEXT NOT NULL,
+		fingerprint TEXT NOT NULL,
+		status INTEGER NOT NULL DEFAULT 0,
+		headers JSONB,
+		body BYTEA,
+		created_at TIMESTAMPTZ NOT NULL,
+		expires_at TIMESTAMPTZ NOT NULL,
+		PRIMARY KEY (account, key)
+	)`,
+	`CREATE INDEX IF NOT EXISTS idempotency_keys_expires_at_idx ON idempotency_keys (expires_at)`,
+
+	`CREATE TABLE IF NOT EXISTS dead_letters (
+		id TEXT PRIMARY KEY,
+		entity TEXT NOT NULL,
+		payload JSONB NOT NULL,
+		error TEXT NOT NULL,
+		attempts INTEGER NOT NULL,
+		failed_at TIMESTAMPTZ NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS dead_letters_entity_idx ON dead_letters (entity, failed_at)`,
+}
+
+// migrate applies postgresSchema in one transaction.
+func migrate(ctx context.Context, db *sql.DB) error {
+	tx, err := db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err = tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, schemaLockID); err != nil {
+		return err
+	}
+
+	for i, statement := range postgresSchema {
+		if _, err = tx.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("schema statement %d: %w", i+1, err)
+		}
+	}
+
+	return tx.Commit()
+}
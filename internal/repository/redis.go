@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/M2rk13/Otus-327619/internal/config"
+
+	"github.com/go-redis/redis"
+)
+
+// NewRedisClient connects to Redis. The client is shared by the audit trail
+// and the repository cache.
+func NewRedisClient(redisCfg config.RedisConfig) (*redis.Client, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     redisCfg.Addr,
+		Password: redisCfg.Password,
+		DB:       0,
+	})
+
+	if _, err := client.Ping().Result(); err != nil {
+		client.Close()
+
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	return client, nil
+}
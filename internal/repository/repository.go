@@ -1,30 +1,101 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/idempotency"
 	logmodel "github.com/M2rk13/Otus-327619/internal/model/log"
+	"github.com/M2rk13/Otus-327619/internal/model/quote"
 )
 
+// Repository stores requests, responses, conversion logs, quotes and
+// idempotency records.
+//
+// Links between entities are modelled by ids: Response.Query.Id points to a
+// request, ConversionLog.Request.Id and ConversionLog.Response.Id point to a
+// request and a response, and so do the Request.Id and Response.Id of each
+// of ConversionLog.Targets. An empty id means "no link". Creating or updating a
+// record with a link to a missing item fails with ErrMissingReference; deleting
+// a referenced item either fails with ErrReferenced or removes the dependants,
+// depending on the configured delete policy.
+//
+// The batch creates store several records of one kind at once. Links of the
+// whole batch are checked first, so a missing reference stores nothing. A
+// store that cannot write the batch atomically fails with a *PartialError
+// when it stored a part of it.
+//
+// Creates keep a caller-supplied id and generate one otherwise. An id that is
+// already taken, by a live or a deleted record, fails with ErrAlreadyExists.
+//
+// Every record carries a version that starts at 1 on create and is incremented
+// by each update. An update whose Version is non-zero only succeeds if it
+// matches the stored version and fails with ErrVersionConflict otherwise; a
+// zero Version overwrites unconditionally. On success the new version is
+// written back into the passed item.
+//
+// Deletes are soft: the record gets a deleted_at mark and disappears from
+// lookups, links and updates, but can be restored until PurgeDeleted removes
+// it for good. Restoring fails with ErrMissingReference while a linked record
+// is still deleted. DeleteRequest and DeleteResponse return the dependants
+// the cascade policy deleted along with the record.
+//
+// Quotes are locked rates stored so that any instance can execute them.
+// UseQuote marks a quote used atomically: it fails with ErrQuoteUsed if the
+// quote was used before and with ErrQuoteExpired once it has expired.
+//
+// Idempotency records keep the responses of requests sent with an
+// Idempotency-Key per account and key. ReserveIdempotencyKey stores the record
+// unless a live one for the same account and key exists, which it returns
+// instead; an expired record is replaced. CompleteIdempotencyKey saves the
+// status, headers and body into the reserved record, ReleaseIdempotencyKey
+// drops it.
+//
+// PurgeDeleted also removes quotes and idempotency records that expired before
+// the cutoff.
 type Repository interface {
-	CreateRequest(req *api.Request)
+	CreateRequest(req *api.Request) error
+	CreateRequests(reqs []*api.Request) error
 	GetRequestByID(id string) *api.Request
-	GetAllRequests() []*api.Request
-	UpdateRequest(req *api.Request) bool
-	DeleteRequest(id string) bool
+	GetAllRequests(includeDeleted bool) []*api.Request
+	UpdateRequest(req *api.Request) error
+	DeleteRequest(id string) (Cascaded, error)
+	RestoreRequest(id string) error
 
-	CreateResponse(resp *api.Response)
+	CreateResponse(resp *api.Response) error
+	CreateResponses(resps []*api.Response) error
 	GetResponseByID(id string) *api.Response
-	GetAllResponses() []*api.Response
-	UpdateResponse(resp *api.Response) bool
-	DeleteResponse(id string) bool
+	GetAllResponses(includeDeleted bool) []*api.Response
+	UpdateResponse(resp *api.Response) error
+	DeleteResponse(id string) (Cascaded, error)
+	RestoreResponse(id string) error
 
-	CreateConversionLog(log *logmodel.ConversionLog)
+	CreateConversionLog(log *logmodel.ConversionLog) error
+	CreateConversionLogs(logs []*logmodel.ConversionLog) error
 	GetConversionLogByID(id string) *logmodel.ConversionLog
-	GetAllConversionLogs() []*logmodel.ConversionLog
-	UpdateConversionLog(log *logmodel.ConversionLog) bool
-	DeleteConversionLog(id string) bool
+	GetAllConversionLogs(includeDeleted bool) []*logmodel.ConversionLog
+	UpdateConversionLog(log *logmodel.ConversionLog) error
+	DeleteConversionLog(id string) error
+	RestoreConversionLog(id string) error
+
+	CreateQuote(q *quote.Quote) error
+	GetQuoteByID(id string) *quote.Quote
+	UseQuote(id string, at time.Time) (*quote.Quote, error)
+
+	ReserveIdempotencyKey(rec *idempotency.Record) (*idempotency.Record, error)
+	CompleteIdempotencyKey(rec *idempotency.Record) error
+	ReleaseIdempotencyKey(account, key string) error
+
+	PurgeDeleted(before time.Time) (int, error)
 
 	GetNewConversionRequests() []*api.Request
 	GetNewConversionResponses() []*api.Response
 	GetNewConversionLogs() []*logmodel.ConversionLog
 }
+
+// Cascaded holds the dependants deleted together with a record, as they were
+// before the delete.
+type Cascaded struct {
+	Responses []*api.Response
+	Logs      []*logmodel.ConversionLog
+}
@@ -7,10 +7,14 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/M2rk13/Otus-327619/internal/config"
+	"github.com/M2rk13/Otus-327619/internal/enum"
 	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/idempotency"
 	logmodel "github.com/M2rk13/Otus-327619/internal/model/log"
+	"github.com/M2rk13/Otus-327619/internal/model/quote"
 
 	"github.com/google/uuid"
 )
@@ -27,17 +31,42 @@ type Identifiable interface {
 	GetId() string
 }
 
+type Versioned interface {
+	Identifiable
+	GetVersion() int64
+	SetVersion(version int64)
+}
+
+type Record interface {
+	Versioned
+	GetDeletedAt() *time.Time
+	SetDeletedAt(deletedAt *time.Time)
+}
+
+func isLive[T Record](data T) bool {
+	return data.GetDeletedAt() == nil
+}
+
 type FileStore struct {
 	requestsItem  *repositoryItem[*api.Request]
 	responsesItem *repositoryItem[*api.Response]
 	logsItem      *repositoryItem[*logmodel.ConversionLog]
+	quotesItem    *repositoryItem[*quote.Quote]
+	keysItem      *repositoryItem[*idempotency.Record]
+	deletePolicy  string
+	// linksMu serialises mutations so that reference checks and the writes
+	// they guard are atomic across the three items.
+	linksMu sync.Mutex
 }
 
-func NewFileStore() *FileStore {
+func NewFileStore(deletePolicy string) *FileStore {
 	return &FileStore{
 		requestsItem:  &repositoryItem[*api.Request]{filePath: config.FileCfg.RequestsFilePath},
 		responsesItem: &repositoryItem[*api.Response]{filePath: config.FileCfg.ResponsesFilePath},
 		logsItem:      &repositoryItem[*logmodel.ConversionLog]{filePath: config.FileCfg.LogsFilePath},
+		quotesItem:    &repositoryItem[*quote.Quote]{filePath: config.FileCfg.QuotesFilePath},
+		keysItem:      &repositoryItem[*idempotency.Record]{filePath: config.FileCfg.IdempotencyFilePath},
+		deletePolicy:  deletePolicy,
 	}
 }
 
@@ -54,6 +83,14 @@ func (f *FileStore) SetupPersistence() error {
 		return fmt.Errorf("failed to setup persistence for logs: %v", err)
 	}
 
+	if err := setupPersistence(f.quotesItem); err != nil {
+		return fmt.Errorf("failed to setup persistence for quotes: %v", err)
+	}
+
+	if err := setupPersistence(f.keysItem); err != nil {
+		return fmt.Errorf("failed to setup persistence for idempotency keys: %v", err)
+	}
+
 	return nil
 }
 
@@ -72,6 +109,16 @@ func (f *FileStore) ClosePersistence() {
 		_ = f.logsItem.file.Close()
 		fmt.Println("Closed logs persistence file.")
 	}
+
+	if f.quotesItem.file != nil {
+		_ = f.quotesItem.file.Close()
+		fmt.Println("Closed quotes persistence file.")
+	}
+
+	if f.keysItem.file != nil {
+		_ = f.keysItem.file.Close()
+		fmt.Println("Closed idempotency keys persistence file.")
+	}
 }
 
 func setupPersistence[T any](repoItem *repositoryItem[T]) error {
@@ -144,11 +191,18 @@ func (ri *repositoryItem[T]) getNew() []T {
 	return newItems
 }
 
-func (ri *repositoryItem[T]) add(item T) {
+func (ri *repositoryItem[T]) add(items ...T) {
 	ri.mu.Lock()
 	defer ri.mu.Unlock()
-	ri.data = append(ri.data, item)
+	ri.data = append(ri.data, items...)
 
+	for _, item := range items {
+		ri.appendToFile(item)
+	}
+}
+
+// appendToFile writes item as a new line of the file. The caller holds mu.
+func (ri *repositoryItem[T]) appendToFile(item T) {
 	jsonData, err := json.Marshal(item)
 
 	if err != nil {
@@ -215,19 +269,27 @@ func (ri *repositoryItem[T]) rewriteAllDataToFile() error {
 	return writer.Flush()
 }
 
-func genericGetAll[T any](repoItem *repositoryItem[T]) []T {
+func genericGetAll[T Record](repoItem *repositoryItem[T], includeDeleted bool) []T {
 	repoItem.mu.Lock()
 	defer repoItem.mu.Unlock()
 
-	return append([]T{}, repoItem.data...)
+	items := []T{}
+
+	for _, data := range repoItem.data {
+		if includeDeleted || isLive(data) {
+			items = append(items, data)
+		}
+	}
+
+	return items
 }
 
-func genericGetByID[T Identifiable](repoItem *repositoryItem[T], id string) T {
+func genericGetByID[T Record](repoItem *repositoryItem[T], id string) T {
 	repoItem.mu.Lock()
 	defer repoItem.mu.Unlock()
 
 	for _, data := range repoItem.data {
-		if data.GetId() == id {
+		if data.GetId() == id && isLive(data) {
 			return data
 		}
 	}
@@ -237,23 +299,153 @@ func genericGetByID[T Identifiable](repoItem *repositoryItem[T], id string) T {
 	return zero
 }
 
-func genericUpdate[T Identifiable](repoItem *repositoryItem[T], updatedData T) bool {
+func genericExists[T Record](repoItem *repositoryItem[T], id string) bool {
+	return len(genericFindIDs(repoItem, func(data T) bool { return data.GetId() == id })) > 0
+}
+
+// checkNewIDs fails with ErrAlreadyExists if the id of one of items is
+// already stored, deleted or not, or is repeated within items. Items without
+// an id get one generated later and are skipped.
+func checkNewIDs[T Record](repoItem *repositoryItem[T], items []T) error {
+	ids := make(map[string]bool, len(items))
+
+	for _, item := range items {
+		if item.GetId() == "" {
+			continue
+		}
+
+		if ids[item.GetId()] {
+			return ErrAlreadyExists
+		}
+
+		ids[item.GetId()] = true
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	repoItem.mu.Lock()
+	defer repoItem.mu.Unlock()
+
+	for _, data := range repoItem.data {
+		if ids[data.GetId()] {
+			return ErrAlreadyExists
+		}
+	}
+
+	return nil
+}
+
+// genericFindIDs returns the ids of live records matching match.
+func genericFindIDs[T Record](repoItem *repositoryItem[T], match func(T) bool) []string {
+	repoItem.mu.Lock()
+	defer repoItem.mu.Unlock()
+
+	var ids []string
+
+	for _, data := range repoItem.data {
+		if isLive(data) && match(data) {
+			ids = append(ids, data.GetId())
+		}
+	}
+
+	return ids
+}
+
+func genericUpdate[T Record](repoItem *repositoryItem[T], updatedData T) error {
 	repoItem.mu.Lock()
 	defer repoItem.mu.Unlock()
 
 	for i, data := range repoItem.data {
-		if data.GetId() == updatedData.GetId() {
+		if data.GetId() == updatedData.GetId() && isLive(data) {
+			if updatedData.GetVersion() != 0 && updatedData.GetVersion() != data.GetVersion() {
+				return ErrVersionConflict
+			}
+
+			updatedData.SetVersion(data.GetVersion() + 1)
+			updatedData.SetDeletedAt(nil)
 			repoItem.data[i] = updatedData
 			_ = repoItem.rewriteAllDataToFile()
 
-			return true
+			return nil
 		}
 	}
 
-	return false
+	return ErrNotFound
+}
+
+func genericDelete[E any, T interface {
+	*E
+	Record
+}](repoItem *repositoryItem[T], id string, deletedAt time.Time) bool {
+	return len(genericDeleteWhere(repoItem, func(data T) bool { return data.GetId() == id }, deletedAt)) > 0
+}
+
+// genericDeleteWhere marks the live records matching match as deleted and
+// returns them as they were before. Like genericUpdate it stores a copy
+// instead of changing the records that lookups have handed out.
+func genericDeleteWhere[E any, T interface {
+	*E
+	Record
+}](repoItem *repositoryItem[T], match func(T) bool, deletedAt time.Time) []T {
+	repoItem.mu.Lock()
+	defer repoItem.mu.Unlock()
+
+	var deleted []T
+
+	for i, data := range repoItem.data {
+		if isLive(data) && match(data) {
+			copied := *data
+			T(&copied).SetDeletedAt(&deletedAt)
+			repoItem.data[i] = &copied
+			deleted = append(deleted, data)
+		}
+	}
+
+	if len(deleted) > 0 {
+		_ = repoItem.rewriteAllDataToFile()
+	}
+
+	return deleted
 }
 
-func genericDelete[T Identifiable](repoItem *repositoryItem[T], id string) bool {
+// genericRestore clears the deleted mark of id on a copy of the record. check
+// runs on the deleted record before it is restored.
+func genericRestore[E any, T interface {
+	*E
+	Record
+}](repoItem *repositoryItem[T], id string, check func(T) error) error {
+	repoItem.mu.Lock()
+	defer repoItem.mu.Unlock()
+
+	for i, data := range repoItem.data {
+		if data.GetId() != id {
+			continue
+		}
+
+		if isLive(data) {
+			return nil
+		}
+
+		if err := check(data); err != nil {
+			return err
+		}
+
+		copied := *data
+		T(&copied).SetDeletedAt(nil)
+		repoItem.data[i] = &copied
+		_ = repoItem.rewriteAllDataToFile()
+
+		return nil
+	}
+
+	return ErrNotFound
+}
+
+// genericPurge permanently removes records deleted before the cutoff, unless
+// keep says they are still needed.
+func genericPurge[T Record](repoItem *repositoryItem[T], before time.Time, keep func(T) bool) int {
 	repoItem.mu.Lock()
 	defer repoItem.mu.Unlock()
 
@@ -261,17 +453,63 @@ func genericDelete[T Identifiable](repoItem *repositoryItem[T], id string) bool
 	var newData []T
 
 	for _, data := range repoItem.data {
-		if data.GetId() != id {
+		deletedAt := data.GetDeletedAt()
+
+		if deletedAt == nil || !deletedAt.Before(before) || keep(data) {
 			newData = append(newData, data)
 		}
 	}
 
 	repoItem.data = newData
+	purged := initialLen - len(repoItem.data)
 
-	if len(repoItem.data) < initialLen {
+	if purged > 0 {
 		_ = repoItem.rewriteAllDataToFile()
+	}
+
+	return purged
+}
+
+func contains(ids []string, id string) bool {
+	for _, item := range ids {
+		if item == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f *FileStore) checkResponseLinks(resp *api.Response) error {
+	if resp.Query.Id != "" && !genericExists(f.requestsItem, resp.Query.Id) {
+		return ErrMissingReference
+	}
+
+	return nil
+}
+
+func (f *FileStore) checkConversionLogLinks(logItem *logmodel.ConversionLog) error {
+	for _, id := range logItem.RequestIds() {
+		if id != "" && !genericExists(f.requestsItem, id) {
+			return ErrMissingReference
+		}
+	}
+
+	for _, id := range logItem.ResponseIds() {
+		if id != "" && !genericExists(f.responsesItem, id) {
+			return ErrMissingReference
+		}
+	}
 
-		return true
+	return nil
+}
+
+// linksAny reports whether ids and linked share an id.
+func linksAny(linked, ids []string) bool {
+	for _, id := range ids {
+		if contains(linked, id) {
+			return true
+		}
 	}
 
 	return false
@@ -289,65 +527,408 @@ func (f *FileStore) GetNewConversionLogs() []*logmodel.ConversionLog {
 	return f.logsItem.getNew()
 }
 
-func (f *FileStore) CreateRequest(req *api.Request) {
-	req.Id = uuid.New().String()
-	f.requestsItem.add(req)
+func (f *FileStore) CreateRequest(req *api.Request) error {
+	return f.CreateRequests([]*api.Request{req})
+}
+
+func (f *FileStore) CreateRequests(reqs []*api.Request) error {
+	f.linksMu.Lock()
+	defer f.linksMu.Unlock()
+
+	if err := checkNewIDs(f.requestsItem, reqs); err != nil {
+		return err
+	}
+
+	for _, req := range reqs {
+		if req.Id == "" {
+			req.Id = uuid.New().String()
+		}
+
+		req.Version = 1
+		req.DeletedAt = nil
+	}
+
+	f.requestsItem.add(reqs...)
+
+	return nil
 }
 
 func (f *FileStore) GetRequestByID(id string) *api.Request {
 	return genericGetByID(f.requestsItem, id)
 }
 
-func (f *FileStore) GetAllRequests() []*api.Request {
-	return genericGetAll(f.requestsItem)
+func (f *FileStore) GetAllRequests(includeDeleted bool) []*api.Request {
+	return genericGetAll(f.requestsItem, includeDeleted)
 }
 
-func (f *FileStore) UpdateRequest(req *api.Request) bool {
+func (f *FileStore) UpdateRequest(req *api.Request) error {
+	f.linksMu.Lock()
+	defer f.linksMu.Unlock()
+
 	return genericUpdate(f.requestsItem, req)
 }
 
-func (f *FileStore) DeleteRequest(id string) bool {
-	return genericDelete(f.requestsItem, id)
+func (f *FileStore) DeleteRequest(id string) (Cascaded, error) {
+	f.linksMu.Lock()
+	defer f.linksMu.Unlock()
+
+	if !genericExists(f.requestsItem, id) {
+		return Cascaded{}, ErrNotFound
+	}
+
+	responseIDs := genericFindIDs(f.responsesItem, func(resp *api.Response) bool {
+		return resp.Query.Id == id
+	})
+
+	isLogLinked := func(logItem *logmodel.ConversionLog) bool {
+		return contains(logItem.RequestIds(), id) || linksAny(logItem.ResponseIds(), responseIDs)
+	}
+
+	if f.deletePolicy != enum.Cascade {
+		if len(responseIDs) > 0 || len(genericFindIDs(f.logsItem, isLogLinked)) > 0 {
+			return Cascaded{}, ErrReferenced
+		}
+	}
+
+	now := time.Now()
+	cascaded := Cascaded{
+		Logs: genericDeleteWhere(f.logsItem, isLogLinked, now),
+		Responses: genericDeleteWhere(f.responsesItem, func(resp *api.Response) bool {
+			return resp.Query.Id == id
+		}, now),
+	}
+	genericDelete(f.requestsItem, id, now)
+
+	return cascaded, nil
 }
 
-func (f *FileStore) CreateResponse(resp *api.Response) {
-	resp.Id = uuid.New().String()
-	f.responsesItem.add(resp)
+func (f *FileStore) RestoreRequest(id string) error {
+	f.linksMu.Lock()
+	defer f.linksMu.Unlock()
+
+	return genericRestore(f.requestsItem, id, func(*api.Request) error { return nil })
+}
+
+func (f *FileStore) CreateResponse(resp *api.Response) error {
+	return f.CreateResponses([]*api.Response{resp})
+}
+
+func (f *FileStore) CreateResponses(resps []*api.Response) error {
+	f.linksMu.Lock()
+	defer f.linksMu.Unlock()
+
+	if err := checkNewIDs(f.responsesItem, resps); err != nil {
+		return err
+	}
+
+	for _, resp := range resps {
+		if err := f.checkResponseLinks(resp); err != nil {
+			return err
+		}
+	}
+
+	for _, resp := range resps {
+		if resp.Id == "" {
+			resp.Id = uuid.New().String()
+		}
+
+		resp.Version = 1
+		resp.DeletedAt = nil
+	}
+
+	f.responsesItem.add(resps...)
+
+	return nil
 }
 
 func (f *FileStore) GetResponseByID(id string) *api.Response {
 	return genericGetByID(f.responsesItem, id)
 }
 
-func (f *FileStore) GetAllResponses() []*api.Response {
-	return genericGetAll(f.responsesItem)
+func (f *FileStore) GetAllResponses(includeDeleted bool) []*api.Response {
+	return genericGetAll(f.responsesItem, includeDeleted)
 }
 
-func (f *FileStore) UpdateResponse(resp *api.Response) bool {
+func (f *FileStore) UpdateResponse(resp *api.Response) error {
+	f.linksMu.Lock()
+	defer f.linksMu.Unlock()
+
+	if err := f.checkResponseLinks(resp); err != nil {
+		return err
+	}
+
 	return genericUpdate(f.responsesItem, resp)
 }
 
-func (f *FileStore) DeleteResponse(id string) bool {
-	return genericDelete(f.responsesItem, id)
+func (f *FileStore) DeleteResponse(id string) (Cascaded, error) {
+	f.linksMu.Lock()
+	defer f.linksMu.Unlock()
+
+	if !genericExists(f.responsesItem, id) {
+		return Cascaded{}, ErrNotFound
+	}
+
+	isLogLinked := func(logItem *logmodel.ConversionLog) bool {
+		return contains(logItem.ResponseIds(), id)
+	}
+
+	if f.deletePolicy != enum.Cascade && len(genericFindIDs(f.logsItem, isLogLinked)) > 0 {
+		return Cascaded{}, ErrReferenced
+	}
+
+	now := time.Now()
+	cascaded := Cascaded{Logs: genericDeleteWhere(f.logsItem, isLogLinked, now)}
+	genericDelete(f.responsesItem, id, now)
+
+	return cascaded, nil
+}
+
+func (f *FileStore) RestoreResponse(id string) error {
+	f.linksMu.Lock()
+	defer f.linksMu.Unlock()
+
+	return genericRestore(f.responsesItem, id, f.checkResponseLinks)
+}
+
+func (f *FileStore) CreateConversionLog(logItem *logmodel.ConversionLog) error {
+	return f.CreateConversionLogs([]*logmodel.ConversionLog{logItem})
 }
 
-func (f *FileStore) CreateConversionLog(logItem *logmodel.ConversionLog) {
-	logItem.Id = uuid.New().String()
-	f.logsItem.add(logItem)
+func (f *FileStore) CreateConversionLogs(logs []*logmodel.ConversionLog) error {
+	f.linksMu.Lock()
+	defer f.linksMu.Unlock()
+
+	if err := checkNewIDs(f.logsItem, logs); err != nil {
+		return err
+	}
+
+	for _, logItem := range logs {
+		if err := f.checkConversionLogLinks(logItem); err != nil {
+			return err
+		}
+	}
+
+	for _, logItem := range logs {
+		if logItem.Id == "" {
+			logItem.Id = uuid.New().String()
+		}
+
+		logItem.Version = 1
+		logItem.DeletedAt = nil
+	}
+
+	f.logsItem.add(logs...)
+
+	return nil
 }
 
 func (f *FileStore) GetConversionLogByID(id string) *logmodel.ConversionLog {
 	return genericGetByID(f.logsItem, id)
 }
 
-func (f *FileStore) GetAllConversionLogs() []*logmodel.ConversionLog {
-	return genericGetAll(f.logsItem)
+func (f *FileStore) GetAllConversionLogs(includeDeleted bool) []*logmodel.ConversionLog {
+	return genericGetAll(f.logsItem, includeDeleted)
 }
 
-func (f *FileStore) UpdateConversionLog(logItem *logmodel.ConversionLog) bool {
+func (f *FileStore) UpdateConversionLog(logItem *logmodel.ConversionLog) error {
+	f.linksMu.Lock()
+	defer f.linksMu.Unlock()
+
+	if err := f.checkConversionLogLinks(logItem); err != nil {
+		return err
+	}
+
 	return genericUpdate(f.logsItem, logItem)
 }
 
-func (f *FileStore) DeleteConversionLog(id string) bool {
-	return genericDelete(f.logsItem, id)
+func (f *FileStore) DeleteConversionLog(id string) error {
+	f.linksMu.Lock()
+	defer f.linksMu.Unlock()
+
+	if !genericDelete(f.logsItem, id, time.Now()) {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (f *FileStore) RestoreConversionLog(id string) error {
+	f.linksMu.Lock()
+	defer f.linksMu.Unlock()
+
+	return genericRestore(f.logsItem, id, f.checkConversionLogLinks)
+}
+
+func (f *FileStore) CreateQuote(q *quote.Quote) error {
+	if q.Id == "" {
+		q.Id = uuid.New().String()
+	}
+
+	q.UsedAt = nil
+	f.quotesItem.add(q)
+
+	return nil
+}
+
+func (f *FileStore) GetQuoteByID(id string) *quote.Quote {
+	f.quotesItem.mu.Lock()
+	defer f.quotesItem.mu.Unlock()
+
+	for _, q := range f.quotesItem.data {
+		if q.Id == id {
+			found := *q
+
+			return &found
+		}
+	}
+
+	return nil
+}
+
+func (f *FileStore) UseQuote(id string, at time.Time) (*quote.Quote, error) {
+	f.quotesItem.mu.Lock()
+	defer f.quotesItem.mu.Unlock()
+
+	for _, q := range f.quotesItem.data {
+		if q.Id != id {
+			continue
+		}
+
+		if q.UsedAt != nil {
+			return nil, ErrQuoteUsed
+		}
+
+		if q.Expired(at) {
+			return nil, ErrQuoteExpired
+		}
+
+		q.UsedAt = &at
+		_ = f.quotesItem.rewriteAllDataToFile()
+		used := *q
+
+		return &used, nil
+	}
+
+	return nil, ErrNotFound
+}
+
+// purgeWhere permanently removes the items matching drop.
+func purgeWhere[T any](repoItem *repositoryItem[T], drop func(T) bool) int {
+	repoItem.mu.Lock()
+	defer repoItem.mu.Unlock()
+
+	initialLen := len(repoItem.data)
+	var kept []T
+
+	for _, data := range repoItem.data {
+		if !drop(data) {
+			kept = append(kept, data)
+		}
+	}
+
+	repoItem.data = kept
+	purged := initialLen - len(kept)
+
+	if purged > 0 {
+		_ = repoItem.rewriteAllDataToFile()
+	}
+
+	return purged
+}
+
+// findKey returns the index of the record for account and key, or -1. The
+// caller holds the lock of keysItem.
+func (f *FileStore) findKey(account, key string) int {
+	for i, rec := range f.keysItem.data {
+		if rec.Account == account && rec.Key == key {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (f *FileStore) ReserveIdempotencyKey(rec *idempotency.Record) (*idempotency.Record, error) {
+	f.keysItem.mu.Lock()
+	defer f.keysItem.mu.Unlock()
+
+	i := f.findKey(rec.Account, rec.Key)
+
+	if i >= 0 && !f.keysItem.data[i].Expired(time.Now()) {
+		existing := *f.keysItem.data[i]
+
+		return &existing, nil
+	}
+
+	stored := *rec
+
+	if i >= 0 {
+		f.keysItem.data[i] = &stored
+
+		return nil, f.keysItem.rewriteAllDataToFile()
+	}
+
+	f.keysItem.data = append(f.keysItem.data, &stored)
+	f.keysItem.appendToFile(&stored)
+
+	return nil, nil
+}
+
+func (f *FileStore) CompleteIdempotencyKey(rec *idempotency.Record) error {
+	f.keysItem.mu.Lock()
+	defer f.keysItem.mu.Unlock()
+
+	i := f.findKey(rec.Account, rec.Key)
+
+	if i < 0 {
+		return ErrNotFound
+	}
+
+	completed := *f.keysItem.data[i]
+	completed.Status = rec.Status
+	completed.Headers = rec.Headers
+	completed.Body = rec.Body
+	f.keysItem.data[i] = &completed
+
+	return f.keysItem.rewriteAllDataToFile()
+}
+
+func (f *FileStore) ReleaseIdempotencyKey(account, key string) error {
+	purgeWhere(f.keysItem, func(rec *idempotency.Record) bool {
+		return rec.Account == account && rec.Key == key
+	})
+
+	return nil
+}
+
+// PurgeDeleted removes records deleted before the cutoff. Records that are
+// still linked from a kept record, deleted or not, are left for a later run.
+func (f *FileStore) PurgeDeleted(before time.Time) (int, error) {
+	f.linksMu.Lock()
+	defer f.linksMu.Unlock()
+
+	purged := genericPurge(f.logsItem, before, func(*logmodel.ConversionLog) bool { return false })
+
+	var linkedRequests, linkedResponses []string
+
+	for _, logItem := range genericGetAll(f.logsItem, true) {
+		linkedRequests = append(linkedRequests, logItem.RequestIds()...)
+		linkedResponses = append(linkedResponses, logItem.ResponseIds()...)
+	}
+
+	purged += genericPurge(f.responsesItem, before, func(resp *api.Response) bool {
+		return contains(linkedResponses, resp.Id)
+	})
+
+	for _, resp := range genericGetAll(f.responsesItem, true) {
+		linkedRequests = append(linkedRequests, resp.Query.Id)
+	}
+
+	purged += genericPurge(f.requestsItem, before, func(req *api.Request) bool {
+		return contains(linkedRequests, req.Id)
+	})
+	purged += purgeWhere(f.quotesItem, func(q *quote.Quote) bool { return q.ExpiresAt.Before(before) })
+	purged += purgeWhere(f.keysItem, func(rec *idempotency.Record) bool { return rec.ExpiresAt.Before(before) })
+
+	return purged, nil
 }
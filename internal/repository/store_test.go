@@ -0,0 +1,362 @@
+package repository
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/config"
+	"github.com/M2rk13/Otus-327619/internal/enum"
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+	logmodel "github.com/M2rk13/Otus-327619/internal/model/log"
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+)
+
+// newTestFileStore returns a file store with the given delete policy that
+// persists into a temporary directory.
+func newTestFileStore(t *testing.T, deletePolicy string) *FileStore {
+	t.Helper()
+
+	dir := t.TempDir()
+	config.FileCfg = config.FileConfig{
+		RequestsFilePath:    filepath.Join(dir, "requests.json"),
+		ResponsesFilePath:   filepath.Join(dir, "responses.json"),
+		LogsFilePath:        filepath.Join(dir, "logs.json"),
+		QuotesFilePath:      filepath.Join(dir, "quotes.json"),
+		IdempotencyFilePath: filepath.Join(dir, "idempotency.json"),
+	}
+
+	store := NewFileStore(deletePolicy)
+
+	if err := store.SetupPersistence(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(store.ClosePersistence)
+
+	return store
+}
+
+func TestFileStore_CreateRejectsExistingIDs(t *testing.T) {
+	tests := []struct {
+		name    string
+		deleted bool
+		batch   []*api.Request
+		wantErr error
+	}{
+		{name: "new id", batch: []*api.Request{{Id: "req-2"}}},
+		{name: "generated id", batch: []*api.Request{{}}},
+		{name: "live id", batch: []*api.Request{{Id: "req-1"}}, wantErr: ErrAlreadyExists},
+		{name: "deleted id", deleted: true, batch: []*api.Request{{Id: "req-1"}}, wantErr: ErrAlreadyExists},
+		{name: "repeated in batch", batch: []*api.Request{{Id: "req-2"}, {Id: "req-2"}}, wantErr: ErrAlreadyExists},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestFileStore(t, enum.Cascade)
+
+			if err := store.CreateRequest(&api.Request{Id: "req-1", From: "USD", To: "EUR"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.deleted {
+				if _, err := store.DeleteRequest("req-1"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := store.CreateRequests(tt.batch); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateRequests() error = %v, want %v", err, tt.wantErr)
+			}
+
+			want := 1 + len(tt.batch)
+
+			if tt.wantErr != nil {
+				want = 1
+			}
+
+			if got := len(store.GetAllRequests(true)); got != want {
+				t.Errorf("stored %d requests, want %d", got, want)
+			}
+		})
+	}
+}
+
+// seedLinked stores a request, a response to it and a log of both.
+func seedLinked(t *testing.T, store *FileStore) (*api.Request, *api.Response, *logmodel.ConversionLog) {
+	t.Helper()
+
+	req := &api.Request{Id: "req-1", From: "USD", To: "EUR", Amount: money.NewFromInt(1)}
+	resp := &api.Response{Id: "resp-1", Success: true, Query: *req}
+	logItem := logmodel.NewConversionLog("log-1", *req, *resp)
+
+	if err := store.CreateRequest(req); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.CreateResponse(resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.CreateConversionLog(logItem); err != nil {
+		t.Fatal(err)
+	}
+
+	return req, resp, logItem
+}
+
+func TestFileStore_UpdateRequestVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		deleted     bool
+		version     int64
+		wantErr     error
+		wantVersion int64
+	}{
+		{name: "current version", version: 1, wantVersion: 2},
+		{name: "unconditional", version: 0, wantVersion: 2},
+		{name: "stale version", version: 5, wantErr: ErrVersionConflict, wantVersion: 1},
+		{name: "deleted", deleted: true, version: 1, wantErr: ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestFileStore(t, enum.Cascade)
+			seedLinked(t, store)
+
+			if tt.deleted {
+				if _, err := store.DeleteRequest("req-1"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			update := &api.Request{Id: "req-1", From: "USD", To: "GBP", Amount: money.NewFromInt(2), Version: tt.version}
+
+			if err := store.UpdateRequest(update); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdateRequest() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.deleted {
+				return
+			}
+
+			if got := store.GetRequestByID("req-1").Version; got != tt.wantVersion {
+				t.Errorf("stored version = %d, want %d", got, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestFileStore_DeletePolicy(t *testing.T) {
+	tests := []struct {
+		name         string
+		policy       string
+		delete       func(*FileStore) (Cascaded, error)
+		wantErr      error
+		wantCascaded []string
+		wantRequest  bool
+		wantResponse bool
+		wantLog      bool
+	}{
+		{
+			name:         "cascade request",
+			policy:       enum.Cascade,
+			delete:       func(s *FileStore) (Cascaded, error) { return s.DeleteRequest("req-1") },
+			wantCascaded: []string{"resp-1", "log-1"},
+		},
+		{
+			name:         "cascade response",
+			policy:       enum.Cascade,
+			delete:       func(s *FileStore) (Cascaded, error) { return s.DeleteResponse("resp-1") },
+			wantCascaded: []string{"log-1"},
+			wantRequest:  true,
+		},
+		{
+			name:         "restrict request",
+			policy:       enum.Restrict,
+			delete:       func(s *FileStore) (Cascaded, error) { return s.DeleteRequest("req-1") },
+			wantErr:      ErrReferenced,
+			wantRequest:  true,
+			wantResponse: true,
+			wantLog:      true,
+		},
+		{
+			name:         "restrict log",
+			policy:       enum.Restrict,
+			delete:       func(s *FileStore) (Cascaded, error) { return Cascaded{}, s.DeleteConversionLog("log-1") },
+			wantRequest:  true,
+			wantResponse: true,
+		},
+		{
+			name:         "missing",
+			policy:       enum.Cascade,
+			delete:       func(s *FileStore) (Cascaded, error) { return s.DeleteRequest("nope") },
+			wantErr:      ErrNotFound,
+			wantRequest:  true,
+			wantResponse: true,
+			wantLog:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestFileStore(t, tt.policy)
+			seedLinked(t, store)
+
+			cascaded, err := tt.delete(store)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("delete error = %v, want %v", err, tt.wantErr)
+			}
+
+			var cascadedIDs []string
+
+			for _, resp := range cascaded.Responses {
+				cascadedIDs = append(cascadedIDs, resp.Id)
+
+				if resp.DeletedAt != nil {
+					t.Errorf("cascaded response %s should be returned as it was before the delete", resp.Id)
+				}
+			}
+
+			for _, logItem := range cascaded.Logs {
+				cascadedIDs = append(cascadedIDs, logItem.Id)
+
+				if logItem.DeletedAt != nil {
+					t.Errorf("cascaded log %s should be returned as it was before the delete", logItem.Id)
+				}
+			}
+
+			if !slices.Equal(cascadedIDs, tt.wantCascaded) {
+				t.Errorf("cascaded = %v, want %v", cascadedIDs, tt.wantCascaded)
+			}
+
+			if got := store.GetRequestByID("req-1") != nil; got != tt.wantRequest {
+				t.Errorf("request live = %v, want %v", got, tt.wantRequest)
+			}
+
+			if got := store.GetResponseByID("resp-1") != nil; got != tt.wantResponse {
+				t.Errorf("response live = %v, want %v", got, tt.wantResponse)
+			}
+
+			if got := store.GetConversionLogByID("log-1") != nil; got != tt.wantLog {
+				t.Errorf("log live = %v, want %v", got, tt.wantLog)
+			}
+		})
+	}
+}
+
+func TestFileStore_DeleteKeepsReadRecords(t *testing.T) {
+	store := newTestFileStore(t, enum.Cascade)
+	seedLinked(t, store)
+
+	before := store.GetRequestByID("req-1")
+
+	if _, err := store.DeleteRequest("req-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if before.DeletedAt != nil {
+		t.Fatal("delete changed the request handed out before it")
+	}
+
+	deleted := store.GetAllRequests(true)[0]
+
+	if err := store.RestoreRequest("req-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if deleted.DeletedAt == nil {
+		t.Fatal("restore changed the deleted request handed out before it")
+	}
+}
+
+func TestFileStore_Restore(t *testing.T) {
+	tests := []struct {
+		name    string
+		restore func(*FileStore) error
+		wantErr error
+		wantIDs []string
+	}{
+		{
+			name:    "request",
+			restore: func(s *FileStore) error { return s.RestoreRequest("req-1") },
+			wantIDs: []string{"req-1"},
+		},
+		{
+			name:    "response before its request",
+			restore: func(s *FileStore) error { return s.RestoreResponse("resp-1") },
+			wantErr: ErrMissingReference,
+		},
+		{
+			name: "request, response and log in order",
+			restore: func(s *FileStore) error {
+				return errors.Join(s.RestoreRequest("req-1"), s.RestoreResponse("resp-1"), s.RestoreConversionLog("log-1"))
+			},
+			wantIDs: []string{"req-1", "resp-1", "log-1"},
+		},
+		{
+			name:    "missing",
+			restore: func(s *FileStore) error { return s.RestoreRequest("nope") },
+			wantErr: ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestFileStore(t, enum.Cascade)
+			seedLinked(t, store)
+
+			if _, err := store.DeleteRequest("req-1"); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tt.restore(store); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("restore error = %v, want %v", err, tt.wantErr)
+			}
+
+			live := map[string]bool{
+				"req-1":  store.GetRequestByID("req-1") != nil,
+				"resp-1": store.GetResponseByID("resp-1") != nil,
+				"log-1":  store.GetConversionLogByID("log-1") != nil,
+			}
+
+			for _, id := range tt.wantIDs {
+				if !live[id] {
+					t.Errorf("%s was not restored", id)
+				}
+
+				delete(live, id)
+			}
+
+			for id, restored := range live {
+				if restored {
+					t.Errorf("%s should still be deleted", id)
+				}
+			}
+		})
+	}
+}
+
+func TestFileStore_PurgeDeleted(t *testing.T) {
+	store := newTestFileStore(t, enum.Cascade)
+	seedLinked(t, store)
+
+	if _, err := store.DeleteRequest("req-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if purged, err := store.PurgeDeleted(time.Now().Add(-time.Hour)); err != nil || purged != 0 {
+		t.Fatalf("PurgeDeleted() before the deletes = %d, %v, want 0", purged, err)
+	}
+
+	if purged, err := store.PurgeDeleted(time.Now().Add(time.Hour)); err != nil || purged != 3 {
+		t.Fatalf("PurgeDeleted() after the deletes = %d, %v, want 3", purged, err)
+	}
+
+	if err := store.RestoreRequest("req-1"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("RestoreRequest() of a purged request = %v, want ErrNotFound", err)
+	}
+}
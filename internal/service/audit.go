@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/model/audit"
+	"github.com/M2rk13/Otus-327619/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// SystemActor is recorded for mutations that do not originate from an
+// authenticated API call, such as the ingestion pipeline or trash purging.
+const SystemActor = "system"
+
+type auditMetaKey struct{}
+
+type auditMeta struct {
+	actor     string
+	requestID string
+}
+
+// WithAuditMeta attaches the actor and request ID recorded in the audit trail
+// for mutations made with ctx.
+func WithAuditMeta(ctx context.Context, actor, requestID string) context.Context {
+	return context.WithValue(ctx, auditMetaKey{}, auditMeta{actor: actor, requestID: requestID})
+}
+
+func metaFrom(ctx context.Context) auditMeta {
+	meta, _ := ctx.Value(auditMetaKey{}).(auditMeta)
+
+	if meta.actor == "" {
+		meta.actor = SystemActor
+	}
+
+	return meta
+}
+
+type AuditService struct {
+	store repository.AuditStore
+}
+
+func NewAuditService(store repository.AuditStore) *AuditService {
+	return &AuditService{store: store}
+}
+
+func (a *AuditService) Find(filter audit.Filter) ([]*audit.Record, error) {
+	return a.store.Find(filter)
+}
+
+// Record writes an audit record for a mutation. before and after are the
+// item states around the change, either may be nil. A failure to record is
+// logged rather than returned: the mutation has already been stored.
+func (a *AuditService) Record(ctx context.Context, action, entity, id string, before, after any) {
+	meta := metaFrom(ctx)
+
+	rec := &audit.Record{
+		Id:        uuid.New().String(),
+		Timestamp: time.Now(),
+		Actor:     meta.actor,
+		RequestId: meta.requestID,
+		Action:    action,
+		Entity:    entity,
+		EntityId:  id,
+		Before:    snapshot(before),
+		After:     snapshot(after),
+	}
+
+	if rec.Before != nil && rec.After != nil {
+		rec.Changes = diff(rec.Before, rec.After)
+	}
+
+	if err := a.store.Append(rec); err != nil {
+		fmt.Printf("Failed to record audit %s on %s %s: %v\n", action, entity, id, err)
+	}
+}
+
+func snapshot(item any) json.RawMessage {
+	if value := reflect.ValueOf(item); !value.IsValid() || value.Kind() == reflect.Pointer && value.IsNil() {
+		return nil
+	}
+
+	data, err := json.Marshal(item)
+
+	if err != nil {
+		return nil
+	}
+
+	return data
+}
+
+// diff lists the leaf fields that differ between two JSON documents.
+func diff(before, after json.RawMessage) []audit.Change {
+	var oldDoc, newDoc any
+
+	if json.Unmarshal(before, &oldDoc) != nil || json.Unmarshal(after, &newDoc) != nil {
+		return nil
+	}
+
+	changes := make([]audit.Change, 0)
+	collectChanges("", oldDoc, newDoc, &changes)
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+
+	return changes
+}
+
+func collectChanges(path string, before, after any, changes *[]audit.Change) {
+	oldMap, oldIsMap := before.(map[string]any)
+	newMap, newIsMap := after.(map[string]any)
+
+	if !oldIsMap || !newIsMap {
+		if !reflect.DeepEqual(before, after) {
+			*changes = append(*changes, audit.Change{Field: path, Before: before, After: after})
+		}
+
+		return
+	}
+
+	for key, value := range oldMap {
+		collectChanges(path+"/"+key, value, newMap[key], changes)
+	}
+
+	for key, value := range newMap {
+		if _, ok := oldMap[key]; !ok {
+			collectChanges(path+"/"+key, nil, value, changes)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/enum"
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/audit"
+	"github.com/M2rk13/Otus-327619/internal/model/log"
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+	"github.com/M2rk13/Otus-327619/internal/repository"
+)
+
+func newTestAudit() *AuditService {
+	return NewAuditService(repository.NewMemoryAuditStore(time.Hour))
+}
+
+func TestAudit_RecordsMutations(t *testing.T) {
+	auditSvc := newTestAudit()
+	s := NewStorageService(NewMockRepository(), auditSvc, nil)
+	ctx := WithAuditMeta(context.Background(), "admin", "req-1")
+
+	req := &api.Request{From: "USD", To: "EUR", Amount: money.NewFromInt(10)}
+
+	if err := s.CreateRequest(ctx, req); err != nil {
+		t.Fatalf("CreateRequest: %v", err)
+	}
+
+	updated := &api.Request{Id: req.Id, From: "USD", To: "EUR", Amount: money.NewFromInt(20)}
+
+	if err := s.UpdateRequest(ctx, updated); err != nil {
+		t.Fatalf("UpdateRequest: %v", err)
+	}
+
+	if err := s.DeleteRequest(context.Background(), req.Id); err != nil {
+		t.Fatalf("DeleteRequest: %v", err)
+	}
+
+	records, _ := auditSvc.Find(audit.Filter{Entity: "request"})
+
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(records))
+	}
+
+	deleted, update, created := records[0], records[1], records[2]
+
+	if created.Action != enum.ActionCreate || created.Actor != "admin" || created.RequestId != "req-1" || created.After == nil {
+		t.Fatalf("unexpected create record: %+v", created)
+	}
+
+	if update.Action != enum.ActionUpdate || update.Before == nil || update.After == nil {
+		t.Fatalf("unexpected update record: %+v", update)
+	}
+
+	changed := map[string]bool{}
+
+	for _, change := range update.Changes {
+		changed[change.Field] = true
+	}
+
+	if !changed["/amount"] || !changed["/version"] || changed["/from"] {
+		t.Fatalf("unexpected changes: %+v", update.Changes)
+	}
+
+	if deleted.Action != enum.ActionDelete || deleted.Actor != SystemActor || deleted.Before == nil || deleted.After != nil {
+		t.Fatalf("unexpected delete record: %+v", deleted)
+	}
+}
+
+func TestAudit_Filter(t *testing.T) {
+	store := repository.NewMemoryAuditStore(time.Hour)
+	now := time.Now()
+
+	_ = store.Append(&audit.Record{Id: "1", Timestamp: now.Add(-time.Minute), Actor: "admin", Entity: "request"})
+	_ = store.Append(&audit.Record{Id: "2", Timestamp: now, Actor: "system", Entity: "response"})
+	_ = store.Append(&audit.Record{Id: "3", Timestamp: now, Actor: "admin", Entity: "response"})
+
+	tests := []struct {
+		name   string
+		filter audit.Filter
+		want   []string
+	}{
+		{"all", audit.Filter{}, []string{"3", "2", "1"}},
+		{"entity", audit.Filter{Entity: "response"}, []string{"3", "2"}},
+		{"actor", audit.Filter{Actor: "admin"}, []string{"3", "1"}},
+		{"time", audit.Filter{To: now.Add(-time.Second)}, []string{"1"}},
+		{"limit", audit.Filter{Limit: 1}, []string{"3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := store.Find(tt.filter)
+
+			if err != nil || len(records) != len(tt.want) {
+				t.Fatalf("Find: %v, %d records", err, len(records))
+			}
+
+			for i, rec := range records {
+				if rec.Id != tt.want[i] {
+					t.Fatalf("record %d: want %s, got %s", i, tt.want[i], rec.Id)
+				}
+			}
+		})
+	}
+}
+
+// cascadingRepository reports cascaded as deleted along with every request.
+type cascadingRepository struct {
+	*MockRepository
+	cascaded repository.Cascaded
+}
+
+func (r *cascadingRepository) DeleteRequest(id string) (repository.Cascaded, error) {
+	if _, err := r.MockRepository.DeleteRequest(id); err != nil {
+		return repository.Cascaded{}, err
+	}
+
+	return r.cascaded, nil
+}
+
+func TestAudit_RecordsCascadedDeletes(t *testing.T) {
+	auditSvc := newTestAudit()
+	req := &api.Request{Id: "req-1", From: "USD", To: "EUR", Amount: money.NewFromInt(10)}
+	resp := &api.Response{Id: "resp-1", Query: *req}
+	repo := &cascadingRepository{
+		MockRepository: NewMockRepository(),
+		cascaded: repository.Cascaded{
+			Responses: []*api.Response{resp},
+			Logs:      []*log.ConversionLog{log.NewConversionLog("log-1", *req, *resp)},
+		},
+	}
+	s := NewStorageService(repo, auditSvc, nil)
+
+	if err := s.CreateRequest(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.DeleteRequest(context.Background(), req.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, entity := range []string{requestEntity, responseEntity, logEntity} {
+		records, _ := auditSvc.Find(audit.Filter{Entity: entity})
+
+		if len(records) == 0 || records[0].Action != enum.ActionDelete || records[0].Before == nil {
+			t.Fatalf("expected a delete record of the %s with its state before, got %+v", entity, records)
+		}
+	}
+}
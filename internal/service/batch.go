@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/log"
+	"github.com/M2rk13/Otus-327619/internal/model/rate"
+
+	"github.com/google/uuid"
+)
+
+// MaxBatchItems is the largest number of conversions accepted in one batch.
+const MaxBatchItems = 100
+
+// pairRate is the outcome of quoting a pair, shared by the items of a batch.
+type pairRate struct {
+	quote *rate.Quote
+	err   error
+}
+
+// ConvertBatch converts every request like Convert and tags the stored logs
+// with a shared batch ID. Each distinct pair is quoted once for the whole
+// batch. Items fail independently: an invalid item or an unavailable rate is
+// reported on the item and does not stop the others.
+func (c *ConversionService) ConvertBatch(ctx context.Context, reqs []api.Request) (*log.Batch, error) {
+	if len(reqs) == 0 || len(reqs) > MaxBatchItems {
+		return nil, &ValidationError{Field: "items", Message: fmt.Sprintf("must contain 1 to %d items", MaxBatchItems)}
+	}
+
+	batch := &log.Batch{BatchId: uuid.New().String(), Items: make([]log.BatchItem, len(reqs))}
+	rates := make(map[rate.Pair]pairRate)
+
+	for i := range reqs {
+		convLog, err := c.convertBatchItem(ctx, &reqs[i], rates, batch.BatchId)
+
+		if err != nil {
+			batch.Items[i].Error = err.Error()
+			batch.Failed++
+
+			continue
+		}
+
+		batch.Items[i].Log = convLog
+		batch.Converted++
+	}
+
+	return batch, nil
+}
+
+func (c *ConversionService) convertBatchItem(ctx context.Context, req *api.Request, rates map[rate.Pair]pairRate, batchId string) (*log.ConversionLog, error) {
+	rounding, err := c.prepareRequest(ctx, req, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	pair := rate.Pair{From: req.From, To: req.To}
+	mid, seen := rates[pair]
+
+	if !seen {
+		mid.quote, mid.err = c.rates.Rate(ctx, pair.From, pair.To)
+		rates[pair] = mid
+	}
+
+	if mid.err != nil {
+		return nil, mid.err
+	}
+
+	q, err := c.priceAt(ctx, req, rounding, mid.quote)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return c.execute(ctx, q, batchId)
+}
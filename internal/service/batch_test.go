@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/fee"
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+)
+
+func TestConversionService_ConvertBatch(t *testing.T) {
+	storage := NewStorageService(NewMockRepository(), newTestAudit(), nil)
+	provider := &countingProvider{}
+	conversion := NewConversionService(provider, storage, NewCurrencyRegistry(ISO4217), newTestRounding(t), NewFeeEngine(fee.Schedules{}), testQuoteTTL)
+
+	batch, err := conversion.ConvertBatch(context.Background(), []api.Request{
+		{From: "USD", To: "EUR", Amount: money.NewFromInt(10)},
+		{From: "usd", To: "gbp", Amount: money.NewFromInt(20)},
+		{From: "USD", To: "XYZ", Amount: money.NewFromInt(30)},
+		{From: "USD", To: "EUR", Amount: money.NewFromInt(40)},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if batch.BatchId == "" || batch.Converted != 3 || batch.Failed != 1 || len(batch.Items) != 4 {
+		t.Fatalf("unexpected batch %+v", batch)
+	}
+
+	if calls := provider.calls.Load(); calls != 2 {
+		t.Fatalf("each distinct pair should be quoted once, got %d calls", calls)
+	}
+
+	if item := batch.Items[2]; item.Log != nil || !strings.Contains(item.Error, "to") {
+		t.Fatalf("invalid item should carry its error, got %+v", item)
+	}
+
+	first, last := batch.Items[0].Log, batch.Items[3].Log
+
+	if !first.Response.Info.Quote.Equal(last.Response.Info.Quote) || last.Response.Result.String() != "40" {
+		t.Fatalf("items of a pair should share the quote: %+v / %+v", first.Response, last.Response)
+	}
+
+	for _, i := range []int{0, 1, 3} {
+		stored := storage.GetConversionLogByID(batch.Items[i].Log.Id)
+
+		if stored == nil || stored.BatchId != batch.BatchId {
+			t.Fatalf("item %d should be stored with the batch id: %+v", i, stored)
+		}
+	}
+}
+
+func TestConversionService_ConvertBatchLimits(t *testing.T) {
+	storage := NewStorageService(NewMockRepository(), newTestAudit(), nil)
+	conversion := NewConversionService(&countingProvider{}, storage, NewCurrencyRegistry(ISO4217), newTestRounding(t), NewFeeEngine(fee.Schedules{}), testQuoteTTL)
+
+	var validationErr *ValidationError
+
+	for _, size := range []int{0, MaxBatchItems + 1} {
+		if _, err := conversion.ConvertBatch(context.Background(), make([]api.Request, size)); !errors.As(err, &validationErr) {
+			t.Fatalf("batch of %d items should be rejected, got %v", size, err)
+		}
+	}
+}
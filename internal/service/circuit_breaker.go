@@ -0,0 +1,115 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/enum"
+	"github.com/M2rk13/Otus-327619/internal/model/rate"
+)
+
+// CircuitBreaker stops calls to a failing dependency. After threshold
+// consecutive failures it opens and rejects calls for cooldown, then lets a
+// single trial call through: success closes it again, failure reopens it.
+type CircuitBreaker struct {
+	mu          sync.Mutex
+	threshold   int
+	cooldown    time.Duration
+	state       string
+	failures    int
+	openedAt    time.Time
+	trialActive bool
+	lastError   string
+	lastSuccess time.Time
+	lastFailure time.Time
+}
+
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{threshold: threshold, cooldown: cooldown, state: enum.BreakerClosed}
+}
+
+// Allow reports whether a call may be made now.
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case enum.BreakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+
+		b.state = enum.BreakerHalfOpen
+		b.trialActive = true
+
+		return true
+	case enum.BreakerHalfOpen:
+		if b.trialActive {
+			return false
+		}
+
+		b.trialActive = true
+
+		return true
+	default:
+		return true
+	}
+}
+
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = enum.BreakerClosed
+	b.failures = 0
+	b.trialActive = false
+	b.lastSuccess = time.Now()
+}
+
+func (b *CircuitBreaker) Failure(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.trialActive = false
+	b.lastError = err.Error()
+	b.lastFailure = time.Now()
+
+	if b.state == enum.BreakerHalfOpen || b.failures >= b.threshold {
+		b.state = enum.BreakerOpen
+		b.openedAt = b.lastFailure
+	}
+}
+
+// Abandon is called when an allowed call was cancelled by the caller and
+// says nothing about the dependency.
+func (b *CircuitBreaker) Abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trialActive = false
+}
+
+func (b *CircuitBreaker) Health(name string) rate.ProviderHealth {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	health := rate.ProviderHealth{
+		Name:                name,
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		LastError:           b.lastError,
+	}
+
+	if !b.lastSuccess.IsZero() {
+		lastSuccess := b.lastSuccess
+		health.LastSuccess = &lastSuccess
+	}
+
+	if !b.lastFailure.IsZero() {
+		lastFailure := b.lastFailure
+		health.LastFailure = &lastFailure
+	}
+
+	return health
+}
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/log"
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+	"github.com/M2rk13/Otus-327619/internal/model/quote"
+	"github.com/M2rk13/Otus-327619/internal/model/rate"
+	"github.com/M2rk13/Otus-327619/internal/repository"
+)
+
+// ConvertOptions are per-call settings of a conversion.
+type ConvertOptions struct {
+	// Rounding overrides the rounding policy of the account.
+	Rounding *money.RoundingPolicy
+	// QuoteId executes a quote locked with Quote instead of pricing the
+	// request at the current rate.
+	QuoteId string
+}
+
+// ConversionService converts amounts using the configured rate provider and
+// stores the request, the response and a log linking the two. The provider
+// quote is the mid rate; the fee schedule of the account sets the margin.
+// Prices can be locked as quotes that are executed later, once, at the locked
+// rate.
+type ConversionService struct {
+	rates      RateProvider
+	storage    *StorageService
+	currencies *CurrencyRegistry
+	rounding   *RoundingPolicies
+	fees       *FeeEngine
+	quoteTTL   time.Duration
+}
+
+func NewConversionService(
+	rates RateProvider,
+	storage *StorageService,
+	currencies *CurrencyRegistry,
+	rounding *RoundingPolicies,
+	fees *FeeEngine,
+	quoteTTL time.Duration,
+) *ConversionService {
+	return &ConversionService{
+		rates:      rates,
+		storage:    storage,
+		currencies: currencies,
+		rounding:   rounding,
+		fees:       fees,
+		quoteTTL:   quoteTTL,
+	}
+}
+
+func (c *ConversionService) Convert(ctx context.Context, req *api.Request, opts ConvertOptions) (*log.ConversionLog, error) {
+	if opts.QuoteId != "" {
+		return c.convertQuote(ctx, req, opts)
+	}
+
+	q, err := c.priceRequest(ctx, req, opts.Rounding)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return c.execute(ctx, q, "")
+}
+
+// Quote prices the request like Convert and stores the result as a quote of
+// the caller that can be executed once until it expires.
+func (c *ConversionService) Quote(ctx context.Context, req *api.Request, rounding *money.RoundingPolicy) (*quote.Quote, error) {
+	q, err := c.priceRequest(ctx, req, rounding)
+
+	if err != nil {
+		return nil, err
+	}
+
+	q.Account = metaFrom(ctx).actor
+	q.CreatedAt = time.Now()
+	q.ExpiresAt = q.CreatedAt.Add(c.quoteTTL)
+
+	if err = c.storage.CreateQuote(ctx, q); err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+// convertQuote executes a stored quote. Fields of the request that are set
+// must match the quote; a quote of another account is treated as unknown.
+func (c *ConversionService) convertQuote(ctx context.Context, req *api.Request, opts ConvertOptions) (*log.ConversionLog, error) {
+	if opts.Rounding != nil {
+		return nil, &ValidationError{Field: "rounding", Message: "is fixed by the quote"}
+	}
+
+	q := c.storage.GetQuoteByID(opts.QuoteId)
+
+	if q == nil || q.Account != metaFrom(ctx).actor {
+		return nil, repository.ErrNotFound
+	}
+
+	if err := matchQuote(req, q); err != nil {
+		return nil, err
+	}
+
+	q, err := c.storage.UseQuote(ctx, q.Id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return c.execute(ctx, q, "")
+}
+
+func matchQuote(req *api.Request, q *quote.Quote) error {
+	if from := strings.TrimSpace(req.From); from != "" && !strings.EqualFold(from, q.From) {
+		return &ValidationError{Field: "from", Message: fmt.Sprintf("does not match the quote (%s)", q.From)}
+	}
+
+	if to := strings.TrimSpace(req.To); to != "" && !strings.EqualFold(to, q.To) {
+		return &ValidationError{Field: "to", Message: fmt.Sprintf("does not match the quote (%s)", q.To)}
+	}
+
+	if !req.Amount.IsZero() && !req.Amount.Equal(q.Amount) {
+		return &ValidationError{Field: "amount", Message: fmt.Sprintf("does not match the quote (%s)", q.Amount)}
+	}
+
+	return nil
+}
+
+// priceRequest validates the request and prices it at the current rate. The
+// returned quote is not stored.
+func (c *ConversionService) priceRequest(ctx context.Context, req *api.Request, requested *money.RoundingPolicy) (*quote.Quote, error) {
+	rounding, err := c.prepareRequest(ctx, req, requested)
+
+	if err != nil {
+		return nil, err
+	}
+
+	mid, err := c.rates.Rate(ctx, req.From, req.To)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return c.priceAt(ctx, req, rounding, mid)
+}
+
+// prepareRequest normalises and validates the request and resolves the
+// rounding policy it is priced with.
+func (c *ConversionService) prepareRequest(ctx context.Context, req *api.Request, requested *money.RoundingPolicy) (money.RoundingPolicy, error) {
+	req.From = strings.ToUpper(strings.TrimSpace(req.From))
+	req.To = strings.ToUpper(strings.TrimSpace(req.To))
+
+	if err := validateRequest("", req); err != nil {
+		return money.RoundingPolicy{}, err
+	}
+
+	if err := c.currencies.ValidateRequest("", req); err != nil {
+		return money.RoundingPolicy{}, err
+	}
+
+	return c.roundingPolicy(ctx, req.To, requested)
+}
+
+// priceAt prices a prepared request at the given provider rate.
+func (c *ConversionService) priceAt(ctx context.Context, req *api.Request, rounding money.RoundingPolicy, mid *rate.Quote) (*quote.Quote, error) {
+	fees, err := c.price(ctx, req, mid.Rate, rounding)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &quote.Quote{
+		From:     req.From,
+		To:       req.To,
+		Amount:   req.Amount,
+		Rate:     fees.AppliedRate,
+		Result:   fees.NetResult,
+		Fees:     fees,
+		Rounding: rounding,
+		Provider: mid.Provider,
+		Legs:     mid.Legs,
+		QuotedAt: mid.Timestamp,
+	}, nil
+}
+
+// execute stores the request, response and log of a priced conversion. The
+// log is tagged with batchId, which is empty outside of a batch.
+func (c *ConversionService) execute(ctx context.Context, q *quote.Quote, batchId string) (*log.ConversionLog, error) {
+	req, resp, err := c.storePair(ctx, q)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rounding := q.Rounding
+	convLog := log.NewConversionLog("", *req, *resp)
+	convLog.Rounding = &rounding
+	convLog.BatchId = batchId
+
+	if err = c.storage.CreateConversionLog(ctx, convLog); err != nil {
+		return nil, err
+	}
+
+	return convLog, nil
+}
+
+// storePair stores the request and response of a priced conversion.
+func (c *ConversionService) storePair(ctx context.Context, q *quote.Quote) (*api.Request, *api.Response, error) {
+	req := quoteRequest(q)
+
+	if err := c.storage.CreateRequest(ctx, req); err != nil {
+		return nil, nil, err
+	}
+
+	resp := quoteResponse(q, req)
+
+	if err := c.storage.CreateResponse(ctx, resp); err != nil {
+		return nil, nil, err
+	}
+
+	return req, resp, nil
+}
+
+func quoteRequest(q *quote.Quote) *api.Request {
+	return &api.Request{From: q.From, To: q.To, Amount: q.Amount}
+}
+
+// quoteResponse is the response of q to the stored req.
+func quoteResponse(q *quote.Quote, req *api.Request) *api.Response {
+	fees := q.Fees
+
+	return &api.Response{
+		Success: true,
+		Query:   *req,
+		Info: api.Info{
+			Timestamp: q.QuotedAt.Unix(),
+			Quote:     q.Rate,
+			Provider:  q.Provider,
+			Legs:      q.Legs,
+		},
+		Result: q.Result,
+		Fees:   &fees,
+	}
+}
+
+// price applies the fee schedule of the caller to the mid rate. The fixed fee
+// is taken from the amount before it is converted at the applied rate.
+func (c *ConversionService) price(ctx context.Context, req *api.Request, mid money.Decimal, rounding money.RoundingPolicy) (api.Fees, error) {
+	fees := c.fees.Price(metaFrom(ctx).actor, req.From, req.Amount, mid)
+	net := req.Amount.Sub(fees.Fee)
+
+	if net.IsNegative() {
+		return fees, &ValidationError{Field: "amount", Message: fmt.Sprintf("must cover the fee of %s %s", fees.Fee, fees.FeeCurrency)}
+	}
+
+	var err error
+
+	if fees.GrossResult, err = req.Amount.Mul(mid).RoundMode(*rounding.Scale, rounding.Mode); err != nil {
+		return fees, err
+	}
+
+	if fees.NetResult, err = net.Mul(fees.AppliedRate).RoundMode(*rounding.Scale, rounding.Mode); err != nil {
+		return fees, err
+	}
+
+	return fees, nil
+}
+
+// roundingPolicy resolves the policy for the caller and fills in the scale
+// from the minor units of the target currency if it is not set.
+func (c *ConversionService) roundingPolicy(ctx context.Context, to string, requested *money.RoundingPolicy) (money.RoundingPolicy, error) {
+	policy := c.rounding.Resolve(metaFrom(ctx).actor, requested)
+
+	// Configured policies are checked at startup, so an invalid one here came
+	// with the request.
+	if err := validateRounding("rounding.", policy); err != nil {
+		return money.RoundingPolicy{}, err
+	}
+
+	if policy.Scale == nil {
+		target, _ := c.currencies.Lookup(to)
+		scale := int32(target.MinorUnits)
+		policy.Scale = &scale
+	}
+
+	return policy, nil
+}
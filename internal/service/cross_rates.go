@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/rate"
+)
+
+// maxLegs bounds the length of a derived path. Every leg adds the provider's
+// spread, so long chains are worse than no quote at all.
+const maxLegs = 3
+
+// CrossRateProvider quotes pairs the wrapped provider does not offer
+// directly. If the provider lists its pairs, the path with the fewest legs
+// through that graph is used; otherwise the pair is triangulated through the
+// base currency.
+type CrossRateProvider struct {
+	provider RateProvider
+	base     string
+}
+
+func NewCrossRateProvider(provider RateProvider, base string) *CrossRateProvider {
+	return &CrossRateProvider{provider: provider, base: base}
+}
+
+func (c *CrossRateProvider) Name() string {
+	return c.provider.Name()
+}
+
+func (c *CrossRateProvider) Rate(ctx context.Context, from, to string) (*rate.Quote, error) {
+	return c.resolve(ctx, from, to, func(from, to string) (*rate.Quote, error) {
+		return c.provider.Rate(ctx, from, to)
+	})
+}
+
+// RateAt derives historical rates the same way, if the wrapped provider has
+// them.
+func (c *CrossRateProvider) RateAt(ctx context.Context, from, to string, date time.Time) (*rate.Quote, error) {
+	historical, ok := c.provider.(HistoricalRateProvider)
+
+	if !ok {
+		return nil, ErrRateUnavailable
+	}
+
+	return c.resolve(ctx, from, to, func(from, to string) (*rate.Quote, error) {
+		return historical.RateAt(ctx, from, to, date)
+	})
+}
+
+func (c *CrossRateProvider) resolve(ctx context.Context, from, to string, fetch rateFetcher) (*rate.Quote, error) {
+	quote, err := fetch(from, to)
+
+	if !errors.Is(err, ErrRateUnavailable) {
+		return quote, err
+	}
+
+	for _, path := range c.paths(ctx, from, to) {
+		if quote, err = derive(path, fetch); !errors.Is(err, ErrRateUnavailable) {
+			return quote, err
+		}
+	}
+
+	return nil, ErrRateUnavailable
+}
+
+// paths returns candidate chains of currencies from `from` to `to`, best
+// first.
+func (c *CrossRateProvider) paths(ctx context.Context, from, to string) [][]string {
+	var candidates [][]string
+
+	if lister, ok := c.provider.(PairLister); ok {
+		if pairs, err := lister.Pairs(ctx); err == nil && len(pairs) > 0 {
+			if path := shortestPath(pairs, from, to); path != nil {
+				candidates = append(candidates, path)
+			}
+		}
+	}
+
+	if c.base != "" && from != c.base && to != c.base {
+		candidates = append(candidates, []string{from, c.base, to})
+	}
+
+	return candidates
+}
+
+type rateFetcher func(from, to string) (*rate.Quote, error)
+
+func derive(path []string, fetch rateFetcher) (*rate.Quote, error) {
+	derived := &rate.Quote{From: path[0], To: path[len(path)-1], Rate: one}
+	providers := make([]string, 0, len(path)-1)
+
+	for i := 0; i+1 < len(path); i++ {
+		leg, err := fetch(path[i], path[i+1])
+
+		if err != nil {
+			return nil, err
+		}
+
+		derived.Rate = derived.Rate.Mul(leg.Rate)
+		derived.Legs = append(derived.Legs, api.Leg{From: leg.From, To: leg.To, Rate: leg.Rate, Provider: leg.Provider})
+		derived.Stale = derived.Stale || leg.Stale
+
+		if derived.Timestamp.IsZero() || leg.Timestamp.Before(derived.Timestamp) {
+			derived.Timestamp = leg.Timestamp
+		}
+
+		if !contains(providers, leg.Provider) {
+			providers = append(providers, leg.Provider)
+		}
+	}
+
+	derived.Provider = strings.Join(providers, ",")
+
+	return derived, nil
+}
+
+// shortestPath finds the path with the fewest legs using breadth-first
+// search. Neighbours are visited in sorted order so the result is stable.
+func shortestPath(pairs []rate.Pair, from, to string) []string {
+	graph := make(map[string][]string)
+
+	for _, pair := range pairs {
+		graph[pair.From] = append(graph[pair.From], pair.To)
+	}
+
+	for _, neighbours := range graph {
+		sort.Strings(neighbours)
+	}
+
+	previous := map[string]string{from: ""}
+	frontier := []string{from}
+
+	for depth := 0; depth < maxLegs && len(frontier) > 0; depth++ {
+		var next []string
+
+		for _, node := range frontier {
+			for _, neighbour := range graph[node] {
+				if _, seen := previous[neighbour]; seen {
+					continue
+				}
+
+				previous[neighbour] = node
+
+				if neighbour == to {
+					return buildPath(previous, from, to)
+				}
+
+				next = append(next, neighbour)
+			}
+		}
+
+		frontier = next
+	}
+
+	return nil
+}
+
+func buildPath(previous map[string]string, from, to string) []string {
+	path := []string{to}
+
+	for node := to; node != from; {
+		node = previous[node]
+		path = append([]string{node}, path...)
+	}
+
+	return path
+}
+
+func contains(items []string, item string) bool {
+	for _, existing := range items {
+		if existing == item {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/fee"
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+)
+
+// unlistedProvider hides the pair list of the wrapped provider.
+type unlistedProvider struct {
+	RateProvider
+}
+
+func TestCrossRate_ShortestPath(t *testing.T) {
+	provider := NewStaticRateProvider(map[string]money.Decimal{
+		"USD/GBP": money.MustParse("0.8"),
+		"USD/JPY": money.MustParse("150"),
+		"JPY/CHF": money.MustParse("0.006"),
+	})
+	cross := NewCrossRateProvider(provider, "")
+
+	quote, err := cross.Rate(context.Background(), "GBP", "CHF")
+
+	if err != nil {
+		t.Fatalf("Rate: %v", err)
+	}
+
+	want := []api.Leg{
+		{From: "GBP", To: "USD", Rate: money.MustParse("1.25"), Provider: "static"},
+		{From: "USD", To: "JPY", Rate: money.MustParse("150"), Provider: "static"},
+		{From: "JPY", To: "CHF", Rate: money.MustParse("0.006"), Provider: "static"},
+	}
+
+	if len(quote.Legs) != len(want) {
+		t.Fatalf("expected %d legs, got %+v", len(want), quote.Legs)
+	}
+
+	for i := range want {
+		got := quote.Legs[i]
+
+		if got.From != want[i].From || got.To != want[i].To || !got.Rate.Equal(want[i].Rate) || got.Provider != want[i].Provider {
+			t.Fatalf("leg %d: want %+v, got %+v", i, want[i], quote.Legs[i])
+		}
+	}
+
+	// The product of the legs, computed exactly.
+	if !quote.Rate.Equal(money.MustParse("1.125")) {
+		t.Fatalf("unexpected rate %s", quote.Rate)
+	}
+}
+
+func TestCrossRate_BaseCurrency(t *testing.T) {
+	provider := unlistedProvider{NewStaticRateProvider(map[string]money.Decimal{"USD/GBP": money.MustParse("0.8"), "USD/JPY": money.MustParse("150")})}
+	cross := NewCrossRateProvider(provider, "USD")
+
+	quote, err := cross.Rate(context.Background(), "GBP", "JPY")
+
+	if err != nil || len(quote.Legs) != 2 || quote.Legs[0].To != "USD" {
+		t.Fatalf("expected triangulation through USD, got %+v, %v", quote, err)
+	}
+
+	direct, _ := cross.Rate(context.Background(), "USD", "JPY")
+
+	if len(direct.Legs) != 0 {
+		t.Fatal("a direct quote should have no legs")
+	}
+
+	if _, err = cross.Rate(context.Background(), "GBP", "CHF"); !errors.Is(err, ErrRateUnavailable) {
+		t.Fatalf("expected ErrRateUnavailable, got %v", err)
+	}
+}
+
+func TestConversionService_Convert(t *testing.T) {
+	storage := NewStorageService(NewMockRepository(), newTestAudit(), nil)
+	provider := NewStaticRateProvider(map[string]money.Decimal{"USD/GBP": money.MustParse("0.8"), "USD/JPY": money.MustParse("150")})
+	conversion := NewConversionService(NewCrossRateProvider(provider, "USD"), storage, NewCurrencyRegistry(ISO4217), newTestRounding(t), NewFeeEngine(fee.Schedules{}), testQuoteTTL)
+
+	convLog, err := conversion.Convert(context.Background(), &api.Request{From: "gbp", To: "jpy", Amount: money.NewFromInt(10)}, ConvertOptions{})
+
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+
+	stored := storage.GetExpandedConversionLog(convLog.Id)
+
+	if stored == nil || stored.Request == nil || stored.Response == nil {
+		t.Fatal("conversion should store the request, response and log")
+	}
+
+	if stored.Request.From != "GBP" || len(stored.Response.Info.Legs) != 2 || stored.Response.Info.Provider != "static" {
+		t.Fatalf("unexpected stored response: %+v", stored.Response)
+	}
+
+	if !stored.Response.Result.Equal(money.NewFromInt(1875)) {
+		t.Fatalf("unexpected result %v", stored.Response.Result)
+	}
+
+	_, err = conversion.Convert(context.Background(), &api.Request{From: "USD", To: "", Amount: money.NewFromInt(1)}, ConvertOptions{})
+
+	var validationErr *ValidationError
+
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/currency"
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+)
+
+// CurrencyRegistry knows which currency codes exist and which of them can be
+// used in new requests.
+type CurrencyRegistry struct {
+	currencies map[string]currency.Currency
+}
+
+func NewCurrencyRegistry(currencies []currency.Currency) *CurrencyRegistry {
+	r := &CurrencyRegistry{currencies: make(map[string]currency.Currency, len(currencies))}
+
+	for _, c := range currencies {
+		r.currencies[c.Code] = c
+	}
+
+	return r
+}
+
+func (r *CurrencyRegistry) Lookup(code string) (currency.Currency, bool) {
+	c, ok := r.currencies[strings.ToUpper(strings.TrimSpace(code))]
+
+	return c, ok
+}
+
+// List returns the currencies sorted by code. Withdrawn currencies are left
+// out unless includeInactive is set.
+func (r *CurrencyRegistry) List(includeInactive bool) []currency.Currency {
+	list := make([]currency.Currency, 0, len(r.currencies))
+
+	for _, c := range r.currencies {
+		if c.Active || includeInactive {
+			list = append(list, c)
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Code < list[j].Code })
+
+	return list
+}
+
+// Validate checks that code is a known, active currency.
+func (r *CurrencyRegistry) Validate(field, code string) error {
+	c, ok := r.Lookup(code)
+
+	if !ok {
+		return &ValidationError{Field: field, Message: fmt.Sprintf("unknown currency %q", code)}
+	}
+
+	if !c.Active {
+		return &ValidationError{Field: field, Message: fmt.Sprintf("currency %s is no longer in use", c.Code)}
+	}
+
+	return nil
+}
+
+// ValidateRequest checks both currencies of a request and that the amount
+// fits the minor units of the source currency.
+func (r *CurrencyRegistry) ValidateRequest(prefix string, req *api.Request) error {
+	if err := r.Validate(prefix+"from", req.From); err != nil {
+		return err
+	}
+
+	if err := r.Validate(prefix+"to", req.To); err != nil {
+		return err
+	}
+
+	if from, _ := r.Lookup(req.From); req.Amount.Places() > int32(from.MinorUnits) {
+		return &ValidationError{
+			Field:   prefix + "amount",
+			Message: fmt.Sprintf("%s allows at most %d decimal places", from.Code, from.MinorUnits),
+		}
+	}
+
+	return nil
+}
+
+// NormalizeRequest upper-cases the currency codes of req and validates it
+// with ValidateRequest. A request embedded in a response or log may be left
+// empty and is then not checked.
+func (r *CurrencyRegistry) NormalizeRequest(prefix string, req *api.Request, embedded bool) error {
+	if embedded && req.From == "" && req.To == "" {
+		return nil
+	}
+
+	req.From = strings.ToUpper(strings.TrimSpace(req.From))
+	req.To = strings.ToUpper(strings.TrimSpace(req.To))
+
+	return r.ValidateRequest(prefix, req)
+}
+
+// Round rounds value to the minor units of the currency. Values in unknown
+// currencies are returned unchanged.
+func (r *CurrencyRegistry) Round(code string, value money.Decimal) money.Decimal {
+	c, ok := r.Lookup(code)
+
+	if !ok {
+		return value
+	}
+
+	return value.Round(int32(c.MinorUnits))
+}
@@ -0,0 +1,173 @@
+package service
+
+import "github.com/M2rk13/Otus-327619/internal/model/currency"
+
+// ISO4217 lists the currencies of ISO 4217 that can be converted, followed by
+// recently withdrawn ones that may still appear in stored records.
+var ISO4217 = []currency.Currency{
+	{Code: "AED", Numeric: "784", MinorUnits: 2, Name: "UAE Dirham", Active: true},
+	{Code: "AFN", Numeric: "971", MinorUnits: 2, Name: "Afghani", Active: true},
+	{Code: "ALL", Numeric: "008", MinorUnits: 2, Name: "Lek", Active: true},
+	{Code: "AMD", Numeric: "051", MinorUnits: 2, Name: "Armenian Dram", Active: true},
+	{Code: "ANG", Numeric: "532", MinorUnits: 2, Name: "Netherlands Antillean Guilder", Active: true},
+	{Code: "AOA", Numeric: "973", MinorUnits: 2, Name: "Kwanza", Active: true},
+	{Code: "ARS", Numeric: "032", MinorUnits: 2, Name: "Argentine Peso", Active: true},
+	{Code: "AUD", Numeric: "036", MinorUnits: 2, Name: "Australian Dollar", Active: true},
+	{Code: "AWG", Numeric: "533", MinorUnits: 2, Name: "Aruban Florin", Active: true},
+	{Code: "AZN", Numeric: "944", MinorUnits: 2, Name: "Azerbaijan Manat", Active: true},
+	{Code: "BAM", Numeric: "977", MinorUnits: 2, Name: "Convertible Mark", Active: true},
+	{Code: "BBD", Numeric: "052", MinorUnits: 2, Name: "Barbados Dollar", Active: true},
+	{Code: "BDT", Numeric: "050", MinorUnits: 2, Name: "Taka", Active: true},
+	{Code: "BGN", Numeric: "975", MinorUnits: 2, Name: "Bulgarian Lev", Active: true},
+	{Code: "BHD", Numeric: "048", MinorUnits: 3, Name: "Bahraini Dinar", Active: true},
+	{Code: "BIF", Numeric: "108", MinorUnits: 0, Name: "Burundi Franc", Active: true},
+	{Code: "BMD", Numeric: "060", MinorUnits: 2, Name: "Bermudian Dollar", Active: true},
+	{Code: "BND", Numeric: "096", MinorUnits: 2, Name: "Brunei Dollar", Active: true},
+	{Code: "BOB", Numeric: "068", MinorUnits: 2, Name: "Boliviano", Active: true},
+	{Code: "BRL", Numeric: "986", MinorUnits: 2, Name: "Brazilian Real", Active: true},
+	{Code: "BSD", Numeric: "044", MinorUnits: 2, Name: "Bahamian Dollar", Active: true},
+	{Code: "BTN", Numeric: "064", MinorUnits: 2, Name: "Ngultrum", Active: true},
+	{Code: "BWP", Numeric: "072", MinorUnits: 2, Name: "Pula", Active: true},
+	{Code: "BYN", Numeric: "933", MinorUnits: 2, Name: "Belarusian Ruble", Active: true},
+	{Code: "BZD", Numeric: "084", MinorUnits: 2, Name: "Belize Dollar", Active: true},
+	{Code: "CAD", Numeric: "124", MinorUnits: 2, Name: "Canadian Dollar", Active: true},
+	{Code: "CDF", Numeric: "976", MinorUnits: 2, Name: "Congolese Franc", Active: true},
+	{Code: "CHF", Numeric: "756", MinorUnits: 2, Name: "Swiss Franc", Active: true},
+	{Code: "CLP", Numeric: "152", MinorUnits: 0, Name: "Chilean Peso", Active: true},
+	{Code: "CNY", Numeric: "156", MinorUnits: 2, Name: "Yuan Renminbi", Active: true},
+	{Code: "COP", Numeric: "170", MinorUnits: 2, Name: "Colombian Peso", Active: true},
+	{Code: "CRC", Numeric: "188", MinorUnits: 2, Name: "Costa Rican Colon", Active: true},
+	{Code: "CUP", Numeric: "192", MinorUnits: 2, Name: "Cuban Peso", Active: true},
+	{Code: "CVE", Numeric: "132", MinorUnits: 2, Name: "Cabo Verde Escudo", Active: true},
+	{Code: "CZK", Numeric: "203", MinorUnits: 2, Name: "Czech Koruna", Active: true},
+	{Code: "DJF", Numeric: "262", MinorUnits: 0, Name: "Djibouti Franc", Active: true},
+	{Code: "DKK", Numeric: "208", MinorUnits: 2, Name: "Danish Krone", Active: true},
+	{Code: "DOP", Numeric: "214", MinorUnits: 2, Name: "Dominican Peso", Active: true},
+	{Code: "DZD", Numeric: "012", MinorUnits: 2, Name: "Algerian Dinar", Active: true},
+	{Code: "EGP", Numeric: "818", MinorUnits: 2, Name: "Egyptian Pound", Active: true},
+	{Code: "ERN", Numeric: "232", MinorUnits: 2, Name: "Nakfa", Active: true},
+	{Code: "ETB", Numeric: "230", MinorUnits: 2, Name: "Ethiopian Birr", Active: true},
+	{Code: "EUR", Numeric: "978", MinorUnits: 2, Name: "Euro", Active: true},
+	{Code: "FJD", Numeric: "242", MinorUnits: 2, Name: "Fiji Dollar", Active: true},
+	{Code: "FKP", Numeric: "238", MinorUnits: 2, Name: "Falkland Islands Pound", Active: true},
+	{Code: "GBP", Numeric: "826", MinorUnits: 2, Name: "Pound Sterling", Active: true},
+	{Code: "GEL", Numeric: "981", MinorUnits: 2, Name: "Lari", Active: true},
+	{Code: "GHS", Numeric: "936", MinorUnits: 2, Name: "Ghana Cedi", Active: true},
+	{Code: "GIP", Numeric: "292", MinorUnits: 2, Name: "Gibraltar Pound", Active: true},
+	{Code: "GMD", Numeric: "270", MinorUnits: 2, Name: "Dalasi", Active: true},
+	{Code: "GNF", Numeric: "324", MinorUnits: 0, Name: "Guinean Franc", Active: true},
+	{Code: "GTQ", Numeric: "320", MinorUnits: 2, Name: "Quetzal", Active: true},
+	{Code: "GYD", Numeric: "328", MinorUnits: 2, Name: "Guyana Dollar", Active: true},
+	{Code: "HKD", Numeric: "344", MinorUnits: 2, Name: "Hong Kong Dollar", Active: true},
+	{Code: "HNL", Numeric: "340", MinorUnits: 2, Name: "Lempira", Active: true},
+	{Code: "HTG", Numeric: "332", MinorUnits: 2, Name: "Gourde", Active: true},
+	{Code: "HUF", Numeric: "348", MinorUnits: 2, Name: "Forint", Active: true},
+	{Code: "IDR", Numeric: "360", MinorUnits: 2, Name: "Rupiah", Active: true},
+	{Code: "ILS", Numeric: "376", MinorUnits: 2, Name: "New Israeli Sheqel", Active: true},
+	{Code: "INR", Numeric: "356", MinorUnits: 2, Name: "Indian Rupee", Active: true},
+	{Code: "IQD", Numeric: "368", MinorUnits: 3, Name: "Iraqi Dinar", Active: true},
+	{Code: "IRR", Numeric: "364", MinorUnits: 2, Name: "Iranian Rial", Active: true},
+	{Code: "ISK", Numeric: "352", MinorUnits: 0, Name: "Iceland Krona", Active: true},
+	{Code: "JMD", Numeric: "388", MinorUnits: 2, Name: "Jamaican Dollar", Active: true},
+	{Code: "JOD", Numeric: "400", MinorUnits: 3, Name: "Jordanian Dinar", Active: true},
+	{Code: "JPY", Numeric: "392", MinorUnits: 0, Name: "Yen", Active: true},
+	{Code: "KES", Numeric: "404", MinorUnits: 2, Name: "Kenyan Shilling", Active: true},
+	{Code: "KGS", Numeric: "417", MinorUnits: 2, Name: "Som", Active: true},
+	{Code: "KHR", Numeric: "116", MinorUnits: 2, Name: "Riel", Active: true},
+	{Code: "KMF", Numeric: "174", MinorUnits: 0, Name: "Comorian Franc", Active: true},
+	{Code: "KPW", Numeric: "408", MinorUnits: 2, Name: "North Korean Won", Active: true},
+	{Code: "KRW", Numeric: "410", MinorUnits: 0, Name: "Won", Active: true},
+	{Code: "KWD", Numeric: "414", MinorUnits: 3, Name: "Kuwaiti Dinar", Active: true},
+	{Code: "KYD", Numeric: "136", MinorUnits: 2, Name: "Cayman Islands Dollar", Active: true},
+	{Code: "KZT", Numeric: "398", MinorUnits: 2, Name: "Tenge", Active: true},
+	{Code: "LAK", Numeric: "418", MinorUnits: 2, Name: "Lao Kip", Active: true},
+	{Code: "LBP", Numeric: "422", MinorUnits: 2, Name: "Lebanese Pound", Active: true},
+	{Code: "LKR", Numeric: "144", MinorUnits: 2, Name: "Sri Lanka Rupee", Active: true},
+	{Code: "LRD", Numeric: "430", MinorUnits: 2, Name: "Liberian Dollar", Active: true},
+	{Code: "LSL", Numeric: "426", MinorUnits: 2, Name: "Loti", Active: true},
+	{Code: "LYD", Numeric: "434", MinorUnits: 3, Name: "Libyan Dinar", Active: true},
+	{Code: "MAD", Numeric: "504", MinorUnits: 2, Name: "Moroccan Dirham", Active: true},
+	{Code: "MDL", Numeric: "498", MinorUnits: 2, Name: "Moldovan Leu", Active: true},
+	{Code: "MGA", Numeric: "969", MinorUnits: 2, Name: "Malagasy Ariary", Active: true},
+	{Code: "MKD", Numeric: "807", MinorUnits: 2, Name: "Denar", Active: true},
+	{Code: "MMK", Numeric: "104", MinorUnits: 2, Name: "Kyat", Active: true},
+	{Code: "MNT", Numeric: "496", MinorUnits: 2, Name: "Tugrik", Active: true},
+	{Code: "MOP", Numeric: "446", MinorUnits: 2, Name: "Pataca", Active: true},
+	{Code: "MRU", Numeric: "929", MinorUnits: 2, Name: "Ouguiya", Active: true},
+	{Code: "MUR", Numeric: "480", MinorUnits: 2, Name: "Mauritius Rupee", Active: true},
+	{Code: "MVR", Numeric: "462", MinorUnits: 2, Name: "Rufiyaa", Active: true},
+	{Code: "MWK", Numeric: "454", MinorUnits: 2, Name: "Malawi Kwacha", Active: true},
+	{Code: "MXN", Numeric: "484", MinorUnits: 2, Name: "Mexican Peso", Active: true},
+	{Code: "MYR", Numeric: "458", MinorUnits: 2, Name: "Malaysian Ringgit", Active: true},
+	{Code: "MZN", Numeric: "943", MinorUnits: 2, Name: "Mozambique Metical", Active: true},
+	{Code: "NAD", Numeric: "516", MinorUnits: 2, Name: "Namibia Dollar", Active: true},
+	{Code: "NGN", Numeric: "566", MinorUnits: 2, Name: "Naira", Active: true},
+	{Code: "NIO", Numeric: "558", MinorUnits: 2, Name: "Cordoba Oro", Active: true},
+	{Code: "NOK", Numeric: "578", MinorUnits: 2, Name: "Norwegian Krone", Active: true},
+	{Code: "NPR", Numeric: "524", MinorUnits: 2, Name: "Nepalese Rupee", Active: true},
+	{Code: "NZD", Numeric: "554", MinorUnits: 2, Name: "New Zealand Dollar", Active: true},
+	{Code: "OMR", Numeric: "512", MinorUnits: 3, Name: "Rial Omani", Active: true},
+	{Code: "PAB", Numeric: "590", MinorUnits: 2, Name: "Balboa", Active: true},
+	{Code: "PEN", Numeric: "604", MinorUnits: 2, Name: "Sol", Active: true},
+	{Code: "PGK", Numeric: "598", MinorUnits: 2, Name: "Kina", Active: true},
+	{Code: "PHP", Numeric: "608", MinorUnits: 2, Name: "Philippine Peso", Active: true},
+	{Code: "PKR", Numeric: "586", MinorUnits: 2, Name: "Pakistan Rupee", Active: true},
+	{Code: "PLN", Numeric: "985", MinorUnits: 2, Name: "Zloty", Active: true},
+	{Code: "PYG", Numeric: "600", MinorUnits: 0, Name: "Guarani", Active: true},
+	{Code: "QAR", Numeric: "634", MinorUnits: 2, Name: "Qatari Rial", Active: true},
+	{Code: "RON", Numeric: "946", MinorUnits: 2, Name: "Romanian Leu", Active: true},
+	{Code: "RSD", Numeric: "941", MinorUnits: 2, Name: "Serbian Dinar", Active: true},
+	{Code: "RUB", Numeric: "643", MinorUnits: 2, Name: "Russian Ruble", Active: true},
+	{Code: "RWF", Numeric: "646", MinorUnits: 0, Name: "Rwanda Franc", Active: true},
+	{Code: "SAR", Numeric: "682", MinorUnits: 2, Name: "Saudi Riyal", Active: true},
+	{Code: "SBD", Numeric: "090", MinorUnits: 2, Name: "Solomon Islands Dollar", Active: true},
+	{Code: "SCR", Numeric: "690", MinorUnits: 2, Name: "Seychelles Rupee", Active: true},
+	{Code: "SDG", Numeric: "938", MinorUnits: 2, Name: "Sudanese Pound", Active: true},
+	{Code: "SEK", Numeric: "752", MinorUnits: 2, Name: "Swedish Krona", Active: true},
+	{Code: "SGD", Numeric: "702", MinorUnits: 2, Name: "Singapore Dollar", Active: true},
+	{Code: "SHP", Numeric: "654", MinorUnits: 2, Name: "Saint Helena Pound", Active: true},
+	{Code: "SLE", Numeric: "925", MinorUnits: 2, Name: "Leone", Active: true},
+	{Code: "SOS", Numeric: "706", MinorUnits: 2, Name: "Somali Shilling", Active: true},
+	{Code: "SRD", Numeric: "968", MinorUnits: 2, Name: "Surinam Dollar", Active: true},
+	{Code: "SSP", Numeric: "728", MinorUnits: 2, Name: "South Sudanese Pound", Active: true},
+	{Code: "STN", Numeric: "930", MinorUnits: 2, Name: "Dobra", Active: true},
+	{Code: "SVC", Numeric: "222", MinorUnits: 2, Name: "El Salvador Colon", Active: true},
+	{Code: "SYP", Numeric: "760", MinorUnits: 2, Name: "Syrian Pound", Active: true},
+	{Code: "SZL", Numeric: "748", MinorUnits: 2, Name: "Lilangeni", Active: true},
+	{Code: "THB", Numeric: "764", MinorUnits: 2, Name: "Baht", Active: true},
+	{Code: "TJS", Numeric: "972", MinorUnits: 2, Name: "Somoni", Active: true},
+	{Code: "TMT", Numeric: "934", MinorUnits: 2, Name: "Turkmenistan New Manat", Active: true},
+	{Code: "TND", Numeric: "788", MinorUnits: 3, Name: "Tunisian Dinar", Active: true},
+	{Code: "TOP", Numeric: "776", MinorUnits: 2, Name: "Pa'anga", Active: true},
+	{Code: "TRY", Numeric: "949", MinorUnits: 2, Name: "Turkish Lira", Active: true},
+	{Code: "TTD", Numeric: "780", MinorUnits: 2, Name: "Trinidad and Tobago Dollar", Active: true},
+	{Code: "TWD", Numeric: "901", MinorUnits: 2, Name: "New Taiwan Dollar", Active: true},
+	{Code: "TZS", Numeric: "834", MinorUnits: 2, Name: "Tanzanian Shilling", Active: true},
+	{Code: "UAH", Numeric: "980", MinorUnits: 2, Name: "Hryvnia", Active: true},
+	{Code: "UGX", Numeric: "800", MinorUnits: 0, Name: "Uganda Shilling", Active: true},
+	{Code: "USD", Numeric: "840", MinorUnits: 2, Name: "US Dollar", Active: true},
+	{Code: "UYU", Numeric: "858", MinorUnits: 2, Name: "Peso Uruguayo", Active: true},
+	{Code: "UZS", Numeric: "860", MinorUnits: 2, Name: "Uzbekistan Sum", Active: true},
+	{Code: "VES", Numeric: "928", MinorUnits: 2, Name: "Bolivar Soberano", Active: true},
+	{Code: "VND", Numeric: "704", MinorUnits: 0, Name: "Dong", Active: true},
+	{Code: "VUV", Numeric: "548", MinorUnits: 0, Name: "Vatu", Active: true},
+	{Code: "WST", Numeric: "882", MinorUnits: 2, Name: "Tala", Active: true},
+	{Code: "XAF", Numeric: "950", MinorUnits: 0, Name: "CFA Franc BEAC", Active: true},
+	{Code: "XCD", Numeric: "951", MinorUnits: 2, Name: "East Caribbean Dollar", Active: true},
+	{Code: "XOF", Numeric: "952", MinorUnits: 0, Name: "CFA Franc BCEAO", Active: true},
+	{Code: "XPF", Numeric: "953", MinorUnits: 0, Name: "CFP Franc", Active: true},
+	{Code: "YER", Numeric: "886", MinorUnits: 2, Name: "Yemeni Rial", Active: true},
+	{Code: "ZAR", Numeric: "710", MinorUnits: 2, Name: "Rand", Active: true},
+	{Code: "ZMW", Numeric: "967", MinorUnits: 2, Name: "Zambian Kwacha", Active: true},
+	{Code: "ZWG", Numeric: "924", MinorUnits: 2, Name: "Zimbabwe Gold", Active: true},
+	{Code: "DEM", Numeric: "276", MinorUnits: 2, Name: "Deutsche Mark", Active: false},
+	{Code: "FRF", Numeric: "250", MinorUnits: 2, Name: "French Franc", Active: false},
+	{Code: "HRK", Numeric: "191", MinorUnits: 2, Name: "Kuna", Active: false},
+	{Code: "ITL", Numeric: "380", MinorUnits: 0, Name: "Italian Lira", Active: false},
+	{Code: "LTL", Numeric: "440", MinorUnits: 2, Name: "Lithuanian Litas", Active: false},
+	{Code: "LVL", Numeric: "428", MinorUnits: 2, Name: "Latvian Lats", Active: false},
+	{Code: "MRO", Numeric: "478", MinorUnits: 2, Name: "Ouguiya", Active: false},
+	{Code: "SLL", Numeric: "694", MinorUnits: 2, Name: "Leone", Active: false},
+	{Code: "VEF", Numeric: "937", MinorUnits: 2, Name: "Bolivar", Active: false},
+	{Code: "ZWL", Numeric: "932", MinorUnits: 2, Name: "Zimbabwe Dollar", Active: false},
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/fee"
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+)
+
+func TestCurrencyRegistry(t *testing.T) {
+	registry := NewCurrencyRegistry(ISO4217)
+
+	usd, ok := registry.Lookup("usd")
+
+	if !ok || usd.Numeric != "840" || usd.MinorUnits != 2 || !usd.Active {
+		t.Fatalf("unexpected USD entry %+v", usd)
+	}
+
+	if jpy, _ := registry.Lookup("JPY"); jpy.MinorUnits != 0 {
+		t.Fatalf("JPY has no minor units, got %d", jpy.MinorUnits)
+	}
+
+	if err := registry.Validate("from", "EUR"); err != nil {
+		t.Fatalf("EUR should be valid: %v", err)
+	}
+
+	var validationErr *ValidationError
+
+	if err := registry.Validate("to", "XYZ"); !errors.As(err, &validationErr) || validationErr.Field != "to" {
+		t.Fatalf("expected validation error for unknown currency, got %v", err)
+	}
+
+	if err := registry.Validate("from", "DEM"); !errors.As(err, &validationErr) {
+		t.Fatalf("withdrawn currency should be rejected, got %v", err)
+	}
+
+	active := registry.List(false)
+	all := registry.List(true)
+
+	if len(all) <= len(active) || active[0].Code > active[1].Code {
+		t.Fatalf("unexpected listing: %d active, %d total", len(active), len(all))
+	}
+}
+
+func TestConversionService_UnknownCurrency(t *testing.T) {
+	storage := NewStorageService(NewMockRepository(), newTestAudit(), nil)
+	provider := NewStaticRateProvider(map[string]money.Decimal{"USD/XYZ": money.MustParse("2")})
+	conversion := NewConversionService(provider, storage, NewCurrencyRegistry(ISO4217), newTestRounding(t), NewFeeEngine(fee.Schedules{}), testQuoteTTL)
+
+	_, err := conversion.Convert(context.Background(), &api.Request{From: "USD", To: "xyz", Amount: money.NewFromInt(1)}, ConvertOptions{})
+
+	var validationErr *ValidationError
+
+	if !errors.As(err, &validationErr) || validationErr.Field != "to" {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+
+	if len(storage.GetAllRequests(true)) != 0 {
+		t.Fatal("rejected conversion should not store anything")
+	}
+}
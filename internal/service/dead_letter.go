@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/enum"
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/deadletter"
+	"github.com/M2rk13/Otus-327619/internal/model/log"
+	"github.com/M2rk13/Otus-327619/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const deadLetterEntity = "dead_letter"
+
+// DeadLetterService keeps the items the storage workers could not store and
+// lets an administrator replay or discard them.
+type DeadLetterService struct {
+	store   repository.DeadLetterStore
+	storage *StorageService
+	audit   *AuditService
+}
+
+func NewDeadLetterService(store repository.DeadLetterStore, storage *StorageService, audit *AuditService) *DeadLetterService {
+	return &DeadLetterService{store: store, storage: storage, audit: audit}
+}
+
+// Add keeps item of entity together with the error of its last attempt.
+func (d *DeadLetterService) Add(entity string, item any, cause error, attempts int) error {
+	payload, err := json.Marshal(item)
+
+	if err == nil {
+		err = d.store.Add(&deadletter.Letter{
+			Id:       uuid.New().String(),
+			Entity:   entity,
+			Payload:  payload,
+			Error:    cause.Error(),
+			Attempts: attempts,
+			FailedAt: time.Now().UTC(),
+		})
+	}
+
+	if err != nil {
+		fmt.Printf("Failed to keep dead letter for %s: %v\n", entity, err)
+	}
+
+	return err
+}
+
+// List returns the letters of entity, or all letters if it is empty.
+func (d *DeadLetterService) List(entity string) ([]*deadletter.Letter, error) {
+	return d.store.List(entity)
+}
+
+// Replay stores the item of a letter again, bypassing the queues, and removes
+// the letter once it succeeds. A failing replay keeps the letter and returns
+// the error.
+func (d *DeadLetterService) Replay(ctx context.Context, id string) (*deadletter.Letter, error) {
+	letter, err := d.store.Get(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = d.create(ctx, letter); err != nil {
+		return nil, err
+	}
+
+	if err = d.store.Remove(id); err != nil {
+		return nil, err
+	}
+
+	return letter, nil
+}
+
+func (d *DeadLetterService) create(ctx context.Context, letter *deadletter.Letter) error {
+	switch letter.Entity {
+	case requestEntity:
+		var req api.Request
+
+		if err := json.Unmarshal(letter.Payload, &req); err != nil {
+			return err
+		}
+
+		return d.storage.CreateRequest(ctx, &req)
+	case responseEntity:
+		var resp api.Response
+
+		if err := json.Unmarshal(letter.Payload, &resp); err != nil {
+			return err
+		}
+
+		return d.storage.CreateResponse(ctx, &resp)
+	case logEntity:
+		var convLog log.ConversionLog
+
+		if err := json.Unmarshal(letter.Payload, &convLog); err != nil {
+			return err
+		}
+
+		return d.storage.CreateConversionLog(ctx, &convLog)
+	default:
+		return fmt.Errorf("unknown dead letter entity %q", letter.Entity)
+	}
+}
+
+// Discard removes a letter for good.
+func (d *DeadLetterService) Discard(ctx context.Context, id string) error {
+	letter, err := d.store.Get(id)
+
+	if err != nil {
+		return err
+	}
+
+	if err = d.store.Remove(id); err != nil {
+		return err
+	}
+
+	d.audit.Record(ctx, enum.ActionDelete, deadLetterEntity, id, letter, nil)
+
+	return nil
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/lifecycle"
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/deadletter"
+	"github.com/M2rk13/Otus-327619/internal/model/log"
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+	"github.com/M2rk13/Otus-327619/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+)
+
+func newTestDeadLetters(t *testing.T, storage *StorageService) (*DeadLetterService, *repository.FileDeadLetterStore) {
+	t.Helper()
+	store, err := repository.NewFileDeadLetterStore(filepath.Join(t.TempDir(), "dead_letters.json"))
+
+	if err != nil {
+		t.Fatalf("dead letter store: %v", err)
+	}
+
+	t.Cleanup(store.Close)
+
+	return NewDeadLetterService(store, storage, newTestAudit()), store
+}
+
+func TestStorageService_DeadLetterAfterRetries(t *testing.T) {
+	mockRepo := NewMockRepository()
+	svc := NewStorageService(mockRepo, newTestAudit(), nil)
+	deadLetters, _ := newTestDeadLetters(t, svc)
+
+	var wg sync.WaitGroup
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logs := lifecycle.NewQueue[*log.ConversionLog](5)
+	pools := PoolConfig{
+		Retry:       RetryPolicy{Attempts: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond},
+		DeadLetters: deadLetters,
+	}
+
+	req := api.Request{Id: uuid.New().String(), From: "USD", To: "EUR", Amount: money.NewFromInt(1)}
+	svc.StartStorageService(&wg, ctx, pools, lifecycle.NewQueue[*api.Request](1), lifecycle.NewQueue[*api.Response](1), logs)
+	push(t, logs, log.NewConversionLog("", req, api.Response{}))
+
+	var letters []*deadletter.Letter
+
+	eventually(t, func() bool {
+		letters, _ = deadLetters.List(logEntity)
+
+		return len(letters) == 1
+	})
+
+	if letters[0].Attempts != 3 || letters[0].Error != repository.ErrMissingReference.Error() {
+		t.Fatalf("unexpected letter: %+v", letters[0])
+	}
+
+	for _, stats := range svc.IngestStats() {
+		if stats.Entity == logEntity && (stats.Failed != 1 || stats.Retries != 2 || stats.DeadLettered != 1) {
+			t.Fatalf("unexpected stats: %+v", stats)
+		}
+	}
+
+	if _, err := deadLetters.Replay(ctx, letters[0].Id); !errors.Is(err, repository.ErrMissingReference) {
+		t.Fatalf("replay without link = %v, want ErrMissingReference", err)
+	}
+
+	if err := svc.CreateRequest(ctx, &req); err != nil {
+		t.Fatalf("create request: %v", err)
+	}
+
+	if _, err := deadLetters.Replay(ctx, letters[0].Id); err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+
+	if _, _, saved := mockRepo.counts(); saved != 1 {
+		t.Fatalf("saved logs = %d, want 1", saved)
+	}
+
+	if left, _ := deadLetters.List(""); len(left) != 0 {
+		t.Fatalf("letters left after replay: %d", len(left))
+	}
+}
+
+func TestStorageService_DrainDeadlineWithoutOutbox(t *testing.T) {
+	svc := NewStorageService(NewMockRepository(), newTestAudit(), nil)
+	deadLetters, _ := newTestDeadLetters(t, svc)
+
+	var wg sync.WaitGroup
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logs := lifecycle.NewQueue[*log.ConversionLog](5)
+	pools := PoolConfig{
+		BatchSize:     10,
+		FlushInterval: time.Hour,
+		Retry:         RetryPolicy{Attempts: 1000, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond},
+		DeadLetters:   deadLetters,
+		DrainTimeout:  50 * time.Millisecond,
+	}
+
+	svc.StartStorageService(&wg, ctx, pools, lifecycle.NewQueue[*api.Request](1), lifecycle.NewQueue[*api.Response](1), logs)
+
+	// Neither log's request is ever stored, so the drain can only end at
+	// its deadline.
+	for range 2 {
+		req := api.Request{Id: uuid.New().String(), From: "USD", To: "EUR", Amount: money.NewFromInt(1)}
+		push(t, logs, log.NewConversionLog("", req, api.Response{}))
+	}
+
+	cancel()
+
+	done := make(chan struct{})
+
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("workers kept retrying past the drain deadline")
+	}
+
+	if letters, _ := deadLetters.List(logEntity); len(letters) != 2 {
+		t.Fatalf("dead letters = %d, want both logs", len(letters))
+	}
+}
+
+func TestDeadLetterService_Discard(t *testing.T) {
+	deadLetters, store := newTestDeadLetters(t, NewStorageService(NewMockRepository(), newTestAudit(), nil))
+	ctx := context.Background()
+
+	if err := deadLetters.Add(requestEntity, &api.Request{Id: "r1"}, &ValidationError{Field: "from"}, 1); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	letters, _ := deadLetters.List(requestEntity)
+
+	if len(letters) != 1 {
+		t.Fatalf("letters = %d, want 1", len(letters))
+	}
+
+	if err := deadLetters.Discard(ctx, letters[0].Id); err != nil {
+		t.Fatalf("discard: %v", err)
+	}
+
+	if _, err := store.Get(letters[0].Id); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("get after discard = %v, want ErrNotFound", err)
+	}
+
+	if err := deadLetters.Discard(ctx, letters[0].Id); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("second discard = %v, want ErrNotFound", err)
+	}
+}
+
+func TestTransient(t *testing.T) {
+	cases := map[error]bool{
+		repository.ErrMissingReference:                   true,
+		fmt.Errorf("dial: %w", syscall.ECONNRESET):       true,
+		context.DeadlineExceeded:                         true,
+		&pgconn.PgError{Code: "08006"}:                   true,
+		&pgconn.PgError{Code: "23505"}:                   false,
+		errors.New("duplicate key value violates index"): false,
+		repository.ErrAlreadyExists:                      false,
+		repository.ErrReferenced:                         false,
+		repository.ErrVersionConflict:                    false,
+		&ValidationError{Field: "to"}:                    false,
+	}
+
+	for err, want := range cases {
+		if got := transient(err); got != want {
+			t.Errorf("transient(%v) = %v, want %v", err, got, want)
+		}
+	}
+
+	for range 100 {
+		if delay := jitter(10 * time.Millisecond); delay < 5*time.Millisecond || delay > 10*time.Millisecond {
+			t.Fatalf("jitter out of range: %s", delay)
+		}
+	}
+}
@@ -1,28 +1,29 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"time"
 
 	"github.com/M2rk13/Otus-327619/internal/model/api"
 	"github.com/M2rk13/Otus-327619/internal/model/log"
+	"github.com/M2rk13/Otus-327619/internal/model/money"
 
 	"github.com/google/uuid"
 )
 
-type DispatcherService struct{}
+type DispatcherService struct {
+	currencies *CurrencyRegistry
+}
 
 func NewDispatcherService() *DispatcherService {
-	return &DispatcherService{}
+	return &DispatcherService{currencies: NewCurrencyRegistry(ISO4217)}
 }
 
-func (d *DispatcherService) DispatchExampleData(
-	iteration int,
-	requestChan chan<- *api.Request,
-	responseChan chan<- *api.Response,
-	logChan chan<- *log.ConversionLog,
-) {
-	amount := float64(105 * iteration)
+// ExampleData builds a demo request with its response and log. Responses to
+// odd iterations are failures.
+func (d *DispatcherService) ExampleData(iteration int) *Envelope {
+	amount := money.NewFromInt(int64(105 * iteration))
 
 	req := api.Request{
 		Id:     uuid.New().String(),
@@ -33,7 +34,7 @@ func (d *DispatcherService) DispatchExampleData(
 
 	info := api.Info{
 		Timestamp: time.Now().Unix(),
-		Quote:     0.9134,
+		Quote:     money.MustParse("0.9134"),
 	}
 
 	var resp api.Response
@@ -46,7 +47,7 @@ func (d *DispatcherService) DispatchExampleData(
 			Privacy: "https://exchangerate.host/privacy",
 			Query:   req,
 			Info:    info,
-			Result:  info.Quote * amount,
+			Result:  d.currencies.Round(req.To, info.Quote.Mul(amount)),
 		}
 	} else {
 		resp = api.Response{
@@ -56,15 +57,49 @@ func (d *DispatcherService) DispatchExampleData(
 			Privacy: "https://exchangerate.host/privacy",
 			Query:   req,
 			Info:    info,
-			Result:  0.0,
+			Result:  money.Zero,
 		}
 	}
 
 	convLog := log.NewConversionLog(uuid.New().String(), req, resp)
 
-	fmt.Println("Dispatching data ...")
+	return &Envelope{Source: demoSourceName, Request: &req, Response: &resp, Log: convLog}
+}
+
+const demoSourceName = "demo"
+
+// DemoSource feeds the pipeline with example data, one envelope per interval.
+// Zero iterations means it runs until the context is cancelled.
+type DemoSource struct {
+	dispatcher *DispatcherService
+	iterations int
+	interval   time.Duration
+}
+
+func NewDemoSource(dispatcher *DispatcherService, iterations int, interval time.Duration) *DemoSource {
+	return &DemoSource{dispatcher: dispatcher, iterations: iterations, interval: interval}
+}
+
+func (s *DemoSource) Name() string { return demoSourceName }
+
+func (s *DemoSource) Run(ctx context.Context, out chan<- *Envelope) error {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for i := 0; s.iterations == 0 || i < s.iterations; i++ {
+		fmt.Println("Dispatching data ...")
+
+		if err := send(ctx, out, s.dispatcher.ExampleData(i)); err != nil {
+			return err
+		}
+
+		select {
+		case <-ticker.C:
+			fmt.Printf("Iteration %d finished.\n", i+1)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
 
-	requestChan <- &req
-	responseChan <- &resp
-	logChan <- convLog
+	return nil
 }
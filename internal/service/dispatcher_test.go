@@ -1,10 +1,10 @@
 package service
 
 import (
+	"context"
+	"sync"
 	"testing"
-
-	"github.com/M2rk13/Otus-327619/internal/model/api"
-	"github.com/M2rk13/Otus-327619/internal/model/log"
+	"time"
 )
 
 func TestNewDispatcherService(t *testing.T) {
@@ -15,39 +15,50 @@ func TestNewDispatcherService(t *testing.T) {
 	}
 }
 
-func TestDispatcherDispatchExampleDataEvenOdd(t *testing.T) {
-	d := NewDispatcherService()
-	reqCh := make(chan *api.Request, 2)
-	respCh := make(chan *api.Response, 2)
-	logCh := make(chan *log.ConversionLog, 2)
+// runDemoSource passes iterations of demo data through a pipeline and returns
+// the envelopes that reached its sink, in order.
+func runDemoSource(t *testing.T, iterations int) []*Envelope {
+	t.Helper()
+
+	sink := &captureSink{}
+	pipeline := NewPipeline([]Source{NewDemoSource(NewDispatcherService(), iterations, time.Millisecond)}, nil, []Sink{sink}, 1)
+
+	var wg sync.WaitGroup
+	ctx, cancel := context.WithCancel(context.Background())
+	pipeline.Start(&wg, ctx)
 
-	d.DispatchExampleData(2, reqCh, respCh, logCh)
-	req1 := <-reqCh
-	resp1 := <-respCh
-	lg1 := <-logCh
+	eventually(t, func() bool { return sink.count() == iterations })
+	cancel()
+	wg.Wait()
 
-	if req1.From != "USD" || req1.To != "EUR" || req1.Amount <= 0 {
-		t.Fatalf("bad request from dispatcher: %+v", req1)
+	return sink.envelopes
+}
+
+func TestDemoSource_EvenOdd(t *testing.T) {
+	envelopes := runDemoSource(t, 4)
+	even, odd := envelopes[2], envelopes[3]
+
+	if even.Source != demoSourceName {
+		t.Fatalf("envelope should name the demo source, got %q", even.Source)
 	}
 
-	if !resp1.Success || resp1.Query.Id != req1.Id || resp1.Result <= 0 {
-		t.Fatalf("bad response (even) from dispatcher: %+v", resp1)
+	if even.Request.From != "USD" || even.Request.To != "EUR" || !even.Request.Amount.IsPositive() {
+		t.Fatalf("bad request from dispatcher: %+v", even.Request)
 	}
 
-	if lg1.Request.Id != req1.Id || lg1.Response.Id != resp1.Id {
-		t.Fatalf("bad log (even): %+v", lg1)
+	if !even.Response.Success || even.Response.Query.Id != even.Request.Id || !even.Response.Result.IsPositive() {
+		t.Fatalf("bad response (even) from dispatcher: %+v", even.Response)
 	}
 
-	d.DispatchExampleData(3, reqCh, respCh, logCh)
-	req2 := <-reqCh
-	resp2 := <-respCh
-	lg2 := <-logCh
+	if even.Log.Request.Id != even.Request.Id || even.Log.Response.Id != even.Response.Id {
+		t.Fatalf("bad log (even): %+v", even.Log)
+	}
 
-	if resp2.Success {
-		t.Fatalf("expected Success=false on odd iteration, got true: %+v", resp2)
+	if odd.Response.Success {
+		t.Fatalf("expected Success=false on odd iteration, got true: %+v", odd.Response)
 	}
 
-	if lg2.Request.Id != req2.Id || lg2.Response.Id != resp2.Id {
-		t.Fatalf("bad log (odd): %+v", lg2)
+	if odd.Log.Request.Id != odd.Request.Id || odd.Log.Response.Id != odd.Response.Id {
+		t.Fatalf("bad log (odd): %+v", odd.Log)
 	}
 }
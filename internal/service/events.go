@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/broker"
+	"github.com/M2rk13/Otus-327619/internal/enum"
+	"github.com/M2rk13/Otus-327619/internal/lifecycle"
+	"github.com/M2rk13/Otus-327619/internal/model/event"
+	"github.com/M2rk13/Otus-327619/internal/model/outbox"
+	"github.com/M2rk13/Otus-327619/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// eventTypes maps the audited actions on requests, responses and conversion
+// logs to the events published for them.
+var eventTypes = map[string]string{
+	enum.ActionCreate:  enum.EventCreated,
+	enum.ActionUpdate:  enum.EventUpdated,
+	enum.ActionDelete:  enum.EventDeleted,
+	enum.ActionRestore: enum.EventRestored,
+}
+
+// eventEntity names the events journalled in the outbox next to the ingested
+// items.
+const eventEntity = "event"
+
+// EventService publishes the changes of stored records. Events are queued and
+// sent by a background worker that retries until the broker accepts them, so
+// a mutation does not wait for the broker. Queuing never blocks either: an
+// event the full queue rejects is counted and, without a journal, lost.
+//
+// With a journal, every event is kept in it until the broker accepts it, and
+// the events left there by a full queue or a shutdown are published after the
+// next start. A nil EventService publishes nothing.
+type EventService struct {
+	publisher broker.Publisher
+	origin    string
+	queue     *lifecycle.Queue[*event.Event]
+	retry     RetryPolicy
+	journal   repository.Outbox
+
+	published atomic.Int64
+	failed    atomic.Int64
+}
+
+// NewEventService creates the service publishing the changes made by the
+// instance named origin; journal may be nil to keep the queued events in
+// memory only.
+func NewEventService(publisher broker.Publisher, origin string, queueSize int, retry RetryPolicy, journal repository.Outbox) *EventService {
+	return &EventService{
+		publisher: publisher,
+		origin:    origin,
+		queue:     lifecycle.NewQueueWithPolicy[*event.Event](queueSize, enum.QueueReject),
+		retry:     retry.orDefault(),
+		journal:   journal,
+	}
+}
+
+// Record queues the event for an action on a record; after is the record
+// following the change and nil for deletions. Actions without an event are
+// ignored.
+func (e *EventService) Record(ctx context.Context, action, entity, id string, after any) {
+	eventType, ok := eventTypes[action]
+
+	if e == nil || !ok {
+		return
+	}
+
+	ev := &event.Event{
+		Id:        uuid.New().String(),
+		Type:      eventType,
+		Entity:    entity,
+		EntityId:  id,
+		Origin:    e.origin,
+		Timestamp: time.Now().UTC(),
+		Actor:     metaFrom(ctx).actor,
+		Data:      snapshot(after),
+	}
+
+	if record, ok := after.(repository.Versioned); ok && ev.Data != nil {
+		ev.Version = record.GetVersion()
+	}
+
+	journalled := e.append(ev)
+
+	if err := e.queue.Push(ctx, ev); err != nil {
+		if journalled {
+			fmt.Printf("Deferred event %s on %s %s to the next start: %v\n", eventType, entity, id, err)
+		} else {
+			e.failed.Add(1)
+			fmt.Printf("Dropped event %s on %s %s: %v\n", eventType, entity, id, err)
+		}
+	}
+}
+
+// append journals ev and reports whether it is kept there.
+func (e *EventService) append(ev *event.Event) bool {
+	if e.journal == nil {
+		return false
+	}
+
+	payload, err := json.Marshal(ev)
+
+	if err == nil {
+		err = e.journal.Append(&outbox.Entry{Entity: eventEntity, Id: ev.Id, Payload: payload, AcceptedAt: ev.Timestamp})
+	}
+
+	if err != nil {
+		fmt.Printf("Failed to journal event %s on %s %s: %v\n", ev.Type, ev.Entity, ev.EntityId, err)
+
+		return false
+	}
+
+	return true
+}
+
+// Start runs the worker publishing the queued events until ctx is cancelled,
+// beginning with those left in the journal. Without a journal, the events
+// still queued then get one more attempt each; with one, they wait there for
+// the next start.
+func (e *EventService) Start(wg *sync.WaitGroup, ctx context.Context) {
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		e.replay(ctx)
+
+		for {
+			select {
+			case entry := <-e.queue.Items():
+				e.publish(ctx, entry.Item)
+			case <-ctx.Done():
+				e.queue.Close()
+
+				for _, entry := range e.queue.Drain() {
+					e.publishOnce(ctx, entry.Item)
+				}
+
+				fmt.Println("Event publisher stopped.")
+
+				return
+			}
+		}
+	}()
+}
+
+// replay publishes the events left in the journal, oldest first.
+func (e *EventService) replay(ctx context.Context) {
+	if e.journal == nil {
+		return
+	}
+
+	for _, entry := range e.journal.Pending() {
+		if entry.Entity != eventEntity || ctx.Err() != nil {
+			continue
+		}
+
+		var ev event.Event
+
+		if err := json.Unmarshal(entry.Payload, &ev); err != nil {
+			fmt.Printf("Skipping journalled event %s: %v\n", entry.Id, err)
+			_ = e.journal.Ack(eventEntity, entry.Id)
+
+			continue
+		}
+
+		e.publish(ctx, &ev)
+	}
+}
+
+// publish sends ev, retrying with backoff for as long as ctx lasts and once
+// more after that.
+func (e *EventService) publish(ctx context.Context, ev *event.Event) {
+	delay := e.retry.BaseDelay
+
+	for {
+		err := e.publisher.Publish(ctx, ev)
+
+		if err == nil {
+			e.delivered(ev)
+
+			return
+		}
+
+		fmt.Printf("Failed to publish event %s on %s %s, retrying: %v\n", ev.Type, ev.Entity, ev.EntityId, err)
+
+		select {
+		case <-time.After(jitter(delay)):
+		case <-ctx.Done():
+			e.publishOnce(ctx, ev)
+
+			return
+		}
+
+		delay = min(delay*2, e.retry.MaxDelay)
+	}
+}
+
+// publishOnce makes the last attempt to send ev at shutdown. A journalled
+// event is not sent, the next start publishes it.
+func (e *EventService) publishOnce(ctx context.Context, ev *event.Event) {
+	if e.journal != nil {
+		return
+	}
+
+	if err := e.publisher.Publish(context.WithoutCancel(ctx), ev); err != nil {
+		e.failed.Add(1)
+		fmt.Printf("Dropped event %s on %s %s at shutdown: %v\n", ev.Type, ev.Entity, ev.EntityId, err)
+
+		return
+	}
+
+	e.delivered(ev)
+}
+
+// delivered counts ev as published and takes it out of the journal.
+func (e *EventService) delivered(ev *event.Event) {
+	e.published.Add(1)
+
+	if e.journal == nil {
+		return
+	}
+
+	if err := e.journal.Ack(eventEntity, ev.Id); err != nil {
+		fmt.Printf("Failed to acknowledge event %s: %v\n", ev.Id, err)
+	}
+}
+
+// stats describes the event queue in the terms of the ingest pools: Stored
+// counts the published events, Failed those lost.
+func (e *EventService) stats() IngestStats {
+	return IngestStats{
+		Entity:  eventEntity,
+		Queue:   e.queue.Stats(),
+		Workers: 1,
+		Stored:  e.published.Load(),
+		Failed:  e.failed.Load(),
+	}
+}
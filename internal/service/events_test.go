@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/broker"
+	"github.com/M2rk13/Otus-327619/internal/config"
+	"github.com/M2rk13/Otus-327619/internal/enum"
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/event"
+	"github.com/M2rk13/Otus-327619/internal/model/log"
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+	"github.com/M2rk13/Otus-327619/internal/repository"
+)
+
+// flakyPublisher fails the first publishes before passing events on.
+type flakyPublisher struct {
+	broker.Publisher
+	mu       sync.Mutex
+	failures int
+}
+
+func (p *flakyPublisher) Publish(ctx context.Context, ev *event.Event) error {
+	p.mu.Lock()
+
+	if p.failures > 0 {
+		p.failures--
+		p.mu.Unlock()
+
+		return errors.New("broker unavailable")
+	}
+
+	p.mu.Unlock()
+
+	return p.Publisher.Publish(ctx, ev)
+}
+
+func TestEvents_ReplicateToAnotherRepository(t *testing.T) {
+	memBroker := broker.NewMemoryBroker(10 * time.Millisecond)
+	events := NewEventService(
+		&flakyPublisher{Publisher: memBroker, failures: 2},
+		"source",
+		10,
+		RetryPolicy{BaseDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond},
+		nil,
+	)
+	source := NewStorageService(NewMockRepository(), newTestAudit(), events)
+	replica := NewMockRepository()
+
+	var wg sync.WaitGroup
+	ctx, cancel := context.WithCancel(context.Background())
+	events.Start(&wg, ctx)
+	NewReplicator(replica, "r1").Start(&wg, ctx, memBroker, "replica", "r1")
+
+	req := &api.Request{From: "USD", To: "EUR", Amount: money.NewFromInt(10)}
+	resp := &api.Response{Success: true, Query: *req}
+
+	if err := source.CreateRequest(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+
+	resp.Query.Id = req.Id
+
+	if err := source.CreateResponse(ctx, resp); err != nil {
+		t.Fatal(err)
+	}
+
+	convLog := log.NewConversionLog("", *req, *resp)
+
+	if err := source.CreateConversionLog(ctx, convLog); err != nil {
+		t.Fatal(err)
+	}
+
+	updated := *req
+	updated.Amount = money.NewFromInt(20)
+
+	if err := source.UpdateRequest(ctx, &updated); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := source.DeleteConversionLog(ctx, convLog.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	eventually(t, func() bool {
+		stored := replica.GetRequestByID(req.Id)
+		deleted := replica.GetAllConversionLogs(true)
+
+		return stored != nil && stored.Amount.Equal(money.NewFromInt(20)) && stored.Version == 2 &&
+			replica.GetResponseByID(resp.Id) != nil &&
+			len(deleted) == 1 && replica.GetConversionLogByID(convLog.Id) == nil
+	})
+
+	cancel()
+	wg.Wait()
+}
+
+func TestReplicator_IsIdempotent(t *testing.T) {
+	replica := NewMockRepository()
+	r := NewReplicator(replica, "replica")
+	req := &api.Request{Id: "r1", From: "USD", To: "EUR", Amount: money.NewFromInt(1)}
+	created := &event.Event{Type: enum.EventCreated, Entity: requestEntity, EntityId: req.Id, Version: 1, Data: snapshot(req)}
+	deleted := &event.Event{Type: enum.EventDeleted, Entity: requestEntity, EntityId: req.Id}
+
+	for _, ev := range []*event.Event{created, created, deleted, deleted} {
+		if err := r.Handle(context.Background(), ev); err != nil {
+			t.Fatalf("%s: %v", ev.Type, err)
+		}
+	}
+
+	if all := replica.GetAllRequests(true); len(all) != 1 || all[0].DeletedAt == nil || all[0].Version != 1 {
+		t.Fatalf("expected one deleted request, got %+v", all)
+	}
+}
+
+func TestReplicator_SkipsOwnAndStaleEvents(t *testing.T) {
+	replica := NewMockRepository()
+	r := NewReplicator(replica, "replica")
+	changed := func(version, amount int64) *event.Event {
+		req := &api.Request{Id: "r1", From: "USD", To: "EUR", Amount: money.NewFromInt(amount), Version: version}
+		eventType := enum.EventUpdated
+
+		if version == 1 {
+			eventType = enum.EventCreated
+		}
+
+		return &event.Event{Type: eventType, Entity: requestEntity, EntityId: req.Id, Version: version, Origin: "source", Data: snapshot(req)}
+	}
+
+	own := changed(1, 1)
+	own.Origin = "replica"
+
+	if err := r.Handle(context.Background(), own); err != nil || replica.GetRequestByID("r1") != nil {
+		t.Fatalf("the events of the replica itself should be skipped, got %v", err)
+	}
+
+	if err := r.Handle(context.Background(), changed(3, 3)); !errors.Is(err, errEventAhead) {
+		t.Fatalf("an update before the create should be left for later, got %v", err)
+	}
+
+	// Redelivered and late changes come after the newer ones.
+	for _, ev := range []*event.Event{changed(1, 1), changed(1, 1), changed(2, 2), changed(3, 3), changed(2, 2), changed(1, 1)} {
+		if err := r.Handle(context.Background(), ev); err != nil {
+			t.Fatalf("%s %d: %v", ev.Type, ev.Version, err)
+		}
+	}
+
+	if err := r.Handle(context.Background(), changed(5, 5)); !errors.Is(err, errEventAhead) {
+		t.Fatalf("a change skipping a version should be left for later, got %v", err)
+	}
+
+	if stored := replica.GetRequestByID("r1"); stored == nil || stored.Version != 3 || !stored.Amount.Equal(money.NewFromInt(3)) {
+		t.Fatalf("the replica should hold version 3 as at the source, got %+v", stored)
+	}
+}
+
+// recordingPublisher keeps the ids of the events it is handed.
+type recordingPublisher struct {
+	mu  sync.Mutex
+	ids map[string]int
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, ev *event.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.ids[ev.Id]++
+
+	return nil
+}
+
+func (p *recordingPublisher) count() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.ids)
+}
+
+func TestEvents_RecordDoesNotBlockAndJournals(t *testing.T) {
+	box := openTestOutbox(t, filepath.Join(t.TempDir(), "outbox.json"))
+	publisher := &recordingPublisher{ids: make(map[string]int)}
+	events := NewEventService(publisher, "source", 1, RetryPolicy{}, box)
+
+	// Nothing consumes the queue yet, so all but the first event are
+	// rejected, but stay journalled.
+	for i := range 3 {
+		events.Record(context.Background(), enum.ActionDelete, requestEntity, fmt.Sprint(i), nil)
+	}
+
+	if stats := events.stats(); stats.Queue.Rejected != 2 || stats.Failed != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	if pending := box.Pending(); len(pending) != 3 {
+		t.Fatalf("journalled %d events, want 3", len(pending))
+	}
+
+	var wg sync.WaitGroup
+	ctx, cancel := context.WithCancel(context.Background())
+	events.Start(&wg, ctx)
+
+	eventually(t, func() bool { return publisher.count() == 3 && len(box.Pending()) == 0 })
+
+	cancel()
+	wg.Wait()
+}
+
+// newReplicaStore returns a file store with the given delete policy in a
+// temporary directory.
+func newReplicaStore(t *testing.T, deletePolicy string) *repository.FileStore {
+	t.Helper()
+
+	dir := t.TempDir()
+	config.FileCfg = config.FileConfig{
+		RequestsFilePath:    filepath.Join(dir, "requests.json"),
+		ResponsesFilePath:   filepath.Join(dir, "responses.json"),
+		LogsFilePath:        filepath.Join(dir, "logs.json"),
+		QuotesFilePath:      filepath.Join(dir, "quotes.json"),
+		IdempotencyFilePath: filepath.Join(dir, "idempotency.json"),
+	}
+
+	store := repository.NewFileStore(deletePolicy)
+
+	if err := store.SetupPersistence(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(store.ClosePersistence)
+
+	return store
+}
+
+func TestReplicator_DeletesUnderRestrictAndRecreates(t *testing.T) {
+	replica := newReplicaStore(t, enum.Restrict)
+	r := NewReplicator(replica, "replica")
+	req := &api.Request{Id: "r1", From: "USD", To: "EUR", Amount: money.NewFromInt(1), Version: 1}
+	resp := &api.Response{Id: "p1", Success: true, Query: *req, Version: 1}
+	convLog := log.NewConversionLog("l1", *req, *resp)
+	convLog.Version = 1
+	changed := *req
+	changed.Amount = money.NewFromInt(2)
+	changed.Version = 2
+
+	// The request's delete overtakes those of its dependants, and the
+	// request is created again afterwards.
+	for _, ev := range []*event.Event{
+		{Type: enum.EventCreated, Entity: requestEntity, EntityId: req.Id, Data: snapshot(req)},
+		{Type: enum.EventCreated, Entity: responseEntity, EntityId: resp.Id, Data: snapshot(resp)},
+		{Type: enum.EventCreated, Entity: logEntity, EntityId: convLog.Id, Data: snapshot(convLog)},
+		{Type: enum.EventDeleted, Entity: requestEntity, EntityId: req.Id},
+		{Type: enum.EventDeleted, Entity: logEntity, EntityId: convLog.Id},
+		{Type: enum.EventCreated, Entity: requestEntity, EntityId: req.Id, Data: snapshot(req)},
+		{Type: enum.EventUpdated, Entity: requestEntity, EntityId: req.Id, Data: snapshot(&changed)},
+	} {
+		if err := r.Handle(context.Background(), ev); err != nil {
+			t.Fatalf("%s %s: %v", ev.Type, ev.Entity, err)
+		}
+	}
+
+	if replica.GetResponseByID(resp.Id) != nil || replica.GetConversionLogByID(convLog.Id) != nil {
+		t.Fatal("the dependants of the deleted request should be deleted")
+	}
+
+	if stored := replica.GetRequestByID(req.Id); stored == nil || !stored.Amount.Equal(changed.Amount) {
+		t.Fatalf("the request should be restored with the change, got %+v", stored)
+	}
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/fee"
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+)
+
+const defaultSchedule = "default"
+
+var hundred = money.NewFromInt(100)
+
+// FeeEngine prices conversions with the margin of the fee schedule that
+// applies to the account.
+type FeeEngine struct {
+	schedules fee.Schedules
+}
+
+func NewFeeEngine(schedules fee.Schedules) *FeeEngine {
+	sortTiers(schedules.Default)
+
+	for _, schedule := range schedules.Accounts {
+		sortTiers(schedule)
+	}
+
+	return &FeeEngine{schedules: schedules}
+}
+
+func sortTiers(schedule fee.Schedule) {
+	for _, tiers := range schedule.Tiers {
+		sort.Slice(tiers, func(i, j int) bool { return tiers[i].MinAmount.Cmp(tiers[j].MinAmount) < 0 })
+	}
+}
+
+// LoadFeeSchedules reads fee schedules from a JSON file, e.g.
+//
+//	{"default": {"spread_percent": "0.5", "fixed_fees": {"USD": "1.00"},
+//	             "tiers": {"USD": [{"min_amount": "10000", "spread_percent": "0.2"}]}},
+//	 "accounts": {"partner": {"spread_percent": "0.1"}}}
+func LoadFeeSchedules(path string) (fee.Schedules, error) {
+	var schedules fee.Schedules
+
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return schedules, err
+	}
+
+	if err = json.Unmarshal(data, &schedules); err != nil {
+		return schedules, fmt.Errorf("invalid fee schedules: %w", err)
+	}
+
+	if err = validateSchedule(schedules.Default); err != nil {
+		return schedules, fmt.Errorf("default fee schedule: %w", err)
+	}
+
+	for account, schedule := range schedules.Accounts {
+		if err = validateSchedule(schedule); err != nil {
+			return schedules, fmt.Errorf("fee schedule of %s: %w", account, err)
+		}
+	}
+
+	return schedules, nil
+}
+
+func validateSchedule(schedule fee.Schedule) error {
+	if err := validateSpread(schedule.SpreadPercent); err != nil {
+		return err
+	}
+
+	for currency, amount := range schedule.FixedFees {
+		if amount.IsNegative() {
+			return fmt.Errorf("fixed fee for %s must not be negative", currency)
+		}
+	}
+
+	for currency, tiers := range schedule.Tiers {
+		for _, tier := range tiers {
+			if tier.MinAmount.IsNegative() {
+				return fmt.Errorf("tier of %s must not start below zero", currency)
+			}
+
+			if tier.SpreadPercent != nil {
+				if err := validateSpread(*tier.SpreadPercent); err != nil {
+					return err
+				}
+			}
+
+			if tier.FixedFee != nil && tier.FixedFee.IsNegative() {
+				return fmt.Errorf("fixed fee of %s tier must not be negative", currency)
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateSpread(spread money.Decimal) error {
+	if spread.IsNegative() || spread.Cmp(hundred) >= 0 {
+		return fmt.Errorf("spread %s%% must be at least 0 and below 100", spread)
+	}
+
+	return nil
+}
+
+// Price works out the spread and fixed fee for converting amount of from at
+// the mid rate. The results are left to the caller, who rounds them.
+func (e *FeeEngine) Price(account, from string, amount, mid money.Decimal) api.Fees {
+	name := account
+	schedule, ok := e.schedules.Accounts[account]
+
+	if !ok {
+		name = defaultSchedule
+		schedule = e.schedules.Default
+	}
+
+	spread := schedule.SpreadPercent
+	fixed := schedule.FixedFees[from]
+
+	for _, tier := range schedule.Tiers[from] {
+		if tier.MinAmount.Cmp(amount) > 0 {
+			break
+		}
+
+		if tier.SpreadPercent != nil {
+			spread = *tier.SpreadPercent
+		}
+
+		if tier.FixedFee != nil {
+			fixed = *tier.FixedFee
+		}
+	}
+
+	return api.Fees{
+		Schedule:      name,
+		MidRate:       mid,
+		SpreadPercent: spread,
+		AppliedRate:   mid.Sub(mid.Percent(spread)),
+		Fee:           fixed,
+		FeeCurrency:   from,
+	}
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+)
+
+const testFeeSchedules = `{
+	"default": {
+		"spread_percent": "1",
+		"fixed_fees": {"USD": "2"},
+		"tiers": {"USD": [
+			{"min_amount": "10000", "spread_percent": "0.2", "fixed_fee": "0"},
+			{"min_amount": "1000", "spread_percent": "0.5"}
+		]}
+	},
+	"accounts": {"partner": {"spread_percent": "0.1"}}
+}`
+
+func newTestFeeEngine(t *testing.T) *FeeEngine {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "fees.json")
+
+	if err := os.WriteFile(path, []byte(testFeeSchedules), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	schedules, err := LoadFeeSchedules(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return NewFeeEngine(schedules)
+}
+
+func TestFeeEngine_Price(t *testing.T) {
+	engine := newTestFeeEngine(t)
+	mid := money.MustParse("0.9")
+
+	cases := []struct {
+		account, amount, spread, fee, schedule string
+	}{
+		{"someone", "100", "1", "2", "default"},
+		{"someone", "1000", "0.5", "2", "default"},
+		{"someone", "25000", "0.2", "0", "default"},
+		{"partner", "25000", "0.1", "0", "partner"},
+	}
+
+	for _, tc := range cases {
+		fees := engine.Price(tc.account, "USD", money.MustParse(tc.amount), mid)
+
+		if !fees.SpreadPercent.Equal(money.MustParse(tc.spread)) || !fees.Fee.Equal(money.MustParse(tc.fee)) || fees.Schedule != tc.schedule {
+			t.Errorf("%s %s: unexpected fees %+v", tc.account, tc.amount, fees)
+		}
+	}
+
+	if fees := engine.Price("someone", "EUR", money.NewFromInt(100), mid); !fees.Fee.IsZero() {
+		t.Errorf("no fixed fee is configured for EUR, got %s", fees.Fee)
+	}
+}
+
+func TestLoadFeeSchedules_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fees.json")
+	_ = os.WriteFile(path, []byte(`{"default": {"spread_percent": "100"}}`), 0o644)
+
+	if _, err := LoadFeeSchedules(path); err == nil {
+		t.Fatal("a spread of 100% should be rejected")
+	}
+}
+
+func TestConversionService_Fees(t *testing.T) {
+	storage := NewStorageService(NewMockRepository(), newTestAudit(), nil)
+	provider := NewStaticRateProvider(map[string]money.Decimal{"USD/EUR": money.MustParse("0.9")})
+	conversion := NewConversionService(provider, storage, NewCurrencyRegistry(ISO4217), newTestRounding(t), newTestFeeEngine(t), testQuoteTTL)
+
+	convLog, err := conversion.Convert(context.Background(), &api.Request{From: "USD", To: "EUR", Amount: money.NewFromInt(100)}, ConvertOptions{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 1% spread: 0.9 → 0.891; (100 - 2 USD) × 0.891 = 87.318 → 87.32 EUR.
+	resp := convLog.Response
+	fees := resp.Fees
+
+	if fees == nil || fees.MidRate.String() != "0.9" || fees.AppliedRate.String() != "0.891" ||
+		fees.GrossResult.String() != "90" || fees.NetResult.String() != "87.32" {
+		t.Fatalf("unexpected breakdown %+v", fees)
+	}
+
+	if !resp.Result.Equal(fees.NetResult) || !resp.Info.Quote.Equal(fees.AppliedRate) {
+		t.Fatalf("response should carry the net result at the applied rate: %+v", resp)
+	}
+
+	if stored := storage.GetConversionLogByID(convLog.Id); stored.Response.Fees == nil || stored.Response.Fees.Fee.String() != "2" {
+		t.Fatalf("breakdown should be stored with the log: %+v", stored.Response.Fees)
+	}
+
+	_, err = conversion.Convert(context.Background(), &api.Request{From: "USD", To: "EUR", Amount: money.NewFromInt(1)}, ConvertOptions{})
+
+	var validationErr *ValidationError
+
+	if !errors.As(err, &validationErr) || validationErr.Field != "amount" {
+		t.Fatalf("amount below the fee should be rejected, got %v", err)
+	}
+}
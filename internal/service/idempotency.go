@@ -0,0 +1,84 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/model/idempotency"
+	"github.com/M2rk13/Otus-327619/internal/repository"
+)
+
+var (
+	ErrIdempotencyKeyReused    = errors.New("idempotency key was already used with a different request")
+	ErrIdempotencyKeyInProcess = errors.New("a request with this idempotency key is still being processed")
+)
+
+// IdempotencyService remembers the responses to requests sent with an
+// Idempotency-Key so that a retry replays the first response instead of
+// repeating the mutation. Records live in the repository, so every instance
+// sees them, and expire after ttl.
+type IdempotencyService struct {
+	repo repository.Repository
+	ttl  time.Duration
+}
+
+func NewIdempotencyService(repo repository.Repository, ttl time.Duration) *IdempotencyService {
+	return &IdempotencyService{repo: repo, ttl: ttl}
+}
+
+// Begin reserves key for the request described by method, path and body. It
+// returns nil if the caller should process the request and then Complete or
+// Release the key, or the stored record to replay if the key was used for the
+// same request before. A key used for a different request fails with
+// ErrIdempotencyKeyReused, one whose first request has not finished yet with
+// ErrIdempotencyKeyInProcess.
+func (s *IdempotencyService) Begin(account, key, method, path string, body []byte) (*idempotency.Record, error) {
+	now := time.Now()
+	rec := &idempotency.Record{
+		Account:     account,
+		Key:         key,
+		Fingerprint: fingerprint(method, path, body),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.ttl),
+	}
+
+	existing, err := s.repo.ReserveIdempotencyKey(rec)
+
+	switch {
+	case err != nil || existing == nil:
+		return nil, err
+	case existing.Fingerprint != rec.Fingerprint:
+		return nil, ErrIdempotencyKeyReused
+	case !existing.Completed():
+		return nil, ErrIdempotencyKeyInProcess
+	}
+
+	return existing, nil
+}
+
+// Complete stores the response to the request key was reserved for.
+func (s *IdempotencyService) Complete(account, key string, status int, headers map[string]string, body []byte) error {
+	return s.repo.CompleteIdempotencyKey(&idempotency.Record{
+		Account: account,
+		Key:     key,
+		Status:  status,
+		Headers: headers,
+		Body:    body,
+	})
+}
+
+// Release frees key so that the request can be retried, for when it failed
+// without a response worth replaying.
+func (s *IdempotencyService) Release(account, key string) error {
+	return s.repo.ReleaseIdempotencyKey(account, key)
+}
+
+func fingerprint(method, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + path + "\n"))
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyService_Replay(t *testing.T) {
+	svc := NewIdempotencyService(NewMockRepository(), time.Hour)
+	body := []byte(`{"from":"USD","to":"EUR","amount":10}`)
+
+	if rec, err := svc.Begin("alice", "key-1", "POST", "/api/convert", body); err != nil || rec != nil {
+		t.Fatalf("first request should be processed, got %v, %v", rec, err)
+	}
+
+	if _, err := svc.Begin("alice", "key-1", "POST", "/api/convert", body); !errors.Is(err, ErrIdempotencyKeyInProcess) {
+		t.Fatalf("expected ErrIdempotencyKeyInProcess before completion, got %v", err)
+	}
+
+	headers := map[string]string{"Content-Type": "application/json"}
+
+	if err := svc.Complete("alice", "key-1", 201, headers, []byte(`{"id":"1"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	rec, err := svc.Begin("alice", "key-1", "POST", "/api/convert", body)
+
+	if err != nil || rec == nil || rec.Status != 201 || string(rec.Body) != `{"id":"1"}` || rec.Headers["Content-Type"] != "application/json" {
+		t.Fatalf("expected the stored response to be replayed, got %+v, %v", rec, err)
+	}
+
+	if _, err := svc.Begin("alice", "key-1", "POST", "/api/convert", []byte(`{}`)); !errors.Is(err, ErrIdempotencyKeyReused) {
+		t.Fatalf("expected ErrIdempotencyKeyReused for a different body, got %v", err)
+	}
+
+	if _, err := svc.Begin("alice", "key-1", "POST", "/api/quotes", body); !errors.Is(err, ErrIdempotencyKeyReused) {
+		t.Fatalf("expected ErrIdempotencyKeyReused for a different endpoint, got %v", err)
+	}
+
+	if rec, err := svc.Begin("bob", "key-1", "POST", "/api/convert", body); err != nil || rec != nil {
+		t.Fatalf("keys should be scoped per account, got %v, %v", rec, err)
+	}
+}
+
+func TestIdempotencyService_Release(t *testing.T) {
+	svc := NewIdempotencyService(NewMockRepository(), time.Hour)
+
+	if _, err := svc.Begin("alice", "key-1", "POST", "/api/convert", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := svc.Release("alice", "key-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if rec, err := svc.Begin("alice", "key-1", "POST", "/api/convert", []byte(`{}`)); err != nil || rec != nil {
+		t.Fatalf("a released key should be usable again, got %v, %v", rec, err)
+	}
+}
+
+func TestIdempotencyService_Expiry(t *testing.T) {
+	svc := NewIdempotencyService(NewMockRepository(), -time.Second)
+
+	if _, err := svc.Begin("alice", "key-1", "POST", "/api/convert", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := svc.Complete("alice", "key-1", 200, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if rec, err := svc.Begin("alice", "key-1", "POST", "/api/convert", []byte(`{}`)); err != nil || rec != nil {
+		t.Fatalf("an expired key should be reserved anew, got %v, %v", rec, err)
+	}
+}
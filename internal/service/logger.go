@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/M2rk13/Otus-327619/internal/lifecycle"
 	"github.com/M2rk13/Otus-327619/internal/repository"
 )
 
@@ -17,13 +18,9 @@ func NewLoggerService(repo repository.Repository) *LoggerService {
 	return &LoggerService{repo: repo}
 }
 
-func (l *LoggerService) StartSliceLogger(
-	wg *sync.WaitGroup,
-	ctx context.Context,
-	requestChanState *int,
-	responseChanState *int,
-	logChanState *int,
-) {
+// StartSliceLogger prints newly stored items until ctx is cancelled or every
+// one of queues is closed and empty.
+func (l *LoggerService) StartSliceLogger(wg *sync.WaitGroup, ctx context.Context, queues ...lifecycle.Observable) {
 	wg.Add(1)
 
 	go func() {
@@ -42,7 +39,7 @@ func (l *LoggerService) StartSliceLogger(
 					fmt.Println("--- New Conversion Requests ---")
 
 					for _, req := range newRequests {
-						fmt.Printf("Request: From=%s, To=%s, Amount=%.2f\n", req.From, req.To, req.Amount)
+						fmt.Printf("Request: From=%s, To=%s, Amount=%s\n", req.From, req.To, req.Amount)
 					}
 				}
 
@@ -52,7 +49,7 @@ func (l *LoggerService) StartSliceLogger(
 					fmt.Println("--- New Conversion Responses ---")
 
 					for _, resp := range newResponses {
-						fmt.Printf("Response: Success=%t, Result=%.2f\n", resp.Success, resp.Result)
+						fmt.Printf("Response: Success=%t, Result=%s\n", resp.Success, resp.Result)
 					}
 				}
 
@@ -63,7 +60,7 @@ func (l *LoggerService) StartSliceLogger(
 
 					for _, logItem := range newLogs {
 						fmt.Printf(
-							"  Log: GetId=%s, GetTimestamp=%s, RequestFrom=%s, ResponseResult=%.2f\n",
+							"  Log: GetId=%s, GetTimestamp=%s, RequestFrom=%s, ResponseResult=%s\n",
 							logItem.Id,
 							logItem.Timestamp.Format(time.RFC3339),
 							logItem.Request.From,
@@ -71,9 +68,9 @@ func (l *LoggerService) StartSliceLogger(
 					}
 				}
 
-				if *requestChanState == 0 && *responseChanState == 0 && *logChanState == 0 {
+				if drained(queues) {
 					time.Sleep(250 * time.Millisecond)
-					fmt.Println("All channels closed. Shutting down logger.")
+					fmt.Println("All queues closed. Shutting down logger.")
 
 					return
 				}
@@ -85,3 +82,25 @@ func (l *LoggerService) StartSliceLogger(
 		}
 	}()
 }
+
+// drained reports whether all queues are closed and empty. Without queues
+// there is nothing to wait for, and the logger runs until cancelled.
+func drained(queues []lifecycle.Observable) bool {
+	if len(queues) == 0 {
+		return false
+	}
+
+	for _, queue := range queues {
+		select {
+		case <-queue.Closed():
+		default:
+			return false
+		}
+
+		if queue.Len() > 0 {
+			return false
+		}
+	}
+
+	return true
+}
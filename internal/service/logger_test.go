@@ -6,8 +6,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/M2rk13/Otus-327619/internal/lifecycle"
 	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/idempotency"
 	"github.com/M2rk13/Otus-327619/internal/model/log"
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+	"github.com/M2rk13/Otus-327619/internal/model/quote"
 	"github.com/M2rk13/Otus-327619/internal/repository"
 
 	"github.com/google/uuid"
@@ -20,21 +24,40 @@ type loggerMockRepo struct {
 	onceLogs []*log.ConversionLog
 }
 
-func (m *loggerMockRepo) CreateRequest(*api.Request)                     {}
-func (m *loggerMockRepo) GetRequestByID(string) *api.Request             { return nil }
-func (m *loggerMockRepo) GetAllRequests() []*api.Request                 { return nil }
-func (m *loggerMockRepo) UpdateRequest(*api.Request) bool                { return false }
-func (m *loggerMockRepo) DeleteRequest(string) bool                      { return false }
-func (m *loggerMockRepo) CreateResponse(*api.Response)                   {}
-func (m *loggerMockRepo) GetResponseByID(string) *api.Response           { return nil }
-func (m *loggerMockRepo) GetAllResponses() []*api.Response               { return nil }
-func (m *loggerMockRepo) UpdateResponse(*api.Response) bool              { return false }
-func (m *loggerMockRepo) DeleteResponse(string) bool                     { return false }
-func (m *loggerMockRepo) CreateConversionLog(*log.ConversionLog)         {}
-func (m *loggerMockRepo) GetConversionLogByID(string) *log.ConversionLog { return nil }
-func (m *loggerMockRepo) GetAllConversionLogs() []*log.ConversionLog     { return nil }
-func (m *loggerMockRepo) UpdateConversionLog(*log.ConversionLog) bool    { return false }
-func (m *loggerMockRepo) DeleteConversionLog(string) bool                { return false }
+func (m *loggerMockRepo) CreateRequest(*api.Request) error                { return nil }
+func (m *loggerMockRepo) GetRequestByID(string) *api.Request              { return nil }
+func (m *loggerMockRepo) GetAllRequests(bool) []*api.Request              { return nil }
+func (m *loggerMockRepo) UpdateRequest(*api.Request) error                { return nil }
+func (m *loggerMockRepo) CreateRequests([]*api.Request) error             { return nil }
+func (m *loggerMockRepo) CreateResponses([]*api.Response) error           { return nil }
+func (m *loggerMockRepo) CreateConversionLogs([]*log.ConversionLog) error { return nil }
+func (m *loggerMockRepo) DeleteRequest(string) (repository.Cascaded, error) {
+	return repository.Cascaded{}, nil
+}
+func (m *loggerMockRepo) CreateResponse(*api.Response) error   { return nil }
+func (m *loggerMockRepo) GetResponseByID(string) *api.Response { return nil }
+func (m *loggerMockRepo) GetAllResponses(bool) []*api.Response { return nil }
+func (m *loggerMockRepo) UpdateResponse(*api.Response) error   { return nil }
+func (m *loggerMockRepo) DeleteResponse(string) (repository.Cascaded, error) {
+	return repository.Cascaded{}, nil
+}
+func (m *loggerMockRepo) CreateConversionLog(*log.ConversionLog) error     { return nil }
+func (m *loggerMockRepo) GetConversionLogByID(string) *log.ConversionLog   { return nil }
+func (m *loggerMockRepo) GetAllConversionLogs(bool) []*log.ConversionLog   { return nil }
+func (m *loggerMockRepo) UpdateConversionLog(*log.ConversionLog) error     { return nil }
+func (m *loggerMockRepo) DeleteConversionLog(string) error                 { return nil }
+func (m *loggerMockRepo) RestoreRequest(string) error                      { return nil }
+func (m *loggerMockRepo) RestoreResponse(string) error                     { return nil }
+func (m *loggerMockRepo) RestoreConversionLog(string) error                { return nil }
+func (m *loggerMockRepo) CreateQuote(*quote.Quote) error                   { return nil }
+func (m *loggerMockRepo) GetQuoteByID(string) *quote.Quote                 { return nil }
+func (m *loggerMockRepo) UseQuote(string, time.Time) (*quote.Quote, error) { return nil, nil }
+func (m *loggerMockRepo) ReserveIdempotencyKey(*idempotency.Record) (*idempotency.Record, error) {
+	return nil, nil
+}
+func (m *loggerMockRepo) CompleteIdempotencyKey(*idempotency.Record) error { return nil }
+func (m *loggerMockRepo) ReleaseIdempotencyKey(string, string) error       { return nil }
+func (m *loggerMockRepo) PurgeDeleted(time.Time) (int, error)              { return 0, nil }
 
 func (m *loggerMockRepo) GetNewConversionRequests() []*api.Request {
 	m.mu.Lock()
@@ -78,11 +101,11 @@ func TestNewLoggerService(t *testing.T) {
 
 func TestLoggerStartSliceLoggerTickerAndShutdown(t *testing.T) {
 	r := &loggerMockRepo{}
-	r.onceReq = []*api.Request{{Id: uuid.New().String(), From: "GBP", To: "USD", Amount: 5}}
-	r.onceResp = []*api.Response{{Id: uuid.New().String(), Success: true, Result: 1.23}}
+	r.onceReq = []*api.Request{{Id: uuid.New().String(), From: "GBP", To: "USD", Amount: money.NewFromInt(5)}}
+	r.onceResp = []*api.Response{{Id: uuid.New().String(), Success: true, Result: money.MustParse("1.23")}}
 	r.onceLogs = []*log.ConversionLog{log.NewConversionLog(uuid.New().String(),
-		api.Request{Id: uuid.New().String(), From: "A", To: "B", Amount: 1},
-		api.Response{Id: uuid.New().String(), Success: true, Result: 2.0},
+		api.Request{Id: uuid.New().String(), From: "A", To: "B", Amount: money.NewFromInt(1)},
+		api.Response{Id: uuid.New().String(), Success: true, Result: money.MustParse("2.0")},
 	)}
 
 	l := NewLoggerService(r)
@@ -91,8 +114,11 @@ func TestLoggerStartSliceLoggerTickerAndShutdown(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	reqState, respState, logState := 0, 0, 0
-	l.StartSliceLogger(&wg, ctx, &reqState, &respState, &logState)
+	requests := lifecycle.NewQueue[*api.Request](1)
+	responses := lifecycle.NewQueue[*api.Response](1)
+	requests.Close()
+	responses.Close()
+	l.StartSliceLogger(&wg, ctx, requests, responses)
 
 	done := make(chan struct{})
 	go func() { wg.Wait(); close(done) }()
@@ -101,7 +127,7 @@ func TestLoggerStartSliceLoggerTickerAndShutdown(t *testing.T) {
 	case <-done:
 		// ок
 	case <-time.After(3 * time.Second):
-		t.Fatal("logger did not stop on closed states")
+		t.Fatal("logger did not stop on closed queues")
 	}
 }
 
@@ -112,9 +138,7 @@ func TestLoggerStartSliceLoggerContextCancel(t *testing.T) {
 	var wg sync.WaitGroup
 	ctx, cancel := context.WithCancel(context.Background())
 
-	reqState, respState, logState := 1, 1, 1
-
-	l.StartSliceLogger(&wg, ctx, &reqState, &respState, &logState)
+	l.StartSliceLogger(&wg, ctx, lifecycle.NewQueue[*api.Request](1))
 
 	cancel()
 
@@ -128,3 +152,33 @@ func TestLoggerStartSliceLoggerContextCancel(t *testing.T) {
 		t.Fatal("logger did not stop on context cancel")
 	}
 }
+
+func TestLoggerStartSliceLoggerWaitsForBacklog(t *testing.T) {
+	l := NewLoggerService(&loggerMockRepo{})
+
+	var wg sync.WaitGroup
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	requests := lifecycle.NewQueue[*api.Request](1)
+	push(t, requests, &api.Request{From: "USD", To: "EUR"})
+	requests.Close()
+	l.StartSliceLogger(&wg, ctx, requests)
+
+	done := make(chan struct{})
+	go func() { wg.Wait(); close(done) }()
+
+	select {
+	case <-done:
+		t.Fatal("logger stopped while a closed queue still held items")
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	requests.Drain()
+
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("logger did not stop once the queue was drained")
+	}
+}
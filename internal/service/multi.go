@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/log"
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+	"github.com/M2rk13/Otus-327619/internal/model/quote"
+)
+
+// MaxTargets is the largest number of target currencies of one multi-target
+// conversion.
+const MaxTargets = 20
+
+// ConvertMulti converts one amount into each of the target currencies. All
+// targets are priced before anything is stored, so an invalid target or an
+// unavailable rate fails the whole call. Each target is stored as its own
+// request/response pair, linked from a single parent log. The pairs are
+// stored in two batches; if a later write fails, the stored pairs are deleted
+// again so that no leg is left without its parent log.
+func (c *ConversionService) ConvertMulti(ctx context.Context, from string, amount money.Decimal, targets []string) (*log.MultiConversion, error) {
+	if len(targets) == 0 || len(targets) > MaxTargets {
+		return nil, &ValidationError{Field: "targets", Message: fmt.Sprintf("must list 1 to %d currencies", MaxTargets)}
+	}
+
+	quotes := make([]*quote.Quote, 0, len(targets))
+	seen := make(map[string]bool, len(targets))
+
+	for i, target := range targets {
+		field := fmt.Sprintf("targets[%d]", i)
+		target = strings.ToUpper(strings.TrimSpace(target))
+
+		if target == "" {
+			return nil, &ValidationError{Field: field, Message: "must not be empty"}
+		}
+
+		if seen[target] {
+			return nil, &ValidationError{Field: field, Message: fmt.Sprintf("duplicate currency %s", target)}
+		}
+
+		if err := c.currencies.Validate(field, target); err != nil {
+			return nil, err
+		}
+
+		seen[target] = true
+		q, err := c.priceRequest(ctx, &api.Request{From: from, To: target, Amount: amount}, nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		quotes = append(quotes, q)
+	}
+
+	quotedAt := quotes[0].QuotedAt
+
+	for _, q := range quotes[1:] {
+		if q.QuotedAt.Before(quotedAt) {
+			quotedAt = q.QuotedAt
+		}
+	}
+
+	source := api.Request{From: quotes[0].From, Amount: amount}
+	convLog := log.NewConversionLog("", source, api.Response{
+		Success: true,
+		Query:   source,
+		Info:    api.Info{Timestamp: quotedAt.Unix()},
+	})
+
+	result := &log.MultiConversion{
+		Log:       convLog,
+		From:      source.From,
+		Amount:    amount,
+		Timestamp: quotedAt.Unix(),
+		Results:   make(map[string]log.TargetResult, len(quotes)),
+	}
+
+	reqs := make([]*api.Request, 0, len(quotes))
+
+	for _, q := range quotes {
+		reqs = append(reqs, quoteRequest(q))
+	}
+
+	if err := c.storage.CreateRequests(ctx, reqs); err != nil {
+		return nil, err
+	}
+
+	resps := make([]*api.Response, 0, len(quotes))
+
+	for i, q := range quotes {
+		resps = append(resps, quoteResponse(q, reqs[i]))
+	}
+
+	if err := c.storage.CreateResponses(ctx, resps); err != nil {
+		c.discardLegs(ctx, reqs, nil)
+
+		return nil, err
+	}
+
+	for i, q := range quotes {
+		rounding := q.Rounding
+		convLog.Targets = append(convLog.Targets, log.Target{Request: *reqs[i], Response: *resps[i], Rounding: &rounding})
+		result.Results[q.To] = log.TargetResult{
+			Result:   q.Result,
+			Quote:    q.Rate,
+			Provider: q.Provider,
+			Legs:     q.Legs,
+			Fees:     resps[i].Fees,
+		}
+	}
+
+	if err := c.storage.CreateConversionLog(ctx, convLog); err != nil {
+		c.discardLegs(ctx, reqs, resps)
+
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// discardLegs deletes the stored pairs of a multi-target conversion that
+// failed, responses first so that the restrict policy lets the requests go.
+func (c *ConversionService) discardLegs(ctx context.Context, reqs []*api.Request, resps []*api.Response) {
+	for _, resp := range resps {
+		if err := c.storage.DeleteResponse(ctx, resp.Id); err != nil {
+			fmt.Printf("Failed to discard response %s of a failed multi-target conversion: %v\n", resp.Id, err)
+		}
+	}
+
+	for _, req := range reqs {
+		if err := c.storage.DeleteRequest(ctx, req.Id); err != nil {
+			fmt.Printf("Failed to discard request %s of a failed multi-target conversion: %v\n", req.Id, err)
+		}
+	}
+}
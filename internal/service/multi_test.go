@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/M2rk13/Otus-327619/internal/model/fee"
+	"github.com/M2rk13/Otus-327619/internal/model/log"
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+)
+
+func newMultiTestConversion(t *testing.T) (*ConversionService, *StorageService) {
+	storage := NewStorageService(NewMockRepository(), newTestAudit(), nil)
+	provider := NewStaticRateProvider(map[string]money.Decimal{"USD/EUR": money.MustParse("0.9"), "USD/GBP": money.MustParse("0.8"), "USD/JPY": money.MustParse("150")})
+
+	return NewConversionService(provider, storage, NewCurrencyRegistry(ISO4217), newTestRounding(t), NewFeeEngine(fee.Schedules{}), testQuoteTTL), storage
+}
+
+func TestConversionService_ConvertMulti(t *testing.T) {
+	conversion, storage := newMultiTestConversion(t)
+
+	result, err := conversion.ConvertMulti(context.Background(), "usd", money.NewFromInt(100), []string{"eur", "GBP", "JPY"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{"EUR": "90", "GBP": "80", "JPY": "15000"}
+
+	for target, want := range expected {
+		if got := result.Results[target]; got.Result.String() != want || got.Quote.IsZero() {
+			t.Fatalf("%s: expected %s, got %+v", target, want, got)
+		}
+	}
+
+	if result.From != "USD" || len(result.Results) != 3 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	stored := storage.GetConversionLogByID(result.Log.Id)
+
+	if stored == nil || len(stored.Targets) != 3 || stored.Request.Id != "" || stored.Response.Info.Timestamp != result.Timestamp {
+		t.Fatalf("parent log should link the three targets: %+v", stored)
+	}
+
+	for _, target := range stored.Targets {
+		req := storage.GetRequestByID(target.Request.Id)
+		resp := storage.GetResponseByID(target.Response.Id)
+
+		if req == nil || resp == nil || resp.Query.Id != req.Id || target.Rounding == nil {
+			t.Fatalf("target %s should be stored as a linked pair", target.Request.To)
+		}
+	}
+}
+
+func TestConversionService_ConvertMultiRejections(t *testing.T) {
+	conversion, storage := newMultiTestConversion(t)
+	var validationErr *ValidationError
+
+	cases := map[string][]string{
+		"targets[1]": {"EUR", "eur"},
+		"targets[2]": {"EUR", "GBP", "XYZ"},
+		"targets":    {},
+	}
+
+	for field, targets := range cases {
+		if _, err := conversion.ConvertMulti(context.Background(), "USD", money.NewFromInt(100), targets); !errors.As(err, &validationErr) || validationErr.Field != field {
+			t.Fatalf("%v: expected error on %s, got %v", targets, field, err)
+		}
+	}
+
+	if _, err := conversion.ConvertMulti(context.Background(), "USD", money.NewFromInt(100), []string{"EUR", "CHF"}); !errors.Is(err, ErrRateUnavailable) {
+		t.Fatalf("missing rate should fail the call, got %v", err)
+	}
+
+	if stored := storage.GetAllRequests(true); len(stored) != 0 {
+		t.Fatalf("failed calls must not store anything, got %d requests", len(stored))
+	}
+}
+
+// failingLogRepository stores everything but conversion logs.
+type failingLogRepository struct {
+	*MockRepository
+}
+
+func (r *failingLogRepository) CreateConversionLog(*log.ConversionLog) error {
+	return errors.New("log store unavailable")
+}
+
+func TestConversionService_ConvertMultiDiscardsLegs(t *testing.T) {
+	repo := &failingLogRepository{MockRepository: NewMockRepository()}
+	storage := NewStorageService(repo, newTestAudit(), nil)
+	provider := NewStaticRateProvider(map[string]money.Decimal{"USD/EUR": money.MustParse("0.9"), "USD/GBP": money.MustParse("0.8")})
+	conversion := NewConversionService(provider, storage, NewCurrencyRegistry(ISO4217), newTestRounding(t), NewFeeEngine(fee.Schedules{}), testQuoteTTL)
+
+	if _, err := conversion.ConvertMulti(context.Background(), "USD", money.NewFromInt(100), []string{"EUR", "GBP"}); err == nil {
+		t.Fatal("ConvertMulti should fail when the parent log cannot be stored")
+	}
+
+	if reqs, resps := storage.GetAllRequests(false), storage.GetAllResponses(false); len(reqs) != 0 || len(resps) != 0 {
+		t.Fatalf("legs of the failed conversion were left behind: %d requests, %d responses", len(reqs), len(resps))
+	}
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/enum"
+	"github.com/M2rk13/Otus-327619/internal/lifecycle"
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/log"
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+	"github.com/M2rk13/Otus-327619/internal/model/outbox"
+	"github.com/M2rk13/Otus-327619/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+func openTestOutbox(t *testing.T, path string) *repository.FileOutbox {
+	t.Helper()
+	box, err := repository.NewFileOutbox(path)
+
+	if err != nil {
+		t.Fatalf("outbox: %v", err)
+	}
+
+	t.Cleanup(box.Close)
+
+	return box
+}
+
+func outboxEntry(t *testing.T, entity string, item interface{ GetId() string }) *outbox.Entry {
+	t.Helper()
+	payload, err := json.Marshal(item)
+
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	return &outbox.Entry{Entity: entity, Id: item.GetId(), Payload: payload, AcceptedAt: time.Now()}
+}
+
+func startWithOutbox(t *testing.T, svc *StorageService, box repository.Outbox, retry RetryPolicy) (*sync.WaitGroup, context.CancelFunc) {
+	t.Helper()
+
+	var wg sync.WaitGroup
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	svc.StartStorageService(&wg, ctx, PoolConfig{Retry: retry, Outbox: box},
+		lifecycle.NewQueue[*api.Request](5),
+		lifecycle.NewQueue[*api.Response](5),
+		lifecycle.NewQueue[*log.ConversionLog](5),
+	)
+
+	return &wg, cancel
+}
+
+func TestStorageService_OutboxReplaysPendingEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.json")
+	box := openTestOutbox(t, path)
+	mockRepo := NewMockRepository()
+	svc := NewStorageService(mockRepo, newTestAudit(), nil)
+
+	stored := &api.Request{From: "USD", To: "EUR", Amount: money.NewFromInt(1)}
+	_ = mockRepo.CreateRequest(stored)
+	req := &api.Request{Id: uuid.New().String(), From: "USD", To: "GBP", Amount: money.NewFromInt(2)}
+	convLog := log.NewConversionLog(uuid.New().String(), *req, api.Response{})
+
+	// The log was journalled before its request, as the queues are
+	// independent; the stored request only missed its acknowledgement.
+	err := box.Append(
+		outboxEntry(t, logEntity, convLog),
+		outboxEntry(t, requestEntity, stored),
+		outboxEntry(t, requestEntity, req),
+	)
+
+	if err != nil {
+		t.Fatalf("append: %v", err)
+	}
+
+	startWithOutbox(t, svc, box, RetryPolicy{BaseDelay: time.Millisecond})
+
+	eventually(t, func() bool {
+		requests, _, logs := mockRepo.counts()
+
+		return requests == 2 && logs == 1
+	})
+
+	eventually(t, func() bool { return len(box.Pending()) == 0 })
+
+	if pending := openTestOutbox(t, path).Pending(); len(pending) != 0 {
+		t.Fatalf("acknowledged entries came back after reopening: %d", len(pending))
+	}
+}
+
+func TestStorageService_OutboxKeepsUnstoredOnShutdown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.json")
+	box := openTestOutbox(t, path)
+	mockRepo := NewMockRepository()
+	svc := NewStorageService(mockRepo, newTestAudit(), nil)
+	wg, cancel := startWithOutbox(t, svc, box, RetryPolicy{Attempts: 1000, BaseDelay: time.Millisecond})
+
+	req := api.Request{Id: uuid.New().String(), From: "USD", To: "EUR", Amount: money.NewFromInt(1)}
+
+	if err := svc.EnqueueConversionLog(context.Background(), log.NewConversionLog("", req, api.Response{})); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	if err := svc.EnqueueRequest(context.Background(), &api.Request{From: "USD", To: "EUR", Amount: money.NewFromInt(2)}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	eventually(t, func() bool {
+		requests, _, _ := mockRepo.counts()
+
+		return requests == 1
+	})
+
+	cancel()
+	wg.Wait()
+
+	pending := openTestOutbox(t, path).Pending()
+
+	if len(pending) != 1 || pending[0].Entity != logEntity {
+		t.Fatalf("expected the unstored log to stay in the outbox, got %+v", pending)
+	}
+}
+
+func TestStorageService_OutboxAcksDroppedItems(t *testing.T) {
+	box := openTestOutbox(t, filepath.Join(t.TempDir(), "outbox.json"))
+	queue := lifecycle.NewQueueWithPolicy[*api.Request](1, enum.QueueDropOldest)
+	svc := NewStorageService(NewMockRepository(), newTestAudit(), nil)
+	pool := newIngestPool(requestEntity, queue, 1, PoolConfig{Outbox: box}, svc.CreateRequest, svc.CreateRequests)
+
+	first := &api.Request{Id: uuid.New().String(), From: "USD", To: "EUR", Amount: money.NewFromInt(1)}
+	second := &api.Request{Id: uuid.New().String(), From: "USD", To: "EUR", Amount: money.NewFromInt(2)}
+
+	for _, req := range []*api.Request{first, second} {
+		if err := pool.enqueue(context.Background(), req); err != nil {
+			t.Fatalf("enqueue: %v", err)
+		}
+	}
+
+	if pending := box.Pending(); len(pending) != 1 || pending[0].Id != second.Id {
+		t.Fatalf("outbox should keep only the queued request, got %d entries", len(pending))
+	}
+}
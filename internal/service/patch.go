@@ -0,0 +1,335 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/M2rk13/Otus-327619/internal/enum"
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/log"
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+	"github.com/M2rk13/Otus-327619/internal/repository"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+var (
+	ErrUnsupportedPatch = errors.New("unsupported patch media type")
+	ErrInvalidPatch     = errors.New("invalid patch document")
+)
+
+var (
+	requestImmutableFields  = []string{"/id", "/version", "/deleted_at"}
+	responseImmutableFields = []string{"/id", "/version", "/deleted_at", "/info/timestamp", "/fees"}
+	logImmutableFields      = []string{"/id", "/version", "/deleted_at", "/timestamp", "/rounding", "/batch_id", "/targets", "/response/fees"}
+
+	requestDecimalFields  = []string{"/amount"}
+	responseDecimalFields = []string{"/query/amount", "/info/quote", "/result"}
+	logDecimalFields      = []string{"/request/amount", "/response/query/amount", "/response/info/quote", "/response/result"}
+)
+
+// Patch is a partial update in one of the supported formats: RFC 7396 merge
+// patch or RFC 6902 JSON patch.
+type Patch struct {
+	Type     string
+	Document []byte
+}
+
+// ValidationError reports a field that is invalid or may not be changed.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// PatchRequest applies patch to the stored request and saves the result.
+// expectedVersion is the version the client based the patch on, zero means
+// the version that was read. The patched currencies are normalised and
+// checked against currencies, as on create and replace.
+func (s *StorageService) PatchRequest(
+	ctx context.Context,
+	id string,
+	patch Patch,
+	expectedVersion int64,
+	currencies *CurrencyRegistry,
+) (*api.Request, error) {
+	current := s.repo.GetRequestByID(id)
+
+	if current == nil {
+		return nil, repository.ErrNotFound
+	}
+
+	patched := &api.Request{}
+
+	if err := applyPatch(current, patched, patch, expectedVersion, requestImmutableFields, requestDecimalFields); err != nil {
+		return nil, err
+	}
+
+	if err := validateRequest("", patched); err != nil {
+		return nil, err
+	}
+
+	if err := currencies.NormalizeRequest("", patched, false); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateRequest(patched); err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, enum.ActionUpdate, requestEntity, id, current, patched)
+
+	return patched, nil
+}
+
+// PatchResponse applies patch to the stored response and saves the result.
+func (s *StorageService) PatchResponse(
+	ctx context.Context,
+	id string,
+	patch Patch,
+	expectedVersion int64,
+	currencies *CurrencyRegistry,
+) (*api.Response, error) {
+	current := s.repo.GetResponseByID(id)
+
+	if current == nil {
+		return nil, repository.ErrNotFound
+	}
+
+	patched := &api.Response{}
+
+	if err := applyPatch(current, patched, patch, expectedVersion, responseImmutableFields, responseDecimalFields); err != nil {
+		return nil, err
+	}
+
+	if err := validateResponse("", patched); err != nil {
+		return nil, err
+	}
+
+	if err := currencies.NormalizeRequest("query.", &patched.Query, true); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateResponse(patched); err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, enum.ActionUpdate, responseEntity, id, current, patched)
+
+	return patched, nil
+}
+
+// PatchConversionLog applies patch to the stored log and saves the result.
+func (s *StorageService) PatchConversionLog(
+	ctx context.Context,
+	id string,
+	patch Patch,
+	expectedVersion int64,
+	currencies *CurrencyRegistry,
+) (*log.ConversionLog, error) {
+	current := s.repo.GetConversionLogByID(id)
+
+	if current == nil {
+		return nil, repository.ErrNotFound
+	}
+
+	patched := &log.ConversionLog{}
+
+	if err := applyPatch(current, patched, patch, expectedVersion, logImmutableFields, logDecimalFields); err != nil {
+		return nil, err
+	}
+
+	if patched.Request.Id != "" {
+		if err := validateRequest("request.", &patched.Request); err != nil {
+			return nil, err
+		}
+	}
+
+	if patched.Response.Id != "" {
+		if err := validateResponse("response.", &patched.Response); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := currencies.NormalizeRequest("request.", &patched.Request, true); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateConversionLog(patched); err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, enum.ActionUpdate, logEntity, id, current, patched)
+
+	return patched, nil
+}
+
+// applyPatch patches the JSON form of current into target and rejects changes
+// to immutable fields. The update is pinned to the version that was read, so
+// a concurrent change between the read and the write is reported as a
+// conflict rather than silently overwritten.
+func applyPatch[T repository.Versioned](current, target T, patch Patch, expectedVersion int64, immutable, decimals []string) error {
+	if expectedVersion != 0 && expectedVersion != current.GetVersion() {
+		return repository.ErrVersionConflict
+	}
+
+	original, err := json.Marshal(current)
+
+	if err != nil {
+		return err
+	}
+
+	var modified []byte
+
+	switch patch.Type {
+	case MergePatchType:
+		modified, err = jsonpatch.MergePatch(original, patch.Document)
+	case JSONPatchType:
+		var decoded jsonpatch.Patch
+
+		if decoded, err = jsonpatch.DecodePatch(patch.Document); err == nil {
+			modified, err = decoded.Apply(original)
+		}
+	default:
+		return ErrUnsupportedPatch
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	if err := checkImmutable(original, modified, immutable); err != nil {
+		return err
+	}
+
+	if err := checkDecimals(modified, decimals); err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(modified))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(target); err != nil {
+		return decodeError(err)
+	}
+
+	target.SetVersion(current.GetVersion())
+
+	return nil
+}
+
+func checkImmutable(original, modified []byte, paths []string) error {
+	var before, after map[string]any
+
+	if err := json.Unmarshal(original, &before); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(modified, &after); err != nil {
+		return &ValidationError{Field: "/", Message: "document must be an object"}
+	}
+
+	for _, path := range paths {
+		if !reflect.DeepEqual(lookup(before, path), lookup(after, path)) {
+			return &ValidationError{Field: strings.ReplaceAll(strings.TrimPrefix(path, "/"), "/", "."), Message: "is immutable"}
+		}
+	}
+
+	return nil
+}
+
+// checkDecimals reports money fields that are neither numbers nor decimal
+// strings, naming the field the way decodeError does for other types.
+func checkDecimals(modified []byte, paths []string) error {
+	var after map[string]any
+
+	if err := json.Unmarshal(modified, &after); err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		switch value := lookup(after, path).(type) {
+		case nil, float64:
+			continue
+		case string:
+			if _, err := money.Parse(value); err == nil {
+				continue
+			}
+		}
+
+		return &ValidationError{Field: strings.ReplaceAll(strings.TrimPrefix(path, "/"), "/", "."), Message: "must be a decimal number"}
+	}
+
+	return nil
+}
+
+func lookup(doc map[string]any, path string) any {
+	var value any = doc
+
+	for _, key := range strings.Split(strings.TrimPrefix(path, "/"), "/") {
+		object, ok := value.(map[string]any)
+
+		if !ok {
+			return nil
+		}
+
+		value = object[key]
+	}
+
+	return value
+}
+
+func decodeError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+
+	if errors.As(err, &typeErr) {
+		return &ValidationError{Field: typeErr.Field, Message: fmt.Sprintf("must be %s", typeErr.Type)}
+	}
+
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return &ValidationError{Field: strings.Trim(field, `"`), Message: "is not a known field"}
+	}
+
+	return &ValidationError{Field: "/", Message: err.Error()}
+}
+
+func validateRequest(prefix string, req *api.Request) error {
+	if strings.TrimSpace(req.From) == "" {
+		return &ValidationError{Field: prefix + "from", Message: "must not be empty"}
+	}
+
+	if strings.TrimSpace(req.To) == "" {
+		return &ValidationError{Field: prefix + "to", Message: "must not be empty"}
+	}
+
+	if req.Amount.IsNegative() {
+		return &ValidationError{Field: prefix + "amount", Message: "must not be negative"}
+	}
+
+	return nil
+}
+
+func validateResponse(prefix string, resp *api.Response) error {
+	if resp.Info.Quote.IsNegative() {
+		return &ValidationError{Field: prefix + "info.quote", Message: "must not be negative"}
+	}
+
+	if resp.Result.IsNegative() {
+		return &ValidationError{Field: prefix + "result", Message: "must not be negative"}
+	}
+
+	return nil
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/log"
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+	"github.com/M2rk13/Otus-327619/internal/repository"
+)
+
+var testCurrencies = NewCurrencyRegistry(ISO4217)
+
+func TestPatchRequest_MergePatch(t *testing.T) {
+	s := NewStorageService(NewMockRepository(), newTestAudit(), nil)
+
+	req := &api.Request{From: "USD", To: "EUR", Amount: money.NewFromInt(10)}
+	_ = s.CreateRequest(context.Background(), req)
+
+	patched, err := s.PatchRequest(context.Background(), req.Id, Patch{Type: MergePatchType, Document: []byte(`{"amount": 25}`)}, 0, testCurrencies)
+
+	if err != nil {
+		t.Fatalf("PatchRequest failed: %v", err)
+	}
+
+	if !patched.Amount.Equal(money.NewFromInt(25)) || patched.From != "USD" || patched.Version != 2 {
+		t.Fatalf("unexpected patched request: %+v", patched)
+	}
+
+	if got := s.GetRequestByID(req.Id); !got.Amount.Equal(money.NewFromInt(25)) {
+		t.Fatalf("patch was not stored: %+v", got)
+	}
+}
+
+func TestPatchRequest_NormalisesCurrencies(t *testing.T) {
+	s := NewStorageService(NewMockRepository(), newTestAudit(), nil)
+
+	req := &api.Request{From: "USD", To: "EUR", Amount: money.NewFromInt(10)}
+	_ = s.CreateRequest(context.Background(), req)
+
+	patched, err := s.PatchRequest(context.Background(), req.Id, Patch{Type: MergePatchType, Document: []byte(`{"to": " gbp "}`)}, 0, testCurrencies)
+
+	if err != nil {
+		t.Fatalf("PatchRequest failed: %v", err)
+	}
+
+	if got := s.GetRequestByID(req.Id); patched.To != "GBP" || got.To != "GBP" {
+		t.Fatalf("currency was not normalised: patched %q, stored %q", patched.To, got.To)
+	}
+}
+
+func TestPatchResponse_JSONPatch(t *testing.T) {
+	s := NewStorageService(NewMockRepository(), newTestAudit(), nil)
+
+	resp := &api.Response{Success: false, Result: money.NewFromInt(1)}
+	_ = s.CreateResponse(context.Background(), resp)
+
+	doc := []byte(`[{"op": "replace", "path": "/success", "value": true}, {"op": "replace", "path": "/result", "value": 2.5}]`)
+	patched, err := s.PatchResponse(context.Background(), resp.Id, Patch{Type: JSONPatchType, Document: doc}, 1, testCurrencies)
+
+	if err != nil {
+		t.Fatalf("PatchResponse failed: %v", err)
+	}
+
+	if !patched.Success || !patched.Result.Equal(money.MustParse("2.5")) {
+		t.Fatalf("unexpected patched response: %+v", patched)
+	}
+}
+
+func TestPatch_Rejections(t *testing.T) {
+	s := NewStorageService(NewMockRepository(), newTestAudit(), nil)
+
+	req := &api.Request{From: "USD", To: "EUR", Amount: money.NewFromInt(10)}
+	_ = s.CreateRequest(context.Background(), req)
+
+	cl := &log.ConversionLog{}
+	_ = s.CreateConversionLog(context.Background(), cl)
+
+	var validationErr *ValidationError
+
+	cases := []struct {
+		name  string
+		apply func() error
+		check func(error) bool
+	}{
+		{
+			name: "immutable id",
+			apply: func() error {
+				_, err := s.PatchRequest(context.Background(), req.Id, Patch{Type: MergePatchType, Document: []byte(`{"id": "other"}`)}, 0, testCurrencies)
+				return err
+			},
+			check: func(err error) bool { return errors.As(err, &validationErr) && validationErr.Field == "id" },
+		},
+		{
+			name: "immutable timestamp",
+			apply: func() error {
+				doc := []byte(`[{"op": "replace", "path": "/timestamp", "value": "2000-01-01T00:00:00Z"}]`)
+				_, err := s.PatchConversionLog(context.Background(), cl.Id, Patch{Type: JSONPatchType, Document: doc}, 0, testCurrencies)
+				return err
+			},
+			check: func(err error) bool { return errors.As(err, &validationErr) && validationErr.Field == "timestamp" },
+		},
+		{
+			name: "unknown field",
+			apply: func() error {
+				_, err := s.PatchRequest(context.Background(), req.Id, Patch{Type: MergePatchType, Document: []byte(`{"rate": 1}`)}, 0, testCurrencies)
+				return err
+			},
+			check: func(err error) bool { return errors.As(err, &validationErr) && validationErr.Field == "rate" },
+		},
+		{
+			name: "wrong type",
+			apply: func() error {
+				_, err := s.PatchRequest(context.Background(), req.Id, Patch{Type: MergePatchType, Document: []byte(`{"amount": "ten"}`)}, 0, testCurrencies)
+				return err
+			},
+			check: func(err error) bool { return errors.As(err, &validationErr) && validationErr.Field == "amount" },
+		},
+		{
+			name: "failed validation",
+			apply: func() error {
+				_, err := s.PatchRequest(context.Background(), req.Id, Patch{Type: MergePatchType, Document: []byte(`{"from": null}`)}, 0, testCurrencies)
+				return err
+			},
+			check: func(err error) bool { return errors.As(err, &validationErr) && validationErr.Field == "from" },
+		},
+		{
+			name: "unknown currency",
+			apply: func() error {
+				_, err := s.PatchRequest(context.Background(), req.Id, Patch{Type: MergePatchType, Document: []byte(`{"from": "XXX"}`)}, 0, testCurrencies)
+				return err
+			},
+			check: func(err error) bool { return errors.As(err, &validationErr) && validationErr.Field == "from" },
+		},
+		{
+			name: "unknown currency in an embedded request",
+			apply: func() error {
+				doc := []byte(`{"request": {"from": "USD", "to": "XXX"}}`)
+				_, err := s.PatchConversionLog(context.Background(), cl.Id, Patch{Type: MergePatchType, Document: doc}, 0, testCurrencies)
+				return err
+			},
+			check: func(err error) bool { return errors.As(err, &validationErr) && validationErr.Field == "request.to" },
+		},
+		{
+			name: "failed test operation",
+			apply: func() error {
+				doc := []byte(`[{"op": "test", "path": "/amount", "value": 99}]`)
+				_, err := s.PatchRequest(context.Background(), req.Id, Patch{Type: JSONPatchType, Document: doc}, 0, testCurrencies)
+				return err
+			},
+			check: func(err error) bool { return errors.Is(err, ErrInvalidPatch) },
+		},
+		{
+			name: "unsupported media type",
+			apply: func() error {
+				_, err := s.PatchRequest(context.Background(), req.Id, Patch{Type: "application/json", Document: []byte(`{}`)}, 0, testCurrencies)
+				return err
+			},
+			check: func(err error) bool { return errors.Is(err, ErrUnsupportedPatch) },
+		},
+		{
+			name: "stale version",
+			apply: func() error {
+				_, err := s.PatchRequest(context.Background(), req.Id, Patch{Type: MergePatchType, Document: []byte(`{"amount": 1}`)}, 7, testCurrencies)
+				return err
+			},
+			check: func(err error) bool { return errors.Is(err, repository.ErrVersionConflict) },
+		},
+		{
+			name: "unknown id",
+			apply: func() error {
+				_, err := s.PatchRequest(context.Background(), "nope", Patch{Type: MergePatchType, Document: []byte(`{}`)}, 0, testCurrencies)
+				return err
+			},
+			check: func(err error) bool { return errors.Is(err, repository.ErrNotFound) },
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.apply(); !tc.check(err) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+
+	if got := s.GetRequestByID(req.Id); !got.Amount.Equal(money.NewFromInt(10)) || got.Version != 1 {
+		t.Fatalf("rejected patches must not change the item: %+v", got)
+	}
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/log"
+)
+
+// Envelope is one unit of ingested data: a request, its response and the log
+// linking them. Any of the three may be nil.
+type Envelope struct {
+	Source   string
+	Request  *api.Request
+	Response *api.Response
+	Log      *log.ConversionLog
+}
+
+// Source produces envelopes. Run sends them to out until the source is
+// exhausted or ctx is cancelled.
+type Source interface {
+	Name() string
+	Run(ctx context.Context, out chan<- *Envelope) error
+}
+
+// Stage transforms an envelope on its way to the sinks. Returning nil drops
+// the envelope, as does an error.
+type Stage interface {
+	Name() string
+	Process(ctx context.Context, env *Envelope) (*Envelope, error)
+}
+
+// Sink receives every envelope that passed all stages. Sinks that also
+// implement io.Closer are closed when the pipeline stops.
+type Sink interface {
+	Name() string
+	Write(ctx context.Context, env *Envelope) error
+}
+
+// Pipeline moves envelopes from its sources through its stages into its
+// sinks. It runs until ctx is cancelled: a source that is exhausted only
+// stops itself, so the pipeline can be fed by long-running sources next to
+// finite ones.
+type Pipeline struct {
+	sources []Source
+	stages  []Stage
+	sinks   []Sink
+	in      chan *Envelope
+}
+
+func NewPipeline(sources []Source, stages []Stage, sinks []Sink, buffer int) *Pipeline {
+	return &Pipeline{
+		sources: sources,
+		stages:  stages,
+		sinks:   sinks,
+		in:      make(chan *Envelope, buffer),
+	}
+}
+
+func (p *Pipeline) Start(wg *sync.WaitGroup, ctx context.Context) {
+	wg.Add(len(p.sources) + 1)
+
+	for _, src := range p.sources {
+		go func() {
+			defer wg.Done()
+
+			if err := src.Run(ctx, p.in); err != nil && ctx.Err() == nil {
+				fmt.Printf("Pipeline source %s failed: %v\n", src.Name(), err)
+
+				return
+			}
+
+			fmt.Printf("Pipeline source %s finished.\n", src.Name())
+		}()
+	}
+
+	go func() {
+		defer wg.Done()
+
+		fmt.Println("Ingestion pipeline started.")
+
+		for {
+			select {
+			case env := <-p.in:
+				p.process(ctx, env)
+			case <-ctx.Done():
+				p.closeSinks()
+				fmt.Println("Ingestion pipeline stopped by context.")
+
+				return
+			}
+		}
+	}()
+}
+
+// closeSinks closes the sinks that hold resources, such as the queues the
+// pipeline produces into.
+func (p *Pipeline) closeSinks() {
+	for _, sink := range p.sinks {
+		if closer, ok := sink.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				fmt.Printf("Failed to close pipeline sink %s: %v\n", sink.Name(), err)
+			}
+		}
+	}
+}
+
+func (p *Pipeline) process(ctx context.Context, env *Envelope) {
+	for _, stage := range p.stages {
+		next, err := stage.Process(ctx, env)
+
+		if err != nil {
+			fmt.Printf("Pipeline stage %s dropped an envelope from %s: %v\n", stage.Name(), env.Source, err)
+
+			return
+		}
+
+		if next == nil {
+			return
+		}
+
+		env = next
+	}
+
+	for _, sink := range p.sinks {
+		if err := sink.Write(ctx, env); err != nil && ctx.Err() == nil {
+			fmt.Printf("Pipeline sink %s failed: %v\n", sink.Name(), err)
+		}
+	}
+}
+
+// NormalizeStage trims and upper-cases the currency codes of an envelope.
+type NormalizeStage struct{}
+
+func (NormalizeStage) Name() string { return "normalize" }
+
+func (NormalizeStage) Process(_ context.Context, env *Envelope) (*Envelope, error) {
+	if env.Request != nil {
+		normalizeCurrencies(env.Request)
+	}
+
+	if env.Response != nil {
+		normalizeCurrencies(&env.Response.Query)
+	}
+
+	if env.Log != nil {
+		normalizeCurrencies(&env.Log.Request)
+		normalizeCurrencies(&env.Log.Response.Query)
+	}
+
+	return env, nil
+}
+
+func normalizeCurrencies(req *api.Request) {
+	req.From = strings.ToUpper(strings.TrimSpace(req.From))
+	req.To = strings.ToUpper(strings.TrimSpace(req.To))
+}
+
+// ValidateStage drops envelopes with invalid requests or responses, including
+// ones in unknown currencies.
+type ValidateStage struct {
+	currencies *CurrencyRegistry
+}
+
+func NewValidateStage(currencies *CurrencyRegistry) *ValidateStage {
+	return &ValidateStage{currencies: currencies}
+}
+
+func (s *ValidateStage) Name() string { return "validate" }
+
+func (s *ValidateStage) Process(_ context.Context, env *Envelope) (*Envelope, error) {
+	if env.Request != nil {
+		if err := s.validateRequest("request.", env.Request); err != nil {
+			return nil, err
+		}
+	}
+
+	if env.Response != nil {
+		if err := validateResponse("response.", env.Response); err != nil {
+			return nil, err
+		}
+	}
+
+	if env.Log != nil && env.Log.Request.Id != "" {
+		if err := s.validateRequest("log.request.", &env.Log.Request); err != nil {
+			return nil, err
+		}
+	}
+
+	return env, nil
+}
+
+func (s *ValidateStage) validateRequest(prefix string, req *api.Request) error {
+	if err := validateRequest(prefix, req); err != nil {
+		return err
+	}
+
+	return s.currencies.ValidateRequest(prefix, req)
+}
+
+// QueueSink hands envelopes to the storage workers, through the outbox when
+// one is configured. As the producer it closes their queues when the
+// pipeline stops.
+type QueueSink struct {
+	storage *StorageService
+}
+
+func NewQueueSink(storage *StorageService) *QueueSink {
+	return &QueueSink{storage: storage}
+}
+
+func (s *QueueSink) Name() string { return "storage" }
+
+func (s *QueueSink) Write(ctx context.Context, env *Envelope) error {
+	if env.Request != nil {
+		if err := s.storage.EnqueueRequest(ctx, env.Request); err != nil {
+			return err
+		}
+	}
+
+	if env.Response != nil {
+		if err := s.storage.EnqueueResponse(ctx, env.Response); err != nil {
+			return err
+		}
+	}
+
+	if env.Log != nil {
+		return s.storage.EnqueueConversionLog(ctx, env.Log)
+	}
+
+	return nil
+}
+
+func (s *QueueSink) Close() error {
+	s.storage.CloseIngest()
+
+	return nil
+}
+
+func send[T any](ctx context.Context, ch chan<- T, item T) error {
+	select {
+	case ch <- item:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/lifecycle"
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/log"
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+)
+
+type sliceSource struct {
+	envelopes []*Envelope
+}
+
+func (s *sliceSource) Name() string { return "slice" }
+
+func (s *sliceSource) Run(ctx context.Context, out chan<- *Envelope) error {
+	for _, env := range s.envelopes {
+		if err := send(ctx, out, env); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type captureSink struct {
+	mu        sync.Mutex
+	envelopes []*Envelope
+}
+
+func (s *captureSink) Name() string { return "capture" }
+
+func (s *captureSink) Write(_ context.Context, env *Envelope) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.envelopes = append(s.envelopes, env)
+
+	return nil
+}
+
+func (s *captureSink) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.envelopes)
+}
+
+func TestPipeline_StagesAndSinks(t *testing.T) {
+	valid := &Envelope{Request: &api.Request{Id: "1", From: " usd", To: "eur ", Amount: money.NewFromInt(10)}}
+	unknown := &Envelope{Request: &api.Request{Id: "2", From: "USD", To: "XXX", Amount: money.NewFromInt(10)}}
+	sink := &captureSink{}
+	pipeline := NewPipeline(
+		[]Source{&sliceSource{envelopes: []*Envelope{valid, unknown}}},
+		[]Stage{NormalizeStage{}, NewValidateStage(NewCurrencyRegistry(ISO4217))},
+		[]Sink{sink},
+		1,
+	)
+
+	var wg sync.WaitGroup
+	ctx, cancel := context.WithCancel(context.Background())
+	pipeline.Start(&wg, ctx)
+
+	eventually(t, func() bool { return sink.count() == 1 })
+	time.Sleep(50 * time.Millisecond)
+
+	if got := sink.envelopes[0].Request; got.From != "USD" || got.To != "EUR" {
+		t.Fatalf("expected normalised currencies, got %+v", got)
+	}
+
+	if sink.count() != 1 {
+		t.Fatalf("the envelope in an unknown currency should be dropped, got %d", sink.count())
+	}
+
+	cancel()
+	wg.Wait()
+}
+
+func TestPipeline_OutlivesSources(t *testing.T) {
+	mockRepo := NewMockRepository()
+	storage := NewStorageService(mockRepo, newTestAudit(), nil)
+	logs := lifecycle.NewQueue[*log.ConversionLog](10)
+	pipeline := NewPipeline(
+		[]Source{NewDemoSource(NewDispatcherService(), 2, time.Millisecond)},
+		nil,
+		[]Sink{NewQueueSink(storage)},
+		1,
+	)
+
+	var wg sync.WaitGroup
+	ctx, cancel := context.WithCancel(context.Background())
+	storage.StartStorageService(&wg, ctx, PoolConfig{}, lifecycle.NewQueue[*api.Request](10), lifecycle.NewQueue[*api.Response](10), logs)
+	pipeline.Start(&wg, ctx)
+
+	eventually(t, func() bool {
+		_, _, saved := mockRepo.counts()
+
+		return saved == 2
+	})
+
+	if requests, responses, _ := mockRepo.counts(); requests != 2 || responses != 2 {
+		t.Fatalf("expected two requests and responses, got %d and %d", requests, responses)
+	}
+
+	done := make(chan struct{})
+	go func() { wg.Wait(); close(done) }()
+
+	select {
+	case <-done:
+		t.Fatal("pipeline stopped when its only source was exhausted")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("pipeline did not stop on context cancel")
+	}
+
+	select {
+	case <-logs.Closed():
+	default:
+		t.Fatal("the pipeline should close the queues it produces into")
+	}
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/model/rate"
+)
+
+type chainedProvider struct {
+	provider RateProvider
+	breaker  *CircuitBreaker
+}
+
+// ProviderChain asks an ordered list of providers for a rate and returns the
+// first answer. Each provider gets its own timeout and circuit breaker, so a
+// provider that is down is skipped quickly instead of slowing every lookup.
+// A provider that does not quote the pair counts as healthy.
+type ProviderChain struct {
+	providers []chainedProvider
+	timeout   time.Duration
+}
+
+func NewProviderChain(providers []RateProvider, timeout time.Duration, threshold int, cooldown time.Duration) *ProviderChain {
+	chain := &ProviderChain{timeout: timeout}
+
+	for _, provider := range providers {
+		chain.providers = append(chain.providers, chainedProvider{
+			provider: provider,
+			breaker:  NewCircuitBreaker(threshold, cooldown),
+		})
+	}
+
+	return chain
+}
+
+func (c *ProviderChain) Name() string {
+	names := make([]string, 0, len(c.providers))
+
+	for _, p := range c.providers {
+		names = append(names, p.provider.Name())
+	}
+
+	return strings.Join(names, ",")
+}
+
+func (c *ProviderChain) Rate(ctx context.Context, from, to string) (*rate.Quote, error) {
+	return c.first(ctx, c.providers, func(ctx context.Context, provider RateProvider) (*rate.Quote, error) {
+		return provider.Rate(ctx, from, to)
+	})
+}
+
+// RateAt asks the providers that keep history, in order.
+func (c *ProviderChain) RateAt(ctx context.Context, from, to string, date time.Time) (*rate.Quote, error) {
+	var historical []chainedProvider
+
+	for _, p := range c.providers {
+		if _, ok := p.provider.(HistoricalRateProvider); ok {
+			historical = append(historical, p)
+		}
+	}
+
+	return c.first(ctx, historical, func(ctx context.Context, provider RateProvider) (*rate.Quote, error) {
+		return provider.(HistoricalRateProvider).RateAt(ctx, from, to, date)
+	})
+}
+
+func (c *ProviderChain) first(
+	ctx context.Context,
+	providers []chainedProvider,
+	call func(ctx context.Context, provider RateProvider) (*rate.Quote, error),
+) (*rate.Quote, error) {
+	var failures []string
+
+	for _, p := range providers {
+		if !p.breaker.Allow() {
+			continue
+		}
+
+		callCtx, cancel := context.WithTimeout(ctx, c.timeout)
+		quote, err := call(callCtx, p.provider)
+		cancel()
+
+		switch {
+		case err == nil:
+			p.breaker.Success()
+
+			return quote, nil
+		case errors.Is(err, ErrRateUnavailable):
+			p.breaker.Success()
+		case ctx.Err() != nil:
+			p.breaker.Abandon()
+
+			return nil, ctx.Err()
+		default:
+			p.breaker.Failure(err)
+			failures = append(failures, fmt.Sprintf("%s: %v", p.provider.Name(), err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrRateUnavailable, strings.Join(failures, "; "))
+	}
+
+	return nil, ErrRateUnavailable
+}
+
+// Pairs merges the pairs of all providers that can list them and are not
+// cut off by their breaker.
+func (c *ProviderChain) Pairs(ctx context.Context) ([]rate.Pair, error) {
+	seen := make(map[rate.Pair]bool)
+	var pairs []rate.Pair
+
+	for _, p := range c.providers {
+		lister, ok := p.provider.(PairLister)
+
+		if !ok || !p.breaker.Allow() {
+			continue
+		}
+
+		callCtx, cancel := context.WithTimeout(ctx, c.timeout)
+		listed, err := lister.Pairs(callCtx)
+		cancel()
+
+		if err != nil {
+			p.breaker.Failure(err)
+
+			continue
+		}
+
+		p.breaker.Success()
+
+		for _, pair := range listed {
+			if !seen[pair] {
+				seen[pair] = true
+				pairs = append(pairs, pair)
+			}
+		}
+	}
+
+	return pairs, nil
+}
+
+func (c *ProviderChain) Health() []rate.ProviderHealth {
+	health := make([]rate.ProviderHealth, 0, len(c.providers))
+
+	for _, p := range c.providers {
+		health = append(health, p.breaker.Health(p.provider.Name()))
+	}
+
+	return health
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/enum"
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+	"github.com/M2rk13/Otus-327619/internal/model/rate"
+)
+
+const ecbDaily = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<Cube>
+		<Cube time="2024-01-02">
+			<Cube currency="USD" rate="1.0956"/>
+			<Cube currency="JPY" rate="155.52"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+type failingProvider struct {
+	name  string
+	err   error
+	delay time.Duration
+	calls int
+}
+
+func (p *failingProvider) Name() string { return p.name }
+
+func (p *failingProvider) Rate(ctx context.Context, _, _ string) (*rate.Quote, error) {
+	p.calls++
+
+	select {
+	case <-time.After(p.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	return nil, p.err
+}
+
+func TestProviderChain_FallbackAndBreaker(t *testing.T) {
+	down := &failingProvider{name: "down", err: errors.New("connection refused")}
+	static := NewStaticRateProvider(map[string]money.Decimal{"USD/EUR": money.MustParse("0.9")})
+	chain := NewProviderChain([]RateProvider{down, static}, time.Second, 2, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		quote, err := chain.Rate(context.Background(), "USD", "EUR")
+
+		if err != nil || quote.Provider != "static" {
+			t.Fatalf("expected fallback to static, got %+v, %v", quote, err)
+		}
+	}
+
+	if down.calls != 2 {
+		t.Fatalf("open breaker should skip the provider, got %d calls", down.calls)
+	}
+
+	health := chain.Health()
+
+	if health[0].State != enum.BreakerOpen || health[0].LastError == "" || health[1].State != enum.BreakerClosed {
+		t.Fatalf("unexpected health: %+v", health)
+	}
+}
+
+func TestProviderChain_Timeout(t *testing.T) {
+	slow := &failingProvider{name: "slow", delay: time.Second}
+	chain := NewProviderChain([]RateProvider{slow}, 20*time.Millisecond, 1, time.Hour)
+
+	start := time.Now()
+	_, err := chain.Rate(context.Background(), "USD", "EUR")
+
+	if !errors.Is(err, ErrRateUnavailable) || time.Since(start) > 500*time.Millisecond {
+		t.Fatalf("slow provider should time out, got %v after %s", err, time.Since(start))
+	}
+}
+
+func TestProviderChain_UnquotedPairIsHealthy(t *testing.T) {
+	chain := NewProviderChain([]RateProvider{NewStaticRateProvider(nil)}, time.Second, 1, time.Hour)
+
+	if _, err := chain.Rate(context.Background(), "USD", "EUR"); !errors.Is(err, ErrRateUnavailable) {
+		t.Fatalf("expected ErrRateUnavailable, got %v", err)
+	}
+
+	if chain.Health()[0].State != enum.BreakerClosed {
+		t.Fatal("a pair the provider does not quote is not a failure")
+	}
+}
+
+func TestCircuitBreaker_HalfOpen(t *testing.T) {
+	breaker := NewCircuitBreaker(1, 10*time.Millisecond)
+	breaker.Failure(errors.New("boom"))
+
+	if breaker.Allow() {
+		t.Fatal("open breaker should reject calls")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if !breaker.Allow() || breaker.Allow() {
+		t.Fatal("half-open breaker should allow exactly one trial")
+	}
+
+	breaker.Success()
+
+	if !breaker.Allow() {
+		t.Fatal("successful trial should close the breaker")
+	}
+}
+
+func TestECBProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(ecbDaily))
+	}))
+	defer server.Close()
+
+	provider := NewECBProvider(server.URL, time.Hour)
+
+	quote, err := provider.Rate(context.Background(), "USD", "EUR")
+
+	if err != nil || !quote.Rate.Equal(invert(money.MustParse("1.0956"))) || quote.Provider != "ecb" {
+		t.Fatalf("unexpected quote %+v, %v", quote, err)
+	}
+
+	if _, err = provider.Rate(context.Background(), "USD", "JPY"); !errors.Is(err, ErrRateUnavailable) {
+		t.Fatal("non-euro pairs are left to cross rates")
+	}
+
+	cross := NewCrossRateProvider(provider, "")
+	quote, err = cross.Rate(context.Background(), "USD", "JPY")
+
+	if err != nil || len(quote.Legs) != 2 || quote.Legs[0].To != "EUR" {
+		t.Fatalf("expected USD→EUR→JPY, got %+v, %v", quote, err)
+	}
+}
+
+func TestExchangeRateHostProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("to") == "XXX" {
+			_, _ = w.Write([]byte(`{"success": false, "error": {"code": 402, "type": "invalid_to_currency", "info": "bad"}}`))
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"success": true, "info": {"timestamp": 1700000000, "quote": 0.91}, "result": 0.91}`))
+	}))
+	defer server.Close()
+
+	provider := NewExchangeRateHostProvider(server.URL, "key")
+
+	quote, err := provider.Rate(context.Background(), "USD", "EUR")
+
+	if err != nil || !quote.Rate.Equal(money.MustParse("0.91")) || quote.Timestamp.Unix() != 1700000000 {
+		t.Fatalf("unexpected quote %+v, %v", quote, err)
+	}
+
+	if _, err = provider.Rate(context.Background(), "USD", "XXX"); !errors.Is(err, ErrRateUnavailable) {
+		t.Fatalf("unknown currency should be unavailable, got %v", err)
+	}
+}
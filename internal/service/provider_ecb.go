@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+	"github.com/M2rk13/Otus-327619/internal/model/rate"
+)
+
+const ecbBase = "EUR"
+
+type ecbEnvelope struct {
+	Days []struct {
+		Time  string `xml:"time,attr"`
+		Rates []struct {
+			Currency string `xml:"currency,attr"`
+			Rate     string `xml:"rate,attr"`
+		} `xml:"Cube"`
+	} `xml:"Cube>Cube"`
+}
+
+// parseECB reads an ECB eurofxref document, daily or historical. Rates are
+// published against the euro; other pairs are left to CrossRateProvider so
+// that the legs through EUR are recorded.
+func parseECB(r io.Reader) (rateHistory, error) {
+	var envelope ecbEnvelope
+
+	if err := xml.NewDecoder(r).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("invalid ECB document: %w", err)
+	}
+
+	days := make(rateHistory, 0, len(envelope.Days))
+
+	for _, cube := range envelope.Days {
+		date, err := time.Parse(time.DateOnly, cube.Time)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid ECB date %q: %w", cube.Time, err)
+		}
+
+		day := rateDay{Date: date, Rates: make(map[rate.Pair]money.Decimal, len(cube.Rates)*2)}
+		explicit := make(map[rate.Pair]bool)
+
+		for _, r := range cube.Rates {
+			value, err := money.Parse(r.Rate)
+
+			if err != nil {
+				return nil, fmt.Errorf("invalid ECB rate for %s: %w", r.Currency, err)
+			}
+
+			day.addRate(ecbBase, r.Currency, value, explicit)
+		}
+
+		days = append(days, day)
+	}
+
+	sort.Slice(days, func(i, j int) bool { return days[i].Date.After(days[j].Date) })
+
+	return days, nil
+}
+
+// ECBProvider downloads the ECB daily reference rates. The rates change once
+// a day, so the document is kept for refresh before it is fetched again.
+type ECBProvider struct {
+	url     string
+	refresh time.Duration
+	client  *http.Client
+
+	mu        sync.Mutex
+	latestDay rateHistory
+	fetchedAt time.Time
+}
+
+func NewECBProvider(url string, refresh time.Duration) *ECBProvider {
+	return &ECBProvider{url: url, refresh: refresh, client: &http.Client{}}
+}
+
+func (p *ECBProvider) Name() string {
+	return "ecb"
+}
+
+func (p *ECBProvider) Rate(ctx context.Context, from, to string) (*rate.Quote, error) {
+	days, err := p.latest(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return days.lookup(from, to, time.Time{}, p.Name())
+}
+
+func (p *ECBProvider) Pairs(ctx context.Context) ([]rate.Pair, error) {
+	days, err := p.latest(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return days.pairs(), nil
+}
+
+// latest returns the most recent day of the feed.
+func (p *ECBProvider) latest(ctx context.Context) (rateHistory, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.latestDay != nil && time.Since(p.fetchedAt) < p.refresh {
+		return p.latestDay, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	days, err := parseECB(resp.Body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(days) == 0 {
+		return nil, fmt.Errorf("ECB document has no rates")
+	}
+
+	p.latestDay = days[:1]
+	p.fetchedAt = time.Now()
+
+	return p.latestDay, nil
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/rate"
+)
+
+// ExchangeRateHostProvider queries the exchangerate.host convert API.
+type ExchangeRateHostProvider struct {
+	baseURL   string
+	accessKey string
+	client    *http.Client
+}
+
+func NewExchangeRateHostProvider(baseURL, accessKey string) *ExchangeRateHostProvider {
+	return &ExchangeRateHostProvider{baseURL: baseURL, accessKey: accessKey, client: &http.Client{}}
+}
+
+func (p *ExchangeRateHostProvider) Name() string {
+	return "exchangerate.host"
+}
+
+type exchangeRateHostResponse struct {
+	api.Response
+	Error *struct {
+		Code int    `json:"code"`
+		Type string `json:"type"`
+		Info string `json:"info"`
+	} `json:"error"`
+}
+
+func (p *ExchangeRateHostProvider) Rate(ctx context.Context, from, to string) (*rate.Quote, error) {
+	query := url.Values{}
+	query.Set("access_key", p.accessKey)
+	query.Set("from", from)
+	query.Set("to", to)
+	query.Set("amount", "1")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/convert?"+query.Encode(), nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var body exchangeRateHostResponse
+
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+
+	if !body.Success {
+		if body.Error != nil && (body.Error.Type == "invalid_from_currency" || body.Error.Type == "invalid_to_currency") {
+			return nil, ErrRateUnavailable
+		}
+
+		if body.Error != nil {
+			return nil, fmt.Errorf("request failed: %s", body.Error.Info)
+		}
+
+		return nil, fmt.Errorf("request failed")
+	}
+
+	return &rate.Quote{
+		From:      from,
+		To:        to,
+		Rate:      body.Info.Quote,
+		Timestamp: time.Unix(body.Info.Timestamp, 0),
+		Provider:  p.Name(),
+	}, nil
+}
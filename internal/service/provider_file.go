@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+	"github.com/M2rk13/Otus-327619/internal/model/rate"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// HistoricalRateProvider can answer rates as of a past date.
+type HistoricalRateProvider interface {
+	RateAt(ctx context.Context, from, to string, date time.Time) (*rate.Quote, error)
+}
+
+// FileRateProvider serves rates from a local file so that conversions work
+// without network access. The file is either an ECB eurofxref XML document
+// (daily or historical) or a CSV of pair,rate,date lines such as
+// "USD/EUR,0.9134,2024-01-02". It is reloaded when it changes on disk.
+type FileRateProvider struct {
+	path string
+
+	mu   sync.RWMutex
+	days rateHistory
+}
+
+func NewFileRateProvider(path string) (*FileRateProvider, error) {
+	p := &FileRateProvider{path: path}
+
+	if err := p.Reload(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (p *FileRateProvider) Name() string {
+	return "file"
+}
+
+func (p *FileRateProvider) Rate(_ context.Context, from, to string) (*rate.Quote, error) {
+	return p.history().lookup(from, to, time.Time{}, p.Name())
+}
+
+func (p *FileRateProvider) RateAt(_ context.Context, from, to string, date time.Time) (*rate.Quote, error) {
+	return p.history().lookup(from, to, date, p.Name())
+}
+
+func (p *FileRateProvider) Pairs(context.Context) ([]rate.Pair, error) {
+	return p.history().pairs(), nil
+}
+
+func (p *FileRateProvider) history() rateHistory {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.days
+}
+
+// Reload parses the file again. On error the previously loaded rates stay in
+// use.
+func (p *FileRateProvider) Reload() error {
+	file, err := os.Open(p.path)
+
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	var days rateHistory
+
+	if strings.EqualFold(filepath.Ext(p.path), ".xml") {
+		days, err = parseECB(file)
+	} else {
+		days, err = parseRatesCSV(file)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to load rates from %s: %w", p.path, err)
+	}
+
+	p.mu.Lock()
+	p.days = days
+	p.mu.Unlock()
+
+	return nil
+}
+
+// StartWatcher reloads the file whenever it is written or replaced. The
+// directory is watched rather than the file, so editors and tools that
+// replace the file by renaming are picked up as well.
+func (p *FileRateProvider) StartWatcher(wg *sync.WaitGroup, ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+
+	if err != nil {
+		return err
+	}
+
+	if err = watcher.Add(filepath.Dir(p.path)); err != nil {
+		watcher.Close()
+
+		return err
+	}
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+		defer watcher.Close()
+
+		fmt.Printf("Watching rates file %s.\n", p.path)
+
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				if filepath.Clean(event.Name) != filepath.Clean(p.path) ||
+					event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+					continue
+				}
+
+				if err := p.Reload(); err != nil {
+					fmt.Printf("Rates file reload failed: %v\n", err)
+				} else {
+					fmt.Printf("Rates file %s reloaded.\n", p.path)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+
+				fmt.Printf("Rates file watcher error: %v\n", err)
+			case <-ctx.Done():
+				fmt.Println("Rates file watcher stopped due context cancel.")
+
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// parseRatesCSV reads pair,rate,date lines. A header line is allowed and
+// pairs may be written as USD/EUR or USDEUR.
+func parseRatesCSV(r io.Reader) (rateHistory, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+
+	byDate := make(map[time.Time]rateDay)
+	explicit := make(map[time.Time]map[rate.Pair]bool)
+
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := money.Parse(record[1])
+
+		if err != nil {
+			if line == 1 {
+				continue
+			}
+
+			return nil, fmt.Errorf("line %d: invalid rate %q", line, record[1])
+		}
+
+		from, to, ok := splitPair(record[0])
+
+		if !ok {
+			return nil, fmt.Errorf("line %d: invalid pair %q", line, record[0])
+		}
+
+		date, err := time.Parse(time.DateOnly, record[2])
+
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid date %q", line, record[2])
+		}
+
+		day, ok := byDate[date]
+
+		if !ok {
+			day = rateDay{Date: date, Rates: make(map[rate.Pair]money.Decimal)}
+			byDate[date] = day
+			explicit[date] = make(map[rate.Pair]bool)
+		}
+
+		day.addRate(from, to, value, explicit[date])
+	}
+
+	days := make(rateHistory, 0, len(byDate))
+
+	for _, day := range byDate {
+		days = append(days, day)
+	}
+
+	sort.Slice(days, func(i, j int) bool { return days[i].Date.After(days[j].Date) })
+
+	return days, nil
+}
+
+func splitPair(pair string) (string, string, bool) {
+	pair = strings.ToUpper(strings.TrimSpace(pair))
+
+	if from, to, ok := strings.Cut(pair, "/"); ok {
+		return from, to, from != "" && to != ""
+	}
+
+	if len(pair) == 6 {
+		return pair[:3], pair[3:], true
+	}
+
+	return "", "", false
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+	"github.com/M2rk13/Otus-327619/internal/repository"
+)
+
+const ecbHistory = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<Cube>
+		<Cube time="2024-01-03">
+			<Cube currency="USD" rate="1.0919"/>
+		</Cube>
+		<Cube time="2024-01-02">
+			<Cube currency="USD" rate="1.0956"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+func writeRatesFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileRateProvider_CSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.csv")
+	writeRatesFile(t, path, "pair,rate,date\nUSD/EUR,0.91,2024-01-05\nUSDEUR,0.92,2024-01-08\nEUR/USD,1.1,2024-01-08\n")
+
+	provider, err := NewFileRateProvider(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	quote, err := provider.Rate(context.Background(), "USD", "EUR")
+
+	if err != nil || !quote.Rate.Equal(money.MustParse("0.92")) || quote.Provider != "file" {
+		t.Fatalf("expected latest rate, got %+v, %v", quote, err)
+	}
+
+	if quote, _ = provider.Rate(context.Background(), "EUR", "USD"); !quote.Rate.Equal(money.MustParse("1.1")) {
+		t.Fatalf("explicit inverse should win over the derived one, got %v", quote.Rate)
+	}
+
+	weekend := time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)
+	quote, err = provider.RateAt(context.Background(), "USD", "EUR", weekend)
+
+	if err != nil || !quote.Rate.Equal(money.MustParse("0.91")) || !quote.Timestamp.Equal(time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected Friday's rate for Sunday, got %+v, %v", quote, err)
+	}
+
+	if _, err = provider.RateAt(context.Background(), "USD", "EUR", weekend.AddDate(0, 0, -30)); !errors.Is(err, ErrRateUnavailable) {
+		t.Fatalf("date before the file should be unavailable, got %v", err)
+	}
+}
+
+func TestFileRateProvider_InvalidCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.csv")
+	writeRatesFile(t, path, "USD/EUR,0.91,2024-01-05\nUSD/EUR,abc,2024-01-08\n")
+
+	if _, err := NewFileRateProvider(path); err == nil {
+		t.Fatal("expected error for invalid rate")
+	}
+}
+
+func TestFileRateProvider_ECBHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "eurofxref-hist.xml")
+	writeRatesFile(t, path, ecbHistory)
+
+	provider, err := NewFileRateProvider(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	quote, err := provider.RateAt(context.Background(), "EUR", "USD", time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC))
+
+	if err != nil || !quote.Rate.Equal(money.MustParse("1.0956")) {
+		t.Fatalf("unexpected historical quote %+v, %v", quote, err)
+	}
+
+	if quote, _ = provider.Rate(context.Background(), "EUR", "USD"); !quote.Rate.Equal(money.MustParse("1.0919")) {
+		t.Fatalf("expected latest rate 1.0919, got %v", quote.Rate)
+	}
+}
+
+func TestFileRateProvider_Watcher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.csv")
+	writeRatesFile(t, path, "USD/EUR,0.91,2024-01-05\n")
+
+	provider, err := NewFileRateProvider(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	if err = provider.StartWatcher(&wg, ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	writeRatesFile(t, path, "USD/EUR,0.95,2024-01-08\n")
+
+	deadline := time.Now().Add(2 * time.Second)
+
+	for {
+		quote, err := provider.Rate(context.Background(), "USD", "EUR")
+
+		if err == nil && quote.Rate.Equal(money.MustParse("0.95")) {
+			return
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("file change was not picked up, got %+v, %v", quote, err)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRateService_HistoricalCrossRate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "eurofxref-hist.xml")
+	writeRatesFile(t, path, ecbHistory)
+
+	provider, err := NewFileRateProvider(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	static := NewStaticRateProvider(map[string]money.Decimal{"USD/GBP": money.MustParse("0.79")})
+	chain := NewProviderChain([]RateProvider{static, provider}, time.Second, 1, time.Hour)
+	rates := NewRateService(chain, repository.NewLRUCache(10), time.Minute, time.Minute, "USD")
+
+	quote, err := rates.RateAt(context.Background(), "EUR", "GBP", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+
+	if !errors.Is(err, ErrRateUnavailable) {
+		t.Fatalf("static rates have no history, got %+v, %v", quote, err)
+	}
+
+	quote, err = rates.RateAt(context.Background(), "USD", "EUR", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+
+	if err != nil || !quote.Rate.Equal(invert(money.MustParse("1.0956"))) {
+		t.Fatalf("unexpected historical quote %+v, %v", quote, err)
+	}
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/model/rate"
+	"github.com/M2rk13/Otus-327619/internal/repository"
+
+	"golang.org/x/sync/singleflight"
+)
+
+type cachedQuote struct {
+	Quote     rate.Quote `json:"quote"`
+	FetchedAt time.Time  `json:"fetched_at"`
+}
+
+// QuoteCache is a RateProvider that caches quotes of another provider per
+// currency pair. A quote is fresh for ttl; for staleTTL after that it is
+// still served, marked stale, while a background lookup refreshes it.
+// Concurrent lookups of the same pair share one upstream call.
+type QuoteCache struct {
+	provider RateProvider
+	cache    repository.Cache
+	ttl      time.Duration
+	staleTTL time.Duration
+	group    singleflight.Group
+}
+
+func NewQuoteCache(provider RateProvider, cache repository.Cache, ttl, staleTTL time.Duration) *QuoteCache {
+	return &QuoteCache{provider: provider, cache: cache, ttl: ttl, staleTTL: staleTTL}
+}
+
+func (q *QuoteCache) Name() string {
+	return q.provider.Name()
+}
+
+// Pairs forwards to the wrapped provider, listing nothing if it cannot tell.
+func (q *QuoteCache) Pairs(ctx context.Context) ([]rate.Pair, error) {
+	if lister, ok := q.provider.(PairLister); ok {
+		return lister.Pairs(ctx)
+	}
+
+	return nil, nil
+}
+
+func (q *QuoteCache) Rate(ctx context.Context, from, to string) (*rate.Quote, error) {
+	return q.Quote(ctx, from, to, false)
+}
+
+// Quote returns the quote for the pair, bypassing the cache when
+// forceRefresh is set.
+func (q *QuoteCache) Quote(ctx context.Context, from, to string, forceRefresh bool) (*rate.Quote, error) {
+	key := fmt.Sprintf("quote:%s/%s", from, to)
+
+	if !forceRefresh {
+		if cached, ok := q.load(key); ok {
+			quote := cached.Quote
+
+			if time.Since(cached.FetchedAt) < q.ttl {
+				return &quote, nil
+			}
+
+			go q.fetch(context.WithoutCancel(ctx), key, from, to)
+
+			quote.Stale = true
+
+			return &quote, nil
+		}
+	}
+
+	return q.fetch(ctx, key, from, to)
+}
+
+func (q *QuoteCache) load(key string) (*cachedQuote, bool) {
+	data, ok := q.cache.Get(key)
+
+	if !ok {
+		return nil, false
+	}
+
+	cached := &cachedQuote{}
+
+	if err := json.Unmarshal(data, cached); err != nil {
+		return nil, false
+	}
+
+	return cached, true
+}
+
+func (q *QuoteCache) fetch(ctx context.Context, key, from, to string) (*rate.Quote, error) {
+	value, err, _ := q.group.Do(key, func() (any, error) {
+		quote, err := q.provider.Rate(ctx, from, to)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if data, err := json.Marshal(cachedQuote{Quote: *quote, FetchedAt: time.Now()}); err == nil {
+			q.cache.Set(key, data, q.ttl+q.staleTTL)
+		}
+
+		return *quote, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	quote := value.(rate.Quote)
+
+	return &quote, nil
+}
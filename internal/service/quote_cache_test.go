@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+	"github.com/M2rk13/Otus-327619/internal/model/rate"
+	"github.com/M2rk13/Otus-327619/internal/repository"
+)
+
+type countingProvider struct {
+	calls atomic.Int64
+	delay time.Duration
+	err   error
+}
+
+func (p *countingProvider) Name() string { return "counting" }
+
+func (p *countingProvider) Rate(_ context.Context, from, to string) (*rate.Quote, error) {
+	n := p.calls.Add(1)
+	time.Sleep(p.delay)
+
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	return &rate.Quote{From: from, To: to, Rate: money.NewFromInt(n), Timestamp: time.Now(), Provider: p.Name()}, nil
+}
+
+func TestQuoteCache_FreshAndForceRefresh(t *testing.T) {
+	provider := &countingProvider{}
+	q := NewQuoteCache(provider, repository.NewLRUCache(10), time.Minute, time.Minute)
+	ctx := context.Background()
+
+	first, _ := q.Quote(ctx, "USD", "EUR", false)
+	second, _ := q.Quote(ctx, "USD", "EUR", false)
+
+	if provider.calls.Load() != 1 || !first.Rate.Equal(second.Rate) || second.Stale {
+		t.Fatalf("fresh quote should be served from cache, calls %d", provider.calls.Load())
+	}
+
+	refreshed, _ := q.Quote(ctx, "USD", "EUR", true)
+
+	if provider.calls.Load() != 2 || !refreshed.Rate.Equal(money.NewFromInt(2)) {
+		t.Fatal("force refresh should bypass the cache")
+	}
+}
+
+func TestQuoteCache_StaleWhileRevalidate(t *testing.T) {
+	provider := &countingProvider{}
+	q := NewQuoteCache(provider, repository.NewLRUCache(10), 100*time.Millisecond, time.Minute)
+	ctx := context.Background()
+
+	_, _ = q.Quote(ctx, "USD", "EUR", false)
+	time.Sleep(150 * time.Millisecond)
+
+	stale, _ := q.Quote(ctx, "USD", "EUR", false)
+
+	if !stale.Stale || !stale.Rate.Equal(one) {
+		t.Fatalf("expired quote should be served stale, got %+v", stale)
+	}
+
+	eventually(t, func() bool {
+		quote, _ := q.Quote(ctx, "USD", "EUR", false)
+
+		return !quote.Stale && quote.Rate.Cmp(one) > 0
+	})
+}
+
+func TestQuoteCache_SingleFlight(t *testing.T) {
+	provider := &countingProvider{delay: 50 * time.Millisecond}
+	q := NewQuoteCache(provider, repository.NewLRUCache(10), time.Minute, time.Minute)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if _, err := q.Quote(context.Background(), "USD", "EUR", false); err != nil {
+				t.Errorf("Quote: %v", err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if provider.calls.Load() != 1 {
+		t.Fatalf("concurrent lookups should share one call, got %d", provider.calls.Load())
+	}
+}
+
+func TestQuoteCache_ProviderError(t *testing.T) {
+	provider := &countingProvider{err: ErrRateUnavailable}
+	q := NewQuoteCache(provider, repository.NewLRUCache(10), time.Minute, time.Minute)
+
+	if _, err := q.Quote(context.Background(), "USD", "XXX", false); !errors.Is(err, ErrRateUnavailable) {
+		t.Fatalf("expected ErrRateUnavailable, got %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/fee"
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+	"github.com/M2rk13/Otus-327619/internal/repository"
+)
+
+const testQuoteTTL = time.Minute
+
+func newQuoteTestConversion(t *testing.T, repo repository.Repository, rate string, ttl time.Duration) *ConversionService {
+	storage := NewStorageService(repo, newTestAudit(), nil)
+	provider := NewStaticRateProvider(map[string]money.Decimal{"USD/EUR": money.MustParse(rate)})
+
+	return NewConversionService(provider, storage, NewCurrencyRegistry(ISO4217), newTestRounding(t), NewFeeEngine(fee.Schedules{}), ttl)
+}
+
+func TestConversionService_QuoteLocksRate(t *testing.T) {
+	repo := NewMockRepository()
+	ctx := WithAuditMeta(context.Background(), "alice", "")
+
+	q, err := newQuoteTestConversion(t, repo, "0.9", testQuoteTTL).Quote(ctx, &api.Request{From: "usd", To: "EUR", Amount: money.NewFromInt(100)}, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if q.Id == "" || q.Rate.String() != "0.9" || q.Result.String() != "90" || !q.ExpiresAt.After(time.Now()) {
+		t.Fatalf("unexpected quote %+v", q)
+	}
+
+	// Another instance sharing the repository honours the quote at the locked
+	// rate even though its provider has moved on.
+	other := newQuoteTestConversion(t, repo, "0.5", testQuoteTTL)
+	convLog, err := other.Convert(ctx, &api.Request{}, ConvertOptions{QuoteId: q.Id})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if convLog.Response.Result.String() != "90" || convLog.Request.From != "USD" || !convLog.Request.Amount.Equal(money.NewFromInt(100)) {
+		t.Fatalf("quote should execute at the locked rate: %+v", convLog)
+	}
+
+	if _, err = other.Convert(ctx, &api.Request{}, ConvertOptions{QuoteId: q.Id}); !errors.Is(err, repository.ErrQuoteUsed) {
+		t.Fatalf("reusing a quote should fail, got %v", err)
+	}
+}
+
+func TestConversionService_QuoteRejections(t *testing.T) {
+	repo := NewMockRepository()
+	ctx := WithAuditMeta(context.Background(), "alice", "")
+	conversion := newQuoteTestConversion(t, repo, "0.9", testQuoteTTL)
+	q, _ := conversion.Quote(ctx, &api.Request{From: "USD", To: "EUR", Amount: money.NewFromInt(100)}, nil)
+
+	var validationErr *ValidationError
+
+	if _, err := conversion.Convert(ctx, &api.Request{Amount: money.NewFromInt(50)}, ConvertOptions{QuoteId: q.Id}); !errors.As(err, &validationErr) || validationErr.Field != "amount" {
+		t.Fatalf("a different amount should be rejected, got %v", err)
+	}
+
+	if _, err := conversion.Convert(WithAuditMeta(context.Background(), "bob", ""), &api.Request{}, ConvertOptions{QuoteId: q.Id}); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("a quote of another account should not be found, got %v", err)
+	}
+
+	if _, err := conversion.Convert(ctx, &api.Request{}, ConvertOptions{QuoteId: "missing"}); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("unknown quote should not be found, got %v", err)
+	}
+
+	expiring := newQuoteTestConversion(t, repo, "0.9", 0)
+	expired, _ := expiring.Quote(ctx, &api.Request{From: "USD", To: "EUR", Amount: money.NewFromInt(100)}, nil)
+
+	if _, err := expiring.Convert(ctx, &api.Request{}, ConvertOptions{QuoteId: expired.Id}); !errors.Is(err, repository.ErrQuoteExpired) {
+		t.Fatalf("expired quote should be rejected, got %v", err)
+	}
+
+	// The rejected attempts must not have used up the quote.
+	if _, err := conversion.Convert(ctx, &api.Request{From: "usd"}, ConvertOptions{QuoteId: q.Id}); err != nil {
+		t.Fatal(err)
+	}
+}
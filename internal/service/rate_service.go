@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/model/rate"
+	"github.com/M2rk13/Otus-327619/internal/repository"
+)
+
+// RateService is the entry point for rate lookups. Current quotes come from
+// the provider chain through the quote cache, historical ones straight from
+// the chain, and pairs without a direct quote are derived by
+// CrossRateProvider in both cases.
+type RateService struct {
+	chain   *ProviderChain
+	quotes  *QuoteCache
+	current *CrossRateProvider
+	history *CrossRateProvider
+}
+
+func NewRateService(chain *ProviderChain, cache repository.Cache, ttl, staleTTL time.Duration, base string) *RateService {
+	quotes := NewQuoteCache(chain, cache, ttl, staleTTL)
+
+	return &RateService{
+		chain:   chain,
+		quotes:  quotes,
+		current: NewCrossRateProvider(quotes, base),
+		history: NewCrossRateProvider(chain, base),
+	}
+}
+
+func (r *RateService) Name() string {
+	return r.chain.Name()
+}
+
+func (r *RateService) Rate(ctx context.Context, from, to string) (*rate.Quote, error) {
+	return r.current.Rate(ctx, from, to)
+}
+
+// Quote returns the current rate. With forceRefresh a direct quote is fetched
+// from the providers instead of the cache.
+func (r *RateService) Quote(ctx context.Context, from, to string, forceRefresh bool) (*rate.Quote, error) {
+	if forceRefresh {
+		quote, err := r.quotes.Quote(ctx, from, to, true)
+
+		if !errors.Is(err, ErrRateUnavailable) {
+			return quote, err
+		}
+	}
+
+	return r.current.Rate(ctx, from, to)
+}
+
+func (r *RateService) RateAt(ctx context.Context, from, to string, date time.Time) (*rate.Quote, error) {
+	return r.history.RateAt(ctx, from, to, date)
+}
+
+func (r *RateService) Health() []rate.ProviderHealth {
+	return r.chain.Health()
+}
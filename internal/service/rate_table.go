@@ -0,0 +1,77 @@
+package service
+
+import (
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+	"github.com/M2rk13/Otus-327619/internal/model/rate"
+)
+
+// inversePlaces is the number of digits after the decimal point kept when a
+// rate is derived as the inverse of another one.
+const inversePlaces = 12
+
+var one = money.NewFromInt(1)
+
+// rateDay holds the rates published for one day.
+type rateDay struct {
+	Date  time.Time
+	Rates map[rate.Pair]money.Decimal
+}
+
+// rateHistory is a list of days, newest first.
+type rateHistory []rateDay
+
+// lookup returns the rate from the newest day not after at that quotes the
+// pair. A zero at means the latest rate. Reference rates are not published
+// on weekends and holidays, so the previous publication is used then.
+func (h rateHistory) lookup(from, to string, at time.Time, provider string) (*rate.Quote, error) {
+	for _, day := range h {
+		if !at.IsZero() && day.Date.After(at) {
+			continue
+		}
+
+		if from == to {
+			return &rate.Quote{From: from, To: to, Rate: one, Timestamp: day.Date, Provider: provider}, nil
+		}
+
+		if value, ok := day.Rates[rate.Pair{From: from, To: to}]; ok {
+			return &rate.Quote{From: from, To: to, Rate: value, Timestamp: day.Date, Provider: provider}, nil
+		}
+	}
+
+	return nil, ErrRateUnavailable
+}
+
+func (h rateHistory) pairs() []rate.Pair {
+	seen := make(map[rate.Pair]bool)
+	var pairs []rate.Pair
+
+	for _, day := range h {
+		for pair := range day.Rates {
+			if !seen[pair] {
+				seen[pair] = true
+				pairs = append(pairs, pair)
+			}
+		}
+	}
+
+	return pairs
+}
+
+// addRate stores value for the pair and its inverse unless the inverse was
+// published explicitly.
+func (d rateDay) addRate(from, to string, value money.Decimal, explicit map[rate.Pair]bool) {
+	pair := rate.Pair{From: from, To: to}
+	d.Rates[pair] = value
+	explicit[pair] = true
+
+	if inverse := (rate.Pair{From: to, To: from}); !explicit[inverse] && !value.IsZero() {
+		d.Rates[inverse] = invert(value)
+	}
+}
+
+// invert returns 1/value rounded to inversePlaces digits.
+func invert(value money.Decimal) money.Decimal {
+	return one.Div(value, inversePlaces)
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+	"github.com/M2rk13/Otus-327619/internal/model/rate"
+)
+
+var ErrRateUnavailable = errors.New("exchange rate is not available")
+
+// DemoRates is the rate table used when no real provider is configured.
+var DemoRates = map[string]money.Decimal{
+	"USD/EUR": money.MustParse("0.9134"),
+	"USD/GBP": money.MustParse("0.7921"),
+	"USD/JPY": money.MustParse("149.52"),
+	"EUR/GBP": money.MustParse("0.8672"),
+}
+
+// RateProvider returns the current exchange rate for a currency pair.
+type RateProvider interface {
+	Name() string
+	Rate(ctx context.Context, from, to string) (*rate.Quote, error)
+}
+
+// PairLister is implemented by providers that know which pairs they quote.
+type PairLister interface {
+	Pairs(ctx context.Context) ([]rate.Pair, error)
+}
+
+// StaticRateProvider serves rates from a fixed table. It stands in for a
+// real provider in development and demo setups.
+type StaticRateProvider struct {
+	rates map[string]money.Decimal
+}
+
+// NewStaticRateProvider takes rates keyed by "FROM/TO". Inverse pairs are
+// derived automatically.
+func NewStaticRateProvider(rates map[string]money.Decimal) *StaticRateProvider {
+	table := make(map[string]money.Decimal, len(rates)*2)
+
+	for pair, value := range rates {
+		table[pair] = value
+
+		if from, to, ok := strings.Cut(pair, "/"); ok && !value.IsZero() {
+			if _, exists := rates[to+"/"+from]; !exists {
+				table[to+"/"+from] = invert(value)
+			}
+		}
+	}
+
+	return &StaticRateProvider{rates: table}
+}
+
+// LoadStaticRates reads a JSON object of rates keyed by "FROM/TO". Rates may
+// be written as numbers or strings; both are read exactly.
+func LoadStaticRates(path string) (map[string]money.Decimal, error) {
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rates := make(map[string]money.Decimal)
+
+	if err = json.Unmarshal(data, &rates); err != nil {
+		return nil, fmt.Errorf("invalid rates file %s: %w", path, err)
+	}
+
+	return rates, nil
+}
+
+func (p *StaticRateProvider) Name() string {
+	return "static"
+}
+
+func (p *StaticRateProvider) Rate(_ context.Context, from, to string) (*rate.Quote, error) {
+	value, ok := p.rates[from+"/"+to]
+
+	if from == to {
+		value, ok = one, true
+	}
+
+	if !ok {
+		return nil, ErrRateUnavailable
+	}
+
+	return &rate.Quote{From: from, To: to, Rate: value, Timestamp: time.Now(), Provider: p.Name()}, nil
+}
+
+func (p *StaticRateProvider) Pairs(context.Context) ([]rate.Pair, error) {
+	pairs := make([]rate.Pair, 0, len(p.rates))
+
+	for pair := range p.rates {
+		from, to, _ := strings.Cut(pair, "/")
+		pairs = append(pairs, rate.Pair{From: from, To: to})
+	}
+
+	return pairs, nil
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/M2rk13/Otus-327619/internal/broker"
+	"github.com/M2rk13/Otus-327619/internal/enum"
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/event"
+	"github.com/M2rk13/Otus-327619/internal/model/log"
+	"github.com/M2rk13/Otus-327619/internal/repository"
+)
+
+// errEventAhead rejects a change that skips versions the replica has not
+// seen yet, so that the broker delivers it again after them.
+var errEventAhead = errors.New("event is ahead of the replica")
+
+// Replicator applies the change events published by other instances to a
+// repository; the events of its own instance are skipped. Events arrive at
+// least once and in no guaranteed order across consumers, so every change is
+// applied idempotently.
+//
+// The replica keeps the versions of the source. A create or update is only
+// applied on top of the version just before it: a change at or below the
+// version the replica holds is skipped, and one that skips versions is left
+// for later. A record the replica holds deleted is restored by a newer
+// change, as the source must have restored it. Deletes of missing records
+// succeed and cascade whatever the replica's delete policy, as the source has
+// deleted the record already. A change that fails, such as a log arriving
+// before its request, is left to the broker to deliver again.
+type Replicator struct {
+	repo   repository.Repository
+	origin string
+}
+
+// NewReplicator creates a replicator for the instance named origin.
+func NewReplicator(repo repository.Repository, origin string) *Replicator {
+	return &Replicator{repo: repo, origin: origin}
+}
+
+// Start consumes the events as consumer of group until ctx is cancelled.
+func (r *Replicator) Start(wg *sync.WaitGroup, ctx context.Context, subscriber broker.Subscriber, group, consumer string) {
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		fmt.Printf("Replicating events as %s of group %s.\n", consumer, group)
+
+		if err := subscriber.Subscribe(ctx, group, consumer, r.Handle); err != nil {
+			fmt.Printf("Replication stopped: %v\n", err)
+		}
+	}()
+}
+
+func (r *Replicator) Handle(_ context.Context, ev *event.Event) error {
+	if r.origin != "" && ev.Origin == r.origin {
+		return nil
+	}
+
+	switch ev.Entity {
+	case requestEntity:
+		return replicated[*api.Request]{
+			get: r.repo.GetRequestByID, getAll: r.repo.GetAllRequests,
+			create: r.repo.CreateRequest, update: r.repo.UpdateRequest,
+			remove: r.deleteRequest, restore: r.repo.RestoreRequest,
+		}.apply(ev)
+	case responseEntity:
+		return replicated[*api.Response]{
+			get: r.repo.GetResponseByID, getAll: r.repo.GetAllResponses,
+			create: r.repo.CreateResponse, update: r.repo.UpdateResponse,
+			remove: r.deleteResponse, restore: r.repo.RestoreResponse,
+		}.apply(ev)
+	case logEntity:
+		return replicated[*log.ConversionLog]{
+			get: r.repo.GetConversionLogByID, getAll: r.repo.GetAllConversionLogs,
+			create: r.repo.CreateConversionLog, update: r.repo.UpdateConversionLog,
+			remove: r.repo.DeleteConversionLog, restore: r.repo.RestoreConversionLog,
+		}.apply(ev)
+	default:
+		fmt.Printf("Skipping event %s on unknown entity %s.\n", ev.Type, ev.Entity)
+
+		return nil
+	}
+}
+
+// deleteRequest deletes a request together with the responses and logs that
+// still link to it, which a replica restricting deletes would refuse. The
+// replica learns of the dependants' deletes from events of their own, so
+// they are gone already unless those events are late.
+func (r *Replicator) deleteRequest(id string) error {
+	_, err := r.repo.DeleteRequest(id)
+
+	if !errors.Is(err, repository.ErrReferenced) {
+		return err
+	}
+
+	for _, resp := range r.repo.GetAllResponses(false) {
+		if resp.Query.Id == id {
+			if err = r.deleteResponse(resp.Id); err != nil && !errors.Is(err, repository.ErrNotFound) {
+				return err
+			}
+		}
+	}
+
+	if err = r.deleteLogs(func(convLog *log.ConversionLog) bool { return convLog.Request.Id == id }); err != nil {
+		return err
+	}
+
+	_, err = r.repo.DeleteRequest(id)
+
+	return err
+}
+
+func (r *Replicator) deleteResponse(id string) error {
+	_, err := r.repo.DeleteResponse(id)
+
+	if !errors.Is(err, repository.ErrReferenced) {
+		return err
+	}
+
+	if err = r.deleteLogs(func(convLog *log.ConversionLog) bool { return convLog.Response.Id == id }); err != nil {
+		return err
+	}
+
+	_, err = r.repo.DeleteResponse(id)
+
+	return err
+}
+
+func (r *Replicator) deleteLogs(linked func(*log.ConversionLog) bool) error {
+	for _, convLog := range r.repo.GetAllConversionLogs(false) {
+		if !linked(convLog) {
+			continue
+		}
+
+		if err := r.repo.DeleteConversionLog(convLog.Id); err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// replicated holds the repository operations on one kind of record.
+type replicated[T interface {
+	comparable
+	repository.Record
+}] struct {
+	get     func(id string) T
+	getAll  func(includeDeleted bool) []T
+	create  func(T) error
+	update  func(T) error
+	remove  func(id string) error
+	restore func(id string) error
+}
+
+func (ops replicated[T]) apply(ev *event.Event) error {
+	var zero T
+
+	switch ev.Type {
+	case enum.EventDeleted:
+		if err := ops.remove(ev.EntityId); err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+
+		return nil
+	case enum.EventRestored:
+		if err := ops.restore(ev.EntityId); !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+	case enum.EventCreated, enum.EventUpdated:
+	default:
+		return nil
+	}
+
+	var item T
+
+	if err := json.Unmarshal(ev.Data, &item); err != nil || item == zero {
+		fmt.Printf("Skipping event %s on %s %s without a record: %v\n", ev.Type, ev.Entity, ev.EntityId, err)
+
+		return nil
+	}
+
+	version := cmp.Or(ev.Version, item.GetVersion())
+	held := ops.find(ev.EntityId)
+
+	if held == zero {
+		if version > 1 {
+			return fmt.Errorf("%s %s version %d: %w", ev.Entity, ev.EntityId, version, errEventAhead)
+		}
+
+		return ops.create(item)
+	}
+
+	switch {
+	case version <= held.GetVersion():
+		return nil
+	case version > held.GetVersion()+1:
+		return fmt.Errorf("%s %s version %d after %d: %w", ev.Entity, ev.EntityId, version, held.GetVersion(), errEventAhead)
+	}
+
+	if held.GetDeletedAt() != nil {
+		if err := ops.restore(ev.EntityId); err != nil {
+			return err
+		}
+	}
+
+	// Updating the version just before the change writes the source's.
+	item.SetVersion(held.GetVersion())
+
+	return ops.update(item)
+}
+
+// find returns the record with id, live or deleted.
+func (ops replicated[T]) find(id string) T {
+	var zero T
+
+	if item := ops.get(id); item != zero {
+		return item
+	}
+
+	for _, item := range ops.getAll(true) {
+		if item.GetId() == id {
+			return item
+		}
+	}
+
+	return zero
+}
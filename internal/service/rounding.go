@@ -0,0 +1,97 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+)
+
+// maxScale bounds the scale of a rounding policy; NUMERIC and Decimal128 both
+// hold far more, but no currency needs more digits.
+const maxScale = 12
+
+// RoundingPolicies picks the rounding policy of a conversion: the one sent
+// with the request, else the one configured for the account, else the
+// default.
+type RoundingPolicies struct {
+	fallback money.RoundingPolicy
+	accounts map[string]money.RoundingPolicy
+}
+
+func NewRoundingPolicies(defaultPolicy string, accounts map[string]string) (*RoundingPolicies, error) {
+	fallback, err := ParseRoundingPolicy(defaultPolicy)
+
+	if err != nil {
+		return nil, fmt.Errorf("default rounding policy: %w", err)
+	}
+
+	p := &RoundingPolicies{fallback: fallback, accounts: make(map[string]money.RoundingPolicy, len(accounts))}
+
+	for account, value := range accounts {
+		if p.accounts[account], err = ParseRoundingPolicy(value); err != nil {
+			return nil, fmt.Errorf("rounding policy of %s: %w", account, err)
+		}
+	}
+
+	return p, nil
+}
+
+// ParseRoundingPolicy reads a policy written as "mode" or "mode:scale", e.g.
+// "half_even" or "down:2".
+func ParseRoundingPolicy(value string) (money.RoundingPolicy, error) {
+	mode, scaleText, hasScale := strings.Cut(strings.TrimSpace(value), ":")
+	policy := money.RoundingPolicy{Mode: mode}
+
+	if hasScale {
+		scale, err := strconv.ParseInt(scaleText, 10, 32)
+
+		if err != nil {
+			return policy, fmt.Errorf("invalid scale %q", scaleText)
+		}
+
+		s := int32(scale)
+		policy.Scale = &s
+	}
+
+	if err := validateRounding("", policy); err != nil {
+		return policy, err
+	}
+
+	return policy, nil
+}
+
+// Resolve returns the policy for a conversion by account. A requested policy
+// that only sets the scale keeps the mode of the account or default policy.
+func (p *RoundingPolicies) Resolve(account string, requested *money.RoundingPolicy) money.RoundingPolicy {
+	policy, ok := p.accounts[account]
+
+	if !ok {
+		policy = p.fallback
+	}
+
+	if requested != nil {
+		if requested.Mode != "" {
+			policy.Mode = requested.Mode
+		}
+
+		if requested.Scale != nil {
+			policy.Scale = requested.Scale
+		}
+	}
+
+	return policy
+}
+
+func validateRounding(prefix string, policy money.RoundingPolicy) error {
+	if _, err := money.Zero.RoundMode(0, policy.Mode); err != nil {
+		return &ValidationError{Field: prefix + "mode", Message: err.Error()}
+	}
+
+	if policy.Scale != nil && (*policy.Scale < 0 || *policy.Scale > maxScale) {
+		return &ValidationError{Field: prefix + "scale", Message: fmt.Sprintf("must be between 0 and %d", maxScale)}
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/M2rk13/Otus-327619/internal/enum"
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/fee"
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+)
+
+func newTestRounding(t *testing.T) *RoundingPolicies {
+	t.Helper()
+
+	policies, err := NewRoundingPolicies(enum.RoundHalfUp, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return policies
+}
+
+func TestRoundingPolicies(t *testing.T) {
+	if _, err := NewRoundingPolicies("half_up", map[string]string{"payouts": "down:x"}); err == nil {
+		t.Fatal("invalid account policy should be rejected")
+	}
+
+	policies, err := NewRoundingPolicies("half_up", map[string]string{"accounting": "half_even", "payouts": "down:1"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storage := NewStorageService(NewMockRepository(), newTestAudit(), nil)
+	provider := NewStaticRateProvider(map[string]money.Decimal{"USD/EUR": money.MustParse("0.9")})
+	conversion := NewConversionService(provider, storage, NewCurrencyRegistry(ISO4217), policies, NewFeeEngine(fee.Schedules{}), testQuoteTTL)
+	amount := money.MustParse("1.05") // 0.945 EUR
+
+	cases := []struct {
+		account   string
+		requested *money.RoundingPolicy
+		want      string
+		mode      string
+		scale     int32
+	}{
+		{"someone", nil, "0.95", enum.RoundHalfUp, 2},
+		{"accounting", nil, "0.94", enum.RoundHalfEven, 2},
+		{"payouts", nil, "0.9", enum.RoundDown, 1},
+		{"accounting", &money.RoundingPolicy{Mode: enum.RoundUp}, "0.95", enum.RoundUp, 2},
+	}
+
+	for _, tc := range cases {
+		ctx := WithAuditMeta(context.Background(), tc.account, "")
+		convLog, err := conversion.Convert(ctx, &api.Request{From: "USD", To: "EUR", Amount: amount}, ConvertOptions{Rounding: tc.requested})
+
+		if err != nil {
+			t.Fatalf("%s: %v", tc.account, err)
+		}
+
+		if convLog.Response.Result.String() != tc.want {
+			t.Errorf("%s: expected %s, got %s", tc.account, tc.want, convLog.Response.Result)
+		}
+
+		stored := storage.GetConversionLogByID(convLog.Id)
+
+		if stored.Rounding == nil || stored.Rounding.Mode != tc.mode || *stored.Rounding.Scale != tc.scale {
+			t.Errorf("%s: policy not recorded: %+v", tc.account, stored.Rounding)
+		}
+	}
+
+	scale := int32(99)
+	_, err = conversion.Convert(context.Background(), &api.Request{From: "USD", To: "EUR", Amount: amount}, ConvertOptions{Rounding: &money.RoundingPolicy{Scale: &scale}})
+
+	var validationErr *ValidationError
+
+	if !errors.As(err, &validationErr) || validationErr.Field != "rounding.scale" {
+		t.Fatalf("expected rounding.scale validation error, got %v", err)
+	}
+}
+
+func TestConversionService_RoundsToMinorUnits(t *testing.T) {
+	if resp := runDemoSource(t, 3)[2].Response; resp.Result.String() != "191.81" {
+		t.Fatalf("210 × 0.9134 should round to 191.81 EUR, got %s", resp.Result)
+	}
+
+	storage := NewStorageService(NewMockRepository(), newTestAudit(), nil)
+	provider := NewStaticRateProvider(map[string]money.Decimal{"USD/JPY": money.MustParse("149.523")})
+	conversion := NewConversionService(provider, storage, NewCurrencyRegistry(ISO4217), newTestRounding(t), NewFeeEngine(fee.Schedules{}), testQuoteTTL)
+
+	convLog, err := conversion.Convert(context.Background(), &api.Request{From: "USD", To: "JPY", Amount: money.MustParse("10.01")}, ConvertOptions{})
+
+	if err != nil || convLog.Response.Result.String() != "1497" {
+		t.Fatalf("JPY has no minor units, got %+v, %v", convLog, err)
+	}
+
+	_, err = conversion.Convert(context.Background(), &api.Request{From: "USD", To: "JPY", Amount: money.MustParse("1.001")}, ConvertOptions{})
+
+	var validationErr *ValidationError
+
+	if !errors.As(err, &validationErr) || validationErr.Field != "amount" {
+		t.Fatalf("USD amounts have two decimal places, got %v", err)
+	}
+}
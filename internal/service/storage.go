@@ -2,151 +2,330 @@ package service
 
 import (
 	"context"
-	"fmt"
-	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/M2rk13/Otus-327619/internal/enum"
 	"github.com/M2rk13/Otus-327619/internal/model/api"
 	"github.com/M2rk13/Otus-327619/internal/model/log"
+	"github.com/M2rk13/Otus-327619/internal/model/quote"
 	"github.com/M2rk13/Otus-327619/internal/repository"
 )
 
+const (
+	requestEntity  = "request"
+	responseEntity = "response"
+	logEntity      = "conversion_log"
+	quoteEntity    = "quote"
+)
+
 type StorageService struct {
-	repo repository.Repository
+	repo     repository.Repository
+	audit    *AuditService
+	events   *EventService
+	ingest   atomic.Pointer[ingestPools]
+	watchers logWatchers
 }
 
-func NewStorageService(repo repository.Repository) *StorageService {
-	return &StorageService{repo: repo}
+// NewStorageService creates the service; events may be nil to publish no
+// change events.
+func NewStorageService(repo repository.Repository, audit *AuditService, events *EventService) *StorageService {
+	return &StorageService{
+		repo:     repo,
+		audit:    audit,
+		events:   events,
+		watchers: logWatchers{channels: make(map[chan *log.ConversionLog]struct{})},
+	}
 }
 
-func (s *StorageService) StartStorageService(
-	wg *sync.WaitGroup,
-	ctx context.Context,
-	requestChan <-chan *api.Request,
-	responseChan <-chan *api.Response,
-	logChan <-chan *log.ConversionLog,
-) {
-	wg.Add(3)
+// record writes the audit record of a mutation and publishes its event.
+// Quotes are internal to the conversions and have no events. Created logs
+// are passed on to the watchers.
+func (s *StorageService) record(ctx context.Context, action, entity, id string, before, after any) {
+	s.audit.Record(ctx, action, entity, id, before, after)
 
-	go func() {
-		defer wg.Done()
+	if entity != quoteEntity {
+		s.events.Record(ctx, action, entity, id, after)
+	}
 
-		for {
-			select {
-			case req, ok := <-requestChan:
-				if !ok {
-					fmt.Println("Request storage goroutine finished.")
+	if convLog, ok := after.(*log.ConversionLog); ok && action == enum.ActionCreate {
+		s.watchers.notify(convLog)
+	}
+}
 
-					return
-				}
+// recordCascaded records the deletes of the dependants a delete cascaded to,
+// in the order the repository deleted them.
+func (s *StorageService) recordCascaded(ctx context.Context, cascaded repository.Cascaded) {
+	for _, convLog := range cascaded.Logs {
+		s.record(ctx, enum.ActionDelete, logEntity, convLog.Id, convLog, nil)
+	}
 
-				s.repo.CreateRequest(req)
-			case <-ctx.Done():
-				fmt.Println("Request storage goroutine stopped by context.")
+	for _, resp := range cascaded.Responses {
+		s.record(ctx, enum.ActionDelete, responseEntity, resp.Id, resp, nil)
+	}
+}
 
-				return
-			}
-		}
-	}()
+func (s *StorageService) CreateRequest(ctx context.Context, req *api.Request) error {
+	if err := s.repo.CreateRequest(req); err != nil {
+		return err
+	}
 
-	go func() {
-		defer wg.Done()
+	s.record(ctx, enum.ActionCreate, requestEntity, req.Id, nil, req)
 
-		for {
-			select {
-			case resp, ok := <-responseChan:
-				if !ok {
-					fmt.Println("Response storage goroutine finished.")
+	return nil
+}
 
-					return
-				}
+// CreateRequests stores reqs at once; see repository.Repository.
+func (s *StorageService) CreateRequests(ctx context.Context, reqs []*api.Request) error {
+	err := s.repo.CreateRequests(reqs)
 
-				s.repo.CreateResponse(resp)
-			case <-ctx.Done():
-				fmt.Println("Response storage goroutine stopped by context.")
+	if err != nil {
+		reqs = reqs[:repository.StoredBefore(err)]
+	}
 
-				return
-			}
-		}
-	}()
+	for _, req := range reqs {
+		s.record(ctx, enum.ActionCreate, requestEntity, req.Id, nil, req)
+	}
 
-	go func() {
-		defer wg.Done()
+	return err
+}
 
-		for {
-			select {
-			case convLog, ok := <-logChan:
-				if !ok {
-					fmt.Println("Log storage goroutine finished.")
+func (s *StorageService) GetRequestByID(id string) *api.Request {
+	return s.repo.GetRequestByID(id)
+}
 
-					return
-				}
+func (s *StorageService) GetAllRequests(includeDeleted bool) []*api.Request {
+	return s.repo.GetAllRequests(includeDeleted)
+}
 
-				s.repo.CreateConversionLog(convLog)
-			case <-ctx.Done():
-				fmt.Println("Log storage goroutine stopped by context.")
+func (s *StorageService) UpdateRequest(ctx context.Context, req *api.Request) error {
+	before := s.repo.GetRequestByID(req.Id)
 
-				return
-			}
-		}
-	}()
-}
+	if err := s.repo.UpdateRequest(req); err != nil {
+		return err
+	}
 
-func (s *StorageService) CreateRequest(req *api.Request) {
-	s.repo.CreateRequest(req)
-}
+	s.record(ctx, enum.ActionUpdate, requestEntity, req.Id, before, req)
 
-func (s *StorageService) GetRequestByID(id string) *api.Request {
-	return s.repo.GetRequestByID(id)
+	return nil
 }
 
-func (s *StorageService) GetAllRequests() []*api.Request {
-	return s.repo.GetAllRequests()
+func (s *StorageService) DeleteRequest(ctx context.Context, id string) error {
+	before := s.repo.GetRequestByID(id)
+	cascaded, err := s.repo.DeleteRequest(id)
+
+	if err != nil {
+		return err
+	}
+
+	s.recordCascaded(ctx, cascaded)
+	s.record(ctx, enum.ActionDelete, requestEntity, id, before, nil)
+
+	return nil
 }
 
-func (s *StorageService) UpdateRequest(req *api.Request) bool {
-	return s.repo.UpdateRequest(req)
+func (s *StorageService) RestoreRequest(ctx context.Context, id string) error {
+	if err := s.repo.RestoreRequest(id); err != nil {
+		return err
+	}
+
+	s.record(ctx, enum.ActionRestore, requestEntity, id, nil, s.repo.GetRequestByID(id))
+
+	return nil
 }
 
-func (s *StorageService) DeleteRequest(id string) bool {
-	return s.repo.DeleteRequest(id)
+func (s *StorageService) CreateResponse(ctx context.Context, resp *api.Response) error {
+	if err := s.repo.CreateResponse(resp); err != nil {
+		return err
+	}
+
+	s.record(ctx, enum.ActionCreate, responseEntity, resp.Id, nil, resp)
+
+	return nil
 }
 
-func (s *StorageService) CreateResponse(resp *api.Response) {
-	s.repo.CreateResponse(resp)
+func (s *StorageService) CreateResponses(ctx context.Context, resps []*api.Response) error {
+	err := s.repo.CreateResponses(resps)
+
+	if err != nil {
+		resps = resps[:repository.StoredBefore(err)]
+	}
+
+	for _, resp := range resps {
+		s.record(ctx, enum.ActionCreate, responseEntity, resp.Id, nil, resp)
+	}
+
+	return err
 }
 
 func (s *StorageService) GetResponseByID(id string) *api.Response {
 	return s.repo.GetResponseByID(id)
 }
 
-func (s *StorageService) GetAllResponses() []*api.Response {
-	return s.repo.GetAllResponses()
+func (s *StorageService) GetAllResponses(includeDeleted bool) []*api.Response {
+	return s.repo.GetAllResponses(includeDeleted)
+}
+
+func (s *StorageService) UpdateResponse(ctx context.Context, resp *api.Response) error {
+	before := s.repo.GetResponseByID(resp.Id)
+
+	if err := s.repo.UpdateResponse(resp); err != nil {
+		return err
+	}
+
+	s.record(ctx, enum.ActionUpdate, responseEntity, resp.Id, before, resp)
+
+	return nil
 }
 
-func (s *StorageService) UpdateResponse(resp *api.Response) bool {
-	return s.repo.UpdateResponse(resp)
+func (s *StorageService) DeleteResponse(ctx context.Context, id string) error {
+	before := s.repo.GetResponseByID(id)
+	cascaded, err := s.repo.DeleteResponse(id)
+
+	if err != nil {
+		return err
+	}
+
+	s.recordCascaded(ctx, cascaded)
+	s.record(ctx, enum.ActionDelete, responseEntity, id, before, nil)
+
+	return nil
 }
 
-func (s *StorageService) DeleteResponse(id string) bool {
-	return s.repo.DeleteResponse(id)
+func (s *StorageService) RestoreResponse(ctx context.Context, id string) error {
+	if err := s.repo.RestoreResponse(id); err != nil {
+		return err
+	}
+
+	s.record(ctx, enum.ActionRestore, responseEntity, id, nil, s.repo.GetResponseByID(id))
+
+	return nil
 }
 
-func (s *StorageService) CreateConversionLog(log *log.ConversionLog) {
-	s.repo.CreateConversionLog(log)
+func (s *StorageService) CreateConversionLog(ctx context.Context, log *log.ConversionLog) error {
+	if err := s.repo.CreateConversionLog(log); err != nil {
+		return err
+	}
+
+	s.record(ctx, enum.ActionCreate, logEntity, log.Id, nil, log)
+
+	return nil
+}
+
+func (s *StorageService) CreateConversionLogs(ctx context.Context, logs []*log.ConversionLog) error {
+	err := s.repo.CreateConversionLogs(logs)
+
+	if err != nil {
+		logs = logs[:repository.StoredBefore(err)]
+	}
+
+	for _, convLog := range logs {
+		s.record(ctx, enum.ActionCreate, logEntity, convLog.Id, nil, convLog)
+	}
+
+	return err
 }
 
 func (s *StorageService) GetConversionLogByID(id string) *log.ConversionLog {
 	return s.repo.GetConversionLogByID(id)
 }
 
-func (s *StorageService) GetAllConversionLogs() []*log.ConversionLog {
-	return s.repo.GetAllConversionLogs()
+// CacheStats reports hit and miss counters of the repository cache. ok is
+// false when caching is disabled.
+func (s *StorageService) CacheStats() (stats repository.CacheStats, ok bool) {
+	cached, ok := s.repo.(*repository.CachedRepository)
+
+	if !ok {
+		return stats, false
+	}
+
+	return cached.Stats(), true
+}
+
+// GetExpandedConversionLog returns the log with its linked request and
+// response loaded from storage. Links that are empty or no longer resolve are
+// left nil.
+func (s *StorageService) GetExpandedConversionLog(id string) *log.ExpandedConversionLog {
+	item := s.repo.GetConversionLogByID(id)
+
+	if item == nil {
+		return nil
+	}
+
+	expanded := &log.ExpandedConversionLog{Log: item}
+
+	if item.Request.Id != "" {
+		expanded.Request = s.repo.GetRequestByID(item.Request.Id)
+	}
+
+	if item.Response.Id != "" {
+		expanded.Response = s.repo.GetResponseByID(item.Response.Id)
+	}
+
+	return expanded
+}
+
+func (s *StorageService) GetAllConversionLogs(includeDeleted bool) []*log.ConversionLog {
+	return s.repo.GetAllConversionLogs(includeDeleted)
+}
+
+func (s *StorageService) UpdateConversionLog(ctx context.Context, log *log.ConversionLog) error {
+	before := s.repo.GetConversionLogByID(log.Id)
+
+	if err := s.repo.UpdateConversionLog(log); err != nil {
+		return err
+	}
+
+	s.record(ctx, enum.ActionUpdate, logEntity, log.Id, before, log)
+
+	return nil
+}
+
+func (s *StorageService) DeleteConversionLog(ctx context.Context, id string) error {
+	before := s.repo.GetConversionLogByID(id)
+
+	if err := s.repo.DeleteConversionLog(id); err != nil {
+		return err
+	}
+
+	s.record(ctx, enum.ActionDelete, logEntity, id, before, nil)
+
+	return nil
+}
+
+func (s *StorageService) RestoreConversionLog(ctx context.Context, id string) error {
+	if err := s.repo.RestoreConversionLog(id); err != nil {
+		return err
+	}
+
+	s.record(ctx, enum.ActionRestore, logEntity, id, nil, s.repo.GetConversionLogByID(id))
+
+	return nil
 }
 
-func (s *StorageService) UpdateConversionLog(log *log.ConversionLog) bool {
-	return s.repo.UpdateConversionLog(log)
+func (s *StorageService) CreateQuote(ctx context.Context, q *quote.Quote) error {
+	if err := s.repo.CreateQuote(q); err != nil {
+		return err
+	}
+
+	s.record(ctx, enum.ActionCreate, quoteEntity, q.Id, nil, q)
+
+	return nil
 }
 
-func (s *StorageService) DeleteConversionLog(id string) bool {
-	return s.repo.DeleteConversionLog(id)
+func (s *StorageService) GetQuoteByID(id string) *quote.Quote {
+	return s.repo.GetQuoteByID(id)
+}
+
+// UseQuote claims the quote for a conversion; see repository.Repository.
+func (s *StorageService) UseQuote(ctx context.Context, id string) (*quote.Quote, error) {
+	q, err := s.repo.UseQuote(id, time.Now())
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, enum.ActionUpdate, quoteEntity, q.Id, nil, q)
+
+	return q, nil
 }
@@ -2,12 +2,17 @@ package service
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/M2rk13/Otus-327619/internal/lifecycle"
 	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/idempotency"
 	"github.com/M2rk13/Otus-327619/internal/model/log"
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+	"github.com/M2rk13/Otus-327619/internal/model/quote"
 	"github.com/M2rk13/Otus-327619/internal/repository"
 
 	"github.com/google/uuid"
@@ -18,6 +23,10 @@ type MockRepository struct {
 	requests  map[string]*api.Request
 	responses map[string]*api.Response
 	logs      map[string]*log.ConversionLog
+	quotes    map[string]*quote.Quote
+	keys      map[string]*idempotency.Record
+	// batches counts the calls of the batch creates.
+	batches int
 }
 
 func NewMockRepository() *MockRepository {
@@ -25,10 +34,12 @@ func NewMockRepository() *MockRepository {
 		requests:  make(map[string]*api.Request),
 		responses: make(map[string]*api.Response),
 		logs:      make(map[string]*log.ConversionLog),
+		quotes:    make(map[string]*quote.Quote),
+		keys:      make(map[string]*idempotency.Record),
 	}
 }
 
-func (m *MockRepository) CreateRequest(req *api.Request) {
+func (m *MockRepository) CreateRequest(req *api.Request) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -36,166 +47,441 @@ func (m *MockRepository) CreateRequest(req *api.Request) {
 		req.Id = uuid.New().String()
 	}
 
+	req.Version = 1
 	m.requests[req.Id] = req
+
+	return nil
+}
+
+func (m *MockRepository) CreateRequests(reqs []*api.Request) error {
+	m.mu.Lock()
+	m.batches++
+	m.mu.Unlock()
+
+	for _, req := range reqs {
+		_ = m.CreateRequest(req)
+	}
+
+	return nil
 }
 
 func (m *MockRepository) GetRequestByID(id string) *api.Request {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	return m.requests[id]
+	if item, ok := m.requests[id]; ok && item.DeletedAt == nil {
+		return item
+	}
+
+	return nil
 }
 
-func (m *MockRepository) GetAllRequests() []*api.Request {
+func (m *MockRepository) GetAllRequests(includeDeleted bool) []*api.Request {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	var all []*api.Request
 
 	for _, r := range m.requests {
-		all = append(all, r)
+		if includeDeleted || r.DeletedAt == nil {
+			all = append(all, r)
+		}
 	}
 
 	return all
 }
-func (m *MockRepository) UpdateRequest(req *api.Request) bool {
+func (m *MockRepository) UpdateRequest(req *api.Request) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if _, ok := m.requests[req.Id]; ok {
+	if stored, ok := m.requests[req.Id]; ok && stored.DeletedAt == nil {
+		if req.Version != 0 && req.Version != stored.Version {
+			return repository.ErrVersionConflict
+		}
+
+		req.Version = stored.Version + 1
 		m.requests[req.Id] = req
 
-		return true
+		return nil
 	}
 
-	return false
+	return repository.ErrNotFound
 }
 
-func (m *MockRepository) DeleteRequest(id string) bool {
+func (m *MockRepository) DeleteRequest(id string) (repository.Cascaded, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if _, ok := m.requests[id]; ok {
-		delete(m.requests, id)
+	if item, ok := m.requests[id]; ok && item.DeletedAt == nil {
+		now := time.Now()
+		item.DeletedAt = &now
 
-		return true
+		return repository.Cascaded{}, nil
 	}
 
-	return false
+	return repository.Cascaded{}, repository.ErrNotFound
 }
 
-func (m *MockRepository) CreateResponse(resp *api.Response) {
+func (m *MockRepository) RestoreRequest(id string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if item, ok := m.requests[id]; ok && item.DeletedAt != nil {
+		item.DeletedAt = nil
+
+		return nil
+	}
+
+	return repository.ErrNotFound
+}
+
+func (m *MockRepository) CreateResponse(resp *api.Response) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.requests[resp.Query.Id]; resp.Query.Id != "" && !ok {
+		return repository.ErrMissingReference
+	}
+
 	if resp.Id == "" {
 		resp.Id = uuid.New().String()
 	}
 
+	resp.Version = 1
 	m.responses[resp.Id] = resp
+
+	return nil
+}
+
+func (m *MockRepository) CreateResponses(resps []*api.Response) error {
+	m.mu.Lock()
+	m.batches++
+
+	for _, resp := range resps {
+		if _, ok := m.requests[resp.Query.Id]; resp.Query.Id != "" && !ok {
+			m.mu.Unlock()
+
+			return repository.ErrMissingReference
+		}
+	}
+
+	m.mu.Unlock()
+
+	for _, resp := range resps {
+		_ = m.CreateResponse(resp)
+	}
+
+	return nil
 }
 
 func (m *MockRepository) GetResponseByID(id string) *api.Response {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	return m.responses[id]
+	if item, ok := m.responses[id]; ok && item.DeletedAt == nil {
+		return item
+	}
+
+	return nil
 }
 
-func (m *MockRepository) GetAllResponses() []*api.Response {
+func (m *MockRepository) GetAllResponses(includeDeleted bool) []*api.Response {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	var all []*api.Response
 
 	for _, r := range m.responses {
-		all = append(all, r)
+		if includeDeleted || r.DeletedAt == nil {
+			all = append(all, r)
+		}
 	}
 
 	return all
 }
 
-func (m *MockRepository) UpdateResponse(resp *api.Response) bool {
+func (m *MockRepository) UpdateResponse(resp *api.Response) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if _, ok := m.responses[resp.Id]; ok {
+	if stored, ok := m.responses[resp.Id]; ok && stored.DeletedAt == nil {
+		if resp.Version != 0 && resp.Version != stored.Version {
+			return repository.ErrVersionConflict
+		}
+
+		resp.Version = stored.Version + 1
 		m.responses[resp.Id] = resp
 
-		return true
+		return nil
 	}
 
-	return false
+	return repository.ErrNotFound
 }
 
-func (m *MockRepository) DeleteResponse(id string) bool {
+func (m *MockRepository) DeleteResponse(id string) (repository.Cascaded, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if _, ok := m.responses[id]; ok {
-		delete(m.responses, id)
+	if item, ok := m.responses[id]; ok && item.DeletedAt == nil {
+		now := time.Now()
+		item.DeletedAt = &now
 
-		return true
+		return repository.Cascaded{}, nil
 	}
 
-	return false
+	return repository.Cascaded{}, repository.ErrNotFound
 }
 
-func (m *MockRepository) CreateConversionLog(item *log.ConversionLog) {
+func (m *MockRepository) RestoreResponse(id string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if item, ok := m.responses[id]; ok && item.DeletedAt != nil {
+		item.DeletedAt = nil
+
+		return nil
+	}
+
+	return repository.ErrNotFound
+}
+
+func (m *MockRepository) CreateConversionLog(item *log.ConversionLog) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.requests[item.Request.Id]; item.Request.Id != "" && !ok {
+		return repository.ErrMissingReference
+	}
+
+	if _, ok := m.responses[item.Response.Id]; item.Response.Id != "" && !ok {
+		return repository.ErrMissingReference
+	}
+
 	if item.Id == "" {
 		item.Id = uuid.New().String()
 	}
 
+	item.Version = 1
 	m.logs[item.Id] = item
+
+	return nil
+}
+
+func (m *MockRepository) CreateConversionLogs(items []*log.ConversionLog) error {
+	m.mu.Lock()
+	m.batches++
+
+	for _, item := range items {
+		_, requestOk := m.requests[item.Request.Id]
+		_, responseOk := m.responses[item.Response.Id]
+
+		if (item.Request.Id != "" && !requestOk) || (item.Response.Id != "" && !responseOk) {
+			m.mu.Unlock()
+
+			return repository.ErrMissingReference
+		}
+	}
+
+	m.mu.Unlock()
+
+	for _, item := range items {
+		_ = m.CreateConversionLog(item)
+	}
+
+	return nil
 }
 
 func (m *MockRepository) GetConversionLogByID(id string) *log.ConversionLog {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	return m.logs[id]
+	if item, ok := m.logs[id]; ok && item.DeletedAt == nil {
+		return item
+	}
+
+	return nil
 }
 
-func (m *MockRepository) GetAllConversionLogs() []*log.ConversionLog {
+func (m *MockRepository) GetAllConversionLogs(includeDeleted bool) []*log.ConversionLog {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	var all []*log.ConversionLog
 
 	for _, r := range m.logs {
-		all = append(all, r)
+		if includeDeleted || r.DeletedAt == nil {
+			all = append(all, r)
+		}
 	}
 
 	return all
 }
 
-func (m *MockRepository) UpdateConversionLog(item *log.ConversionLog) bool {
+func (m *MockRepository) UpdateConversionLog(item *log.ConversionLog) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if _, ok := m.logs[item.Id]; ok {
+	if stored, ok := m.logs[item.Id]; ok && stored.DeletedAt == nil {
+		if item.Version != 0 && item.Version != stored.Version {
+			return repository.ErrVersionConflict
+		}
+
+		item.Version = stored.Version + 1
 		m.logs[item.Id] = item
 
-		return true
+		return nil
 	}
 
-	return false
+	return repository.ErrNotFound
 }
 
-func (m *MockRepository) DeleteConversionLog(id string) bool {
+func (m *MockRepository) DeleteConversionLog(id string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if _, ok := m.logs[id]; ok {
-		delete(m.logs, id)
+	if item, ok := m.logs[id]; ok && item.DeletedAt == nil {
+		now := time.Now()
+		item.DeletedAt = &now
 
-		return true
+		return nil
 	}
 
-	return false
+	return repository.ErrNotFound
+}
+
+func (m *MockRepository) RestoreConversionLog(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if item, ok := m.logs[id]; ok && item.DeletedAt != nil {
+		item.DeletedAt = nil
+
+		return nil
+	}
+
+	return repository.ErrNotFound
+}
+
+func (m *MockRepository) CreateQuote(q *quote.Quote) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if q.Id == "" {
+		q.Id = uuid.New().String()
+	}
+
+	stored := *q
+	m.quotes[q.Id] = &stored
+
+	return nil
+}
+
+func (m *MockRepository) GetQuoteByID(id string) *quote.Quote {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if q, ok := m.quotes[id]; ok {
+		found := *q
+
+		return &found
+	}
+
+	return nil
+}
+
+func (m *MockRepository) UseQuote(id string, at time.Time) (*quote.Quote, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	q, ok := m.quotes[id]
+
+	switch {
+	case !ok:
+		return nil, repository.ErrNotFound
+	case q.UsedAt != nil:
+		return nil, repository.ErrQuoteUsed
+	case q.Expired(at):
+		return nil, repository.ErrQuoteExpired
+	}
+
+	q.UsedAt = &at
+	used := *q
+
+	return &used, nil
+}
+
+func (m *MockRepository) ReserveIdempotencyKey(rec *idempotency.Record) (*idempotency.Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := rec.Account + "/" + rec.Key
+
+	if existing, ok := m.keys[id]; ok && !existing.Expired(time.Now()) {
+		found := *existing
+
+		return &found, nil
+	}
+
+	stored := *rec
+	m.keys[id] = &stored
+
+	return nil, nil
+}
+
+func (m *MockRepository) CompleteIdempotencyKey(rec *idempotency.Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.keys[rec.Account+"/"+rec.Key]
+
+	if !ok {
+		return repository.ErrNotFound
+	}
+
+	existing.Status = rec.Status
+	existing.Headers = rec.Headers
+	existing.Body = rec.Body
+
+	return nil
+}
+
+func (m *MockRepository) ReleaseIdempotencyKey(account, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.keys, account+"/"+key)
+
+	return nil
+}
+
+func (m *MockRepository) PurgeDeleted(before time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	purged := 0
+
+	for id, item := range m.logs {
+		if item.DeletedAt != nil && item.DeletedAt.Before(before) {
+			delete(m.logs, id)
+			purged++
+		}
+	}
+
+	for id, item := range m.responses {
+		if item.DeletedAt != nil && item.DeletedAt.Before(before) {
+			delete(m.responses, id)
+			purged++
+		}
+	}
+
+	for id, item := range m.requests {
+		if item.DeletedAt != nil && item.DeletedAt.Before(before) {
+			delete(m.requests, id)
+			purged++
+		}
+	}
+
+	return purged, nil
 }
 
 func (m *MockRepository) GetNewConversionRequests() []*api.Request   { return nil }
@@ -204,6 +490,34 @@ func (m *MockRepository) GetNewConversionLogs() []*log.ConversionLog { return ni
 
 var _ repository.Repository = (*MockRepository)(nil)
 
+// counts returns the number of stored requests, responses and logs.
+func (m *MockRepository) counts() (requests, responses, logs int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.requests), len(m.responses), len(m.logs)
+}
+
+func push[T any](t *testing.T, queue *lifecycle.Queue[T], item T) {
+	t.Helper()
+
+	if err := queue.Push(context.Background(), item); err != nil {
+		t.Error(err)
+	}
+}
+
+// retried reports whether the pool of entity has retried a write, i.e. an item
+// has been tried and found waiting for its parent.
+func retried(svc *StorageService, entity string) bool {
+	for _, stats := range svc.IngestStats() {
+		if stats.Entity == entity && stats.Retries > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
 func eventually(t *testing.T, ok func() bool) {
 	t.Helper()
 	deadline := time.Now().Add(2 * time.Second)
@@ -221,10 +535,10 @@ func eventually(t *testing.T, ok func() bool) {
 
 func TestStorageService_CRUD_Wrappers(t *testing.T) {
 	m := NewMockRepository()
-	s := NewStorageService(m)
+	s := NewStorageService(m, newTestAudit(), nil)
 
-	req := &api.Request{From: "USD", To: "EUR", Amount: 100}
-	s.CreateRequest(req)
+	req := &api.Request{From: "USD", To: "EUR", Amount: money.NewFromInt(100)}
+	s.CreateRequest(context.Background(), req)
 
 	if req.Id == "" {
 		t.Fatal("CreateRequest must set Id")
@@ -232,16 +546,16 @@ func TestStorageService_CRUD_Wrappers(t *testing.T) {
 
 	gotReq := s.GetRequestByID(req.Id)
 
-	if gotReq == nil || gotReq.Amount != 100 {
+	if gotReq == nil || !gotReq.Amount.Equal(money.NewFromInt(100)) {
 		t.Fatalf("GetRequestByID failed: got=%v", gotReq)
 	}
 
-	if len(s.GetAllRequests()) != 1 {
+	if len(s.GetAllRequests(false)) != 1 {
 		t.Fatal("GetAllRequests should return 1")
 	}
 
-	resp := &api.Response{Success: true, Result: 123.45}
-	s.CreateResponse(resp)
+	resp := &api.Response{Success: true, Result: money.MustParse("123.45")}
+	s.CreateResponse(context.Background(), resp)
 
 	if resp.Id == "" {
 		t.Fatal("CreateResponse must set Id")
@@ -253,35 +567,35 @@ func TestStorageService_CRUD_Wrappers(t *testing.T) {
 		t.Fatalf("GetResponseByID failed: got=%v", gotResp)
 	}
 
-	if len(s.GetAllResponses()) != 1 {
+	if len(s.GetAllResponses(false)) != 1 {
 		t.Fatal("GetAllResponses should return 1")
 	}
 
 	cl := &log.ConversionLog{}
-	s.CreateConversionLog(cl)
+	s.CreateConversionLog(context.Background(), cl)
 
 	if cl.Id == "" {
 		t.Fatal("CreateConversionLog must set Id")
 	}
 
-	if len(s.GetAllConversionLogs()) != 1 {
+	if len(s.GetAllConversionLogs(false)) != 1 {
 		t.Fatal("GetAllConversionLogs should return 1")
 	}
 
-	reqUpd := &api.Request{Id: req.Id, From: "USD", To: "RUB", Amount: 200}
+	reqUpd := &api.Request{Id: req.Id, From: "USD", To: "RUB", Amount: money.NewFromInt(200)}
 
-	if !s.UpdateRequest(reqUpd) {
-		t.Fatal("UpdateRequest should return true")
+	if err := s.UpdateRequest(context.Background(), reqUpd); err != nil {
+		t.Fatalf("UpdateRequest failed: %v", err)
 	}
 
-	if s.GetRequestByID(req.Id).Amount != 200 {
+	if !s.GetRequestByID(req.Id).Amount.Equal(money.NewFromInt(200)) {
 		t.Fatal("UpdateRequest did not apply changes")
 	}
 
-	respUpd := &api.Response{Id: resp.Id, Success: false, Result: 0}
+	respUpd := &api.Response{Id: resp.Id, Success: false, Result: money.NewFromInt(0)}
 
-	if !s.UpdateResponse(respUpd) {
-		t.Fatal("UpdateResponse should return true")
+	if err := s.UpdateResponse(context.Background(), respUpd); err != nil {
+		t.Fatalf("UpdateResponse failed: %v", err)
 	}
 
 	if s.GetResponseByID(resp.Id).Success {
@@ -290,124 +604,150 @@ func TestStorageService_CRUD_Wrappers(t *testing.T) {
 
 	logUpd := &log.ConversionLog{Id: cl.Id}
 
-	if !s.UpdateConversionLog(logUpd) {
-		t.Fatal("UpdateConversionLog should return true")
+	if err := s.UpdateConversionLog(context.Background(), logUpd); err != nil {
+		t.Fatalf("UpdateConversionLog failed: %v", err)
 	}
 
-	if s.UpdateRequest(&api.Request{Id: "nope"}) {
-		t.Fatal("UpdateRequest should return false for unknown id")
+	if err := s.UpdateRequest(context.Background(), &api.Request{Id: "nope"}); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("UpdateRequest should return ErrNotFound for unknown id, got %v", err)
 	}
 
-	if s.DeleteRequest("nope") {
-		t.Fatal("DeleteRequest should return false for unknown id")
+	if err := s.DeleteRequest(context.Background(), "nope"); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("DeleteRequest should return ErrNotFound for unknown id, got %v", err)
 	}
 
-	if s.UpdateResponse(&api.Response{Id: "nope"}) {
-		t.Fatal("UpdateResponse should return false for unknown id")
+	if err := s.UpdateResponse(context.Background(), &api.Response{Id: "nope"}); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("UpdateResponse should return ErrNotFound for unknown id, got %v", err)
 	}
 
-	if s.DeleteResponse("nope") {
-		t.Fatal("DeleteResponse should return false for unknown id")
+	if err := s.DeleteResponse(context.Background(), "nope"); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("DeleteResponse should return ErrNotFound for unknown id, got %v", err)
 	}
 
-	if s.UpdateConversionLog(&log.ConversionLog{Id: "nope"}) {
-		t.Fatal("UpdateConversionLog should return false for unknown id")
+	if err := s.UpdateConversionLog(context.Background(), &log.ConversionLog{Id: "nope"}); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("UpdateConversionLog should return ErrNotFound for unknown id, got %v", err)
 	}
 
-	if s.DeleteConversionLog("nope") {
-		t.Fatal("DeleteConversionLog should return false for unknown id")
+	if err := s.DeleteConversionLog(context.Background(), "nope"); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("DeleteConversionLog should return ErrNotFound for unknown id, got %v", err)
 	}
 
-	if !s.DeleteRequest(req.Id) || s.GetRequestByID(req.Id) != nil {
+	if s.DeleteRequest(context.Background(), req.Id) != nil || s.GetRequestByID(req.Id) != nil {
 		t.Fatal("DeleteRequest failed")
 	}
 
-	if !s.DeleteResponse(resp.Id) || s.GetResponseByID(resp.Id) != nil {
+	if s.DeleteResponse(context.Background(), resp.Id) != nil || s.GetResponseByID(resp.Id) != nil {
 		t.Fatal("DeleteResponse failed")
 	}
 
-	if !s.DeleteConversionLog(cl.Id) || s.GetConversionLogByID(cl.Id) != nil {
+	if s.DeleteConversionLog(context.Background(), cl.Id) != nil || s.GetConversionLogByID(cl.Id) != nil {
 		t.Fatal("DeleteConversionLog failed")
 	}
 }
 
-func TestStartStorageService_CloseChannels(t *testing.T) {
+func TestStartStorageService_CloseQueues(t *testing.T) {
 	mockRepo := NewMockRepository()
-	svc := NewStorageService(mockRepo)
+	svc := NewStorageService(mockRepo, newTestAudit(), nil)
 
 	var wg sync.WaitGroup
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	reqCh := make(chan *api.Request, 2)
-	respCh := make(chan *api.Response, 2)
-	logCh := make(chan *log.ConversionLog, 2)
+	requests := lifecycle.NewQueue[*api.Request](2)
+	responses := lifecycle.NewQueue[*api.Response](2)
+	logs := lifecycle.NewQueue[*log.ConversionLog](2)
 
-	svc.StartStorageService(&wg, ctx, reqCh, respCh, logCh)
+	svc.StartStorageService(&wg, ctx, PoolConfig{}, requests, responses, logs)
 
-	reqCh <- &api.Request{From: "USD", To: "EUR", Amount: 1}
-	respCh <- &api.Response{Success: true, Result: 42}
-	logCh <- &log.ConversionLog{}
+	push(t, requests, &api.Request{From: "USD", To: "EUR", Amount: money.NewFromInt(1)})
+	push(t, responses, &api.Response{Success: true, Result: money.NewFromInt(42)})
+	push(t, logs, &log.ConversionLog{})
 
-	close(reqCh)
-	close(respCh)
-	close(logCh)
+	requests.Close()
+	responses.Close()
+	logs.Close()
 
 	wg.Wait()
 
-	if got := len(mockRepo.requests); got != 1 {
-		t.Fatalf("requests saved = %d, want 1", got)
+	savedRequests, savedResponses, savedLogs := mockRepo.counts()
+
+	if savedRequests != 1 {
+		t.Fatalf("requests saved = %d, want 1", savedRequests)
 	}
 
-	if got := len(mockRepo.responses); got != 1 {
-		t.Fatalf("responses saved = %d, want 1", got)
+	if savedResponses != 1 {
+		t.Fatalf("responses saved = %d, want 1", savedResponses)
 	}
 
-	if got := len(mockRepo.logs); got != 1 {
-		t.Fatalf("logs saved = %d, want 1", got)
+	if savedLogs != 1 {
+		t.Fatalf("logs saved = %d, want 1", savedLogs)
 	}
 }
 
 func TestStartStorageService_ContextCancel(t *testing.T) {
 	mockRepo := NewMockRepository()
-	svc := NewStorageService(mockRepo)
+	svc := NewStorageService(mockRepo, newTestAudit(), nil)
 
 	var wg sync.WaitGroup
 	ctx, cancel := context.WithCancel(context.Background())
 
-	reqCh := make(chan *api.Request, 1)
-	respCh := make(chan *api.Response, 1)
-	logCh := make(chan *log.ConversionLog, 1)
+	requests := lifecycle.NewQueue[*api.Request](1)
+	responses := lifecycle.NewQueue[*api.Response](1)
+	logs := lifecycle.NewQueue[*log.ConversionLog](1)
 
-	svc.StartStorageService(&wg, ctx, reqCh, respCh, logCh)
+	svc.StartStorageService(&wg, ctx, PoolConfig{}, requests, responses, logs)
 
-	reqCh <- &api.Request{From: "GBP", To: "USD", Amount: 5}
-	respCh <- &api.Response{Success: true, Result: 1.23}
-	logCh <- &log.ConversionLog{}
+	push(t, requests, &api.Request{From: "GBP", To: "USD", Amount: money.NewFromInt(5)})
+	push(t, responses, &api.Response{Success: true, Result: money.MustParse("1.23")})
+	push(t, logs, &log.ConversionLog{})
 
 	eventually(t, func() bool {
-		return len(mockRepo.requests) == 1 &&
-			len(mockRepo.responses) == 1 &&
-			len(mockRepo.logs) == 1
+		requests, responses, logs := mockRepo.counts()
+
+		return requests == 1 && responses == 1 && logs == 1
 	})
 
 	cancel()
 	wg.Wait()
 }
 
+func TestStartStorageService_DrainOnCancel(t *testing.T) {
+	mockRepo := NewMockRepository()
+	svc := NewStorageService(mockRepo, newTestAudit(), nil)
+
+	var wg sync.WaitGroup
+	ctx, cancel := context.WithCancel(context.Background())
+
+	requests := lifecycle.NewQueue[*api.Request](3)
+	responses := lifecycle.NewQueue[*api.Response](1)
+	logs := lifecycle.NewQueue[*log.ConversionLog](1)
+
+	for i := 0; i < 3; i++ {
+		push(t, requests, &api.Request{From: "USD", To: "EUR", Amount: money.NewFromInt(int64(i))})
+	}
+
+	cancel()
+	svc.StartStorageService(&wg, ctx, PoolConfig{}, requests, responses, logs)
+	wg.Wait()
+
+	if saved, _, _ := mockRepo.counts(); saved != 3 {
+		t.Fatalf("queued requests should be stored on shutdown, got %d of 3", saved)
+	}
+}
+
 func TestStartStorageService_ConcurrentPush(t *testing.T) {
 	mockRepo := NewMockRepository()
-	svc := NewStorageService(mockRepo)
+	svc := NewStorageService(mockRepo, newTestAudit(), nil)
 
 	var wg sync.WaitGroup
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	reqCh := make(chan *api.Request, 1000)
-	respCh := make(chan *api.Response, 1000)
-	logCh := make(chan *log.ConversionLog, 1000)
+	requests := lifecycle.NewQueue[*api.Request](1000)
+	responses := lifecycle.NewQueue[*api.Response](1000)
+	logs := lifecycle.NewQueue[*log.ConversionLog](1000)
 
-	svc.StartStorageService(&wg, ctx, reqCh, respCh, logCh)
+	svc.StartStorageService(&wg, ctx, PoolConfig{}, requests, responses, logs)
 
 	var producers sync.WaitGroup
 	N := 200
@@ -418,7 +758,7 @@ func TestStartStorageService_ConcurrentPush(t *testing.T) {
 		defer producers.Done()
 
 		for i := 0; i < N; i++ {
-			reqCh <- &api.Request{From: "A", To: "B", Amount: float64(i)}
+			push(t, requests, &api.Request{From: "A", To: "B", Amount: money.NewFromInt(int64(i))})
 		}
 	}()
 
@@ -426,7 +766,7 @@ func TestStartStorageService_ConcurrentPush(t *testing.T) {
 		defer producers.Done()
 
 		for i := 0; i < N; i++ {
-			respCh <- &api.Response{Success: i%2 == 0, Result: float64(i)}
+			push(t, responses, &api.Response{Success: i%2 == 0, Result: money.NewFromInt(int64(i))})
 		}
 	}()
 
@@ -434,16 +774,16 @@ func TestStartStorageService_ConcurrentPush(t *testing.T) {
 		defer producers.Done()
 
 		for i := 0; i < N; i++ {
-			logCh <- &log.ConversionLog{}
+			push(t, logs, &log.ConversionLog{})
 		}
 	}()
 
 	producers.Wait()
 
 	eventually(t, func() bool {
-		return len(mockRepo.requests) == N &&
-			len(mockRepo.responses) == N &&
-			len(mockRepo.logs) == N
+		requests, responses, logs := mockRepo.counts()
+
+		return requests == N && responses == N && logs == N
 	})
 
 	cancel()
@@ -452,5 +792,112 @@ func TestStartStorageService_ConcurrentPush(t *testing.T) {
 
 func TestNewStorageService_Implements(t *testing.T) {
 	var _ repository.Repository = NewMockRepository()
-	_ = NewStorageService(NewMockRepository())
+	_ = NewStorageService(NewMockRepository(), newTestAudit(), nil)
+}
+
+func TestStorageService_GetExpandedConversionLog(t *testing.T) {
+	m := NewMockRepository()
+	s := NewStorageService(m, newTestAudit(), nil)
+
+	req := &api.Request{From: "USD", To: "EUR", Amount: money.NewFromInt(10)}
+	_ = s.CreateRequest(context.Background(), req)
+
+	resp := &api.Response{Success: true, Query: *req, Result: money.MustParse("9.1")}
+	_ = s.CreateResponse(context.Background(), resp)
+
+	cl := log.NewConversionLog("", *req, *resp)
+	_ = s.CreateConversionLog(context.Background(), cl)
+
+	updated := &api.Request{Id: req.Id, From: "USD", To: "EUR", Amount: money.NewFromInt(20)}
+	_ = s.UpdateRequest(context.Background(), updated)
+
+	expanded := s.GetExpandedConversionLog(cl.Id)
+
+	if expanded == nil || expanded.Log.Id != cl.Id {
+		t.Fatalf("GetExpandedConversionLog failed: got=%v", expanded)
+	}
+
+	if expanded.Request == nil || !expanded.Request.Amount.Equal(money.NewFromInt(20)) {
+		t.Fatalf("expanded request must be the stored record, got=%v", expanded.Request)
+	}
+
+	if expanded.Response == nil || expanded.Response.Id != resp.Id {
+		t.Fatalf("expanded response mismatch, got=%v", expanded.Response)
+	}
+
+	if s.GetExpandedConversionLog("nope") != nil {
+		t.Fatal("GetExpandedConversionLog should return nil for unknown id")
+	}
+}
+
+func TestStorageService_CreateWithMissingReference(t *testing.T) {
+	s := NewStorageService(NewMockRepository(), newTestAudit(), nil)
+
+	resp := &api.Response{Query: api.Request{Id: "nope"}}
+
+	if err := s.CreateResponse(context.Background(), resp); !errors.Is(err, repository.ErrMissingReference) {
+		t.Fatalf("CreateResponse should return ErrMissingReference, got %v", err)
+	}
+}
+
+func TestStartStorageService_ChildBeforeParent(t *testing.T) {
+	mockRepo := NewMockRepository()
+	svc := NewStorageService(mockRepo, newTestAudit(), nil)
+
+	var wg sync.WaitGroup
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	requests := lifecycle.NewQueue[*api.Request](1)
+	responses := lifecycle.NewQueue[*api.Response](1)
+	logs := lifecycle.NewQueue[*log.ConversionLog](1)
+
+	svc.StartStorageService(&wg, ctx, PoolConfig{}, requests, responses, logs)
+
+	req := api.Request{Id: uuid.New().String(), From: "USD", To: "EUR", Amount: money.NewFromInt(1)}
+	resp := api.Response{Id: uuid.New().String(), Success: true, Query: req}
+
+	push(t, logs, log.NewConversionLog(uuid.New().String(), req, resp))
+	push(t, responses, &resp)
+	eventually(t, func() bool { return retried(svc, responseEntity) && retried(svc, logEntity) })
+	push(t, requests, &req)
+
+	requests.Close()
+	responses.Close()
+	logs.Close()
+
+	wg.Wait()
+
+	if requests, responses, logs := mockRepo.counts(); requests != 1 || responses != 1 || logs != 1 {
+		t.Fatalf("linked items were not stored: requests=%d responses=%d logs=%d", requests, responses, logs)
+	}
+}
+
+func TestStorageService_UpdateVersionConflict(t *testing.T) {
+	s := NewStorageService(NewMockRepository(), newTestAudit(), nil)
+
+	req := &api.Request{From: "USD", To: "EUR", Amount: money.NewFromInt(1)}
+	_ = s.CreateRequest(context.Background(), req)
+
+	if req.Version != 1 {
+		t.Fatalf("created request version = %d, want 1", req.Version)
+	}
+
+	first := &api.Request{Id: req.Id, From: "USD", To: "EUR", Amount: money.NewFromInt(2), Version: 1}
+
+	if err := s.UpdateRequest(context.Background(), first); err != nil || first.Version != 2 {
+		t.Fatalf("UpdateRequest with current version failed: err=%v version=%d", err, first.Version)
+	}
+
+	stale := &api.Request{Id: req.Id, From: "USD", To: "EUR", Amount: money.NewFromInt(3), Version: 1}
+
+	if err := s.UpdateRequest(context.Background(), stale); !errors.Is(err, repository.ErrVersionConflict) {
+		t.Fatalf("UpdateRequest with stale version should return ErrVersionConflict, got %v", err)
+	}
+
+	blind := &api.Request{Id: req.Id, From: "USD", To: "EUR", Amount: money.NewFromInt(4)}
+
+	if err := s.UpdateRequest(context.Background(), blind); err != nil || blind.Version != 3 {
+		t.Fatalf("UpdateRequest without version failed: err=%v version=%d", err, blind.Version)
+	}
 }
@@ -0,0 +1,629 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/lifecycle"
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/log"
+	"github.com/M2rk13/Otus-327619/internal/model/outbox"
+	"github.com/M2rk13/Otus-327619/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// RetryPolicy sets how a failed write is retried: up to Attempts tries in
+// total, waiting a jittered delay that starts at BaseDelay and doubles up to
+// MaxDelay between them.
+type RetryPolicy struct {
+	Attempts  int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+var defaultRetry = RetryPolicy{Attempts: 10, BaseDelay: 50 * time.Millisecond, MaxDelay: time.Second}
+
+const defaultDrainTimeout = 5 * time.Second
+
+var errDrainTimeout = errors.New("not stored before the shutdown deadline")
+
+// orDefault fills the unset fields of r from defaultRetry.
+func (r RetryPolicy) orDefault() RetryPolicy {
+	if r.Attempts <= 0 {
+		r.Attempts = defaultRetry.Attempts
+	}
+
+	if r.BaseDelay <= 0 {
+		r.BaseDelay = defaultRetry.BaseDelay
+	}
+
+	if r.MaxDelay < r.BaseDelay {
+		r.MaxDelay = max(defaultRetry.MaxDelay, r.BaseDelay)
+	}
+
+	return r
+}
+
+// PoolConfig sets the workers storing the queued items of each entity. A
+// worker collects up to BatchSize items, or what arrived within
+// FlushInterval of the first one, and stores them in one repository call.
+type PoolConfig struct {
+	RequestWorkers  int
+	ResponseWorkers int
+	LogWorkers      int
+	BatchSize       int
+	FlushInterval   time.Duration
+	// Retry applies to the items whose write failed; unset fields fall back
+	// to defaultRetry.
+	Retry RetryPolicy
+	// DeadLetters receives the items that failed permanently or ran out of
+	// retries. Without it they are only logged.
+	DeadLetters *DeadLetterService
+	// Outbox journals the enqueued items until they are stored or dead
+	// lettered. Its pending entries are queued again on start, and items
+	// still queued or failing on shutdown are left in it instead of being
+	// retried. Without it, the queued items are stored before shutdown.
+	Outbox repository.Outbox
+	// DrainTimeout bounds storing the queued items on shutdown without an
+	// outbox; what is left then goes to the dead-letter store. It defaults
+	// to defaultDrainTimeout.
+	DrainTimeout time.Duration
+}
+
+// IngestStats describes the queue and the workers of one entity. Wait is the
+// time an item spent queued, store latency is measured per flushed batch.
+// Failed counts the items given up on, DeadLettered those of them kept in the
+// dead-letter store.
+type IngestStats struct {
+	Entity       string               `json:"entity"`
+	Queue        lifecycle.QueueStats `json:"queue"`
+	Workers      int                  `json:"workers"`
+	Stored       int64                `json:"stored"`
+	Failed       int64                `json:"failed"`
+	Batches      int64                `json:"batches"`
+	Retries      int64                `json:"retries"`
+	DeadLettered int64                `json:"dead_lettered"`
+	AvgWaitMs    float64              `json:"avg_wait_ms"`
+	MaxWaitMs    float64              `json:"max_wait_ms"`
+	AvgStoreMs   float64              `json:"avg_store_ms"`
+	MaxStoreMs   float64              `json:"max_store_ms"`
+}
+
+type ingestPools struct {
+	requests  *ingestPool[*api.Request]
+	responses *ingestPool[*api.Response]
+	logs      *ingestPool[*log.ConversionLog]
+}
+
+// StartStorageService starts the worker pools storing the items arriving on
+// the queues until they are closed or ctx is cancelled. On cancellation the
+// items already queued are still stored, or kept in the outbox or the
+// dead-letter store, so nothing accepted by a queue is lost on shutdown.
+func (s *StorageService) StartStorageService(
+	wg *sync.WaitGroup,
+	ctx context.Context,
+	cfg PoolConfig,
+	requests *lifecycle.Queue[*api.Request],
+	responses *lifecycle.Queue[*api.Response],
+	logs *lifecycle.Queue[*log.ConversionLog],
+) {
+	pools := &ingestPools{
+		requests:  newIngestPool(requestEntity, requests, cfg.RequestWorkers, cfg, s.CreateRequest, s.CreateRequests),
+		responses: newIngestPool(responseEntity, responses, cfg.ResponseWorkers, cfg, s.CreateResponse, s.CreateResponses),
+		logs:      newIngestPool(logEntity, logs, cfg.LogWorkers, cfg, s.CreateConversionLog, s.CreateConversionLogs),
+	}
+
+	s.ingest.Store(pools)
+	pools.requests.start(wg, ctx)
+	pools.responses.start(wg, ctx)
+	pools.logs.start(wg, ctx)
+
+	if cfg.Outbox != nil {
+		pending := slices.DeleteFunc(cfg.Outbox.Pending(), func(entry *outbox.Entry) bool {
+			if entry.Entity == eventEntity {
+				// Replayed by the event service.
+				return true
+			}
+
+			if !s.stored(entry) {
+				return false
+			}
+
+			// Stored before the acknowledgement was written.
+			_ = cfg.Outbox.Ack(entry.Entity, entry.Id)
+
+			return true
+		})
+
+		if len(pending) > 0 {
+			fmt.Printf("Replaying %d outbox entries.\n", len(pending))
+		}
+
+		// Requests go first so that the links of the others are likely stored
+		// by the time they arrive.
+		pools.requests.replay(ctx, pending)
+		pools.responses.replay(ctx, pending)
+		pools.logs.replay(ctx, pending)
+	}
+}
+
+// stored reports whether the record of an outbox entry is in the repository.
+func (s *StorageService) stored(entry *outbox.Entry) bool {
+	switch entry.Entity {
+	case requestEntity:
+		return s.repo.GetRequestByID(entry.Id) != nil
+	case responseEntity:
+		return s.repo.GetResponseByID(entry.Id) != nil
+	case logEntity:
+		return s.repo.GetConversionLogByID(entry.Id) != nil
+	default:
+		return false
+	}
+}
+
+// CloseIngest closes the queues of the storage workers, which stop once the
+// queued items are stored.
+func (s *StorageService) CloseIngest() {
+	pools := s.ingest.Load()
+
+	if pools == nil {
+		return
+	}
+
+	pools.requests.queue.Close()
+	pools.responses.queue.Close()
+	pools.logs.queue.Close()
+}
+
+// EnqueueRequest hands req to the storage workers, assigning an id first if
+// it has none. With an outbox, req is journalled before it is queued. It
+// fails with lifecycle.ErrQueueFull if the queue is full and rejects items,
+// and with lifecycle.ErrClosed if the workers are not running.
+func (s *StorageService) EnqueueRequest(ctx context.Context, req *api.Request) error {
+	pools := s.ingest.Load()
+
+	if pools == nil {
+		return lifecycle.ErrClosed
+	}
+
+	if req.Id == "" {
+		req.Id = uuid.New().String()
+	}
+
+	return pools.requests.enqueue(ctx, req)
+}
+
+func (s *StorageService) EnqueueResponse(ctx context.Context, resp *api.Response) error {
+	pools := s.ingest.Load()
+
+	if pools == nil {
+		return lifecycle.ErrClosed
+	}
+
+	if resp.Id == "" {
+		resp.Id = uuid.New().String()
+	}
+
+	return pools.responses.enqueue(ctx, resp)
+}
+
+func (s *StorageService) EnqueueConversionLog(ctx context.Context, convLog *log.ConversionLog) error {
+	pools := s.ingest.Load()
+
+	if pools == nil {
+		return lifecycle.ErrClosed
+	}
+
+	if convLog.Id == "" {
+		convLog.Id = uuid.New().String()
+	}
+
+	return pools.logs.enqueue(ctx, convLog)
+}
+
+// IngestStats reports the queues and workers started by StartStorageService,
+// followed by the event queue if events are published.
+func (s *StorageService) IngestStats() []IngestStats {
+	stats := []IngestStats{}
+
+	if pools := s.ingest.Load(); pools != nil {
+		stats = append(stats, pools.requests.stats(), pools.responses.stats(), pools.logs.stats())
+	}
+
+	if s.events != nil {
+		stats = append(stats, s.events.stats())
+	}
+
+	return stats
+}
+
+type ingestPool[T repository.Identifiable] struct {
+	entity        string
+	queue         *lifecycle.Queue[T]
+	workers       int
+	batchSize     int
+	flushInterval time.Duration
+	drainTimeout  time.Duration
+	retry         RetryPolicy
+	deadLetters   *DeadLetterService
+	outbox        repository.Outbox
+	createOne     func(context.Context, T) error
+	createMany    func(context.Context, []T) error
+
+	stored       atomic.Int64
+	failed       atomic.Int64
+	batches      atomic.Int64
+	retries      atomic.Int64
+	deadLettered atomic.Int64
+	waited       atomic.Int64
+	waitTotal    atomic.Int64
+	waitMax      atomic.Int64
+	storeTotal   atomic.Int64
+	storeMax     atomic.Int64
+}
+
+func newIngestPool[T repository.Identifiable](
+	entity string,
+	queue *lifecycle.Queue[T],
+	workers int,
+	cfg PoolConfig,
+	createOne func(context.Context, T) error,
+	createMany func(context.Context, []T) error,
+) *ingestPool[T] {
+	p := &ingestPool[T]{
+		entity:        entity,
+		queue:         queue,
+		workers:       max(workers, 1),
+		batchSize:     max(cfg.BatchSize, 1),
+		flushInterval: cfg.FlushInterval,
+		drainTimeout:  cmp.Or(cfg.DrainTimeout, defaultDrainTimeout),
+		retry:         cfg.Retry.orDefault(),
+		deadLetters:   cfg.DeadLetters,
+		outbox:        cfg.Outbox,
+		createOne:     createOne,
+		createMany:    createMany,
+	}
+
+	// An item a dropping queue discards is never stored, so it must not be
+	// replayed from the outbox either.
+	if p.outbox != nil {
+		queue.OnDrop(func(item T) { p.ack(item) })
+	}
+
+	return p
+}
+
+// enqueue journals item in the outbox and queues it. An item the queue does
+// not accept is taken out of the outbox again.
+func (p *ingestPool[T]) enqueue(ctx context.Context, item T) error {
+	if p.outbox != nil {
+		payload, err := json.Marshal(item)
+
+		if err != nil {
+			return err
+		}
+
+		entry := &outbox.Entry{Entity: p.entity, Id: item.GetId(), Payload: payload, AcceptedAt: time.Now().UTC()}
+
+		if err = p.outbox.Append(entry); err != nil {
+			return fmt.Errorf("failed to journal %s: %w", p.entity, err)
+		}
+	}
+
+	if err := p.queue.Push(ctx, item); err != nil {
+		p.ack(item)
+
+		return err
+	}
+
+	return nil
+}
+
+// replay queues the outbox entries of the pool's entity again. It gives up at
+// the first item the queue does not take; the rest stay in the outbox.
+func (p *ingestPool[T]) replay(ctx context.Context, entries []*outbox.Entry) {
+	for _, entry := range entries {
+		if entry.Entity != p.entity {
+			continue
+		}
+
+		var item T
+
+		if err := json.Unmarshal(entry.Payload, &item); err != nil {
+			fmt.Printf("Skipping outbox entry %s of %s: %v\n", entry.Id, p.entity, err)
+
+			continue
+		}
+
+		if err := p.queue.Push(ctx, item); err != nil {
+			fmt.Printf("Failed to replay outbox entries of %s: %v\n", p.entity, err)
+
+			return
+		}
+	}
+}
+
+// ack takes items out of the outbox once they need no further delivery.
+func (p *ingestPool[T]) ack(items ...T) {
+	if p.outbox == nil || len(items) == 0 {
+		return
+	}
+
+	ids := make([]string, len(items))
+
+	for i, item := range items {
+		ids[i] = item.GetId()
+	}
+
+	if err := p.outbox.Ack(p.entity, ids...); err != nil {
+		fmt.Printf("Failed to acknowledge %d outbox entries of %s: %v\n", len(ids), p.entity, err)
+	}
+}
+
+func (p *ingestPool[T]) start(wg *sync.WaitGroup, ctx context.Context) {
+	wg.Add(p.workers)
+
+	for i := 1; i <= p.workers; i++ {
+		go p.work(wg, ctx, i)
+	}
+}
+
+func (p *ingestPool[T]) work(wg *sync.WaitGroup, ctx context.Context, id int) {
+	defer wg.Done()
+
+	name := strings.ReplaceAll(p.entity, "_", " ")
+	batch := make([]lifecycle.Entry[T], 0, p.batchSize)
+	timer := time.NewTimer(p.flushInterval)
+	timer.Stop()
+
+	// pending is the timer channel while a partial batch waits to be flushed.
+	var pending <-chan time.Time
+
+	flush := func(ctx context.Context) {
+		timer.Stop()
+		pending = nil
+		p.flush(ctx, batch)
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case entry, ok := <-p.queue.Items():
+			if !ok {
+				flush(ctx)
+				fmt.Printf("Storage worker %d for %s finished.\n", id, name)
+
+				return
+			}
+
+			batch = append(batch, entry)
+
+			if len(batch) >= p.batchSize {
+				flush(ctx)
+			} else if pending == nil {
+				timer.Reset(p.flushInterval)
+				pending = timer.C
+			}
+		case <-pending:
+			flush(ctx)
+		case <-ctx.Done():
+			// Without an outbox the queued items are only in memory, so they
+			// are retried until the drain deadline and dead lettered after
+			// it. With one, they get a single attempt and whatever fails
+			// waits in the outbox for the next start.
+			drainCtx := ctx
+
+			if p.outbox == nil {
+				var cancel context.CancelFunc
+				drainCtx, cancel = context.WithTimeoutCause(context.WithoutCancel(ctx), p.drainTimeout, errDrainTimeout)
+				defer cancel()
+			}
+
+			drained := p.queue.Drain()
+			batch = append(batch, drained...)
+
+			for len(batch) > 0 {
+				chunk := batch[:min(len(batch), p.batchSize)]
+
+				if p.outbox == nil && drainCtx.Err() != nil {
+					p.abandon(chunk, context.Cause(drainCtx))
+				} else {
+					p.flush(drainCtx, chunk)
+				}
+
+				batch = batch[len(chunk):]
+			}
+
+			fmt.Printf("Storage worker %d for %s stopped by context, %d queued items handled.\n", id, name, len(drained))
+
+			return
+		}
+	}
+}
+
+// flush stores a batch with one repository call. If that fails, the items
+// it did not store are stored one by one instead, so that each is retried on
+// its own and a missing link or a bad item does not hold back the others.
+func (p *ingestPool[T]) flush(ctx context.Context, batch []lifecycle.Entry[T]) {
+	if len(batch) == 0 {
+		return
+	}
+
+	now := time.Now()
+	items := make([]T, len(batch))
+
+	for i, entry := range batch {
+		items[i] = entry.Item
+		wait := int64(now.Sub(entry.Enqueued))
+		p.waited.Add(1)
+		p.waitTotal.Add(wait)
+		storeMax(&p.waitMax, wait)
+	}
+
+	stored := p.store(ctx, items)
+	elapsed := int64(time.Since(now))
+
+	p.batches.Add(1)
+	p.storeTotal.Add(elapsed)
+	storeMax(&p.storeMax, elapsed)
+	p.stored.Add(int64(stored))
+	p.failed.Add(int64(len(items) - stored))
+}
+
+// abandon gives up on a batch without storing it.
+func (p *ingestPool[T]) abandon(batch []lifecycle.Entry[T], err error) {
+	for _, entry := range batch {
+		p.giveUp(entry.Item, err, 0)
+	}
+
+	p.failed.Add(int64(len(batch)))
+}
+
+func (p *ingestPool[T]) store(ctx context.Context, items []T) int {
+	count := 0
+
+	if len(items) > 1 {
+		err := p.createMany(ctx, items)
+
+		if err == nil {
+			p.ack(items...)
+
+			return len(items)
+		}
+
+		// A store that is not atomic may have kept the head of the batch.
+		if stored := repository.StoredBefore(err); stored > 0 {
+			p.ack(items[:stored]...)
+			items = items[stored:]
+			count = stored
+		}
+
+		if !errors.Is(err, repository.ErrMissingReference) {
+			fmt.Printf("Failed to store %d items of %s at once, storing one by one: %v\n", len(items), p.entity, err)
+		}
+	}
+
+	for _, item := range items {
+		if p.persist(ctx, item) == nil {
+			count++
+		}
+	}
+
+	return count
+}
+
+func (p *ingestPool[T]) stats() IngestStats {
+	stats := IngestStats{
+		Entity:       p.entity,
+		Queue:        p.queue.Stats(),
+		Workers:      p.workers,
+		Stored:       p.stored.Load(),
+		Failed:       p.failed.Load(),
+		Batches:      p.batches.Load(),
+		Retries:      p.retries.Load(),
+		DeadLettered: p.deadLettered.Load(),
+		MaxWaitMs:    milliseconds(p.waitMax.Load()),
+		MaxStoreMs:   milliseconds(p.storeMax.Load()),
+	}
+
+	if waited := p.waited.Load(); waited > 0 {
+		stats.AvgWaitMs = milliseconds(p.waitTotal.Load() / waited)
+	}
+
+	if stats.Batches > 0 {
+		stats.AvgStoreMs = milliseconds(p.storeTotal.Load() / stats.Batches)
+	}
+
+	return stats
+}
+
+func storeMax(value *atomic.Int64, candidate int64) {
+	for {
+		current := value.Load()
+
+		if candidate <= current || value.CompareAndSwap(current, candidate) {
+			return
+		}
+	}
+}
+
+func milliseconds(nanos int64) float64 {
+	return float64(nanos) / float64(time.Millisecond)
+}
+
+// persist stores an item received from the pipeline. Transient errors are
+// retried with backoff; this includes missing links, as the queues are
+// drained independently and a response or log may arrive before the records
+// it links to. Items that fail permanently, run out of attempts or are still
+// failing when ctx is cancelled go to the dead-letter store, unless an outbox
+// keeps the latter for the next start.
+func (p *ingestPool[T]) persist(ctx context.Context, item T) error {
+	delay := p.retry.BaseDelay
+
+	for attempt := 1; ; attempt++ {
+		err := p.createOne(ctx, item)
+
+		if err == nil {
+			p.ack(item)
+
+			return nil
+		}
+
+		if !transient(err) || attempt >= p.retry.Attempts {
+			p.giveUp(item, err, attempt)
+
+			return err
+		}
+
+		p.retries.Add(1)
+
+		select {
+		case <-time.After(jitter(delay)):
+		case <-ctx.Done():
+			if p.outbox == nil {
+				p.giveUp(item, err, attempt)
+			}
+
+			return err
+		}
+
+		delay = min(delay*2, p.retry.MaxDelay)
+	}
+}
+
+func (p *ingestPool[T]) giveUp(item T, err error, attempts int) {
+	fmt.Printf("Failed to store %s after %d attempts: %v\n", p.entity, attempts, err)
+
+	if p.deadLetters != nil && p.deadLetters.Add(p.entity, item, err, attempts) == nil {
+		p.deadLettered.Add(1)
+		p.ack(item)
+	} else if !transient(err) {
+		// Replaying it on the next start would fail the same way.
+		p.ack(item)
+	}
+}
+
+// transient reports whether a write failing with err may succeed when
+// repeated: the store was unavailable, or a record the item links to is not
+// stored yet. Anything else is a rejection of the item itself.
+func transient(err error) bool {
+	return errors.Is(err, repository.ErrMissingReference) || repository.Unavailable(err)
+}
+
+// jitter spreads delay randomly over its upper half, so that workers failing
+// together do not retry in lockstep.
+func jitter(delay time.Duration) time.Duration {
+	half := delay / 2
+
+	return half + rand.N(delay-half+1)
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/enum"
+	"github.com/M2rk13/Otus-327619/internal/lifecycle"
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/audit"
+	"github.com/M2rk13/Otus-327619/internal/model/log"
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+	"github.com/M2rk13/Otus-327619/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+func TestStorageWorkers_Batching(t *testing.T) {
+	mockRepo := NewMockRepository()
+	svc := NewStorageService(mockRepo, newTestAudit(), nil)
+
+	var wg sync.WaitGroup
+	ctx, cancel := context.WithCancel(context.Background())
+
+	requests := lifecycle.NewQueue[*api.Request](20)
+	pools := PoolConfig{RequestWorkers: 2, BatchSize: 5, FlushInterval: 20 * time.Millisecond}
+
+	for i := 0; i < 12; i++ {
+		push(t, requests, &api.Request{From: "USD", To: "EUR", Amount: money.NewFromInt(int64(i))})
+	}
+
+	svc.StartStorageService(&wg, ctx, pools, requests, lifecycle.NewQueue[*api.Response](1), lifecycle.NewQueue[*log.ConversionLog](1))
+
+	eventually(t, func() bool {
+		saved, _, _ := mockRepo.counts()
+
+		return saved == 12
+	})
+
+	stats := svc.IngestStats()[0]
+
+	if stats.Entity != requestEntity || stats.Workers != 2 || stats.Stored != 12 || stats.Failed != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	if stats.Batches >= 12 || stats.Queue.Pushed != 12 || stats.Queue.Depth != 0 {
+		t.Fatalf("items should be stored in batches, got %+v", stats)
+	}
+
+	cancel()
+	wg.Wait()
+
+	if mockRepo.batches == 0 {
+		t.Fatal("the batch create of the repository was not used")
+	}
+}
+
+func TestStorageWorkers_BatchWaitsForLinks(t *testing.T) {
+	mockRepo := NewMockRepository()
+	svc := NewStorageService(mockRepo, newTestAudit(), nil)
+
+	var wg sync.WaitGroup
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	requests := lifecycle.NewQueue[*api.Request](5)
+	logs := lifecycle.NewQueue[*log.ConversionLog](5)
+	pools := PoolConfig{BatchSize: 2, FlushInterval: time.Millisecond}
+
+	req := api.Request{Id: uuid.New().String(), From: "USD", To: "EUR", Amount: money.NewFromInt(1)}
+	push(t, logs, log.NewConversionLog("", req, api.Response{}))
+	push(t, logs, log.NewConversionLog("", api.Request{}, api.Response{}))
+
+	svc.StartStorageService(&wg, ctx, pools, requests, lifecycle.NewQueue[*api.Response](1), logs)
+	eventually(t, func() bool { return retried(svc, logEntity) })
+	push(t, requests, &req)
+
+	eventually(t, func() bool {
+		_, _, saved := mockRepo.counts()
+
+		return saved == 2
+	})
+}
+
+// partialRepository stores only the first half of a batch, like a Mongo
+// insert that fails in the middle, and rejects taken ids.
+type partialRepository struct {
+	*MockRepository
+}
+
+func (r *partialRepository) CreateRequest(req *api.Request) error {
+	if r.GetRequestByID(req.Id) != nil {
+		return repository.ErrAlreadyExists
+	}
+
+	return r.MockRepository.CreateRequest(req)
+}
+
+func (r *partialRepository) CreateRequests(reqs []*api.Request) error {
+	stored := len(reqs) / 2
+
+	for _, req := range reqs[:stored] {
+		_ = r.CreateRequest(req)
+	}
+
+	return &repository.PartialError{Stored: stored, Err: errors.New("connection reset")}
+}
+
+func TestStorageWorkers_PartialBatch(t *testing.T) {
+	mockRepo := &partialRepository{MockRepository: NewMockRepository()}
+	auditSvc := newTestAudit()
+	svc := NewStorageService(mockRepo, auditSvc, nil)
+	deadLetters, _ := newTestDeadLetters(t, svc)
+
+	var wg sync.WaitGroup
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	requests := lifecycle.NewQueue[*api.Request](5)
+	pools := PoolConfig{BatchSize: 4, FlushInterval: 20 * time.Millisecond, DeadLetters: deadLetters}
+
+	for i := 0; i < 4; i++ {
+		push(t, requests, &api.Request{Id: uuid.New().String(), From: "USD", To: "EUR", Amount: money.NewFromInt(int64(i))})
+	}
+
+	svc.StartStorageService(&wg, ctx, pools, requests, lifecycle.NewQueue[*api.Response](1), lifecycle.NewQueue[*log.ConversionLog](1))
+
+	eventually(t, func() bool { return svc.IngestStats()[0].Stored == 4 })
+
+	if stats := svc.IngestStats()[0]; stats.Failed != 0 || stats.DeadLettered != 0 {
+		t.Fatalf("the stored head of the batch should not fail, got %+v", stats)
+	}
+
+	if letters, _ := deadLetters.List(requestEntity); len(letters) != 0 {
+		t.Fatalf("expected no dead letters, got %d", len(letters))
+	}
+
+	records, _ := auditSvc.Find(audit.Filter{Entity: requestEntity})
+
+	if len(records) != 4 {
+		t.Fatalf("expected a record per request, got %d", len(records))
+	}
+
+	for _, rec := range records {
+		if rec.Action != enum.ActionCreate {
+			t.Fatalf("unexpected audit record %+v", rec)
+		}
+	}
+}
+
+func TestStorageService_Enqueue(t *testing.T) {
+	mockRepo := NewMockRepository()
+	svc := NewStorageService(mockRepo, newTestAudit(), nil)
+	req := &api.Request{From: "USD", To: "EUR", Amount: money.NewFromInt(1)}
+
+	if err := svc.EnqueueRequest(context.Background(), req); !errors.Is(err, lifecycle.ErrClosed) {
+		t.Fatalf("enqueueing before the workers start should fail with ErrClosed, got %v", err)
+	}
+
+	var wg sync.WaitGroup
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	svc.StartStorageService(&wg, ctx, PoolConfig{},
+		lifecycle.NewQueue[*api.Request](1),
+		lifecycle.NewQueue[*api.Response](1),
+		lifecycle.NewQueue[*log.ConversionLog](1),
+	)
+
+	if err := svc.EnqueueRequest(context.Background(), req); err != nil || req.Id == "" {
+		t.Fatalf("expected the request to be queued with an id, got %v", err)
+	}
+
+	eventually(t, func() bool { return svc.GetRequestByID(req.Id) != nil })
+}
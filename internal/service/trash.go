@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/enum"
+	"github.com/M2rk13/Otus-327619/internal/repository"
+)
+
+// TrashService permanently removes soft-deleted records once they have been
+// in the trash longer than the retention period.
+type TrashService struct {
+	repo      repository.Repository
+	audit     *AuditService
+	retention time.Duration
+	interval  time.Duration
+}
+
+func NewTrashService(repo repository.Repository, audit *AuditService, retention, interval time.Duration) *TrashService {
+	return &TrashService{repo: repo, audit: audit, retention: retention, interval: interval}
+}
+
+// Purge removes everything deleted before now minus the retention period.
+func (t *TrashService) Purge(now time.Time) (int, error) {
+	before := now.Add(-t.retention)
+	purged, err := t.repo.PurgeDeleted(before)
+
+	if err == nil && purged > 0 {
+		t.audit.Record(context.Background(), enum.ActionPurge, "trash", "", nil, map[string]any{
+			"deleted_before": before,
+			"purged":         purged,
+		})
+	}
+
+	return purged, err
+}
+
+func (t *TrashService) StartPurger(wg *sync.WaitGroup, ctx context.Context) {
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(t.interval)
+		defer ticker.Stop()
+
+		fmt.Printf("Trash purger started, retention %s.\n", t.retention)
+
+		for {
+			select {
+			case <-ticker.C:
+				purged, err := t.Purge(time.Now())
+
+				if err != nil {
+					fmt.Printf("Trash purge failed: %v\n", err)
+				} else if purged > 0 {
+					fmt.Printf("Trash purge removed %d records.\n", purged)
+				}
+			case <-ctx.Done():
+				fmt.Println("Trash purger stopped due context cancel.")
+
+				return
+			}
+		}
+	}()
+}
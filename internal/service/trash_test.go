@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+	"github.com/M2rk13/Otus-327619/internal/repository"
+)
+
+func TestStorageService_RestoreRequest(t *testing.T) {
+	repo := NewMockRepository()
+	s := NewStorageService(repo, newTestAudit(), nil)
+
+	req := &api.Request{From: "USD", To: "EUR", Amount: money.NewFromInt(10)}
+
+	if err := s.CreateRequest(context.Background(), req); err != nil {
+		t.Fatalf("CreateRequest: %v", err)
+	}
+
+	if err := s.DeleteRequest(context.Background(), req.Id); err != nil {
+		t.Fatalf("DeleteRequest: %v", err)
+	}
+
+	if len(s.GetAllRequests(false)) != 0 || len(s.GetAllRequests(true)) != 1 {
+		t.Fatal("deleted request should only be listed with includeDeleted")
+	}
+
+	if err := s.RestoreRequest(context.Background(), req.Id); err != nil {
+		t.Fatalf("RestoreRequest: %v", err)
+	}
+
+	if got := s.GetRequestByID(req.Id); got == nil || got.DeletedAt != nil {
+		t.Fatal("restored request should be visible again")
+	}
+
+	if err := s.RestoreRequest(context.Background(), req.Id); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("restoring a live request should fail with ErrNotFound, got %v", err)
+	}
+}
+
+func TestTrashService_Purge(t *testing.T) {
+	repo := NewMockRepository()
+	s := NewStorageService(repo, newTestAudit(), nil)
+	trash := NewTrashService(repo, newTestAudit(), time.Hour, time.Minute)
+
+	kept := &api.Request{From: "USD", To: "EUR", Amount: money.NewFromInt(1)}
+	purged := &api.Request{From: "USD", To: "GBP", Amount: money.NewFromInt(2)}
+
+	for _, req := range []*api.Request{kept, purged} {
+		if err := s.CreateRequest(context.Background(), req); err != nil {
+			t.Fatalf("CreateRequest: %v", err)
+		}
+
+		if err := s.DeleteRequest(context.Background(), req.Id); err != nil {
+			t.Fatalf("DeleteRequest: %v", err)
+		}
+	}
+
+	expired := time.Now().Add(-2 * time.Hour)
+	purged.DeletedAt = &expired
+
+	count, err := trash.Purge(time.Now())
+
+	if err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+
+	if count != 1 {
+		t.Fatalf("expected 1 purged record, got %d", count)
+	}
+
+	if err := s.RestoreRequest(context.Background(), purged.Id); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("purged request should be gone, got %v", err)
+	}
+
+	if err := s.RestoreRequest(context.Background(), kept.Id); err != nil {
+		t.Fatalf("request inside retention should be restorable: %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	"github.com/M2rk13/Otus-327619/internal/model/log"
+)
+
+// logWatchBuffer is how many stored logs a watcher may fall behind by before
+// it is dropped.
+const logWatchBuffer = 64
+
+// logWatchers fans the conversion logs stored by this instance out to the
+// watchers. Sends never block the storage: a watcher whose buffer is full is
+// dropped and its channel closed, so that it does not silently miss logs.
+type logWatchers struct {
+	mu       sync.Mutex
+	channels map[chan *log.ConversionLog]struct{}
+}
+
+// WatchConversionLogs returns the conversion logs stored from now on. The
+// channel is closed when ctx is done or when the reader falls behind, which
+// it can tell apart by ctx.Err().
+func (s *StorageService) WatchConversionLogs(ctx context.Context) <-chan *log.ConversionLog {
+	ch := make(chan *log.ConversionLog, logWatchBuffer)
+
+	s.watchers.mu.Lock()
+	s.watchers.channels[ch] = struct{}{}
+	s.watchers.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		s.watchers.remove(ch)
+	}()
+
+	return ch
+}
+
+func (w *logWatchers) notify(convLog *log.ConversionLog) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for ch := range w.channels {
+		select {
+		case ch <- convLog:
+		default:
+			delete(w.channels, ch)
+			close(ch)
+		}
+	}
+}
+
+func (w *logWatchers) remove(ch chan *log.ConversionLog) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, ok := w.channels[ch]; ok {
+		delete(w.channels, ch)
+		close(ch)
+	}
+}
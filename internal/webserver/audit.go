@@ -0,0 +1,99 @@
+package webserver
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/model/audit"
+	"github.com/M2rk13/Otus-327619/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+	requestIDKey    = "request_id"
+	actorKey        = "actor"
+)
+
+// requestIDMiddleware keeps the caller's X-Request-ID or generates one, and
+// echoes it back so that audit records can be matched to API calls.
+func requestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(requestIDHeader)
+
+		if requestID == "" {
+			requestID = uuid.New().String()
+		}
+
+		c.Set(requestIDKey, requestID)
+		c.Header(requestIDHeader, requestID)
+		c.Next()
+	}
+}
+
+// auditContext carries the authenticated actor and request ID of c into the
+// service layer.
+func auditContext(c *gin.Context) context.Context {
+	return service.WithAuditMeta(c.Request.Context(), c.GetString(actorKey), c.GetString(requestIDKey))
+}
+
+// @Summary      Get audit trail
+// @Description  Retrieves audit records of all mutations, newest first
+// @Tags         audit
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        entity  query  string  false  "Entity type: request, response, conversion_log or trash"
+// @Param        actor   query  string  false  "Login of the user who made the change"
+// @Param        from    query  string  false  "Start of the time range, RFC 3339"
+// @Param        to      query  string  false  "End of the time range, RFC 3339"
+// @Param        limit   query  int     false  "Maximum number of records"
+// @Success      200 {array}  audit.Record
+// @Failure      400 {object} object{error=string}
+// @Failure      500 {object} object{error=string}
+// @Router       /audit [get]
+func (h *APIHandler) getAuditRecords(c *gin.Context) {
+	filter := audit.Filter{
+		Entity: c.Query("entity"),
+		Actor:  c.Query("actor"),
+	}
+
+	var err error
+
+	if from := c.Query("from"); from != "" {
+		if filter.From, err = time.Parse(time.RFC3339, from); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from time, expected RFC 3339"})
+
+			return
+		}
+	}
+
+	if to := c.Query("to"); to != "" {
+		if filter.To, err = time.Parse(time.RFC3339, to); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to time, expected RFC 3339"})
+
+			return
+		}
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil || filter.Limit < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+
+			return
+		}
+	}
+
+	records, err := h.auditSvc.Find(filter)
+
+	if err != nil {
+		respondWithError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, records)
+}
@@ -1,15 +1,13 @@
 package webserver
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
-	"strings"
-	"time"
 
+	"github.com/M2rk13/Otus-327619/internal/auth"
 	"github.com/M2rk13/Otus-327619/internal/config"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4"
 )
 
 type loginCredentials struct {
@@ -17,11 +15,6 @@ type loginCredentials struct {
 	Password string `json:"password" example:"secret"`
 }
 
-type jwtClaims struct {
-	Login string `json:"login"`
-	jwt.RegisteredClaims
-}
-
 // @Summary      User login
 // @Description  Authenticates a user and returns a JWT token
 // @Tags         auth
@@ -48,18 +41,7 @@ func loginHandler(c *gin.Context) {
 		return
 	}
 
-	expirationTime := time.Now().Add(5 * time.Minute)
-
-	claims := &jwtClaims{
-		Login: creds.Login,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(config.AdminCfg.JwtKey))
+	tokenString, err := auth.IssueToken(creds.Login)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create token"})
@@ -80,31 +62,20 @@ func authMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		parts := strings.Split(authHeader, " ")
+		login, err := auth.ParseBearer(authHeader)
 
-		if len(parts) != 2 || parts[0] != "Bearer" {
+		switch {
+		case errors.Is(err, auth.ErrMalformed):
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header must be 'Bearer <token>'"})
 
 			return
-		}
-
-		tokenString := parts[1]
-		claims := &jwtClaims{}
-
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-
-			return []byte(config.AdminCfg.JwtKey), nil
-		})
-
-		if err != nil || !token.Valid {
+		case err != nil:
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 
 			return
 		}
 
+		c.Set(actorKey, login)
 		c.Next()
 	}
 }
@@ -0,0 +1,170 @@
+package webserver
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/money"
+	"github.com/M2rk13/Otus-327619/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// quoteRequest is a conversion request with an optional rounding policy that
+// overrides the one of the account.
+type quoteRequest struct {
+	api.Request
+	Rounding *money.RoundingPolicy `json:"rounding,omitempty"`
+}
+
+// convertRequest is a quote request that can instead name a locked quote to
+// execute; from, to and amount may then be left out.
+type convertRequest struct {
+	quoteRequest
+	QuoteId string `json:"quote_id,omitempty"`
+}
+
+// @Summary      Lock a quote
+// @Description  Prices a conversion like POST /convert without executing it and locks the rate, fees and rounding for the caller until expires_at. The quote is executed by passing its id as quote_id to POST /convert, at most once
+// @Tags         conversion
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request          body    quoteRequest  true   "Currencies and amount to quote"
+// @Param        Idempotency-Key  header  string        false  "Key to replay the first response of a repeated request"
+// @Success      201      {object}  quote.Quote
+// @Failure      400      {object}  object{error=string}
+// @Failure      422      {object}  object{error=string}
+// @Failure      503      {object}  object{error=string}
+// @Router       /quotes [post]
+func (h *APIHandler) createQuote(c *gin.Context) {
+	var req quoteRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request body: %v", err)})
+
+		return
+	}
+
+	q, err := h.conversionSvc.Quote(auditContext(c), &req.Request, req.Rounding)
+
+	if err != nil {
+		respondWithError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusCreated, q)
+}
+
+// @Summary      Convert currency
+// @Description  Converts an amount at the current rate and stores the request, response and conversion log. Pairs without a direct rate are derived from other pairs, the legs used are listed in response.info.legs. With quote_id a quote locked by the caller is executed at its rate instead; fields that are given must match the quote and it can be used only once before it expires. The result is rounded with the given rounding policy, else the one of the account, by default half_up to the minor units of the target currency; the policy used is recorded in the log. The rate quoted by the providers is the mid rate; the spread and fixed fee of the account fee schedule are applied on top and listed in response.fees
+// @Tags         conversion
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request          body    convertRequest  true   "Currencies and amount to convert"
+// @Param        Idempotency-Key  header  string          false  "Key to replay the first response of a repeated request"
+// @Success      201      {object}  log.ConversionLog
+// @Failure      400      {object}  object{error=string}
+// @Failure      404      {object}  object{error=string}
+// @Failure      409      {object}  object{error=string}
+// @Failure      410      {object}  object{error=string}
+// @Failure      422      {object}  object{error=string}
+// @Failure      503      {object}  object{error=string}
+// @Router       /convert [post]
+func (h *APIHandler) convert(c *gin.Context) {
+	var req convertRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request body: %v", err)})
+
+		return
+	}
+
+	convLog, err := h.conversionSvc.Convert(auditContext(c), &req.Request, service.ConvertOptions{
+		Rounding: req.Rounding,
+		QuoteId:  req.QuoteId,
+	})
+
+	if err != nil {
+		respondWithError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusCreated, convLog)
+}
+
+// @Summary      Convert a batch
+// @Description  Converts up to 100 amounts in one call, like POST /convert with the rounding policy of the account. Each distinct pair is quoted once for the whole batch. Items are converted independently: items listed with an error were not stored, the others are stored with a shared batch_id in their conversion logs
+// @Tags         conversion
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        requests         body    []api.Request  true   "Currencies and amounts to convert"
+// @Param        Idempotency-Key  header  string         false  "Key to replay the first response of a repeated request"
+// @Success      200       {object}  log.Batch
+// @Failure      400       {object}  object{error=string}
+// @Failure      422       {object}  object{error=string}
+// @Router       /convert/batch [post]
+func (h *APIHandler) convertBatch(c *gin.Context) {
+	var reqs []api.Request
+
+	if err := c.ShouldBindJSON(&reqs); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request body: %v", err)})
+
+		return
+	}
+
+	batch, err := h.conversionSvc.ConvertBatch(auditContext(c), reqs)
+
+	if err != nil {
+		respondWithError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, batch)
+}
+
+// multiConvertRequest is one source amount to convert into several currencies.
+type multiConvertRequest struct {
+	From    string        `json:"from" example:"USD"`
+	Amount  money.Decimal `json:"amount" swaggertype:"string" example:"100.50"`
+	Targets []string      `json:"targets" example:"EUR,GBP,JPY"`
+}
+
+// @Summary      Convert into several currencies
+// @Description  Converts one amount into up to 20 target currencies with the rounding policy of the account. All targets are priced before anything is stored; each is stored as its own request and response, linked from the targets of one parent conversion log. timestamp is the time of the oldest quote used
+// @Tags         conversion
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request          body    multiConvertRequest  true   "Source currency, amount and target currencies"
+// @Param        Idempotency-Key  header  string               false  "Key to replay the first response of a repeated request"
+// @Success      201      {object}  log.MultiConversion
+// @Failure      400      {object}  object{error=string}
+// @Failure      422      {object}  object{error=string}
+// @Failure      503      {object}  object{error=string}
+// @Router       /convert/multi [post]
+func (h *APIHandler) convertMulti(c *gin.Context) {
+	var req multiConvertRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request body: %v", err)})
+
+		return
+	}
+
+	result, err := h.conversionSvc.ConvertMulti(auditContext(c), req.From, req.Amount, req.Targets)
+
+	if err != nil {
+		respondWithError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
@@ -0,0 +1,46 @@
+package webserver
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary      List currencies
+// @Description  Lists the ISO 4217 currencies accepted in requests. Withdrawn currencies are included with include_inactive
+// @Tags         currencies
+// @Produce      json
+// @Param        include_inactive  query  bool  false  "Include withdrawn currencies"
+// @Success      200  {array}  currency.Currency
+// @Router       /currencies [get]
+func (h *APIHandler) getCurrencies(c *gin.Context) {
+	includeInactive, _ := strconv.ParseBool(c.Query("include_inactive"))
+	c.JSON(http.StatusOK, h.currencies.List(includeInactive))
+}
+
+// checkCurrencies normalises the currency codes of req and responds with 422
+// if either of them is not an active ISO 4217 currency.
+func (h *APIHandler) checkCurrencies(c *gin.Context, prefix string, req *api.Request) bool {
+	if err := h.currencies.NormalizeRequest(prefix, req, false); err != nil {
+		respondWithError(c, err)
+
+		return false
+	}
+
+	return true
+}
+
+// checkEmbeddedCurrencies is checkCurrencies for requests embedded in
+// responses and logs, which may be left empty.
+func (h *APIHandler) checkEmbeddedCurrencies(c *gin.Context, prefix string, req *api.Request) bool {
+	if err := h.currencies.NormalizeRequest(prefix, req, true); err != nil {
+		respondWithError(c, err)
+
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,70 @@
+package webserver
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary      List dead letters
+// @Description  Retrieves the items the storage workers gave up on, with the error of their last attempt, oldest first
+// @Tags         dead-letters
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        entity  query  string  false  "Entity type: request, response or conversion_log"
+// @Success      200 {array}  deadletter.Letter
+// @Failure      500 {object} object{error=string}
+// @Router       /dead-letters [get]
+func (h *APIHandler) getDeadLetters(c *gin.Context) {
+	letters, err := h.deadLetterSvc.List(c.Query("entity"))
+
+	if err != nil {
+		respondWithError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, letters)
+}
+
+// @Summary      Replay dead letter
+// @Description  Stores the item of a dead letter again and removes the letter on success. A failing replay keeps the letter
+// @Tags         dead-letters
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id  path  string  true  "Dead letter ID"
+// @Success      200 {object} deadletter.Letter
+// @Failure      404 {object} object{error=string}
+// @Failure      422 {object} object{error=string}
+// @Failure      500 {object} object{error=string}
+// @Router       /dead-letters/{id}/replay [post]
+func (h *APIHandler) replayDeadLetter(c *gin.Context) {
+	letter, err := h.deadLetterSvc.Replay(auditContext(c), c.Param("id"))
+
+	if err != nil {
+		respondWithError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, letter)
+}
+
+// @Summary      Discard dead letter
+// @Description  Removes a dead letter without storing its item
+// @Tags         dead-letters
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id  path  string  true  "Dead letter ID"
+// @Success      200 {object} object{message=string}
+// @Failure      404 {object} object{error=string}
+// @Router       /dead-letters/{id} [delete]
+func (h *APIHandler) discardDeadLetter(c *gin.Context) {
+	if err := h.deadLetterSvc.Discard(auditContext(c), c.Param("id")); err != nil {
+		respondWithError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Dead letter discarded"})
+}
@@ -0,0 +1,61 @@
+package webserver
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+func setETag(c *gin.Context, version int64) {
+	c.Header("ETag", fmt.Sprintf("%q", strconv.FormatInt(version, 10)))
+}
+
+// expectedVersion returns the version an update must match. If-Match wins over
+// the version in the body, "*" matches any version. An update must name the
+// version it replaces, so without either it responds 428. It responds 400 when
+// If-Match cannot be parsed and 412 for a weak ETag, which never matches. The
+// second result is false once a response has been written.
+func expectedVersion(c *gin.Context, bodyVersion int64) (int64, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+
+	if header == "" {
+		if bodyVersion <= 0 {
+			c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match or a version is required"})
+
+			return 0, false
+		}
+
+		return bodyVersion, true
+	}
+
+	if header == "*" {
+		return 0, true
+	}
+
+	if strings.HasPrefix(header, "W/") {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "If-Match does not match the current version"})
+
+		return 0, false
+	}
+
+	unquoted, err := strconv.Unquote(header)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "If-Match must be a quoted ETag"})
+
+		return 0, false
+	}
+
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+
+	if err != nil || version <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "If-Match must be an ETag issued by this API"})
+
+		return 0, false
+	}
+
+	return version, true
+}
@@ -0,0 +1,117 @@
+package webserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// createTestRequest stores a request through the API and returns its id.
+func createTestRequest(t *testing.T, s *testServer) string {
+	t.Helper()
+
+	recorder := s.send(http.MethodPost, "/api/requests", `{"from": "USD", "to": "EUR", "amount": "10"}`)
+	requireStatus(t, recorder, http.StatusCreated)
+
+	if etag := recorder.Header().Get("ETag"); etag != `"1"` {
+		t.Fatalf("ETag of a created request = %s, want \"1\"", etag)
+	}
+
+	var created struct {
+		Id string `json:"id"`
+	}
+
+	if err := json.Unmarshal(recorder.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+
+	return created.Id
+}
+
+func TestServer_UpdateIfMatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		ifMatch     string
+		bodyVersion int64
+		wantStatus  int
+		wantETag    string
+	}{
+		{name: "missing", wantStatus: http.StatusPreconditionRequired},
+		{name: "body version", bodyVersion: 1, wantStatus: http.StatusOK, wantETag: `"2"`},
+		{name: "stale body version", bodyVersion: 3, wantStatus: http.StatusPreconditionFailed},
+		{name: "current", ifMatch: `"1"`, wantStatus: http.StatusOK, wantETag: `"2"`},
+		{name: "header wins over body", ifMatch: `"1"`, bodyVersion: 3, wantStatus: http.StatusOK, wantETag: `"2"`},
+		{name: "any", ifMatch: "*", wantStatus: http.StatusOK, wantETag: `"2"`},
+		{name: "stale", ifMatch: `"7"`, wantStatus: http.StatusPreconditionFailed},
+		{name: "weak", ifMatch: `W/"1"`, wantStatus: http.StatusPreconditionFailed},
+		{name: "unquoted", ifMatch: "1", wantStatus: http.StatusBadRequest},
+		{name: "not a version", ifMatch: `"abc"`, wantStatus: http.StatusBadRequest},
+		{name: "zero", ifMatch: `"0"`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			id := createTestRequest(t, s)
+			body := fmt.Sprintf(`{"id": %q, "from": "USD", "to": "GBP", "amount": "20", "version": %d}`, id, tt.bodyVersion)
+
+			var headers []string
+
+			if tt.ifMatch != "" {
+				headers = []string{"If-Match", tt.ifMatch}
+			}
+
+			recorder := s.send(http.MethodPut, "/api/requests/"+id, body, headers...)
+			requireStatus(t, recorder, tt.wantStatus)
+
+			if etag := recorder.Header().Get("ETag"); etag != tt.wantETag {
+				t.Errorf("ETag = %q, want %q", etag, tt.wantETag)
+			}
+
+			wantVersion := int64(1)
+
+			if tt.wantStatus == http.StatusOK {
+				wantVersion = 2
+			}
+
+			if stored := s.store.GetRequestByID(id); stored.Version != wantVersion {
+				t.Errorf("stored version = %d, want %d", stored.Version, wantVersion)
+			}
+		})
+	}
+}
+
+func TestServer_PatchIfMatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		ifMatch    string
+		wantStatus int
+	}{
+		{name: "missing", wantStatus: http.StatusPreconditionRequired},
+		{name: "current", ifMatch: `"1"`, wantStatus: http.StatusOK},
+		{name: "any", ifMatch: "*", wantStatus: http.StatusOK},
+		{name: "stale", ifMatch: `"2"`, wantStatus: http.StatusPreconditionFailed},
+		{name: "weak", ifMatch: `W/"1"`, wantStatus: http.StatusPreconditionFailed},
+		{name: "malformed", ifMatch: `"1`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			id := createTestRequest(t, s)
+			headers := []string{"Content-Type", "application/merge-patch+json"}
+
+			if tt.ifMatch != "" {
+				headers = append(headers, "If-Match", tt.ifMatch)
+			}
+
+			recorder := s.send(http.MethodPatch, "/api/requests/"+id, `{"amount": "30"}`, headers...)
+			requireStatus(t, recorder, tt.wantStatus)
+
+			if tt.wantStatus == http.StatusOK && recorder.Header().Get("ETag") != `"2"` {
+				t.Errorf("ETag = %q, want \"2\"", recorder.Header().Get("ETag"))
+			}
+		})
+	}
+}
@@ -1,23 +1,105 @@
 package webserver
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
-	"reflect"
+	"strconv"
 
+	"github.com/M2rk13/Otus-327619/internal/lifecycle"
 	"github.com/M2rk13/Otus-327619/internal/model/api"
 	"github.com/M2rk13/Otus-327619/internal/model/log"
+	"github.com/M2rk13/Otus-327619/internal/repository"
 	"github.com/M2rk13/Otus-327619/internal/service"
 
 	"github.com/gin-gonic/gin"
 )
 
 type APIHandler struct {
-	storageSvc *service.StorageService
+	storageSvc    *service.StorageService
+	auditSvc      *service.AuditService
+	conversionSvc *service.ConversionService
+	rateSvc       *service.RateService
+	currencies    *service.CurrencyRegistry
+	deadLetterSvc *service.DeadLetterService
 }
 
-func NewAPIHandler(storageSvc *service.StorageService) *APIHandler {
-	return &APIHandler{storageSvc: storageSvc}
+func NewAPIHandler(
+	storageSvc *service.StorageService,
+	auditSvc *service.AuditService,
+	conversionSvc *service.ConversionService,
+	rateSvc *service.RateService,
+	currencies *service.CurrencyRegistry,
+	deadLetterSvc *service.DeadLetterService,
+) *APIHandler {
+	return &APIHandler{
+		storageSvc:    storageSvc,
+		auditSvc:      auditSvc,
+		conversionSvc: conversionSvc,
+		rateSvc:       rateSvc,
+		currencies:    currencies,
+		deadLetterSvc: deadLetterSvc,
+	}
+}
+
+// includeDeleted reads the include_deleted query flag of list endpoints.
+func includeDeleted(c *gin.Context) bool {
+	value, err := strconv.ParseBool(c.DefaultQuery("include_deleted", "false"))
+
+	return err == nil && value
+}
+
+// readPatch extracts the patch document and the version it was based on.
+func readPatch(c *gin.Context) (service.Patch, int64, bool) {
+	body, err := c.GetRawData()
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request body: %v", err)})
+
+		return service.Patch{}, 0, false
+	}
+
+	version, ok := expectedVersion(c, 0)
+
+	if !ok {
+		return service.Patch{}, 0, false
+	}
+
+	return service.Patch{Type: c.ContentType(), Document: body}, version, true
+}
+
+func respondWithError(c *gin.Context, err error) {
+	var validationErr *service.ValidationError
+
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+	case errors.Is(err, repository.ErrAlreadyExists):
+		c.JSON(http.StatusConflict, gin.H{"error": "Item with this id already exists"})
+	case errors.Is(err, repository.ErrReferenced):
+		c.JSON(http.StatusConflict, gin.H{"error": "Item is referenced by other records"})
+	case errors.Is(err, repository.ErrMissingReference):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Referenced item does not exist"})
+	case errors.Is(err, repository.ErrVersionConflict):
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Item has been modified, version does not match"})
+	case errors.Is(err, repository.ErrQuoteUsed):
+		c.JSON(http.StatusConflict, gin.H{"error": "Quote has already been used"})
+	case errors.Is(err, repository.ErrQuoteExpired):
+		c.JSON(http.StatusGone, gin.H{"error": "Quote has expired"})
+	case errors.Is(err, service.ErrUnsupportedPatch):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": fmt.Sprintf(
+			"Content-Type must be %s or %s", service.MergePatchType, service.JSONPatchType)})
+	case errors.Is(err, service.ErrInvalidPatch):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.As(err, &validationErr):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": validationErr.Error()})
+	case errors.Is(err, service.ErrRateUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Exchange rate is not available"})
+	case errors.Is(err, lifecycle.ErrQueueFull), errors.Is(err, lifecycle.ErrClosed):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Ingest queue is not accepting items, retry later"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Storage error: %v", err)})
+	}
 }
 
 // @Summary      Create request
@@ -26,9 +108,12 @@ func NewAPIHandler(storageSvc *service.StorageService) *APIHandler {
 // @Accept       json
 // @Produce      json
 // @Security     ApiKeyAuth
-// @Param        request  body      api.Request  true  "Request to create"
+// @Param        request          body    api.Request  true   "Request to create"
+// @Param        Idempotency-Key  header  string       false  "Key to replay the first response of a repeated request"
 // @Success      201      {object}  api.Request
 // @Failure      400      {object}  object{error=string}
+// @Failure      422      {object}  object{error=string}
+// @Failure      409      {object}  object{error=string}
 // @Router       /requests [post]
 func (h *APIHandler) createRequest(c *gin.Context) {
 	var req api.Request
@@ -39,7 +124,19 @@ func (h *APIHandler) createRequest(c *gin.Context) {
 		return
 	}
 
-	h.storageSvc.CreateRequest(&req)
+	if !h.checkCurrencies(c, "", &req) {
+		return
+	}
+
+	req.Id = ""
+
+	if err := h.storageSvc.CreateRequest(auditContext(c), &req); err != nil {
+		respondWithError(c, err)
+
+		return
+	}
+
+	setETag(c, req.Version)
 	c.JSON(http.StatusCreated, req)
 }
 
@@ -61,31 +158,37 @@ func (h *APIHandler) getRequestByID(c *gin.Context) {
 		return
 	}
 
+	setETag(c, item.Version)
 	c.JSON(http.StatusOK, item)
 }
 
 // @Summary      Get all requests
-// @Description  Retrieves all requests
+// @Description  Retrieves all requests. Deleted requests are hidden unless include_deleted is set
 // @Tags         requests
 // @Produce      json
+// @Param        include_deleted  query  bool  false  "Include soft-deleted items"
 // @Success      200 {array}  api.Request
 // @Router       /requests [get]
 func (h *APIHandler) getAllRequests(c *gin.Context) {
-	items := h.storageSvc.GetAllRequests()
+	items := h.storageSvc.GetAllRequests(includeDeleted(c))
 	c.JSON(http.StatusOK, items)
 }
 
 // @Summary      Update request
-// @Description  Updates a request by ID
+// @Description  Updates a request by ID. If-Match (or the version in the body) is required and must equal the stored version
 // @Tags         requests
 // @Accept       json
 // @Produce      json
 // @Security     ApiKeyAuth
 // @Param        id       path      string       true  "Request ID"
+// @Param        If-Match header    string       false "ETag of the version being replaced"
 // @Param        request  body      api.Request  true  "Updated request"
 // @Success      200      {object}  api.Request
 // @Failure      400      {object}  object{error=string}
 // @Failure      404      {object}  object{error=string}
+// @Failure      422      {object}  object{error=string}
+// @Failure      412      {object}  object{error=string}
+// @Failure      428      {object}  object{error=string}
 // @Router       /requests/{id} [put]
 func (h *APIHandler) updateRequest(c *gin.Context) {
 	id := c.Param("id")
@@ -97,38 +200,113 @@ func (h *APIHandler) updateRequest(c *gin.Context) {
 		return
 	}
 
-	v := reflect.ValueOf(updatedItem).Elem().FieldByName("Id")
+	if !h.checkCurrencies(c, "", &updatedItem) {
+		return
+	}
 
-	if v.IsValid() && v.String() != id {
+	if updatedItem.Id != id {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Id in body must match Id in path"})
 
 		return
 	}
 
-	if h.storageSvc.UpdateRequest(&updatedItem) {
-		c.JSON(http.StatusOK, updatedItem)
-	} else {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+	version, ok := expectedVersion(c, updatedItem.Version)
+
+	if !ok {
+		return
+	}
+
+	updatedItem.Version = version
+
+	if err := h.storageSvc.UpdateRequest(auditContext(c), &updatedItem); err != nil {
+		respondWithError(c, err)
+
+		return
+	}
+
+	setETag(c, updatedItem.Version)
+	c.JSON(http.StatusOK, updatedItem)
+}
+
+// @Summary      Patch request
+// @Description  Partially updates a request with a JSON merge patch (RFC 7396) or a JSON patch (RFC 6902). id, version and timestamps are immutable
+// @Tags         requests
+// @Accept       application/merge-patch+json,application/json-patch+json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id        path      string  true   "Request ID"
+// @Param        If-Match  header    string  true   "ETag of the version being patched"
+// @Param        patch     body      object  true   "Merge patch or JSON patch document"
+// @Success      200       {object}  api.Request
+// @Failure      400       {object}  object{error=string}
+// @Failure      404       {object}  object{error=string}
+// @Failure      412       {object}  object{error=string}
+// @Failure      428       {object}  object{error=string}
+// @Failure      415       {object}  object{error=string}
+// @Failure      422       {object}  object{error=string}
+// @Router       /requests/{id} [patch]
+func (h *APIHandler) patchRequest(c *gin.Context) {
+	patch, version, ok := readPatch(c)
+
+	if !ok {
+		return
+	}
+
+	item, err := h.storageSvc.PatchRequest(auditContext(c), c.Param("id"), patch, version, h.currencies)
+
+	if err != nil {
+		respondWithError(c, err)
+
+		return
 	}
+
+	setETag(c, item.Version)
+	c.JSON(http.StatusOK, item)
 }
 
 // @Summary      Delete request
-// @Description  Deletes a request by ID
+// @Description  Moves a request to the trash. Linked responses and logs are either deleted with it (cascade) or block the deletion (restrict)
 // @Tags         requests
 // @Produce      json
 // @Security     ApiKeyAuth
 // @Param        id  path  string  true  "Request ID"
 // @Success      200 {object} object{message=string}
 // @Failure      404 {object} object{error=string}
+// @Failure      409 {object} object{error=string}
 // @Router       /requests/{id} [delete]
 func (h *APIHandler) deleteRequest(c *gin.Context) {
 	id := c.Param("id")
 
-	if h.storageSvc.DeleteRequest(id) {
-		c.JSON(http.StatusOK, gin.H{"message": "Item deleted successfully"})
-	} else {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+	if err := h.storageSvc.DeleteRequest(auditContext(c), id); err != nil {
+		respondWithError(c, err)
+
+		return
 	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Item deleted successfully"})
+}
+
+// @Summary      Restore request
+// @Description  Restores a deleted request from the trash. Fails while a linked record is still deleted
+// @Tags         requests
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id               path    string  true   "Request ID"
+// @Param        Idempotency-Key  header  string  false  "Key to replay the first response of a repeated request"
+// @Success      200 {object} object{message=string}
+// @Failure      404 {object} object{error=string}
+// @Failure      422 {object} object{error=string}
+// @Router       /requests/{id}/restore [post]
+func (h *APIHandler) restoreRequest(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.storageSvc.RestoreRequest(auditContext(c), id); err != nil {
+		respondWithError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Item restored successfully"})
 }
 
 // @Summary      Create response
@@ -137,9 +315,12 @@ func (h *APIHandler) deleteRequest(c *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Security     ApiKeyAuth
-// @Param        response  body      api.Response  true  "Response to create"
+// @Param        response         body    api.Response  true   "Response to create"
+// @Param        Idempotency-Key  header  string        false  "Key to replay the first response of a repeated request"
 // @Success      201       {object}  api.Response
 // @Failure      400       {object}  object{error=string}
+// @Failure      422       {object}  object{error=string}
+// @Failure      409       {object}  object{error=string}
 // @Router       /responses [post]
 func (h *APIHandler) createResponse(c *gin.Context) {
 	var resp api.Response
@@ -150,7 +331,19 @@ func (h *APIHandler) createResponse(c *gin.Context) {
 		return
 	}
 
-	h.storageSvc.CreateResponse(&resp)
+	if !h.checkEmbeddedCurrencies(c, "query.", &resp.Query) {
+		return
+	}
+
+	resp.Id = ""
+
+	if err := h.storageSvc.CreateResponse(auditContext(c), &resp); err != nil {
+		respondWithError(c, err)
+
+		return
+	}
+
+	setETag(c, resp.Version)
 	c.JSON(http.StatusCreated, resp)
 }
 
@@ -172,31 +365,37 @@ func (h *APIHandler) getResponseByID(c *gin.Context) {
 		return
 	}
 
+	setETag(c, item.Version)
 	c.JSON(http.StatusOK, item)
 }
 
 // @Summary      Get all responses
-// @Description  Retrieves all responses
+// @Description  Retrieves all responses. Deleted responses are hidden unless include_deleted is set
 // @Tags         responses
 // @Produce      json
+// @Param        include_deleted  query  bool  false  "Include soft-deleted items"
 // @Success      200 {array}  api.Response
 // @Router       /responses [get]
 func (h *APIHandler) getAllResponses(c *gin.Context) {
-	items := h.storageSvc.GetAllResponses()
+	items := h.storageSvc.GetAllResponses(includeDeleted(c))
 	c.JSON(http.StatusOK, items)
 }
 
 // @Summary      Update response
-// @Description  Updates a response by ID
+// @Description  Updates a response by ID. If-Match (or the version in the body) is required and must equal the stored version
 // @Tags         responses
 // @Accept       json
 // @Produce      json
 // @Security     ApiKeyAuth
 // @Param        id        path      string       true  "Response ID"
+// @Param        If-Match  header    string       false "ETag of the version being replaced"
 // @Param        response  body      api.Response true  "Updated response"
 // @Success      200       {object}  api.Response
 // @Failure      400       {object}  object{error=string}
 // @Failure      404       {object}  object{error=string}
+// @Failure      422       {object}  object{error=string}
+// @Failure      412       {object}  object{error=string}
+// @Failure      428       {object}  object{error=string}
 // @Router       /responses/{id} [put]
 func (h *APIHandler) updateResponse(c *gin.Context) {
 	id := c.Param("id")
@@ -208,36 +407,113 @@ func (h *APIHandler) updateResponse(c *gin.Context) {
 		return
 	}
 
+	if !h.checkEmbeddedCurrencies(c, "query.", &updatedItem.Query) {
+		return
+	}
+
 	if updatedItem.Id != id {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Id in body must match Id in path"})
 
 		return
 	}
 
-	if h.storageSvc.UpdateResponse(&updatedItem) {
-		c.JSON(http.StatusOK, updatedItem)
-	} else {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+	version, ok := expectedVersion(c, updatedItem.Version)
+
+	if !ok {
+		return
 	}
+
+	updatedItem.Version = version
+
+	if err := h.storageSvc.UpdateResponse(auditContext(c), &updatedItem); err != nil {
+		respondWithError(c, err)
+
+		return
+	}
+
+	setETag(c, updatedItem.Version)
+	c.JSON(http.StatusOK, updatedItem)
+}
+
+// @Summary      Patch response
+// @Description  Partially updates a response with a JSON merge patch (RFC 7396) or a JSON patch (RFC 6902). id, version and timestamps are immutable
+// @Tags         responses
+// @Accept       application/merge-patch+json,application/json-patch+json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id        path      string  true   "Response ID"
+// @Param        If-Match  header    string  true   "ETag of the version being patched"
+// @Param        patch     body      object  true   "Merge patch or JSON patch document"
+// @Success      200       {object}  api.Response
+// @Failure      400       {object}  object{error=string}
+// @Failure      404       {object}  object{error=string}
+// @Failure      412       {object}  object{error=string}
+// @Failure      428       {object}  object{error=string}
+// @Failure      415       {object}  object{error=string}
+// @Failure      422       {object}  object{error=string}
+// @Router       /responses/{id} [patch]
+func (h *APIHandler) patchResponse(c *gin.Context) {
+	patch, version, ok := readPatch(c)
+
+	if !ok {
+		return
+	}
+
+	item, err := h.storageSvc.PatchResponse(auditContext(c), c.Param("id"), patch, version, h.currencies)
+
+	if err != nil {
+		respondWithError(c, err)
+
+		return
+	}
+
+	setETag(c, item.Version)
+	c.JSON(http.StatusOK, item)
 }
 
 // @Summary      Delete response
-// @Description  Deletes a response by ID
+// @Description  Moves a response to the trash. Linked logs are either deleted with it (cascade) or block the deletion (restrict)
 // @Tags         responses
 // @Produce      json
 // @Security     ApiKeyAuth
 // @Param        id  path  string  true  "Response ID"
 // @Success      200 {object} object{message=string}
 // @Failure      404 {object} object{error=string}
+// @Failure      409 {object} object{error=string}
 // @Router       /responses/{id} [delete]
 func (h *APIHandler) deleteResponse(c *gin.Context) {
 	id := c.Param("id")
 
-	if h.storageSvc.DeleteResponse(id) {
-		c.JSON(http.StatusOK, gin.H{"message": "Item deleted successfully"})
-	} else {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+	if err := h.storageSvc.DeleteResponse(auditContext(c), id); err != nil {
+		respondWithError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Item deleted successfully"})
+}
+
+// @Summary      Restore response
+// @Description  Restores a deleted response from the trash. Fails while a linked record is still deleted
+// @Tags         responses
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id               path    string  true   "Response ID"
+// @Param        Idempotency-Key  header  string  false  "Key to replay the first response of a repeated request"
+// @Success      200 {object} object{message=string}
+// @Failure      404 {object} object{error=string}
+// @Failure      422 {object} object{error=string}
+// @Router       /responses/{id}/restore [post]
+func (h *APIHandler) restoreResponse(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.storageSvc.RestoreResponse(auditContext(c), id); err != nil {
+		respondWithError(c, err)
+
+		return
 	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Item restored successfully"})
 }
 
 // @Summary      Create log record
@@ -246,9 +522,12 @@ func (h *APIHandler) deleteResponse(c *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Security     ApiKeyAuth
-// @Param        log  body      log.ConversionLog  true  "Log to create"
+// @Param        log              body    log.ConversionLog  true   "Log to create"
+// @Param        Idempotency-Key  header  string             false  "Key to replay the first response of a repeated request"
 // @Success      201  {object}  log.ConversionLog
 // @Failure      400  {object}  object{error=string}
+// @Failure      422  {object}  object{error=string}
+// @Failure      409  {object}  object{error=string}
 // @Router       /logs [post]
 func (h *APIHandler) createLog(c *gin.Context) {
 	var logItem log.ConversionLog
@@ -259,7 +538,19 @@ func (h *APIHandler) createLog(c *gin.Context) {
 		return
 	}
 
-	h.storageSvc.CreateConversionLog(&logItem)
+	if !h.checkEmbeddedCurrencies(c, "request.", &logItem.Request) {
+		return
+	}
+
+	logItem.Id = ""
+
+	if err := h.storageSvc.CreateConversionLog(auditContext(c), &logItem); err != nil {
+		respondWithError(c, err)
+
+		return
+	}
+
+	setETag(c, logItem.Version)
 	c.JSON(http.StatusCreated, logItem)
 }
 
@@ -281,31 +572,58 @@ func (h *APIHandler) getLogByID(c *gin.Context) {
 		return
 	}
 
+	setETag(c, item.Version)
+	c.JSON(http.StatusOK, item)
+}
+
+// @Summary      Get expanded log by ID
+// @Description  Retrieves a conversion log together with the stored request and response it links to
+// @Tags         logs
+// @Produce      json
+// @Param        id  path  string  true  "Log ID"
+// @Success      200 {object} log.ExpandedConversionLog
+// @Failure      404 {object} object{error=string}
+// @Router       /logs/{id}/expanded [get]
+func (h *APIHandler) getExpandedLogByID(c *gin.Context) {
+	id := c.Param("id")
+	item := h.storageSvc.GetExpandedConversionLog(id)
+
+	if item == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+
+		return
+	}
+
 	c.JSON(http.StatusOK, item)
 }
 
 // @Summary      Get all logs
-// @Description  Retrieves all conversion logs
+// @Description  Retrieves all conversion logs. Deleted logs are hidden unless include_deleted is set
 // @Tags         logs
 // @Produce      json
+// @Param        include_deleted  query  bool  false  "Include soft-deleted items"
 // @Success      200 {array}  log.ConversionLog
 // @Router       /logs [get]
 func (h *APIHandler) getAllLogs(c *gin.Context) {
-	items := h.storageSvc.GetAllConversionLogs()
+	items := h.storageSvc.GetAllConversionLogs(includeDeleted(c))
 	c.JSON(http.StatusOK, items)
 }
 
 // @Summary      Update log
-// @Description  Updates a conversion log by ID
+// @Description  Updates a conversion log by ID. If-Match (or the version in the body) is required and must equal the stored version
 // @Tags         logs
 // @Accept       json
 // @Produce      json
 // @Security     ApiKeyAuth
 // @Param        id   path      string            true  "Log ID"
+// @Param        If-Match  header  string            false "ETag of the version being replaced"
 // @Param        log  body      log.ConversionLog true  "Updated log"
 // @Success      200  {object}  log.ConversionLog
 // @Failure      400  {object}  object{error=string}
 // @Failure      404  {object}  object{error=string}
+// @Failure      422  {object}  object{error=string}
+// @Failure      412  {object}  object{error=string}
+// @Failure      428  {object}  object{error=string}
 // @Router       /logs/{id} [put]
 func (h *APIHandler) updateLog(c *gin.Context) {
 	id := c.Param("id")
@@ -317,21 +635,72 @@ func (h *APIHandler) updateLog(c *gin.Context) {
 		return
 	}
 
+	if !h.checkEmbeddedCurrencies(c, "request.", &updatedItem.Request) {
+		return
+	}
+
 	if updatedItem.Id != id {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Id in body must match Id in path"})
 
 		return
 	}
 
-	if h.storageSvc.UpdateConversionLog(&updatedItem) {
-		c.JSON(http.StatusOK, updatedItem)
-	} else {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+	version, ok := expectedVersion(c, updatedItem.Version)
+
+	if !ok {
+		return
 	}
+
+	updatedItem.Version = version
+
+	if err := h.storageSvc.UpdateConversionLog(auditContext(c), &updatedItem); err != nil {
+		respondWithError(c, err)
+
+		return
+	}
+
+	setETag(c, updatedItem.Version)
+	c.JSON(http.StatusOK, updatedItem)
+}
+
+// @Summary      Patch conversion log
+// @Description  Partially updates a conversion log with a JSON merge patch (RFC 7396) or a JSON patch (RFC 6902). id, version and timestamps are immutable
+// @Tags         logs
+// @Accept       application/merge-patch+json,application/json-patch+json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id        path      string  true   "Log ID"
+// @Param        If-Match  header    string  true   "ETag of the version being patched"
+// @Param        patch     body      object  true   "Merge patch or JSON patch document"
+// @Success      200       {object}  log.ConversionLog
+// @Failure      400       {object}  object{error=string}
+// @Failure      404       {object}  object{error=string}
+// @Failure      412       {object}  object{error=string}
+// @Failure      428       {object}  object{error=string}
+// @Failure      415       {object}  object{error=string}
+// @Failure      422       {object}  object{error=string}
+// @Router       /logs/{id} [patch]
+func (h *APIHandler) patchLog(c *gin.Context) {
+	patch, version, ok := readPatch(c)
+
+	if !ok {
+		return
+	}
+
+	item, err := h.storageSvc.PatchConversionLog(auditContext(c), c.Param("id"), patch, version, h.currencies)
+
+	if err != nil {
+		respondWithError(c, err)
+
+		return
+	}
+
+	setETag(c, item.Version)
+	c.JSON(http.StatusOK, item)
 }
 
 // @Summary      Delete log
-// @Description  Deletes a conversion log by ID
+// @Description  Moves a conversion log to the trash
 // @Tags         logs
 // @Produce      json
 // @Security     ApiKeyAuth
@@ -342,9 +711,47 @@ func (h *APIHandler) updateLog(c *gin.Context) {
 func (h *APIHandler) deleteLog(c *gin.Context) {
 	id := c.Param("id")
 
-	if h.storageSvc.DeleteConversionLog(id) {
-		c.JSON(http.StatusOK, gin.H{"message": "Item deleted successfully"})
-	} else {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+	if err := h.storageSvc.DeleteConversionLog(auditContext(c), id); err != nil {
+		respondWithError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Item deleted successfully"})
+}
+
+// @Summary      Restore conversion log
+// @Description  Restores a deleted conversion log from the trash. Fails while a linked record is still deleted
+// @Tags         logs
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id               path    string  true   "Log ID"
+// @Param        Idempotency-Key  header  string  false  "Key to replay the first response of a repeated request"
+// @Success      200 {object} object{message=string}
+// @Failure      404 {object} object{error=string}
+// @Failure      422 {object} object{error=string}
+// @Router       /logs/{id}/restore [post]
+func (h *APIHandler) restoreLog(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.storageSvc.RestoreConversionLog(auditContext(c), id); err != nil {
+		respondWithError(c, err)
+
+		return
 	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Item restored successfully"})
+}
+
+// @Summary      Get cache statistics
+// @Description  Returns hit and miss counters of the repository cache
+// @Tags         cache
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200 {object} object{enabled=bool,hits=int,misses=int}
+// @Router       /cache/stats [get]
+func (h *APIHandler) getCacheStats(c *gin.Context) {
+	stats, enabled := h.storageSvc.CacheStats()
+
+	c.JSON(http.StatusOK, gin.H{"enabled": enabled, "hits": stats.Hits, "misses": stats.Misses})
 }
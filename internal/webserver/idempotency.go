@@ -0,0 +1,123 @@
+package webserver
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/M2rk13/Otus-327619/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	idempotencyKeyHeader    = "Idempotency-Key"
+	idempotentReplayHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength = 255
+)
+
+// replayedHeaders are the response headers stored with an idempotency record
+// and sent again on replay.
+var replayedHeaders = []string{"Content-Type", "ETag"}
+
+// capturingWriter keeps a copy of the response body while writing it.
+type capturingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *capturingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *capturingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+
+	return w.ResponseWriter.WriteString(s)
+}
+
+// idempotencyMiddleware honours the Idempotency-Key header on POST requests.
+// The response to the first request with a key is stored, and later requests
+// with the same key and body get it replayed without running the handler.
+// Server errors are not stored, so such requests can be retried with the key.
+func idempotencyMiddleware(idempotencySvc *service.IdempotencyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyKeyHeader)
+
+		if c.Request.Method != http.MethodPost || key == "" {
+			c.Next()
+
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf(
+				"%s must not be longer than %d characters", idempotencyKeyHeader, maxIdempotencyKeyLength)})
+
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request body: %v", err)})
+
+			return
+		}
+
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		account := c.GetString(actorKey)
+		replay, err := idempotencySvc.Begin(account, key, c.Request.Method, c.Request.URL.Path, body)
+
+		switch {
+		case errors.Is(err, service.ErrIdempotencyKeyReused):
+			c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+
+			return
+		case errors.Is(err, service.ErrIdempotencyKeyInProcess):
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+
+			return
+		case err != nil:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Storage error: %v", err)})
+
+			return
+		case replay != nil:
+			for name, value := range replay.Headers {
+				c.Header(name, value)
+			}
+
+			c.Header(idempotentReplayHeader, "true")
+			c.Data(replay.Status, replay.Headers["Content-Type"], replay.Body)
+			c.Abort()
+
+			return
+		}
+
+		writer := &capturingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			err = idempotencySvc.Release(account, key)
+		} else {
+			headers := make(map[string]string)
+
+			for _, name := range replayedHeaders {
+				if value := c.Writer.Header().Get(name); value != "" {
+					headers[name] = value
+				}
+			}
+
+			err = idempotencySvc.Complete(account, key, c.Writer.Status(), headers, writer.body.Bytes())
+		}
+
+		if err != nil {
+			fmt.Printf("Failed to store the response for idempotency key %s: %v\n", key, err)
+		}
+	}
+}
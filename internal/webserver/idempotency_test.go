@@ -0,0 +1,110 @@
+package webserver
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testRequestBody = `{"from": "USD", "to": "EUR", "amount": "10"}`
+
+func TestIdempotency_ReplaysResponse(t *testing.T) {
+	s := newTestServer(t)
+
+	first := s.send(http.MethodPost, "/api/requests", testRequestBody, idempotencyKeyHeader, "key-1")
+	requireStatus(t, first, http.StatusCreated)
+
+	second := s.send(http.MethodPost, "/api/requests", testRequestBody, idempotencyKeyHeader, "key-1")
+	requireStatus(t, second, http.StatusCreated)
+
+	if second.Header().Get(idempotentReplayHeader) != "true" || first.Header().Get(idempotentReplayHeader) != "" {
+		t.Fatalf("only the second response should be a replay")
+	}
+
+	if second.Body.String() != first.Body.String() || second.Header().Get("ETag") != first.Header().Get("ETag") {
+		t.Fatalf("replay = %s %s, want %s %s",
+			second.Header().Get("ETag"), second.Body, first.Header().Get("ETag"), first.Body)
+	}
+
+	if other := s.send(http.MethodPost, "/api/requests", testRequestBody, idempotencyKeyHeader, "key-2"); other.Body.String() == first.Body.String() {
+		t.Fatal("another key should create another request")
+	}
+
+	if stored := len(s.store.GetAllRequests(true)); stored != 2 {
+		t.Fatalf("stored %d requests, want 2", stored)
+	}
+}
+
+func TestIdempotency_Rejections(t *testing.T) {
+	tests := []struct {
+		name       string
+		key        string
+		body       string
+		wantStatus int
+	}{
+		{name: "other body under the key", key: "key-1", body: `{"from": "USD", "to": "GBP", "amount": "10"}`, wantStatus: http.StatusUnprocessableEntity},
+		{name: "key in process", key: "busy", body: testRequestBody, wantStatus: http.StatusConflict},
+		{name: "key too long", key: strings.Repeat("k", maxIdempotencyKeyLength+1), body: testRequestBody, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			requireStatus(t, s.send(http.MethodPost, "/api/requests", testRequestBody, idempotencyKeyHeader, "key-1"), http.StatusCreated)
+
+			// A request with this key is still being handled.
+			if _, err := s.idempotency.Begin("admin", "busy", http.MethodPost, "/api/requests", []byte(testRequestBody)); err != nil {
+				t.Fatal(err)
+			}
+
+			requireStatus(t, s.send(http.MethodPost, "/api/requests", tt.body, idempotencyKeyHeader, tt.key), tt.wantStatus)
+
+			if stored := len(s.store.GetAllRequests(true)); stored != 1 {
+				t.Fatalf("stored %d requests, want 1", stored)
+			}
+		})
+	}
+}
+
+func TestIdempotency_ConcurrentRequests(t *testing.T) {
+	s := newTestServer(t)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	statuses := make(map[int]int)
+	bodies := make(map[string]bool)
+
+	for range 10 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			recorder := s.send(http.MethodPost, "/api/requests", testRequestBody, idempotencyKeyHeader, "key-1")
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			statuses[recorder.Code]++
+
+			if recorder.Code == http.StatusCreated {
+				bodies[recorder.Body.String()] = true
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if statuses[http.StatusCreated] == 0 || statuses[http.StatusCreated]+statuses[http.StatusConflict] != 10 {
+		t.Fatalf("statuses = %v, want only 201 and 409", statuses)
+	}
+
+	if len(bodies) != 1 {
+		t.Fatalf("the created responses differ: %v", bodies)
+	}
+
+	if stored := len(s.store.GetAllRequests(true)); stored != 1 {
+		t.Fatalf("stored %d requests, want 1", stored)
+	}
+}
@@ -0,0 +1,138 @@
+package webserver
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/log"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ingestRetryAfter is the Retry-After hint, in seconds, sent when an ingest
+// queue rejects an item.
+const ingestRetryAfter = "1"
+
+// @Summary      Queue request
+// @Description  Queues a request for the storage workers. The id is assigned up front; the request is stored asynchronously
+// @Tags         ingest
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request          body    api.Request  true   "Request to store"
+// @Param        Idempotency-Key  header  string       false  "Key to replay the first response of a repeated request"
+// @Success      202  {object}  api.Request
+// @Failure      400  {object}  object{error=string}
+// @Failure      422  {object}  object{error=string}
+// @Failure      503  {object}  object{error=string}
+// @Router       /ingest/requests [post]
+func (h *APIHandler) enqueueRequest(c *gin.Context) {
+	var req api.Request
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request body: %v", err)})
+
+		return
+	}
+
+	if !h.checkCurrencies(c, "", &req) {
+		return
+	}
+
+	if err := h.storageSvc.EnqueueRequest(c.Request.Context(), &req); err != nil {
+		respondWithIngestError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusAccepted, req)
+}
+
+// @Summary      Queue response
+// @Description  Queues a response for the storage workers. The id is assigned up front; the response is stored asynchronously
+// @Tags         ingest
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        response         body    api.Response  true   "Response to store"
+// @Param        Idempotency-Key  header  string        false  "Key to replay the first response of a repeated request"
+// @Success      202  {object}  api.Response
+// @Failure      400  {object}  object{error=string}
+// @Failure      422  {object}  object{error=string}
+// @Failure      503  {object}  object{error=string}
+// @Router       /ingest/responses [post]
+func (h *APIHandler) enqueueResponse(c *gin.Context) {
+	var resp api.Response
+
+	if err := c.ShouldBindJSON(&resp); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request body: %v", err)})
+
+		return
+	}
+
+	if !h.checkEmbeddedCurrencies(c, "query.", &resp.Query) {
+		return
+	}
+
+	if err := h.storageSvc.EnqueueResponse(c.Request.Context(), &resp); err != nil {
+		respondWithIngestError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusAccepted, resp)
+}
+
+// @Summary      Queue log
+// @Description  Queues a conversion log for the storage workers. The id is assigned up front; the log is stored asynchronously
+// @Tags         ingest
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        log              body    log.ConversionLog  true   "Log to store"
+// @Param        Idempotency-Key  header  string             false  "Key to replay the first response of a repeated request"
+// @Success      202  {object}  log.ConversionLog
+// @Failure      400  {object}  object{error=string}
+// @Failure      422  {object}  object{error=string}
+// @Failure      503  {object}  object{error=string}
+// @Router       /ingest/logs [post]
+func (h *APIHandler) enqueueLog(c *gin.Context) {
+	var logItem log.ConversionLog
+
+	if err := c.ShouldBindJSON(&logItem); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request body: %v", err)})
+
+		return
+	}
+
+	if !h.checkEmbeddedCurrencies(c, "request.", &logItem.Request) {
+		return
+	}
+
+	if err := h.storageSvc.EnqueueConversionLog(c.Request.Context(), &logItem); err != nil {
+		respondWithIngestError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusAccepted, logItem)
+}
+
+// respondWithIngestError is respondWithError with a Retry-After hint for
+// queues that are full or not running.
+func respondWithIngestError(c *gin.Context, err error) {
+	c.Header("Retry-After", ingestRetryAfter)
+	respondWithError(c, err)
+}
+
+// @Summary      Get ingest statistics
+// @Description  Returns depth, counters and latencies of the queues and worker pools storing ingested items, followed by the queue of change events
+// @Tags         ingest
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200 {array} service.IngestStats
+// @Router       /ingest/stats [get]
+func (h *APIHandler) getIngestStats(c *gin.Context) {
+	c.JSON(http.StatusOK, h.storageSvc.IngestStats())
+}
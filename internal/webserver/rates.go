@@ -0,0 +1,77 @@
+package webserver
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/rate"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary      Get exchange rate
+// @Description  Returns the cached quote for a currency pair. A quote past its TTL is served marked stale while it is refreshed in the background. With date, the rate published on that day (or the last one before it) is returned by providers that keep history
+// @Tags         rates
+// @Produce      json
+// @Param        from     query  string  true   "Source currency"
+// @Param        to       query  string  true   "Target currency"
+// @Param        refresh  query  bool    false  "Bypass the cache and fetch a new quote"
+// @Param        date     query  string  false  "Historical date, YYYY-MM-DD"
+// @Success      200 {object} rate.Quote
+// @Failure      400 {object} object{error=string}
+// @Failure      422 {object} object{error=string}
+// @Failure      503 {object} object{error=string}
+// @Router       /rates [get]
+func (h *APIHandler) getRate(c *gin.Context) {
+	from := strings.ToUpper(c.Query("from"))
+	to := strings.ToUpper(c.Query("to"))
+
+	if from == "" || to == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameters from and to are required"})
+
+		return
+	}
+
+	if !h.checkCurrencies(c, "", &api.Request{From: from, To: to}) {
+		return
+	}
+
+	var quote *rate.Quote
+	var err error
+
+	if date := c.Query("date"); date != "" {
+		day, parseErr := time.Parse(time.DateOnly, date)
+
+		if parseErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date, expected YYYY-MM-DD"})
+
+			return
+		}
+
+		quote, err = h.rateSvc.RateAt(c.Request.Context(), from, to, day)
+	} else {
+		refresh, _ := strconv.ParseBool(c.Query("refresh"))
+		quote, err = h.rateSvc.Quote(c.Request.Context(), from, to, refresh)
+	}
+
+	if err != nil {
+		respondWithError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
+
+// @Summary      Get rate provider health
+// @Description  Lists the rate providers in fallback order with their circuit breaker state
+// @Tags         rates
+// @Produce      json
+// @Success      200 {array} rate.ProviderHealth
+// @Router       /rates/providers [get]
+func (h *APIHandler) getProviderHealth(c *gin.Context) {
+	c.JSON(http.StatusOK, h.rateSvc.Health())
+}
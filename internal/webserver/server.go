@@ -17,41 +17,24 @@ import (
 	"github.com/M2rk13/Otus-327619/internal/service"
 )
 
-func StartWebServer(ctx context.Context, wg *sync.WaitGroup, addr string, storageSvc *service.StorageService) {
+func StartWebServer(
+	ctx context.Context,
+	wg *sync.WaitGroup,
+	addr string,
+	storageSvc *service.StorageService,
+	auditSvc *service.AuditService,
+	conversionSvc *service.ConversionService,
+	rateSvc *service.RateService,
+	currencies *service.CurrencyRegistry,
+	idempotencySvc *service.IdempotencyService,
+	deadLetterSvc *service.DeadLetterService,
+) {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 
 		gin.SetMode(gin.ReleaseMode)
-		router := gin.Default()
-
-		// Роуты Swagger возвращены
-		router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-		router.POST("/api/auth/login", loginHandler)
-
-		protected := router.Group("/api")
-		protected.Use(authMiddleware())
-
-		// Роуты теперь используют созданный внутри APIHandler
-		apiHandler := NewAPIHandler(storageSvc)
-
-		protected.POST("/requests", apiHandler.createRequest)
-		protected.PUT("/requests/:id", apiHandler.updateRequest)
-		router.GET("/api/requests", apiHandler.getAllRequests)
-		router.GET("/api/requests/:id", apiHandler.getRequestByID)
-		protected.DELETE("/requests/:id", apiHandler.deleteRequest)
-
-		protected.POST("/responses", apiHandler.createResponse)
-		protected.PUT("/responses/:id", apiHandler.updateResponse)
-		router.GET("/api/responses", apiHandler.getAllResponses)
-		router.GET("/api/responses/:id", apiHandler.getResponseByID)
-		protected.DELETE("/responses/:id", apiHandler.deleteResponse)
-
-		protected.POST("/logs", apiHandler.createLog)
-		protected.PUT("/logs/:id", apiHandler.updateLog)
-		router.GET("/api/logs", apiHandler.getAllLogs)
-		router.GET("/api/logs/:id", apiHandler.getLogByID)
-		protected.DELETE("/logs/:id", apiHandler.deleteLog)
+		router := newRouter(storageSvc, auditSvc, conversionSvc, rateSvc, currencies, idempotencySvc, deadLetterSvc)
 
 		server := &http.Server{
 			Addr:    addr,
@@ -78,3 +61,74 @@ func StartWebServer(ctx context.Context, wg *sync.WaitGroup, addr string, storag
 		}
 	}()
 }
+
+// newRouter registers the API routes on a new router.
+func newRouter(
+	storageSvc *service.StorageService,
+	auditSvc *service.AuditService,
+	conversionSvc *service.ConversionService,
+	rateSvc *service.RateService,
+	currencies *service.CurrencyRegistry,
+	idempotencySvc *service.IdempotencyService,
+	deadLetterSvc *service.DeadLetterService,
+) *gin.Engine {
+	router := gin.Default()
+	router.Use(requestIDMiddleware())
+
+	// Роуты Swagger возвращены
+	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	router.POST("/api/auth/login", loginHandler)
+
+	protected := router.Group("/api")
+	protected.Use(authMiddleware(), idempotencyMiddleware(idempotencySvc))
+
+	// Роуты теперь используют созданный внутри APIHandler
+	apiHandler := NewAPIHandler(storageSvc, auditSvc, conversionSvc, rateSvc, currencies, deadLetterSvc)
+
+	protected.POST("/requests", apiHandler.createRequest)
+	protected.PUT("/requests/:id", apiHandler.updateRequest)
+	protected.PATCH("/requests/:id", apiHandler.patchRequest)
+	router.GET("/api/requests", apiHandler.getAllRequests)
+	router.GET("/api/requests/:id", apiHandler.getRequestByID)
+	protected.DELETE("/requests/:id", apiHandler.deleteRequest)
+	protected.POST("/requests/:id/restore", apiHandler.restoreRequest)
+
+	protected.POST("/responses", apiHandler.createResponse)
+	protected.PUT("/responses/:id", apiHandler.updateResponse)
+	protected.PATCH("/responses/:id", apiHandler.patchResponse)
+	router.GET("/api/responses", apiHandler.getAllResponses)
+	router.GET("/api/responses/:id", apiHandler.getResponseByID)
+	protected.DELETE("/responses/:id", apiHandler.deleteResponse)
+	protected.POST("/responses/:id/restore", apiHandler.restoreResponse)
+
+	protected.POST("/logs", apiHandler.createLog)
+	protected.PUT("/logs/:id", apiHandler.updateLog)
+	protected.PATCH("/logs/:id", apiHandler.patchLog)
+	router.GET("/api/logs", apiHandler.getAllLogs)
+	router.GET("/api/logs/:id", apiHandler.getLogByID)
+	router.GET("/api/logs/:id/expanded", apiHandler.getExpandedLogByID)
+	protected.DELETE("/logs/:id", apiHandler.deleteLog)
+	protected.POST("/logs/:id/restore", apiHandler.restoreLog)
+
+	router.GET("/api/rates", apiHandler.getRate)
+	router.GET("/api/rates/providers", apiHandler.getProviderHealth)
+	router.GET("/api/currencies", apiHandler.getCurrencies)
+	protected.POST("/quotes", apiHandler.createQuote)
+	protected.POST("/convert", apiHandler.convert)
+	protected.POST("/convert/batch", apiHandler.convertBatch)
+	protected.POST("/convert/multi", apiHandler.convertMulti)
+
+	protected.GET("/audit", apiHandler.getAuditRecords)
+	protected.GET("/cache/stats", apiHandler.getCacheStats)
+
+	protected.POST("/ingest/requests", apiHandler.enqueueRequest)
+	protected.POST("/ingest/responses", apiHandler.enqueueResponse)
+	protected.POST("/ingest/logs", apiHandler.enqueueLog)
+	protected.GET("/ingest/stats", apiHandler.getIngestStats)
+
+	protected.GET("/dead-letters", apiHandler.getDeadLetters)
+	protected.POST("/dead-letters/:id/replay", apiHandler.replayDeadLetter)
+	protected.DELETE("/dead-letters/:id", apiHandler.discardDeadLetter)
+
+	return router
+}
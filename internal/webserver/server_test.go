@@ -0,0 +1,85 @@
+package webserver
+
+import (
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/M2rk13/Otus-327619/internal/auth"
+	"github.com/M2rk13/Otus-327619/internal/config"
+	"github.com/M2rk13/Otus-327619/internal/enum"
+	"github.com/M2rk13/Otus-327619/internal/repository"
+	"github.com/M2rk13/Otus-327619/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testServer is the API backed by a file store in a temporary directory.
+type testServer struct {
+	router      *gin.Engine
+	store       *repository.FileStore
+	idempotency *service.IdempotencyService
+	token       string
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	dir := t.TempDir()
+	config.AdminCfg.JwtKey = "test-key"
+	config.FileCfg = config.FileConfig{
+		RequestsFilePath:    filepath.Join(dir, "requests.json"),
+		ResponsesFilePath:   filepath.Join(dir, "responses.json"),
+		LogsFilePath:        filepath.Join(dir, "logs.json"),
+		QuotesFilePath:      filepath.Join(dir, "quotes.json"),
+		IdempotencyFilePath: filepath.Join(dir, "idempotency.json"),
+	}
+
+	store := repository.NewFileStore(enum.Cascade)
+
+	if err := store.SetupPersistence(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(store.ClosePersistence)
+
+	token, err := auth.IssueToken("admin")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	storage := service.NewStorageService(store, service.NewAuditService(repository.NewMemoryAuditStore(time.Hour)), nil)
+	idempotency := service.NewIdempotencyService(store, time.Hour)
+	router := newRouter(storage, nil, nil, nil, service.NewCurrencyRegistry(service.ISO4217), idempotency, nil)
+
+	return &testServer{router: router, store: store, idempotency: idempotency, token: token}
+}
+
+// send serves an authorized request with the given headers, given as name
+// and value pairs.
+func (s *testServer) send(method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+s.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	recorder := httptest.NewRecorder()
+	s.router.ServeHTTP(recorder, req)
+
+	return recorder
+}
+
+func requireStatus(t *testing.T, recorder *httptest.ResponseRecorder, want int) {
+	t.Helper()
+
+	if recorder.Code != want {
+		t.Fatalf("status = %d, want %d: %s", recorder.Code, want, recorder.Body)
+	}
+}
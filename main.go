@@ -12,40 +12,21 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/M2rk13/Otus-327619/internal/broker"
 	"github.com/M2rk13/Otus-327619/internal/config"
 	"github.com/M2rk13/Otus-327619/internal/enum"
+	"github.com/M2rk13/Otus-327619/internal/grpcserver"
+	"github.com/M2rk13/Otus-327619/internal/lifecycle"
 	"github.com/M2rk13/Otus-327619/internal/model/api"
+	"github.com/M2rk13/Otus-327619/internal/model/fee"
 	logmodel "github.com/M2rk13/Otus-327619/internal/model/log"
 	"github.com/M2rk13/Otus-327619/internal/repository"
 	"github.com/M2rk13/Otus-327619/internal/service"
 	"github.com/M2rk13/Otus-327619/internal/webserver"
-)
-
-type chanItem[T any] struct {
-	ch    chan T
-	state int
-}
 
-var (
-	requestChan  *chanItem[*api.Request]
-	responseChan *chanItem[*api.Response]
-	logChan      *chanItem[*logmodel.ConversionLog]
+	"github.com/go-redis/redis"
 )
 
-func init() {
-	requestChan = &chanItem[*api.Request]{}
-	requestChan.ch = make(chan *api.Request, 10)
-	requestChan.state = 1
-
-	responseChan = &chanItem[*api.Response]{}
-	responseChan.ch = make(chan *api.Response, 10)
-	responseChan.state = 1
-
-	logChan = &chanItem[*logmodel.ConversionLog]{}
-	logChan.ch = make(chan *logmodel.ConversionLog, 10)
-	logChan.state = 1
-}
-
 func main() {
 	var wg sync.WaitGroup
 	ctx, cancelFunc := context.WithCancel(context.Background())
@@ -58,7 +39,7 @@ func main() {
 
 	switch config.AppCfg.StorageType {
 	case enum.Mongo:
-		mongoStore, mongoErr := repository.NewMongoStore(ctx, config.MongoCfg, config.RedisCfg)
+		mongoStore, mongoErr := repository.NewMongoStore(ctx, config.MongoCfg, config.AppCfg.DeletePolicy)
 
 		if mongoErr != nil {
 			log.Fatalf("Failed to setup mongo persistence: %v", mongoErr)
@@ -67,7 +48,7 @@ func main() {
 		store = mongoStore
 		defer mongoStore.Close(ctx)
 	case enum.Postgres:
-		postgresStore, pgErr := repository.NewPostgresStore(ctx, config.PostgresCfg)
+		postgresStore, pgErr := repository.NewPostgresStore(ctx, config.PostgresCfg, config.AppCfg.DeletePolicy)
 
 		if pgErr != nil {
 			log.Fatalf("Failed to setup postgres persistence: %v", pgErr)
@@ -76,7 +57,7 @@ func main() {
 		store = postgresStore
 		defer postgresStore.Close()
 	case enum.File:
-		fileStore := repository.NewFileStore()
+		fileStore := repository.NewFileStore(config.AppCfg.DeletePolicy)
 
 		if err = fileStore.SetupPersistence(); err != nil {
 			log.Fatalf("Failed to setup file persistence: %v", err)
@@ -88,9 +69,121 @@ func main() {
 		log.Fatalf("Unknown storage type: %s", config.AppCfg.StorageType)
 	}
 
+	var deadLetterStore repository.DeadLetterStore
+
+	switch config.DeadCfg.Store {
+	case enum.Postgres:
+		postgresStore, ok := store.(*repository.PostgresStore)
+
+		if !ok {
+			log.Fatalf("Dead letter store %s requires STORAGE_TYPE=%s", enum.Postgres, enum.Postgres)
+		}
+
+		deadLetterStore = repository.NewPostgresDeadLetterStore(postgresStore)
+	default:
+		fileDeadLetters, deadErr := repository.NewFileDeadLetterStore(config.DeadCfg.FilePath)
+
+		if deadErr != nil {
+			log.Fatalf("Failed to setup dead letter persistence: %v", deadErr)
+		}
+
+		deadLetterStore = fileDeadLetters
+		defer fileDeadLetters.Close()
+	}
+
+	var outbox repository.Outbox
+
+	if config.OutboxCfg.Enabled {
+		fileOutbox, outboxErr := repository.NewFileOutbox(config.OutboxCfg.FilePath)
+
+		if outboxErr != nil {
+			log.Fatalf("Failed to setup outbox persistence: %v", outboxErr)
+		}
+
+		outbox = fileOutbox
+		defer fileOutbox.Close()
+	}
+
+	var auditStore repository.AuditStore
+	var cache, quoteCache repository.Cache
+	var redisClient *redis.Client
+
+	if config.RedisCfg.Addr != "" {
+		var redisErr error
+		redisClient, redisErr = repository.NewRedisClient(config.RedisCfg)
+
+		if redisErr != nil {
+			log.Fatalf("Failed to setup redis: %v", redisErr)
+		}
+
+		defer redisClient.Close()
+		auditStore = repository.NewRedisAuditStore(redisClient, config.AuditCfg.Retention)
+		cache = repository.NewRedisCache(redisClient)
+		quoteCache = cache
+	} else {
+		log.Println("REDIS_ADDR is not set, audit trail and cache are kept in memory.")
+		auditStore = repository.NewMemoryAuditStore(config.AuditCfg.Retention)
+		cache = repository.NewLRUCache(config.CacheCfg.Size)
+		quoteCache = repository.NewLRUCache(config.QuoteCfg.Size)
+	}
+
+	if config.CacheCfg.Enabled {
+		store = repository.NewCachedRepository(store, cache, config.CacheCfg.ItemTTL, config.CacheCfg.ListTTL)
+	}
+
+	var eventService *service.EventService
+	eventBroker := buildBroker(ctx, redisClient)
+
+	if eventBroker != nil {
+		defer eventBroker.Close()
+		eventService = service.NewEventService(eventBroker, config.EventsCfg.Consumer, config.EventsCfg.QueueSize, service.RetryPolicy{
+			BaseDelay: config.RetryCfg.BaseDelay,
+			MaxDelay:  config.RetryCfg.MaxDelay,
+		}, outbox)
+	}
+
+	auditService := service.NewAuditService(auditStore)
 	dispatcherService := service.NewDispatcherService()
-	storageService := service.NewStorageService(store)
+	storageService := service.NewStorageService(store, auditService, eventService)
+	deadLetterService := service.NewDeadLetterService(deadLetterStore, storageService, auditService)
 	loggerService := service.NewLoggerService(store)
+	trashService := service.NewTrashService(store, auditService, config.TrashCfg.Retention, config.TrashCfg.PurgeInterval)
+	providerChain := service.NewProviderChain(
+		buildRateProviders(&wg, ctx),
+		config.RatesCfg.ProviderTimeout,
+		config.RatesCfg.BreakerThreshold,
+		config.RatesCfg.BreakerCooldown,
+	)
+	rateService := service.NewRateService(
+		providerChain,
+		quoteCache,
+		config.QuoteCfg.TTL,
+		config.QuoteCfg.StaleTTL,
+		config.RatesCfg.BaseCurrency,
+	)
+	currencyRegistry := service.NewCurrencyRegistry(service.ISO4217)
+	roundingPolicies, err := service.NewRoundingPolicies(config.RoundingCfg.DefaultPolicy, config.RoundingCfg.Accounts)
+
+	if err != nil {
+		log.Fatalf("Invalid rounding configuration: %v", err)
+	}
+
+	var feeSchedules fee.Schedules
+
+	if config.FeesCfg.SchedulesFile != "" {
+		if feeSchedules, err = service.LoadFeeSchedules(config.FeesCfg.SchedulesFile); err != nil {
+			log.Fatalf("Failed to load fee schedules: %v", err)
+		}
+	}
+
+	conversionService := service.NewConversionService(
+		rateService,
+		storageService,
+		currencyRegistry,
+		roundingPolicies,
+		service.NewFeeEngine(feeSchedules),
+		config.LockCfg.TTL,
+	)
 
 	wg.Add(1)
 
@@ -108,42 +201,197 @@ func main() {
 		}
 	}()
 
-	storageService.StartStorageService(&wg, ctx, requestChan.ch, responseChan.ch, logChan.ch)
-	loggerService.StartSliceLogger(&wg, ctx, &requestChan.state, &responseChan.state, &logChan.state)
-	webserver.StartWebServer(ctx, &wg, ":8081", storageService)
+	requests := lifecycle.NewQueueWithPolicy[*api.Request](config.IngestCfg.QueueSize, config.IngestCfg.QueuePolicy)
+	responses := lifecycle.NewQueueWithPolicy[*api.Response](config.IngestCfg.QueueSize, config.IngestCfg.QueuePolicy)
+	logs := lifecycle.NewQueueWithPolicy[*logmodel.ConversionLog](config.IngestCfg.QueueSize, config.IngestCfg.QueuePolicy)
+	pools := service.PoolConfig{
+		RequestWorkers:  config.IngestCfg.RequestWorkers,
+		ResponseWorkers: config.IngestCfg.ResponseWorkers,
+		LogWorkers:      config.IngestCfg.LogWorkers,
+		BatchSize:       config.IngestCfg.BatchSize,
+		FlushInterval:   config.IngestCfg.FlushInterval,
+		DrainTimeout:    config.IngestCfg.DrainTimeout,
+		Retry: service.RetryPolicy{
+			Attempts:  config.RetryCfg.Attempts,
+			BaseDelay: config.RetryCfg.BaseDelay,
+			MaxDelay:  config.RetryCfg.MaxDelay,
+		},
+		DeadLetters: deadLetterService,
+		Outbox:      outbox,
+	}
 
-	wg.Add(1)
-	go doForever(&wg, ctx, dispatcherService)
+	storageService.StartStorageService(&wg, ctx, pools, requests, responses, logs)
+	loggerService.StartSliceLogger(&wg, ctx, requests, responses, logs)
+	trashService.StartPurger(&wg, ctx)
+
+	if eventService != nil {
+		eventService.Start(&wg, ctx)
+
+		if config.EventsCfg.Group != "" {
+			service.NewReplicator(store, config.EventsCfg.Consumer).Start(&wg, ctx, eventBroker, config.EventsCfg.Group, config.EventsCfg.Consumer)
+		}
+	}
+
+	idempotencyService := service.NewIdempotencyService(store, config.IdemCfg.TTL)
+	webserver.StartWebServer(
+		ctx,
+		&wg,
+		":8081",
+		storageService,
+		auditService,
+		conversionService,
+		rateService,
+		currencyRegistry,
+		idempotencyService,
+		deadLetterService,
+	)
+	grpcserver.StartGRPCServer(ctx, &wg, config.GrpcCfg.Addr, storageService, conversionService, currencyRegistry)
+	buildPipeline(dispatcherService, currencyRegistry, service.NewQueueSink(storageService)).Start(&wg, ctx)
 
 	wg.Wait()
 
 	fmt.Print("Finished application. All goroutines completed.\n")
 }
 
-func doForever(wg *sync.WaitGroup, ctx context.Context, dispatcher *service.DispatcherService) {
-	defer wg.Done()
+// buildRateProviders creates the rate providers in the configured fallback
+// order.
+func buildRateProviders(wg *sync.WaitGroup, ctx context.Context) []service.RateProvider {
+	var providers []service.RateProvider
 
-	for i := 0; i < 5; i++ {
-		select {
-		case <-ctx.Done():
-			fmt.Println("Dispatcher stopped by context.")
+	for _, name := range config.RatesCfg.Providers {
+		switch name {
+		case enum.ProviderExchangeRateHost:
+			if config.RatesCfg.ExchangeRateHostKey == "" {
+				log.Println("EXCHANGERATE_HOST_ACCESS_KEY is not set, skipping exchangerate.host provider.")
+
+				continue
+			}
+
+			providers = append(providers, service.NewExchangeRateHostProvider(
+				config.RatesCfg.ExchangeRateHostURL,
+				config.RatesCfg.ExchangeRateHostKey,
+			))
+		case enum.ProviderECB:
+			providers = append(providers, service.NewECBProvider(config.RatesCfg.ECBURL, time.Hour))
+		case enum.ProviderStatic:
+			rates := service.DemoRates
 
-			return
+			if config.RatesCfg.StaticRatesFile != "" {
+				fileRates, err := service.LoadStaticRates(config.RatesCfg.StaticRatesFile)
+
+				if err != nil {
+					log.Fatalf("Failed to load static rates: %v", err)
+				}
+
+				rates = fileRates
+			}
+
+			providers = append(providers, service.NewStaticRateProvider(rates))
+		case enum.ProviderFile:
+			if config.RatesCfg.RatesFile == "" {
+				log.Println("RATES_FILE is not set, skipping file provider.")
+
+				continue
+			}
+
+			fileProvider, err := service.NewFileRateProvider(config.RatesCfg.RatesFile)
+
+			if err != nil {
+				log.Fatalf("Failed to load rates file: %v", err)
+			}
+
+			if err = fileProvider.StartWatcher(wg, ctx); err != nil {
+				log.Fatalf("Failed to watch rates file: %v", err)
+			}
+
+			providers = append(providers, fileProvider)
 		default:
-			dispatcher.DispatchExampleData(i, requestChan.ch, responseChan.ch, logChan.ch)
-			time.Sleep(500 * time.Millisecond)
-			fmt.Printf("Iteration %d finished.\n", i+1)
+			log.Fatalf("Unknown rate provider: %s", name)
 		}
 	}
 
-	close(requestChan.ch)
-	requestChan.state = 0
+	return providers
+}
 
-	close(responseChan.ch)
-	responseChan.state = 0
+// buildBroker connects to the configured events broker, or returns nil when
+// events are disabled. redisClient is nil unless Redis is configured.
+func buildBroker(ctx context.Context, redisClient *redis.Client) broker.Broker {
+	switch config.EventsCfg.Broker {
+	case enum.BrokerNone:
+		return nil
+	case enum.BrokerMemory:
+		return broker.NewMemoryBroker(config.EventsCfg.Redeliver)
+	case enum.BrokerRedis:
+		if redisClient == nil {
+			log.Fatalf("Events broker %s requires REDIS_ADDR", enum.BrokerRedis)
+		}
 
-	close(logChan.ch)
-	logChan.state = 0
+		return broker.NewRedisStreamBroker(
+			redisClient,
+			config.EventsCfg.Stream,
+			config.EventsCfg.StreamMaxLen,
+			config.EventsCfg.Redeliver,
+		)
+	case enum.BrokerNats:
+		natsBroker, err := broker.NewNatsBroker(ctx, config.EventsCfg.NatsURL, config.EventsCfg.Stream, config.EventsCfg.Redeliver)
+
+		if err != nil {
+			log.Fatalf("Failed to setup nats: %v", err)
+		}
+
+		return natsBroker
+	default:
+		log.Fatalf("Unknown events broker: %s", config.EventsCfg.Broker)
+	}
+
+	return nil
+}
+
+// buildPipeline assembles the ingestion pipeline from the configured sources,
+// stages and sinks.
+func buildPipeline(
+	dispatcher *service.DispatcherService,
+	currencies *service.CurrencyRegistry,
+	storage *service.QueueSink,
+) *service.Pipeline {
+	var sources []service.Source
+
+	for _, name := range config.PipelineCfg.Sources {
+		switch name {
+		case enum.SourceDemo:
+			sources = append(sources, service.NewDemoSource(
+				dispatcher,
+				config.PipelineCfg.DemoIterations,
+				config.PipelineCfg.DemoInterval,
+			))
+		default:
+			log.Fatalf("Unknown pipeline source: %s", name)
+		}
+	}
+
+	var stages []service.Stage
+
+	for _, name := range config.PipelineCfg.Stages {
+		switch name {
+		case enum.StageNormalize:
+			stages = append(stages, service.NormalizeStage{})
+		case enum.StageValidate:
+			stages = append(stages, service.NewValidateStage(currencies))
+		default:
+			log.Fatalf("Unknown pipeline stage: %s", name)
+		}
+	}
+
+	var sinks []service.Sink
+
+	for _, name := range config.PipelineCfg.Sinks {
+		switch name {
+		case enum.SinkStorage:
+			sinks = append(sinks, storage)
+		default:
+			log.Fatalf("Unknown pipeline sink: %s", name)
+		}
+	}
 
-	fmt.Println("All data sent, channels closed.")
+	return service.NewPipeline(sources, stages, sinks, config.PipelineCfg.Buffer)
 }